@@ -3,6 +3,6 @@ module gitlab.com/mute/api-gateway
 go 1.24
 
 require (
-	github.com/gorilla/mux v1.8.1 // indirect
-	github.com/rs/cors v1.11.1 // indirect
+	github.com/gorilla/mux v1.8.1
+	github.com/rs/cors v1.11.1
 )
@@ -5,6 +5,8 @@ import (
 	"io"
 	"log"
 	"net/http"
+	"net/http/httputil"
+	"net/url"
 	"os"
 	"strings"
 
@@ -13,8 +15,9 @@ import (
 )
 
 var (
-	usersServiceURL = getEnv("USERS_SERVICE_URL", "http://users-service:8888")
-	musicServiceURL = getEnv("MUSIC_SERVICE_URL", "http://music-service:8080")
+	usersServiceURL         = getEnv("USERS_SERVICE_URL", "http://users-service:8888")
+	musicServiceURL         = getEnv("MUSIC_SERVICE_URL", "http://music-service:8080")
+	notificationsServiceURL = getEnv("NOTIFICATIONS_SERVICE_URL", "http://notifications:8080")
 )
 
 func getEnv(key, defaultValue string) string {
@@ -72,6 +75,7 @@ func proxyRequest(w http.ResponseWriter, r *http.Request, targetURL string) {
 func main() {
 	fmt.Printf("usersServiceURL: %v\n", usersServiceURL)
 	fmt.Printf("musicServiceURL: %v\n", musicServiceURL)
+	fmt.Printf("notificationsServiceURL: %v\n", notificationsServiceURL)
 
 	router := mux.NewRouter()
 
@@ -101,6 +105,17 @@ func main() {
 		proxyRequest(w, r, targetURL)
 	}).Methods("POST")
 
+	router.HandleFunc("/user/reactivate", func(w http.ResponseWriter, r *http.Request) {
+		targetURL := fmt.Sprintf("%s/user/reactivate", usersServiceURL)
+		proxyRequest(w, r, targetURL)
+	}).Methods("POST")
+
+	// /oauth/introspect is for internal callers only and is not proxied
+	router.HandleFunc("/oauth/revoke", func(w http.ResponseWriter, r *http.Request) {
+		targetURL := fmt.Sprintf("%s/oauth/revoke", usersServiceURL)
+		proxyRequest(w, r, targetURL)
+	}).Methods("POST")
+
 	router.HandleFunc("/user/get", func(w http.ResponseWriter, r *http.Request) {
 		targetURL := fmt.Sprintf("%s/user/get", usersServiceURL)
 		proxyRequest(w, r, targetURL)
@@ -121,6 +136,22 @@ func main() {
 		proxyRequest(w, r, targetURL)
 	}).Methods("PUT")
 
+	router.HandleFunc("/user/role", func(w http.ResponseWriter, r *http.Request) {
+		targetURL := fmt.Sprintf("%s/user/role", usersServiceURL)
+		proxyRequest(w, r, targetURL)
+	}).Methods("PUT")
+
+	router.HandleFunc("/user/export", func(w http.ResponseWriter, r *http.Request) {
+		targetURL := fmt.Sprintf("%s/user/export", usersServiceURL)
+		proxyRequest(w, r, targetURL)
+	}).Methods("POST")
+
+	router.HandleFunc("/user/export/{exportId}", func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		targetURL := fmt.Sprintf("%s/user/export/%s", usersServiceURL, vars["exportId"])
+		proxyRequest(w, r, targetURL)
+	}).Methods("GET")
+
 	router.HandleFunc("/user/track/favorite", func(w http.ResponseWriter, r *http.Request) {
 		targetURL := fmt.Sprintf("%s/user/track/favorite", usersServiceURL)
 		proxyRequest(w, r, targetURL)
@@ -131,7 +162,107 @@ func main() {
 		proxyRequest(w, r, targetURL)
 	}).Methods("DELETE")
 
+	router.HandleFunc("/me/plays", func(w http.ResponseWriter, r *http.Request) {
+		targetURL := fmt.Sprintf("%s/me/plays", usersServiceURL)
+		proxyRequest(w, r, targetURL)
+	}).Methods("POST")
+
+	// Follows and the new-release feed
+	router.HandleFunc("/me/following/{kind}/{id}", func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		targetURL := fmt.Sprintf("%s/me/following/%s/%s", usersServiceURL, vars["kind"], vars["id"])
+		proxyRequest(w, r, targetURL)
+	}).Methods("POST", "DELETE")
+
+	router.HandleFunc("/me/feed", func(w http.ResponseWriter, r *http.Request) {
+		targetURL := fmt.Sprintf("%s/me/feed", usersServiceURL)
+		proxyRequest(w, r, targetURL)
+	}).Methods("GET")
+
+	router.HandleFunc("/social/{userId}", func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		targetURL := fmt.Sprintf("%s/social/%s", usersServiceURL, vars["userId"])
+		proxyRequest(w, r, targetURL)
+	}).Methods("GET")
+
+	router.HandleFunc("/social/{userId}/{list:followers|following}", func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		targetURL := fmt.Sprintf("%s/social/%s/%s", usersServiceURL, vars["userId"], vars["list"])
+		proxyRequest(w, r, targetURL)
+	}).Methods("GET")
+
 	// Music Service
+	router.HandleFunc("/me/history", func(w http.ResponseWriter, r *http.Request) {
+		targetURL := fmt.Sprintf("%s/me/history", musicServiceURL)
+		proxyRequest(w, r, targetURL)
+	}).Methods("GET")
+
+	router.HandleFunc("/me/recommendations", func(w http.ResponseWriter, r *http.Request) {
+		targetURL := fmt.Sprintf("%s/me/recommendations", musicServiceURL)
+		proxyRequest(w, r, targetURL)
+	}).Methods("GET")
+
+	router.HandleFunc("/track/{trackId}/similar", func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		trackId := vars["trackId"]
+		targetURL := fmt.Sprintf("%s/track/%s/similar", musicServiceURL, trackId)
+		proxyRequest(w, r, targetURL)
+	}).Methods("GET")
+
+	// Comments: threads under a track, timestamped reactions, flags and moderation
+	router.HandleFunc("/track/{trackId}/comments", func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		targetURL := fmt.Sprintf("%s/track/%s/comments", musicServiceURL, vars["trackId"])
+		proxyRequest(w, r, targetURL)
+	}).Methods("GET", "POST")
+
+	router.HandleFunc("/comment/{commentId}", func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		targetURL := fmt.Sprintf("%s/comment/%s", musicServiceURL, vars["commentId"])
+		proxyRequest(w, r, targetURL)
+	}).Methods("PATCH", "DELETE")
+
+	router.HandleFunc("/comment/{commentId}/replies", func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		targetURL := fmt.Sprintf("%s/comment/%s/replies", musicServiceURL, vars["commentId"])
+		proxyRequest(w, r, targetURL)
+	}).Methods("GET")
+
+	router.HandleFunc("/comment/{commentId}/{action:flag|moderation}", func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		targetURL := fmt.Sprintf("%s/comment/%s/%s", musicServiceURL, vars["commentId"], vars["action"])
+		proxyRequest(w, r, targetURL)
+	}).Methods("POST")
+
+	// Moderation: reports from users, queue, actions and audit log for admins
+	router.HandleFunc("/reports", func(w http.ResponseWriter, r *http.Request) {
+		targetURL := fmt.Sprintf("%s/reports", musicServiceURL)
+		proxyRequest(w, r, targetURL)
+	}).Methods("POST")
+
+	router.HandleFunc("/moderation/{section:queue|reports|log}", func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		targetURL := fmt.Sprintf("%s/moderation/%s", musicServiceURL, vars["section"])
+		proxyRequest(w, r, targetURL)
+	}).Methods("GET")
+
+	router.HandleFunc("/moderation/actions", func(w http.ResponseWriter, r *http.Request) {
+		targetURL := fmt.Sprintf("%s/moderation/actions", musicServiceURL)
+		proxyRequest(w, r, targetURL)
+	}).Methods("POST")
+
+	// Lyrics: plain or synchronized (LRC) text per track and search over titles and lyrics
+	router.HandleFunc("/track/{trackId}/lyrics", func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		targetURL := fmt.Sprintf("%s/track/%s/lyrics", musicServiceURL, vars["trackId"])
+		proxyRequest(w, r, targetURL)
+	}).Methods("GET", "PUT", "DELETE")
+
+	router.HandleFunc("/search", func(w http.ResponseWriter, r *http.Request) {
+		targetURL := fmt.Sprintf("%s/search", musicServiceURL)
+		proxyRequest(w, r, targetURL)
+	}).Methods("GET")
+
 	router.HandleFunc("/tracks", func(w http.ResponseWriter, r *http.Request) {
 		targetURL := fmt.Sprintf("%s/tracks", musicServiceURL)
 		proxyRequest(w, r, targetURL)
@@ -165,6 +296,46 @@ func main() {
 		proxyRequest(w, r, targetURL)
 	}).Methods("POST")
 
+	router.HandleFunc("/charts/{period}", func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		targetURL := fmt.Sprintf("%s/charts/%s", musicServiceURL, vars["period"])
+		proxyRequest(w, r, targetURL)
+	}).Methods("GET")
+
+	router.HandleFunc("/charts/{period}/snapshots", func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		targetURL := fmt.Sprintf("%s/charts/%s/snapshots", musicServiceURL, vars["period"])
+		proxyRequest(w, r, targetURL)
+	}).Methods("GET")
+
+	// Radio: endless queue from a seed track, artist or list of tracks
+	router.HandleFunc("/radio", func(w http.ResponseWriter, r *http.Request) {
+		targetURL := fmt.Sprintf("%s/radio", musicServiceURL)
+		proxyRequest(w, r, targetURL)
+	}).Methods("POST")
+
+	router.HandleFunc("/radio/{token}", func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		targetURL := fmt.Sprintf("%s/radio/%s", musicServiceURL, vars["token"])
+		proxyRequest(w, r, targetURL)
+	}).Methods("GET")
+
+	router.HandleFunc("/radio/{token}/feedback", func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		targetURL := fmt.Sprintf("%s/radio/%s/feedback", musicServiceURL, vars["token"])
+		proxyRequest(w, r, targetURL)
+	}).Methods("POST")
+
+	// Notifications: SSE and WebSocket. proxyRequest neither flushes nor upgrades
+	// the connection, so the stream goes through a reverse proxy that flushes every write
+	notificationsURL, err := url.Parse(notificationsServiceURL)
+	if err != nil {
+		log.Fatalf("NOTIFICATIONS_SERVICE_URL: %v", err)
+	}
+	notificationsProxy := httputil.NewSingleHostReverseProxy(notificationsURL)
+	notificationsProxy.FlushInterval = -1
+	router.Handle("/events", notificationsProxy).Methods("GET")
+
 	// Add simple stream endpoint for player functionality
 	router.HandleFunc("/stream", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "audio/mpeg")
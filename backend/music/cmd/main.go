@@ -2,14 +2,33 @@ package main
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"music/iternal/analysis"
+	"music/iternal/auth"
+	"music/iternal/charts"
 	config "music/iternal/config"
 	kafkaconsumer "music/iternal/kafka"
 	"music/iternal/musicserver"
+	"music/iternal/radio"
+	"music/iternal/recommendations"
+	"music/iternal/repository"
 	"music/iternal/storage"
+	"music/iternal/users"
 	postgres "music/pkg/postgres"
+
+	"golang.org/x/sync/errgroup"
 )
 
+const shutdownTimeout = 15 * time.Second
+
 func main() {
 	config.LoadEnv()
 
@@ -19,15 +38,19 @@ func main() {
 		Username: config.Get("DB_USER"),
 		Password: config.Get("DB_PASSWORD"),
 		Database: config.Get("DB_NAME"),
+		MaxConns: config.GetInt("DB_MAX_CONNS", 20),
+		MinConns: config.GetInt("DB_MIN_CONNS", 5),
 	}
 
-	dbConn, err := postgres.New(dbConfig)
+	pool, err := postgres.New(dbConfig)
 	if err != nil {
 		log.Fatalf("Не удалось подключиться к базе данных: %v", err)
 	}
-	defer dbConn.Close(context.Background())
+	defer pool.Close()
 	log.Println("Успешное подключение к базе данных!")
 
+	repo := repository.New(pool)
+
 	s3Config := storage.Config{
 		AccessKey: config.Get("S3_ACCESS_KEY"),
 		SecretKey: config.Get("S3_SECRET_KEY"),
@@ -42,20 +65,88 @@ func main() {
 	}
 	log.Println("Успешное подключение к S3-хранилищу!")
 
-	// Запуск Kafka-консьюмера в отдельной горутине
-	ctx := context.Background()
-	go kafkaconsumer.Start(
-		ctx,
-		dbConn,
-		config.Get("KAFKA_BOOTSTRAP_SERVERS"),
-		"music-consumer-group",
-		"songs_actions",
-	)
-	log.Println("🔄 Kafka consumer запущен")
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	address := ":8080"
-	if err := musicserver.StartServer(address, dbConn, s3Client); err != nil {
-		log.Fatalf("Ошибка запуска сервера: %v", err)
+	usersURL := config.Get("USERS_SERVICE_URL")
+	if usersURL == "" {
+		usersURL = "http://users:8888"
+	}
+	// Тот же токен проверяют внутренние эндпоинты music: без него не работают ни интроспекция, ни экспорт
+	serviceToken := config.Get("SERVICE_TOKEN")
+	if serviceToken == "" {
+		log.Fatal("❌ SERVICE_TOKEN не задан")
+	}
+	authClient := auth.New(usersURL, serviceToken)
+	usersGRPC := config.Get("USERS_GRPC_ADDR")
+	if usersGRPC == "" {
+		usersGRPC = "users:9090"
 	}
+	usersClient, err := users.New(usersURL, usersGRPC, serviceToken)
+	if err != nil {
+		log.Fatalf("❌ Клиент users: %v", err)
+	}
+	defer usersClient.Close()
+
+	server := musicserver.New(":8080", repo, s3Client, authClient, usersClient)
+
+	g, gCtx := errgroup.WithContext(ctx)
+
+	// Kafka-консьюмер: после сигнала доводит текущую пачку и коммитит оффсеты
+	g.Go(func() error {
+		log.Println("🔄 Kafka consumer запущен")
+		return kafkaconsumer.Run(
+			gCtx,
+			repo,
+			s3Client,
+			config.Get("KAFKA_BOOTSTRAP_SERVERS"),
+			"music-consumer-group",
+			"songs_actions",
+		)
+	})
 
+	// События из outbox (новые треки) в Kafka
+	g.Go(func() error {
+		return kafkaconsumer.RunOutbox(gCtx, repo, config.Get("KAFKA_BOOTSTRAP_SERVERS"))
+	})
+
+	g.Go(func() error {
+		return recommendations.Run(gCtx, repo, config.GetDuration("RECOMMENDATIONS_INTERVAL", 6*time.Hour))
+	})
+
+	g.Go(func() error {
+		return radio.RunCleanup(gCtx, repo)
+	})
+
+	g.Go(func() error {
+		return charts.Run(gCtx, repo)
+	})
+
+	// Волна и громкость загруженных треков
+	g.Go(func() error {
+		return analysis.Run(gCtx, repo, s3Client)
+	})
+
+	g.Go(func() error {
+		log.Printf("Сервер запущен на %s", server.Addr)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return fmt.Errorf("ошибка запуска сервера: %w", err)
+		}
+		return nil
+	})
+
+	// Остановка: новые запросы не принимаются, начатые дорабатывают до shutdownTimeout
+	g.Go(func() error {
+		<-gCtx.Done()
+		log.Println("🛑 Остановка music")
+
+		ctxShutdown, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+
+		return server.Shutdown(ctxShutdown)
+	})
+
+	if err := g.Wait(); err != nil {
+		log.Printf("Сервис остановлен с ошибкой: %v", err)
+	}
 }
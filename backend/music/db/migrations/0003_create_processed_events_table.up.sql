@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS processed_events (
+    event_id UUID NOT NULL PRIMARY KEY,
+    processed_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS processed_events_processed_at_idx ON processed_events (processed_at);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS track_listeners;
+DROP TABLE IF EXISTS track_stats;
@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS track_stats (
+    track_id UUID NOT NULL PRIMARY KEY,
+    like_count BIGINT NOT NULL DEFAULT 0,
+    play_count BIGINT NOT NULL DEFAULT 0,
+    unique_listeners BIGINT NOT NULL DEFAULT 0,
+    last_played_at TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS track_listeners (
+    track_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    PRIMARY KEY (track_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS track_listeners_user_id_idx ON track_listeners (user_id);
+
+INSERT INTO track_stats (track_id, like_count)
+SELECT track_id, COUNT(*) FROM liked_music GROUP BY track_id
+ON CONFLICT (track_id) DO UPDATE SET like_count = EXCLUDED.like_count;
@@ -0,0 +1,2 @@
+DROP FUNCTION IF EXISTS ensure_plays_partition(TIMESTAMP);
+DROP TABLE IF EXISTS plays;
@@ -0,0 +1,31 @@
+-- Журнал прослушиваний, по партиции на месяц. Партиции создаются заранее
+-- консьюмером (ensure_plays_partition), plays_default ловит строки вне созданных месяцев.
+CREATE TABLE IF NOT EXISTS plays (
+    event_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    track_id UUID NOT NULL,
+    position_ms BIGINT,
+    duration_ms BIGINT,
+    played_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (event_id, played_at)
+) PARTITION BY RANGE (played_at);
+
+CREATE INDEX IF NOT EXISTS plays_user_played_at_idx ON plays (user_id, played_at DESC);
+CREATE INDEX IF NOT EXISTS plays_track_played_at_idx ON plays (track_id, played_at);
+
+CREATE TABLE IF NOT EXISTS plays_default PARTITION OF plays DEFAULT;
+
+CREATE OR REPLACE FUNCTION ensure_plays_partition(ts TIMESTAMP) RETURNS void AS $$
+DECLARE
+    from_ts TIMESTAMP := date_trunc('month', ts);
+    to_ts TIMESTAMP := date_trunc('month', ts) + INTERVAL '1 month';
+    part TEXT := 'plays_' || to_char(from_ts, 'YYYY_MM');
+BEGIN
+    IF to_regclass(part) IS NULL THEN
+        EXECUTE format('CREATE TABLE %I PARTITION OF plays FOR VALUES FROM (%L) TO (%L)', part, from_ts, to_ts);
+    END IF;
+END;
+$$ LANGUAGE plpgsql;
+
+SELECT ensure_plays_partition(NOW()::TIMESTAMP);
+SELECT ensure_plays_partition((NOW() + INTERVAL '1 month')::TIMESTAMP);
@@ -0,0 +1,11 @@
+-- Соседи трека по совместным лайкам и прослушиваниям, top-N на трек.
+-- Пересчитывается целиком периодической задачей (recommendations.Run).
+CREATE TABLE IF NOT EXISTS track_similar (
+    track_id UUID NOT NULL,
+    similar_id UUID NOT NULL,
+    score DOUBLE PRECISION NOT NULL,
+    computed_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (track_id, similar_id)
+);
+
+CREATE INDEX IF NOT EXISTS track_similar_track_score_idx ON track_similar (track_id, score DESC);
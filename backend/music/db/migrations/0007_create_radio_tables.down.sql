@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS radio_queue;
+DROP TABLE IF EXISTS radio_sessions;
@@ -0,0 +1,23 @@
+-- Сессии радио: сид и очередь выданных треков с реакцией пользователя.
+CREATE TABLE IF NOT EXISTS radio_sessions (
+    id UUID NOT NULL PRIMARY KEY,
+    user_id UUID NOT NULL,
+    seed_type VARCHAR(16) NOT NULL,
+    seed_tracks UUID[] NOT NULL,
+    seed_artist_id UUID,
+    next_position INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS radio_sessions_updated_at_idx ON radio_sessions (updated_at);
+
+CREATE TABLE IF NOT EXISTS radio_queue (
+    session_id UUID NOT NULL REFERENCES radio_sessions (id) ON DELETE CASCADE,
+    position INT NOT NULL,
+    track_id UUID NOT NULL,
+    artist_id UUID NOT NULL,
+    feedback VARCHAR(8),
+    queued_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (session_id, position)
+);
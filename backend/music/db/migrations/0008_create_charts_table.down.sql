@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS charts;
+DROP TABLE IF EXISTS like_events;
@@ -0,0 +1,24 @@
+-- Лайки и снятия лайков со временем: liked_music хранит только текущее состояние,
+-- а чартам нужна динамика. Записи старше двух недель не нужны и удаляются.
+CREATE TABLE IF NOT EXISTS like_events (
+    track_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    delta SMALLINT NOT NULL,
+    occurred_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS like_events_occurred_at_idx ON like_events (occurred_at);
+CREATE INDEX IF NOT EXISTS like_events_user_id_idx ON like_events (user_id);
+
+-- Снимки чартов. Снимок - все строки с одинаковыми (period, kind, computed_at).
+-- item_id - трек для kind = tracks/rising, исполнитель для kind = artists.
+CREATE TABLE IF NOT EXISTS charts (
+    period VARCHAR(16) NOT NULL,
+    kind VARCHAR(16) NOT NULL,
+    computed_at TIMESTAMP NOT NULL,
+    position INT NOT NULL,
+    item_id UUID NOT NULL,
+    score DOUBLE PRECISION NOT NULL,
+    previous_position INT,
+    PRIMARY KEY (period, kind, computed_at, position)
+);
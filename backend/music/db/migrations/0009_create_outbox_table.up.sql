@@ -0,0 +1,13 @@
+-- События music для Kafka: пишутся в одной транзакции с изменением, которое их породило,
+-- и отправляются фоновым релеем (at-least-once, получатели дедуплицируют по id события)
+CREATE TABLE IF NOT EXISTS outbox (
+    id UUID NOT NULL PRIMARY KEY,
+    topic VARCHAR(255) NOT NULL,
+    key VARCHAR(255) NOT NULL,
+    data JSONB NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    sent_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS outbox_unsent_idx ON outbox (created_at) WHERE sent_at IS NULL;
+CREATE INDEX IF NOT EXISTS outbox_sent_at_idx ON outbox (sent_at) WHERE sent_at IS NOT NULL;
@@ -0,0 +1,33 @@
+-- Комментарии к трекам. Ответы — только на комментарии верхнего уровня (parent_id),
+-- position_ms — момент трека, к которому привязан комментарий.
+-- user_id NULL — автор стерт, от комментария с ответами остается заглушка.
+CREATE TABLE IF NOT EXISTS comments (
+    id UUID NOT NULL PRIMARY KEY,
+    track_id UUID NOT NULL,
+    user_id UUID,
+    parent_id UUID REFERENCES comments (id) ON DELETE CASCADE,
+    body TEXT NOT NULL,
+    position_ms BIGINT,
+    reply_count INT NOT NULL DEFAULT 0,
+    flag_count INT NOT NULL DEFAULT 0,
+    hidden_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    edited_at TIMESTAMP,
+    deleted_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS comments_track_created_idx ON comments (track_id, created_at DESC, id DESC) WHERE parent_id IS NULL;
+CREATE INDEX IF NOT EXISTS comments_track_position_idx ON comments (track_id, position_ms) WHERE parent_id IS NULL AND position_ms IS NOT NULL;
+CREATE INDEX IF NOT EXISTS comments_parent_created_idx ON comments (parent_id, created_at, id) WHERE parent_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS comments_user_idx ON comments (user_id);
+
+-- Жалобы на комментарии: одна от пользователя, после нескольких жалоб комментарий скрывается до решения модератора
+CREATE TABLE IF NOT EXISTS comment_flags (
+    comment_id UUID NOT NULL REFERENCES comments (id) ON DELETE CASCADE,
+    user_id UUID NOT NULL,
+    reason VARCHAR(32) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (comment_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS comment_flags_user_idx ON comment_flags (user_id);
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS moderation_log;
+DROP TABLE IF EXISTS reports;
+ALTER TABLE music DROP COLUMN IF EXISTS cover_hidden;
+ALTER TABLE music DROP COLUMN IF EXISTS visibility;
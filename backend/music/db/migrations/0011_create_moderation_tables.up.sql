@@ -0,0 +1,39 @@
+-- visibility: public — трек виден во всех списках, hidden — скрыт модератором.
+-- cover_hidden — обложка скрыта модератором, сам трек при этом виден.
+ALTER TABLE music ADD COLUMN IF NOT EXISTS visibility VARCHAR(16) NOT NULL DEFAULT 'public';
+ALTER TABLE music ADD COLUMN IF NOT EXISTS cover_hidden BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Жалобы пользователей на треки, обложки, комментарии и пользователей.
+-- Открытая жалоба одного пользователя на одну цель — одна.
+CREATE TABLE IF NOT EXISTS reports (
+    id UUID NOT NULL PRIMARY KEY,
+    target_type VARCHAR(16) NOT NULL,
+    target_id UUID NOT NULL,
+    reporter_id UUID NOT NULL,
+    reason VARCHAR(32) NOT NULL,
+    details TEXT NOT NULL DEFAULT '',
+    status VARCHAR(16) NOT NULL DEFAULT 'open',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    resolved_at TIMESTAMP,
+    resolved_by UUID,
+    resolution VARCHAR(16)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS reports_open_uniq ON reports (target_type, target_id, reporter_id) WHERE status = 'open';
+CREATE INDEX IF NOT EXISTS reports_target_idx ON reports (target_type, target_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS reports_reporter_idx ON reports (reporter_id);
+
+-- Журнал действий модераторов. Не чистится: это история решений.
+CREATE TABLE IF NOT EXISTS moderation_log (
+    id UUID NOT NULL PRIMARY KEY,
+    moderator_id UUID NOT NULL,
+    target_type VARCHAR(16) NOT NULL,
+    target_id UUID NOT NULL,
+    action VARCHAR(16) NOT NULL,
+    note TEXT NOT NULL DEFAULT '',
+    resolved_reports INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS moderation_log_created_idx ON moderation_log (created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS moderation_log_target_idx ON moderation_log (target_type, target_id, created_at DESC);
@@ -0,0 +1,13 @@
+-- Текст песни: body — как загрузил исполнитель, text — строки без разметки,
+-- lines — разобранный LRC ([{"time_ms": 12340, "text": "..."}]), у plain NULL.
+CREATE TABLE IF NOT EXISTS track_lyrics (
+    track_id UUID NOT NULL PRIMARY KEY,
+    format VARCHAR(8) NOT NULL,
+    body TEXT NOT NULL,
+    text TEXT NOT NULL,
+    lines JSONB,
+    search_vector TSVECTOR GENERATED ALWAYS AS (to_tsvector('simple', text)) STORED,
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS track_lyrics_search_idx ON track_lyrics USING GIN (search_vector);
@@ -0,0 +1,23 @@
+-- Анализ аудио после загрузки: волна (JSON в S3) и громкость по EBU R128.
+-- status: pending — ждет обработчика, processing — взят до next_attempt_at,
+-- done — посчитан, failed — файл не разобран или кончились попытки.
+CREATE TABLE IF NOT EXISTS track_analysis (
+    track_id UUID NOT NULL PRIMARY KEY,
+    status VARCHAR(16) NOT NULL DEFAULT 'pending',
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    error TEXT,
+    waveform_key TEXT,
+    duration_ms BIGINT,
+    loudness_lufs DOUBLE PRECISION,
+    true_peak_dbtp DOUBLE PRECISION,
+    replay_gain_db DOUBLE PRECISION,
+    analyzed_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS track_analysis_queue_idx ON track_analysis (next_attempt_at) WHERE status IN ('pending', 'processing');
+
+-- Уже загруженные треки встают в очередь
+INSERT INTO track_analysis (track_id)
+SELECT id FROM music WHERE track_s3_key IS NOT NULL AND track_s3_key <> ''
+ON CONFLICT DO NOTHING;
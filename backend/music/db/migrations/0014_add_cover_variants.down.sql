@@ -0,0 +1,3 @@
+ALTER TABLE music DROP COLUMN IF EXISTS cover_color;
+ALTER TABLE music DROP COLUMN IF EXISTS cover_blurhash;
+ALTER TABLE music DROP COLUMN IF EXISTS cover_prefix;
@@ -0,0 +1,5 @@
+-- Обработанная обложка: варианты 64/300/1000 px в WebP и JPEG лежат в S3 под cover_prefix,
+-- cover_s3_key указывает на 1000 px JPEG. У обложек, загруженных раньше, cover_prefix NULL.
+ALTER TABLE music ADD COLUMN IF NOT EXISTS cover_prefix TEXT;
+ALTER TABLE music ADD COLUMN IF NOT EXISTS cover_blurhash TEXT;
+ALTER TABLE music ADD COLUMN IF NOT EXISTS cover_color VARCHAR(7);
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS outbox_dead;
+DROP INDEX IF EXISTS outbox_key_unsent_idx;
+ALTER TABLE outbox DROP COLUMN IF EXISTS locked_until;
+ALTER TABLE outbox DROP COLUMN IF EXISTS locked_by;
+ALTER TABLE outbox DROP COLUMN IF EXISTS next_attempt_at;
+ALTER TABLE outbox DROP COLUMN IF EXISTS last_error;
+ALTER TABLE outbox DROP COLUMN IF EXISTS attempts;
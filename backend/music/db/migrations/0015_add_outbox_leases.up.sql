@@ -0,0 +1,22 @@
+-- Релей берет события в аренду (locked_by, locked_until), а не держит транзакцию на время
+-- отправки. Недоставленное событие повторяется с задержкой (next_attempt_at), после
+-- нескольких попыток переносится в outbox_dead.
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS attempts INT NOT NULL DEFAULT 0;
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS last_error TEXT NOT NULL DEFAULT '';
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS next_attempt_at TIMESTAMP NOT NULL DEFAULT NOW();
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS locked_by VARCHAR(255);
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS locked_until TIMESTAMP;
+
+-- Порядок по ключу: следующее событие ключа берется только после отправки предыдущего
+CREATE INDEX IF NOT EXISTS outbox_key_unsent_idx ON outbox (key, created_at, id) WHERE sent_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS outbox_dead (
+    id UUID NOT NULL PRIMARY KEY,
+    topic VARCHAR(255) NOT NULL,
+    key VARCHAR(255) NOT NULL,
+    data JSONB NOT NULL,
+    attempts INT NOT NULL,
+    last_error TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    failed_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,6 @@
+-- Пользователи, удаленные по user_deleted. Лайки и прослушивания идут в Kafka с другим ключом
+-- (user_id:track_id) и могут прийти после удаления: по этой таблице они отбрасываются.
+CREATE TABLE IF NOT EXISTS erased_users (
+    user_id UUID NOT NULL PRIMARY KEY,
+    erased_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
@@ -3,15 +3,26 @@ module music
 go 1.24
 
 require (
+	github.com/Cwby333/user-microservice v0.0.0-00010101000000-000000000000
 	github.com/aws/aws-sdk-go v1.55.6
+	github.com/buckket/go-blurhash v1.1.0
+	github.com/chai2010/webp v1.1.1
+	github.com/confluentinc/confluent-kafka-go/v2 v2.10.0
+	github.com/go-audio/audio v1.0.0
+	github.com/go-audio/wav v1.1.0
 	github.com/golang-migrate/migrate/v4 v4.18.2
 	github.com/google/uuid v1.6.0
+	github.com/hajimehoshi/go-mp3 v0.3.4
 	github.com/jackc/pgx/v5 v5.7.4
 	github.com/joho/godotenv v1.5.1
+	gitlab.com/Go34/Mute/shared v0.0.0-00010101000000-000000000000
+	golang.org/x/image v0.23.0
+	golang.org/x/sync v0.13.0
+	google.golang.org/grpc v1.72.2
 )
 
 require (
-	github.com/confluentinc/confluent-kafka-go/v2 v2.10.0 // indirect
+	github.com/go-audio/riff v1.0.0 // indirect
 	github.com/hashicorp/errwrap v1.1.0 // indirect
 	github.com/hashicorp/go-multierror v1.1.1 // indirect
 	github.com/jackc/chunkreader/v2 v2.0.1 // indirect
@@ -26,8 +37,16 @@ require (
 	github.com/jackc/puddle/v2 v2.2.2 // indirect
 	github.com/jmespath/go-jmespath v0.4.0 // indirect
 	github.com/lib/pq v1.10.9 // indirect
+	github.com/santhosh-tekuri/jsonschema/v6 v6.0.2 // indirect
 	go.uber.org/atomic v1.7.0 // indirect
-	golang.org/x/crypto v0.31.0 // indirect
-	golang.org/x/sync v0.10.0 // indirect
-	golang.org/x/text v0.21.0 // indirect
+	golang.org/x/crypto v0.37.0 // indirect
+	golang.org/x/net v0.39.0 // indirect
+	golang.org/x/sys v0.32.0 // indirect
+	golang.org/x/text v0.24.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20250218202821-56aae31c358a // indirect
+	google.golang.org/protobuf v1.36.6 // indirect
 )
+
+replace gitlab.com/Go34/Mute/shared => ../../shared
+
+replace github.com/Cwby333/user-microservice => ../users
@@ -0,0 +1,224 @@
+package analysis
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/go-audio/audio"
+	"github.com/go-audio/wav"
+	"github.com/hajimehoshi/go-mp3"
+)
+
+const (
+	// ReplayGain 2.0: к этой громкости клиент приводит треки
+	ReferenceLUFS = -18.0
+	// Усиление не должно поднимать true peak выше
+	MaxTruePeakDBTP = -1.0
+
+	// Нижние границы для тишины, где громкость и пик — минус бесконечность
+	silenceLUFS = -70.0
+	silenceDBTP = -100.0
+
+	// Сколько кадров декодируется за раз
+	readFrames = 4096
+)
+
+var (
+	ErrUnsupported = errors.New("unsupported audio format")
+	ErrEmpty       = errors.New("no audio frames")
+	errDecode      = errors.New("decode audio")
+)
+
+// Result — волна и громкость одного трека.
+type Result struct {
+	DurationMs int64
+	// Интегральная громкость по EBU R128 (LUFS) и true peak (dBTP)
+	LoudnessLUFS float64
+	TruePeakDBTP float64
+	// Сколько дБ добавить при воспроизведении, чтобы трек звучал на ReferenceLUFS
+	ReplayGainDB float64
+	Waveform     Waveform
+}
+
+// source отдает PCM кадрами: сэмплы каналов подряд, в диапазоне [-1, 1].
+type source interface {
+	rate() int
+	channels() int
+	// read заполняет buf и возвращает число сэмплов; io.EOF — данных больше нет
+	read(buf []float64) (int, error)
+}
+
+// Analyze декодирует MP3 или WAV (PCM) и считает волну, громкость и true peak.
+// Ошибки относятся к самому файлу: повтор с теми же данными даст то же.
+func Analyze(data []byte) (Result, error) {
+	src, err := open(data)
+	if err != nil {
+		return Result{}, err
+	}
+
+	rate, channels := src.rate(), src.channels()
+	if rate <= 0 || channels <= 0 {
+		return Result{}, fmt.Errorf("%w: %d Hz, %d channels", ErrUnsupported, rate, channels)
+	}
+
+	m := newMeter(rate, channels)
+	p := &peaks{}
+
+	buf := make([]float64, readFrames*channels)
+	var frames int64
+	for {
+		n, err := src.read(buf)
+		n -= n % channels
+		for i := 0; i < n; i += channels {
+			frame := buf[i : i+channels]
+			m.add(frame)
+
+			var mono float64
+			for _, x := range frame {
+				mono += x
+			}
+			p.add(mono / float64(channels))
+		}
+		frames += int64(n / channels)
+
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return Result{}, fmt.Errorf("%w: %v", errDecode, err)
+		}
+		if n == 0 {
+			break
+		}
+	}
+	if frames == 0 {
+		return Result{}, ErrEmpty
+	}
+
+	res := Result{
+		DurationMs:   frames * 1000 / int64(rate),
+		LoudnessLUFS: silenceLUFS,
+		TruePeakDBTP: silenceDBTP,
+		Waveform:     p.waveform(rate),
+	}
+	if l, ok := m.integrated(); ok {
+		res.LoudnessLUFS = max(l, silenceLUFS)
+	}
+	if tp := m.truePeak(); tp > 0 {
+		res.TruePeakDBTP = max(20*math.Log10(tp), silenceDBTP)
+	}
+	res.ReplayGainDB = replayGain(res.LoudnessLUFS, res.TruePeakDBTP)
+
+	return res, nil
+}
+
+// replayGain приводит трек к ReferenceLUFS, но тихий трек с высокими пиками поднимается
+// только до MaxTruePeakDBTP, чтобы не было клиппинга.
+func replayGain(loudness, truePeak float64) float64 {
+	gain := ReferenceLUFS - loudness
+	gain = min(gain, MaxTruePeakDBTP-truePeak)
+	return math.Round(gain*100) / 100
+}
+
+func open(data []byte) (source, error) {
+	switch {
+	case len(data) >= 12 && string(data[:4]) == "RIFF" && string(data[8:12]) == "WAVE":
+		return openWAV(data)
+	case len(data) >= 3 && string(data[:3]) == "ID3",
+		len(data) >= 2 && data[0] == 0xFF && data[1]&0xE0 == 0xE0:
+		return openMP3(data)
+	}
+
+	return nil, ErrUnsupported
+}
+
+// mp3Source — go-mp3 всегда отдает 16 бит, стерео.
+type mp3Source struct {
+	d   *mp3.Decoder
+	raw []byte
+}
+
+func openMP3(data []byte) (source, error) {
+	d, err := mp3.NewDecoder(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: mp3: %v", ErrUnsupported, err)
+	}
+
+	return &mp3Source{d: d}, nil
+}
+
+func (s *mp3Source) rate() int     { return s.d.SampleRate() }
+func (s *mp3Source) channels() int { return 2 }
+
+func (s *mp3Source) read(buf []float64) (int, error) {
+	if need := len(buf) * 2; len(s.raw) < need {
+		s.raw = make([]byte, need)
+	}
+
+	n, err := io.ReadFull(s.d, s.raw[:len(buf)*2])
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		err = io.EOF
+	}
+	for i := 0; i+1 < n; i += 2 {
+		buf[i/2] = float64(int16(uint16(s.raw[i])|uint16(s.raw[i+1])<<8)) / 32768
+	}
+
+	return n / 2, err
+}
+
+type wavSource struct {
+	d     *wav.Decoder
+	ib    *audio.IntBuffer
+	scale float64
+	// 8-битные WAV беззнаковые
+	offset float64
+}
+
+func openWAV(data []byte) (source, error) {
+	d := wav.NewDecoder(bytes.NewReader(data))
+	d.ReadInfo()
+	if err := d.Err(); err != nil {
+		return nil, fmt.Errorf("%w: wav: %v", ErrUnsupported, err)
+	}
+	// 1 — целочисленный PCM; float и сжатые WAV не поддерживаются
+	if d.WavAudioFormat != 1 {
+		return nil, fmt.Errorf("%w: wav format %d", ErrUnsupported, d.WavAudioFormat)
+	}
+
+	s := &wavSource{d: d, ib: &audio.IntBuffer{}}
+	switch d.BitDepth {
+	case 8:
+		s.scale, s.offset = 128, 128
+	case 16, 24, 32:
+		s.scale = float64(int64(1) << (d.BitDepth - 1))
+	default:
+		return nil, fmt.Errorf("%w: wav %d bit", ErrUnsupported, d.BitDepth)
+	}
+
+	return s, nil
+}
+
+func (s *wavSource) rate() int     { return int(s.d.SampleRate) }
+func (s *wavSource) channels() int { return int(s.d.NumChans) }
+
+func (s *wavSource) read(buf []float64) (int, error) {
+	if len(s.ib.Data) != len(buf) {
+		s.ib.Data = make([]int, len(buf))
+	}
+
+	n, err := s.d.PCMBuffer(s.ib)
+	if err != nil {
+		return 0, err
+	}
+	if n == 0 {
+		return 0, io.EOF
+	}
+	for i, v := range s.ib.Data[:n] {
+		buf[i] = (float64(v) - s.offset) / s.scale
+	}
+
+	return n, nil
+}
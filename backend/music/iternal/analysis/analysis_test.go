@@ -0,0 +1,282 @@
+package analysis
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"testing"
+)
+
+// segment — синус частоты freq с пиковым уровнем level dBFS во всех каналах
+type segment struct {
+	seconds float64
+	freq    float64
+	level   float64
+}
+
+// tone синтезирует сигнал по сегментам: сэмплы каналов подряд
+func tone(rate, channels int, segments ...segment) []float64 {
+	var out []float64
+	var n int
+	for _, s := range segments {
+		amp := math.Pow(10, s.level/20)
+		for range int(s.seconds * float64(rate)) {
+			x := amp * math.Sin(2*math.Pi*s.freq*float64(n)/float64(rate))
+			for range channels {
+				out = append(out, x)
+			}
+			n++
+		}
+	}
+	return out
+}
+
+// wavFile собирает WAV с целочисленным PCM (format 1) или float (format 3)
+func wavFile(rate, channels, bits, format int, samples []float64) []byte {
+	full := math.Ldexp(1, bits-1)
+	// quantize — целое значение сэмпла, +1.0 упирается в максимум
+	quantize := func(x float64) int64 {
+		return int64(max(min(math.Round(x*full), full-1), -full))
+	}
+
+	var data bytes.Buffer
+	for _, x := range samples {
+		switch {
+		case format == 3:
+			binary.Write(&data, binary.LittleEndian, float32(x))
+		case bits == 8:
+			data.WriteByte(byte(quantize(x) + 128))
+		case bits == 16:
+			binary.Write(&data, binary.LittleEndian, int16(quantize(x)))
+		case bits == 24:
+			v := quantize(x)
+			data.Write([]byte{byte(v), byte(v >> 8), byte(v >> 16)})
+		case bits == 32:
+			binary.Write(&data, binary.LittleEndian, int32(quantize(x)))
+		}
+	}
+
+	var b bytes.Buffer
+	b.WriteString("RIFF")
+	binary.Write(&b, binary.LittleEndian, uint32(36+data.Len()))
+	b.WriteString("WAVEfmt ")
+	binary.Write(&b, binary.LittleEndian, uint32(16))
+	binary.Write(&b, binary.LittleEndian, uint16(format))
+	binary.Write(&b, binary.LittleEndian, uint16(channels))
+	binary.Write(&b, binary.LittleEndian, uint32(rate))
+	binary.Write(&b, binary.LittleEndian, uint32(rate*channels*bits/8))
+	binary.Write(&b, binary.LittleEndian, uint16(channels*bits/8))
+	binary.Write(&b, binary.LittleEndian, uint16(bits))
+	b.WriteString("data")
+	binary.Write(&b, binary.LittleEndian, uint32(data.Len()))
+	b.Write(data.Bytes())
+
+	return b.Bytes()
+}
+
+// Сигналы из EBU Tech 3341 (минимальные требования к измерителю R128), допуск ±0.1 LU
+func TestAnalyzeLoudness(t *testing.T) {
+	testCases := []struct {
+		name     string
+		rate     int
+		channels int
+		bits     int
+		segments []segment
+		want     float64
+	}{
+		{
+			name:     "stereo 1 kHz at -23 dBFS",
+			rate:     48000,
+			channels: 2,
+			bits:     16,
+			segments: []segment{{20, 1000, -23}},
+			want:     -23,
+		},
+		{
+			name:     "stereo 1 kHz at -33 dBFS",
+			rate:     48000,
+			channels: 2,
+			bits:     16,
+			segments: []segment{{20, 1000, -33}},
+			want:     -33,
+		},
+		{
+			name:     "44.1 kHz",
+			rate:     44100,
+			channels: 2,
+			bits:     24,
+			segments: []segment{{20, 1000, -23}},
+			want:     -23,
+		},
+		{
+			// Один канал — вдвое меньше энергии, чем тот же сигнал в стерео
+			name:     "mono",
+			rate:     48000,
+			channels: 1,
+			bits:     16,
+			segments: []segment{{20, 1000, -23}},
+			want:     -26.01,
+		},
+		{
+			// Tech 3341, тест 3: тихие части ниже относительного порога не считаются
+			name:     "relative gate",
+			rate:     48000,
+			channels: 2,
+			bits:     24,
+			segments: []segment{{10, 1000, -36}, {60, 1000, -23}, {10, 1000, -36}},
+			want:     -23,
+		},
+		{
+			// Tech 3341, тест 4: части ниже -70 LUFS отсекает абсолютный порог
+			name:     "absolute gate",
+			rate:     48000,
+			channels: 2,
+			bits:     24,
+			segments: []segment{{10, 1000, -72}, {10, 1000, -36}, {60, 1000, -23}, {10, 1000, -36}, {10, 1000, -72}},
+			want:     -23,
+		},
+		{
+			// Tech 3341, тест 5: все части выше порога и усредняются по энергии
+			name:     "level changes",
+			rate:     48000,
+			channels: 2,
+			bits:     24,
+			segments: []segment{{20, 1000, -26}, {20.1, 1000, -20}, {20, 1000, -26}},
+			want:     -23,
+		},
+		{
+			// На -23 dBFS у 8 бит слишком грубый шаг, тон громче
+			name:     "8 bit",
+			rate:     48000,
+			channels: 2,
+			bits:     8,
+			segments: []segment{{20, 1000, -12}},
+			want:     -12,
+		},
+		{
+			name:     "32 bit",
+			rate:     48000,
+			channels: 2,
+			bits:     32,
+			segments: []segment{{20, 1000, -23}},
+			want:     -23,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			data := wavFile(tc.rate, tc.channels, tc.bits, 1, tone(tc.rate, tc.channels, tc.segments...))
+
+			res, err := Analyze(data)
+			if err != nil {
+				t.Fatalf("Analyze() error = %v", err)
+			}
+			if math.Abs(res.LoudnessLUFS-tc.want) > 0.1 {
+				t.Fatalf("loudness = %.2f LUFS, want %.2f", res.LoudnessLUFS, tc.want)
+			}
+			if want := replayGain(res.LoudnessLUFS, res.TruePeakDBTP); res.ReplayGainDB != want {
+				t.Fatalf("replay gain = %v, want %v", res.ReplayGainDB, want)
+			}
+		})
+	}
+}
+
+func TestAnalyzeResult(t *testing.T) {
+	const rate = 48000
+	data := wavFile(rate, 2, 16, 1, tone(rate, 2, segment{30, 1000, -6}))
+
+	res, err := Analyze(data)
+	if err != nil {
+		t.Fatalf("Analyze() error = %v", err)
+	}
+
+	if res.DurationMs != 30000 {
+		t.Fatalf("duration = %d ms, want 30000", res.DurationMs)
+	}
+	if math.Abs(res.TruePeakDBTP+6) > 0.2 {
+		t.Fatalf("true peak = %.2f dBTP, want -6", res.TruePeakDBTP)
+	}
+	// Громкость -6 LUFS: опустить на 12 дБ до ReferenceLUFS
+	if math.Abs(res.ReplayGainDB+12) > 0.1 {
+		t.Fatalf("replay gain = %v, want -12", res.ReplayGainDB)
+	}
+
+	// 30 с — 5625 блоков, по 3 блока на точку
+	w := res.Waveform
+	if w.Version != 2 || w.Channels != 1 || w.Bits != 8 || w.SampleRate != rate {
+		t.Fatalf("waveform header %+v", w)
+	}
+	if w.SamplesPerPixel != 3*waveformBlock || w.Length != 1875 || len(w.Data) != 2*w.Length {
+		t.Fatalf("waveform: %d samples per pixel, length %d, %d values", w.SamplesPerPixel, w.Length, len(w.Data))
+	}
+	// Пик -6 dBFS ≈ 0.5 → ±64
+	if w.Data[0] != -64 || w.Data[1] != 64 {
+		t.Fatalf("first point = [%d %d], want [-64 64]", w.Data[0], w.Data[1])
+	}
+}
+
+func TestAnalyzeSilence(t *testing.T) {
+	data := wavFile(48000, 2, 16, 1, make([]float64, 2*48000))
+
+	res, err := Analyze(data)
+	if err != nil {
+		t.Fatalf("Analyze() error = %v", err)
+	}
+	if res.LoudnessLUFS != silenceLUFS || res.TruePeakDBTP != silenceDBTP {
+		t.Fatalf("silence: %v LUFS, %v dBTP", res.LoudnessLUFS, res.TruePeakDBTP)
+	}
+	if res.DurationMs != 1000 {
+		t.Fatalf("duration = %d ms, want 1000", res.DurationMs)
+	}
+}
+
+func TestAnalyzeErrors(t *testing.T) {
+	short := tone(48000, 1, segment{0.1, 1000, -6})
+
+	testCases := []struct {
+		name    string
+		data    []byte
+		wantErr error
+	}{
+		{name: "empty", data: nil, wantErr: ErrUnsupported},
+		{name: "ogg", data: []byte("OggS\x00\x02\x00\x00\x00\x00\x00\x00"), wantErr: ErrUnsupported},
+		{name: "riff without wave", data: []byte("RIFF\x00\x00\x00\x00AVI LIST"), wantErr: ErrUnsupported},
+		{name: "float wav", data: wavFile(48000, 1, 32, 3, short), wantErr: ErrUnsupported},
+		{name: "no frames", data: wavFile(48000, 2, 16, 1, nil), wantErr: ErrEmpty},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Analyze(tc.data)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("Analyze() error = %v, want %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestReplayGain(t *testing.T) {
+	testCases := []struct {
+		name     string
+		loudness float64
+		truePeak float64
+		want     float64
+	}{
+		{name: "at reference", loudness: -18, truePeak: -10, want: 0},
+		{name: "quiet track raised", loudness: -23, truePeak: -10, want: 5},
+		{name: "loud track lowered", loudness: -8, truePeak: -0.5, want: -10},
+		{name: "raise limited by true peak", loudness: -30, truePeak: -3, want: 2},
+		{name: "peak already above limit", loudness: -20, truePeak: 0.5, want: -1.5},
+		{name: "rounded to hundredths", loudness: -23.456, truePeak: -20, want: 5.46},
+		{name: "silence", loudness: silenceLUFS, truePeak: silenceDBTP, want: 52},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := replayGain(tc.loudness, tc.truePeak); got != tc.want {
+				t.Fatalf("replayGain(%v, %v) = %v, want %v", tc.loudness, tc.truePeak, got, tc.want)
+			}
+		})
+	}
+}
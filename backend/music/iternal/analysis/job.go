@@ -0,0 +1,147 @@
+package analysis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"music/iternal/repository"
+	"music/iternal/storage"
+)
+
+const (
+	// Как часто проверять очередь, когда она пуста
+	pollInterval = 10 * time.Second
+	// Сколько трек числится за обработчиком; потом его возьмет другой
+	lease = 10 * time.Minute
+	// Попыток при ошибках S3 и базы; ошибки в самом файле не повторяются
+	maxAttempts = 5
+	// Больше загрузка трека не пропускает
+	maxTrackSize = 100 << 20
+)
+
+// Run анализирует загруженные треки из очереди track_analysis, пока не отменен ctx.
+// Несколько экземпляров music берут разные треки.
+func Run(ctx context.Context, repo *repository.Repository, s3 *storage.S3Client) error {
+	for ctx.Err() == nil {
+		now := time.Now().UTC()
+		job, ok, err := repo.ClaimAnalysis(ctx, now, now.Add(lease))
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Printf("⚠ анализ аудио: не удалось взять трек: %v", err)
+			}
+			sleep(ctx, pollInterval)
+			continue
+		}
+		if !ok {
+			sleep(ctx, pollInterval)
+			continue
+		}
+
+		process(ctx, repo, s3, job)
+	}
+
+	log.Println("🛑 Анализ аудио остановлен")
+	return nil
+}
+
+func process(ctx context.Context, repo *repository.Repository, s3 *storage.S3Client, job repository.AnalysisJob) {
+	err := analyze(ctx, repo, s3, job)
+	if err == nil {
+		log.Printf("✅ Трек %s проанализирован", job.TrackID)
+		return
+	}
+	if ctx.Err() != nil {
+		// Трек вернется в очередь, когда истечет lease
+		return
+	}
+
+	var failErr error
+	switch {
+	case errors.Is(err, repository.ErrTrackNotFound):
+		return
+	case isFileError(err) || job.Attempts >= maxAttempts:
+		log.Printf("❌ анализ трека %s: %v", job.TrackID, err)
+		failErr = repo.FailAnalysis(ctx, job.TrackID, err.Error())
+	default:
+		retryAt := time.Now().UTC().Add(time.Duration(job.Attempts*job.Attempts) * time.Minute)
+		log.Printf("⚠ анализ трека %s: %v, повтор в %s", job.TrackID, err, retryAt.Format(time.RFC3339))
+		failErr = repo.RetryAnalysis(ctx, job.TrackID, err.Error(), retryAt)
+	}
+	if failErr != nil {
+		log.Printf("⚠ анализ трека %s: не удалось сохранить ошибку: %v", job.TrackID, failErr)
+	}
+}
+
+func analyze(ctx context.Context, repo *repository.Repository, s3 *storage.S3Client, job repository.AnalysisJob) error {
+	if job.TrackKey == "" {
+		return fmt.Errorf("%w: track has no file", ErrEmpty)
+	}
+
+	obj, err := s3.GetObject(ctx, &storage.GetObjectInput{Key: job.TrackKey})
+	if err != nil {
+		return fmt.Errorf("s3 get %s: %w", job.TrackKey, err)
+	}
+	data, err := io.ReadAll(io.LimitReader(obj.Body, maxTrackSize+1))
+	obj.Body.Close()
+	if err != nil {
+		return fmt.Errorf("s3 read %s: %w", job.TrackKey, err)
+	}
+	if len(data) > maxTrackSize {
+		return fmt.Errorf("%w: file larger than %d bytes", ErrUnsupported, maxTrackSize)
+	}
+
+	res, err := Analyze(data)
+	if err != nil {
+		return err
+	}
+
+	waveform, err := json.Marshal(res.Waveform)
+	if err != nil {
+		return err
+	}
+	waveformKey, err := s3.UploadObject(waveform, "application/json")
+	if err != nil {
+		return err
+	}
+
+	oldKey, err := repo.CompleteAnalysis(ctx, job.TrackID, repository.AnalysisResult{
+		WaveformKey:  waveformKey,
+		DurationMs:   res.DurationMs,
+		LoudnessLUFS: res.LoudnessLUFS,
+		TruePeakDBTP: res.TruePeakDBTP,
+		ReplayGainDB: res.ReplayGainDB,
+		AnalyzedAt:   time.Now().UTC(),
+	})
+	if err != nil {
+		// Волна ни к чему не привязана
+		if delErr := s3.DeleteObject(ctx, waveformKey); delErr != nil {
+			log.Printf("⚠ не удалось удалить волну %s из S3: %v", waveformKey, delErr)
+		}
+		return err
+	}
+
+	if oldKey != "" {
+		if err := s3.DeleteObject(ctx, oldKey); err != nil {
+			log.Printf("⚠ не удалось удалить прежнюю волну %s из S3: %v", oldKey, err)
+		}
+	}
+
+	return nil
+}
+
+// isFileError — ошибка в самом файле: повтор ничего не изменит.
+func isFileError(err error) bool {
+	return errors.Is(err, ErrUnsupported) || errors.Is(err, ErrEmpty) || errors.Is(err, errDecode)
+}
+
+func sleep(ctx context.Context, d time.Duration) {
+	select {
+	case <-ctx.Done():
+	case <-time.After(d):
+	}
+}
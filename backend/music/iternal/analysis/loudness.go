@@ -0,0 +1,242 @@
+package analysis
+
+import "math"
+
+// Громкость по ITU-R BS.1770-4 / EBU R128: K-фильтр, блоки по 400 мс с шагом 100 мс,
+// абсолютный порог -70 LUFS и относительный на 10 LU ниже средней громкости.
+const (
+	absoluteGateLUFS = -70.0
+	relativeGateLU   = -10.0
+	// Блок — 4 шага по 100 мс
+	blockSteps = 4
+	// Сэмплов на один исходный при поиске true peak
+	oversampleTaps = 12
+)
+
+// biquad — фильтр второго порядка, транспонированная прямая форма II.
+type biquad struct {
+	b0, b1, b2, a1, a2 float64
+	z1, z2             float64
+}
+
+func (f *biquad) process(x float64) float64 {
+	y := f.b0*x + f.z1
+	f.z1 = f.b1*x - f.a1*y + f.z2
+	f.z2 = f.b2*x - f.a2*y
+	return y
+}
+
+// kWeighting — коэффициенты K-фильтра для частоты дискретизации rate (как в libebur128):
+// полка +4 дБ на высоких и срез ниже ~38 Гц.
+func kWeighting(rate int) [2]biquad {
+	fs := float64(rate)
+
+	f0, g, q := 1681.974450955533, 3.999843853973347, 0.7071752369554196
+	k := math.Tan(math.Pi * f0 / fs)
+	vh := math.Pow(10, g/20)
+	vb := math.Pow(vh, 0.4996667741545416)
+	a0 := 1 + k/q + k*k
+	shelf := biquad{
+		b0: (vh + vb*k/q + k*k) / a0,
+		b1: 2 * (k*k - vh) / a0,
+		b2: (vh - vb*k/q + k*k) / a0,
+		a1: 2 * (k*k - 1) / a0,
+		a2: (1 - k/q + k*k) / a0,
+	}
+
+	f0, q = 38.13547087602444, 0.5003270373238773
+	k = math.Tan(math.Pi * f0 / fs)
+	a0 = 1 + k/q + k*k
+	highpass := biquad{
+		b0: 1,
+		b1: -2,
+		b2: 1,
+		a1: 2 * (k*k - 1) / a0,
+		a2: (1 - k/q + k*k) / a0,
+	}
+
+	return [2]biquad{shelf, highpass}
+}
+
+// channelWeight — вес канала в сумме: для 5.1 (L R C LFE Ls Rs) LFE не учитывается,
+// тыловые каналы +1.5 дБ; остальные раскладки считаются фронтальными.
+func channelWeight(ch, channels int) float64 {
+	if channels != 6 {
+		return 1
+	}
+	switch ch {
+	case 3:
+		return 0
+	case 4, 5:
+		return 1.41
+	}
+	return 1
+}
+
+type meter struct {
+	filters [][2]biquad
+	weights []float64
+	peaks   []*oversampler
+
+	// Кадров в шаге 100 мс и накопленная взвешенная энергия текущего шага
+	step   int
+	n      int
+	energy float64
+	// Энергия по шагам: из соседних четырех складывается блок
+	steps []float64
+}
+
+func newMeter(rate, channels int) *meter {
+	m := &meter{
+		filters: make([][2]biquad, channels),
+		weights: make([]float64, channels),
+		peaks:   make([]*oversampler, channels),
+		step:    max(rate/10, 1),
+	}
+
+	factor := 4
+	switch {
+	case rate >= 192000:
+		factor = 1
+	case rate >= 96000:
+		factor = 2
+	}
+
+	for ch := range channels {
+		m.filters[ch] = kWeighting(rate)
+		m.weights[ch] = channelWeight(ch, channels)
+		m.peaks[ch] = newOversampler(factor)
+	}
+
+	return m
+}
+
+func (m *meter) add(frame []float64) {
+	for ch, x := range frame {
+		y := m.filters[ch][1].process(m.filters[ch][0].process(x))
+		m.energy += m.weights[ch] * y * y
+		m.peaks[ch].add(x)
+	}
+
+	m.n++
+	if m.n == m.step {
+		m.steps = append(m.steps, m.energy)
+		m.n, m.energy = 0, 0
+	}
+}
+
+// integrated — интегральная громкость в LUFS; false — нет ни одного блока громче -70 LUFS.
+func (m *meter) integrated() (float64, bool) {
+	if len(m.steps) < blockSteps {
+		return 0, false
+	}
+
+	blocks := make([]float64, 0, len(m.steps)-blockSteps+1)
+	for i := 0; i+blockSteps <= len(m.steps); i++ {
+		var sum float64
+		for _, e := range m.steps[i : i+blockSteps] {
+			sum += e
+		}
+		blocks = append(blocks, sum/float64(blockSteps*m.step))
+	}
+
+	gated := func(threshold float64) (float64, bool) {
+		var (
+			sum float64
+			n   int
+		)
+		for _, z := range blocks {
+			if z > threshold {
+				sum += z
+				n++
+			}
+		}
+		if n == 0 {
+			return 0, false
+		}
+		return sum / float64(n), true
+	}
+
+	absolute := fromLUFS(absoluteGateLUFS)
+	mean, ok := gated(absolute)
+	if !ok {
+		return 0, false
+	}
+
+	relative := fromLUFS(toLUFS(mean) + relativeGateLU)
+	mean, ok = gated(max(absolute, relative))
+	if !ok {
+		return 0, false
+	}
+
+	return toLUFS(mean), true
+}
+
+// truePeak — максимум модуля сигнала после передискретизации, линейно.
+func (m *meter) truePeak() float64 {
+	var peak float64
+	for _, o := range m.peaks {
+		peak = max(peak, o.peak)
+	}
+	return peak
+}
+
+func toLUFS(z float64) float64   { return -0.691 + 10*math.Log10(z) }
+func fromLUFS(l float64) float64 { return math.Pow(10, (l+0.691)/10) }
+
+// oversampler ищет межсэмпловые пики: восстанавливает factor-1 промежуточных сэмплов
+// полифазным фильтром (sinc с окном Ханна, oversampleTaps исходных сэмплов на фазу).
+type oversampler struct {
+	factor int
+	// phases[p][k] — коэффициент для x[n-k] в фазе p
+	phases  [][]float64
+	history []float64
+	pos     int
+	peak    float64
+}
+
+func newOversampler(factor int) *oversampler {
+	o := &oversampler{factor: factor}
+	if factor == 1 {
+		return o
+	}
+
+	size := factor * oversampleTaps
+	center := float64(size / 2)
+	o.phases = make([][]float64, factor)
+	for p := range factor {
+		o.phases[p] = make([]float64, oversampleTaps)
+		for k := range oversampleTaps {
+			t := (float64(k*factor+p) - center) / float64(factor)
+			window := 0.5 * (1 + math.Cos(math.Pi*(float64(k*factor+p)-center)/center))
+			o.phases[p][k] = sinc(t) * window
+		}
+	}
+	o.history = make([]float64, oversampleTaps)
+
+	return o
+}
+
+func (o *oversampler) add(x float64) {
+	o.peak = max(o.peak, math.Abs(x))
+	if o.factor == 1 {
+		return
+	}
+
+	o.history[o.pos] = x
+	for _, h := range o.phases {
+		var y float64
+		for k, c := range h {
+			y += c * o.history[(o.pos-k+oversampleTaps)%oversampleTaps]
+		}
+		o.peak = max(o.peak, math.Abs(y))
+	}
+	o.pos = (o.pos + 1) % oversampleTaps
+}
+
+func sinc(x float64) float64 {
+	if x == 0 {
+		return 1
+	}
+	return math.Sin(math.Pi*x) / (math.Pi * x)
+}
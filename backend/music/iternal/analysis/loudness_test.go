@@ -0,0 +1,133 @@
+package analysis
+
+import (
+	"math"
+	"testing"
+)
+
+// Коэффициенты K-фильтра для 48 кГц из ITU-R BS.1770-4, таблицы 1 и 2
+func TestKWeightingCoefficients(t *testing.T) {
+	f := kWeighting(48000)
+
+	testCases := []struct {
+		name string
+		got  biquad
+		want biquad
+	}{
+		{
+			name: "shelf",
+			got:  f[0],
+			want: biquad{b0: 1.53512485958697, b1: -2.69169618940638, b2: 1.19839281085285, a1: -1.69065929318241, a2: 0.73248077421585},
+		},
+		{
+			name: "highpass",
+			got:  f[1],
+			want: biquad{b0: 1, b1: -2, b2: 1, a1: -1.99004745483398, a2: 0.99007225036621},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := []float64{tc.got.b0, tc.got.b1, tc.got.b2, tc.got.a1, tc.got.a2}
+			want := []float64{tc.want.b0, tc.want.b1, tc.want.b2, tc.want.a1, tc.want.a2}
+			for i := range want {
+				if math.Abs(got[i]-want[i]) > 1e-6 {
+					t.Fatalf("coefficients %v, want %v", got, want)
+				}
+			}
+		})
+	}
+}
+
+// gain — усиление K-фильтра на частоте freq в дБ, по RMS установившегося синуса
+func gain(rate int, freq float64) float64 {
+	f := kWeighting(rate)
+
+	var in, out float64
+	for n := range 2 * rate {
+		x := math.Sin(2 * math.Pi * freq * float64(n) / float64(rate))
+		y := f[1].process(f[0].process(x))
+		// Первая секунда — переходный процесс
+		if n >= rate {
+			in += x * x
+			out += y * y
+		}
+	}
+
+	return 10 * math.Log10(out/in)
+}
+
+func TestKWeightingResponse(t *testing.T) {
+	testCases := []struct {
+		name  string
+		freq  float64
+		want  float64
+		delta float64
+	}{
+		// На 997 Гц усиление компенсирует поправку -0.691 в формуле громкости
+		{name: "997 Hz", freq: 997, want: 0.691, delta: 0.02},
+		{name: "high shelf", freq: 10000, want: 4, delta: 0.1},
+		{name: "20 Hz cut", freq: 20, want: -14, delta: 2},
+	}
+
+	for _, rate := range []int{44100, 48000, 96000} {
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				if got := gain(rate, tc.freq); math.Abs(got-tc.want) > tc.delta {
+					t.Fatalf("%d Hz rate: gain %.3f dB, want %.3f ± %.2f", rate, got, tc.want, tc.delta)
+				}
+			})
+		}
+	}
+}
+
+func TestChannelWeight(t *testing.T) {
+	// 5.1: L R C LFE Ls Rs
+	want := []float64{1, 1, 1, 0, 1.41, 1.41}
+	for ch, w := range want {
+		if got := channelWeight(ch, 6); got != w {
+			t.Fatalf("5.1 channel %d: weight %v, want %v", ch, got, w)
+		}
+	}
+	// В остальных раскладках все каналы фронтальные
+	for ch := range 4 {
+		if got := channelWeight(ch, 4); got != 1 {
+			t.Fatalf("4.0 channel %d: weight %v, want 1", ch, got)
+		}
+	}
+}
+
+func TestMeterTooShort(t *testing.T) {
+	m := newMeter(48000, 2)
+	// 300 мс — меньше одного блока
+	for _, x := range tone(48000, 1, segment{0.3, 1000, -6}) {
+		m.add([]float64{x, x})
+	}
+
+	if _, ok := m.integrated(); ok {
+		t.Fatal("integrated loudness measured without a full block")
+	}
+}
+
+// Сэмплы синуса fs/4 со сдвигом 45° не попадают в пик: сэмплы на уровне 0.5 (-6 dBFS),
+// а сам сигнал доходит до 0.5·√2 (-3 dBTP). Допуск как в EBU Tech 3341: +0.2/-0.4 дБ.
+func TestTruePeak(t *testing.T) {
+	for _, rate := range []int{44100, 48000, 96000, 192000} {
+		m := newMeter(rate, 1)
+		for n := range rate {
+			m.add([]float64{0.5 * math.Sqrt2 * math.Sin(math.Pi/2*float64(n)+math.Pi/4)})
+		}
+
+		got := 20 * math.Log10(m.truePeak())
+		if rate == 192000 {
+			// Без передискретизации — только пик по сэмплам
+			if math.Abs(got+6.02) > 0.01 {
+				t.Fatalf("%d Hz: peak %.2f dB, want sample peak -6.02", rate, got)
+			}
+			continue
+		}
+		if want := -3.01; got < want-0.4 || got > want+0.2 {
+			t.Fatalf("%d Hz: true peak %.2f dBTP, want %.2f", rate, got, want)
+		}
+	}
+}
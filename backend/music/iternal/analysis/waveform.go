@@ -0,0 +1,80 @@
+package analysis
+
+import "math"
+
+const (
+	// Мельчайший шаг волны в кадрах; итоговый — кратный ему
+	waveformBlock = 256
+	// Сколько точек в волне не больше
+	WaveformPoints = 2000
+)
+
+// Waveform — волна в формате JSON audiowaveform (version 2), его читают peaks.js и похожие
+// плееры: data — пары min, max для каждой точки, 8 бит, каналы сведены в один.
+type Waveform struct {
+	Version         int    `json:"version"`
+	Channels        int    `json:"channels"`
+	SampleRate      int    `json:"sample_rate"`
+	SamplesPerPixel int    `json:"samples_per_pixel"`
+	Bits            int    `json:"bits"`
+	Length          int    `json:"length"`
+	Data            []int8 `json:"data"`
+}
+
+// peaks собирает min и max по блокам waveformBlock кадров; длина трека заранее неизвестна.
+type peaks struct {
+	mins, maxs []float64
+	lo, hi     float64
+	n          int
+}
+
+func (p *peaks) add(x float64) {
+	if p.n == 0 {
+		p.lo, p.hi = x, x
+	}
+	p.lo, p.hi = min(p.lo, x), max(p.hi, x)
+
+	p.n++
+	if p.n == waveformBlock {
+		p.flush()
+	}
+}
+
+func (p *peaks) flush() {
+	if p.n == 0 {
+		return
+	}
+	p.mins = append(p.mins, p.lo)
+	p.maxs = append(p.maxs, p.hi)
+	p.n = 0
+}
+
+// waveform сжимает блоки до WaveformPoints точек.
+func (p *peaks) waveform(rate int) Waveform {
+	p.flush()
+
+	group := max((len(p.mins)+WaveformPoints-1)/WaveformPoints, 1)
+	w := Waveform{
+		Version:         2,
+		Channels:        1,
+		SampleRate:      rate,
+		SamplesPerPixel: waveformBlock * group,
+		Bits:            8,
+	}
+
+	for i := 0; i < len(p.mins); i += group {
+		end := min(i+group, len(p.mins))
+		lo, hi := p.mins[i], p.maxs[i]
+		for j := i + 1; j < end; j++ {
+			lo, hi = min(lo, p.mins[j]), max(hi, p.maxs[j])
+		}
+		w.Data = append(w.Data, toInt8(lo), toInt8(hi))
+	}
+	w.Length = len(w.Data) / 2
+
+	return w
+}
+
+func toInt8(x float64) int8 {
+	return int8(max(min(math.Round(x*128), 127), -128))
+}
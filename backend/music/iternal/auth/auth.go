@@ -0,0 +1,170 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Сколько помнить ответ интроспекции. Отзыв токена доходит до music не позже чем через cacheTTL.
+const (
+	cacheTTL     = 30 * time.Second
+	cacheMaxSize = 10000
+)
+
+// Identity — владелец access-токена.
+type Identity struct {
+	UserID string
+	Role   string
+}
+
+type ctxKey struct{}
+
+// FromContext возвращает пользователя, которого положил Middleware.
+func FromContext(ctx context.Context) (Identity, bool) {
+	id, ok := ctx.Value(ctxKey{}).(Identity)
+	return id, ok
+}
+
+type introspectResponse struct {
+	Active    bool   `json:"active"`
+	TokenType string `json:"token_type"`
+	Sub       string `json:"sub"`
+	Role      string `json:"role"`
+	EXP       int64  `json:"exp"`
+}
+
+type cached struct {
+	identity Identity
+	active   bool
+	until    time.Time
+}
+
+// Client проверяет access JWT через POST /oauth/introspect сервиса users.
+// Подпись токена music не проверяет: секрет есть только у users.
+type Client struct {
+	url          string
+	serviceToken string
+	http         *http.Client
+
+	mu    sync.Mutex
+	cache map[string]cached
+}
+
+func New(usersURL, serviceToken string) *Client {
+	return &Client{
+		url:          strings.TrimRight(usersURL, "/") + "/oauth/introspect",
+		serviceToken: serviceToken,
+		http:         &http.Client{Timeout: 5 * time.Second},
+		cache:        make(map[string]cached),
+	}
+}
+
+// Introspect возвращает владельца токена; ok == false — токен невалиден, просрочен, отозван или это не access.
+func (c *Client) Introspect(ctx context.Context, token string) (Identity, bool, error) {
+	now := time.Now()
+
+	c.mu.Lock()
+	entry, found := c.cache[token]
+	c.mu.Unlock()
+	if found && now.Before(entry.until) {
+		return entry.identity, entry.active, nil
+	}
+
+	form := url.Values{"token": {token}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, strings.NewReader(form.Encode()))
+	if err != nil {
+		return Identity{}, false, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Authorization", "Bearer "+c.serviceToken)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return Identity{}, false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return Identity{}, false, fmt.Errorf("introspect: status %d", resp.StatusCode)
+	}
+
+	var body introspectResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return Identity{}, false, fmt.Errorf("introspect: %w", err)
+	}
+
+	entry = cached{
+		identity: Identity{UserID: body.Sub, Role: body.Role},
+		active:   body.Active && body.TokenType == "access" && body.Sub != "",
+		until:    now.Add(cacheTTL),
+	}
+	// Кэш не переживает сам токен
+	if entry.active && body.EXP > 0 {
+		if exp := time.Unix(body.EXP, 0); exp.Before(entry.until) {
+			entry.until = exp
+		}
+	}
+	c.store(token, entry, now)
+
+	return entry.identity, entry.active, nil
+}
+
+func (c *Client) store(token string, entry cached, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.cache) >= cacheMaxSize {
+		for k, v := range c.cache {
+			if !now.Before(v.until) {
+				delete(c.cache, k)
+			}
+		}
+		// Все записи еще живые — проще начать заново, чем выбирать, что вытеснить
+		if len(c.cache) >= cacheMaxSize {
+			c.cache = make(map[string]cached)
+		}
+	}
+	c.cache[token] = entry
+}
+
+// Middleware пропускает запросы с действующим access-токеном в Authorization: Bearer
+// и кладет пользователя в контекст (FromContext).
+func (c *Client) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !found || token == "" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		identity, ok, err := c.Introspect(r.Context(), token)
+		if err != nil {
+			http.Error(w, "Сервис пользователей недоступен", http.StatusServiceUnavailable)
+			return
+		}
+		if !ok {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), ctxKey{}, identity)))
+	})
+}
+
+// OptionalMiddleware — Middleware для публичных эндпоинтов: без Authorization запрос
+// проходит анонимно, с ним токен проверяется так же и пользователь кладется в контекст.
+func (c *Client) OptionalMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		c.Middleware(next).ServeHTTP(w, r)
+	})
+}
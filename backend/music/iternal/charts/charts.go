@@ -0,0 +1,239 @@
+package charts
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"log"
+	"slices"
+	"time"
+
+	"music/iternal/repository"
+)
+
+// Виды чартов
+const (
+	KindTracks  = "tracks"
+	KindArtists = "artists"
+	KindRising  = "rising"
+)
+
+var Kinds = []string{KindTracks, KindArtists, KindRising}
+
+// Period — окно чарта и как часто делать снимок.
+type Period struct {
+	Name string
+	// Какие события учитываются; «растущие» сравнивают окно с предыдущим таким же
+	Window time.Duration
+	// За сколько вес события падает вдвое
+	HalfLife time.Duration
+	// Как часто делать снимок
+	Every time.Duration
+	// Сколько хранить снимки
+	Retention time.Duration
+}
+
+var Periods = map[string]Period{
+	"daily": {
+		Name:      "daily",
+		Window:    24 * time.Hour,
+		HalfLife:  6 * time.Hour,
+		Every:     time.Hour,
+		Retention: 30 * 24 * time.Hour,
+	},
+	"weekly": {
+		Name:      "weekly",
+		Window:    7 * 24 * time.Hour,
+		HalfLife:  2 * 24 * time.Hour,
+		Every:     24 * time.Hour,
+		Retention: 365 * 24 * time.Hour,
+	},
+}
+
+const (
+	// Позиций в чарте
+	chartSize = 100
+	// Сглаживание для «растущих»: трек с 0 → 1 не должен обгонять трек с 50 → 200
+	risingSmoothing = 5.0
+	// Минимальная активность за окно, чтобы попасть в «растущие»
+	risingMinScore = 3.0
+	// like_events нужны за два недельных окна
+	likeEventsRetention = 15 * 24 * time.Hour
+	checkInterval       = 5 * time.Minute
+)
+
+// Run делает снимки чартов, когда подходит их время, пока не отменен ctx.
+// При нескольких экземплярах music снимок делает один из них.
+func Run(ctx context.Context, repo *repository.Repository) error {
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	for {
+		for _, p := range Periods {
+			if err := snapshotIfDue(ctx, repo, p, time.Now().UTC()); err != nil && ctx.Err() == nil {
+				log.Printf("❌ Не удалось посчитать чарт %s: %v", p.Name, err)
+			}
+		}
+
+		if err := repo.DeleteLikeEventsBefore(ctx, time.Now().UTC().Add(-likeEventsRetention)); err != nil && ctx.Err() == nil {
+			log.Printf("⚠ не удалось почистить like_events: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Println("🛑 Расчет чартов остановлен")
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// NextAt — когда ожидается следующий снимок после снимка computedAt.
+func (p Period) NextAt(computedAt time.Time) time.Time {
+	return computedAt.Add(p.Every)
+}
+
+func snapshotIfDue(ctx context.Context, repo *repository.Repository, p Period, now time.Time) error {
+	return repo.InTx(ctx, func(tx *repository.Repository) error {
+		locked, err := tx.TryLock(ctx, "charts:"+p.Name)
+		if err != nil || !locked {
+			return err
+		}
+
+		// Проверка под блокировкой: другой экземпляр мог только что сделать снимок
+		last, err := tx.LatestChartAt(ctx, p.Name)
+		if err != nil {
+			return err
+		}
+		if now.Before(p.NextAt(last)) {
+			return nil
+		}
+
+		if err := snapshot(ctx, tx, p, now); err != nil {
+			return err
+		}
+
+		if err := tx.DeleteChartsBefore(ctx, p.Name, now.Add(-p.Retention)); err != nil {
+			return err
+		}
+
+		log.Printf("✅ Чарт %s посчитан", p.Name)
+		return nil
+	})
+}
+
+func snapshot(ctx context.Context, tx *repository.Repository, p Period, now time.Time) error {
+	current, err := tx.ChartScores(ctx, now.Add(-p.Window), now, p.HalfLife)
+	if err != nil {
+		return err
+	}
+	previous, err := tx.ChartScores(ctx, now.Add(-2*p.Window), now.Add(-p.Window), p.HalfLife)
+	if err != nil {
+		return err
+	}
+
+	charts := map[string][]scored{
+		KindTracks:  topTracks(current),
+		KindArtists: topArtists(current),
+		KindRising:  rising(current, previous),
+	}
+
+	for _, kind := range Kinds {
+		before, err := previousPositions(ctx, tx, p.Name, kind)
+		if err != nil {
+			return err
+		}
+
+		list := charts[kind]
+		entries := make([]repository.ChartEntry, 0, len(list))
+		for i, s := range list {
+			e := repository.ChartEntry{Position: i + 1, ItemID: s.id, Score: s.score}
+			if pos, ok := before[s.id]; ok {
+				e.PreviousPosition = &pos
+			}
+			entries = append(entries, e)
+		}
+
+		if err := tx.SaveChart(ctx, p.Name, kind, now, entries); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type scored struct {
+	id    string
+	score float64
+}
+
+func topTracks(current []repository.TrackScore) []scored {
+	list := make([]scored, 0, len(current))
+	for _, s := range current {
+		list = append(list, scored{s.TrackID, s.Score})
+	}
+	return top(list)
+}
+
+func topArtists(current []repository.TrackScore) []scored {
+	sums := make(map[string]float64)
+	for _, s := range current {
+		sums[s.ArtistID] += s.Score
+	}
+
+	list := make([]scored, 0, len(sums))
+	for id, score := range sums {
+		list = append(list, scored{id, score})
+	}
+	return top(list)
+}
+
+// rising — рост активности трека относительно предыдущего окна.
+func rising(current, previous []repository.TrackScore) []scored {
+	before := make(map[string]float64, len(previous))
+	for _, s := range previous {
+		before[s.TrackID] = max(s.Score, 0)
+	}
+
+	list := make([]scored, 0, len(current))
+	for _, s := range current {
+		if s.Score < risingMinScore {
+			continue
+		}
+		prev := before[s.TrackID]
+		list = append(list, scored{s.TrackID, (s.Score - prev) / (prev + risingSmoothing)})
+	}
+	return top(list)
+}
+
+// top — положительные оценки по убыванию, не больше chartSize.
+func top(list []scored) []scored {
+	list = slices.DeleteFunc(list, func(s scored) bool { return s.score <= 0 })
+	slices.SortFunc(list, func(a, b scored) int {
+		if c := cmp.Compare(b.score, a.score); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.id, b.id)
+	})
+
+	if len(list) > chartSize {
+		list = list[:chartSize]
+	}
+	return list
+}
+
+func previousPositions(ctx context.Context, tx *repository.Repository, period, kind string) (map[string]int, error) {
+	_, entries, err := tx.GetChart(ctx, period, kind, nil, chartSize)
+	if errors.Is(err, repository.ErrChartNotFound) {
+		return map[string]int{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	positions := make(map[string]int, len(entries))
+	for _, e := range entries {
+		positions[e.ItemID] = e.Position
+	}
+	return positions, nil
+}
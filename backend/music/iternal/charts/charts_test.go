@@ -0,0 +1,156 @@
+package charts
+
+import (
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"music/iternal/repository"
+)
+
+func checkScored(t *testing.T, got, want []scored) {
+	t.Helper()
+
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i].id != want[i].id || math.Abs(got[i].score-want[i].score) > 1e-9 {
+			t.Fatalf("position %d: got %v, want %v", i+1, got[i], want[i])
+		}
+	}
+}
+
+func TestTop(t *testing.T) {
+	many := make([]scored, 0, chartSize+10)
+	for i := range chartSize + 10 {
+		many = append(many, scored{fmt.Sprintf("t%03d", i), float64(i + 1)})
+	}
+
+	testCases := []struct {
+		name    string
+		list    []scored
+		wantLen int
+		want    []scored
+	}{
+		{
+			name: "descending by score",
+			list: []scored{{"a", 1}, {"b", 3}, {"c", 2}},
+			want: []scored{{"b", 3}, {"c", 2}, {"a", 1}},
+		},
+		{
+			name: "ties ordered by id",
+			list: []scored{{"b", 1}, {"c", 1}, {"a", 1}},
+			want: []scored{{"a", 1}, {"b", 1}, {"c", 1}},
+		},
+		{
+			name: "zero and negative scores dropped",
+			list: []scored{{"a", 0}, {"b", -1}, {"c", 0.5}},
+			want: []scored{{"c", 0.5}},
+		},
+		{
+			name:    "cut to chart size",
+			list:    many,
+			wantLen: chartSize,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := top(tc.list)
+			if tc.want != nil {
+				checkScored(t, got, tc.want)
+				return
+			}
+			if len(got) != tc.wantLen {
+				t.Fatalf("got %d entries, want %d", len(got), tc.wantLen)
+			}
+			if got[0].score != float64(chartSize+10) {
+				t.Fatalf("first entry %v, want the highest score", got[0])
+			}
+		})
+	}
+}
+
+func TestTopArtists(t *testing.T) {
+	current := []repository.TrackScore{
+		{TrackID: "a1", ArtistID: "a", Score: 2},
+		{TrackID: "a2", ArtistID: "a", Score: 3},
+		{TrackID: "b1", ArtistID: "b", Score: 4},
+		{TrackID: "c1", ArtistID: "c", Score: 0},
+	}
+
+	// Оценка исполнителя — сумма оценок его треков
+	checkScored(t, topArtists(current), []scored{{"a", 5}, {"b", 4}})
+}
+
+func TestTopTracks(t *testing.T) {
+	current := []repository.TrackScore{
+		{TrackID: "a1", ArtistID: "a", Score: 2},
+		{TrackID: "b1", ArtistID: "b", Score: 4},
+	}
+
+	checkScored(t, topTracks(current), []scored{{"b1", 4}, {"a1", 2}})
+}
+
+func TestRising(t *testing.T) {
+	testCases := []struct {
+		name     string
+		current  []repository.TrackScore
+		previous []repository.TrackScore
+		want     []scored
+	}{
+		{
+			name:    "new track",
+			current: []repository.TrackScore{{TrackID: "a", Score: 10}},
+			want:    []scored{{"a", 10 / risingSmoothing}},
+		},
+		{
+			// Сглаживание: 50 → 200 растет быстрее, чем 0 → 3
+			name:     "smoothing favours sustained growth",
+			current:  []repository.TrackScore{{TrackID: "small", Score: 3}, {TrackID: "big", Score: 200}},
+			previous: []repository.TrackScore{{TrackID: "big", Score: 50}},
+			want:     []scored{{"big", 150 / (50 + risingSmoothing)}, {"small", 3 / risingSmoothing}},
+		},
+		{
+			name:    "below minimum activity",
+			current: []repository.TrackScore{{TrackID: "a", Score: risingMinScore - 0.1}},
+			want:    []scored{},
+		},
+		{
+			name:     "falling tracks dropped",
+			current:  []repository.TrackScore{{TrackID: "a", Score: 5}},
+			previous: []repository.TrackScore{{TrackID: "a", Score: 20}},
+			want:     []scored{},
+		},
+		{
+			// Отрицательная оценка прошлого окна (отозванные лайки) считается нулем
+			name:     "negative previous score clamped",
+			current:  []repository.TrackScore{{TrackID: "a", Score: 5}},
+			previous: []repository.TrackScore{{TrackID: "a", Score: -4}},
+			want:     []scored{{"a", 5 / risingSmoothing}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			checkScored(t, rising(tc.current, tc.previous), tc.want)
+		})
+	}
+}
+
+func TestNextAt(t *testing.T) {
+	at := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	if got := Periods["daily"].NextAt(at); !got.Equal(at.Add(time.Hour)) {
+		t.Fatalf("daily NextAt = %s", got)
+	}
+	if got := Periods["weekly"].NextAt(at); !got.Equal(at.Add(24 * time.Hour)) {
+		t.Fatalf("weekly NextAt = %s", got)
+	}
+	// Снимка еще не было: нулевое время, снимок нужен сразу
+	if got := Periods["daily"].NextAt(time.Time{}); !got.Before(at) {
+		t.Fatalf("NextAt of zero time = %s, want in the past", got)
+	}
+}
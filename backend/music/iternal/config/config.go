@@ -3,6 +3,8 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -17,3 +19,21 @@ func LoadEnv() {
 func Get(key string) string {
 	return os.Getenv(key)
 }
+
+// GetInt возвращает значение переменной как число или def, если она не задана или не число.
+func GetInt(key string, def int) int {
+	v, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return def
+	}
+	return v
+}
+
+// GetDuration возвращает значение переменной как длительность ("6h", "30m") или def.
+func GetDuration(key string, def time.Duration) time.Duration {
+	v, err := time.ParseDuration(os.Getenv(key))
+	if err != nil || v <= 0 {
+		return def
+	}
+	return v
+}
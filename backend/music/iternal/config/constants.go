@@ -1 +1,5 @@
 package config
+
+// MusicEventsTopic — топик событий music (track_published, track_hidden, track_restored,
+// track_deleted, comment_posted), их читают сервисы users и notifications
+const MusicEventsTopic = "music_events"
@@ -0,0 +1,264 @@
+package covers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	_ "image/png"
+	"log"
+	"math"
+
+	"music/iternal/storage"
+
+	"github.com/buckket/go-blurhash"
+	"github.com/chai2010/webp"
+	"github.com/google/uuid"
+	"golang.org/x/image/draw"
+)
+
+// Форматы вариантов обложки
+const (
+	FormatWebP = "webp"
+	FormatJPEG = "jpeg"
+)
+
+var (
+	// Стороны вариантов: обложка вписывается в квадрат, меньшие картинки не растягиваются
+	Sizes   = []int{64, 300, 1000}
+	Formats = []string{FormatWebP, FormatJPEG}
+
+	ErrUnsupported = errors.New("cover must be JPEG, PNG or WebP")
+	ErrTooLarge    = errors.New("cover is too large")
+)
+
+const (
+	// Защита от картинок, которые при декодировании займут гигабайты
+	maxSide   = 10000
+	maxPixels = 50_000_000
+
+	webpQuality = 80
+	jpegQuality = 85
+
+	// Обложка отдается в coverUrl — для клиентов, которые не знают про covers
+	defaultSize = 1000
+)
+
+var contentTypes = map[string]string{
+	FormatWebP: "image/webp",
+	FormatJPEG: "image/jpeg",
+}
+
+var extensions = map[string]string{
+	FormatWebP: "webp",
+	FormatJPEG: "jpg",
+}
+
+type Variant struct {
+	Size   int
+	Format string
+	Data   []byte
+}
+
+// Processed — обложка, готовая к загрузке: варианты без метаданных исходного файла.
+type Processed struct {
+	Variants []Variant
+	// Заглушка, пока грузится картинка, и основной цвет вида #rrggbb
+	Blurhash string
+	Color    string
+}
+
+// Cover — загруженная обложка. Key — вариант для coverUrl, остальные — Key(Prefix, ...).
+type Cover struct {
+	Key      string
+	Prefix   string
+	Blurhash string
+	Color    string
+}
+
+// Key — ключ варианта в S3.
+func Key(prefix string, size int, format string) string {
+	return fmt.Sprintf("%s/%d.%s", prefix, size, extensions[format])
+}
+
+// Keys — ключи всех вариантов обложки; пустой prefix — обложка не обработана.
+func Keys(prefix string) []string {
+	if prefix == "" {
+		return nil
+	}
+
+	keys := make([]string, 0, len(Sizes)*len(Formats))
+	for _, size := range Sizes {
+		for _, format := range Formats {
+			keys = append(keys, Key(prefix, size, format))
+		}
+	}
+	return keys
+}
+
+// Process проверяет тип по первым байтам, поворачивает по EXIF и делает варианты
+// всех размеров. Перекодирование отбрасывает EXIF и прочие метаданные.
+func Process(data []byte) (Processed, error) {
+	if !supported(data) {
+		return Processed{}, ErrUnsupported
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return Processed{}, fmt.Errorf("%w: %v", ErrUnsupported, err)
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width > maxSide || cfg.Height > maxSide || cfg.Width*cfg.Height > maxPixels {
+		return Processed{}, fmt.Errorf("%w: %dx%d", ErrTooLarge, cfg.Width, cfg.Height)
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return Processed{}, fmt.Errorf("%w: %v", ErrUnsupported, err)
+	}
+
+	img := orient(flatten(src), orientation(data))
+
+	var p Processed
+	// От большего к меньшему: каждый следующий размер уменьшается из предыдущего
+	scaled := img
+	for i := len(Sizes) - 1; i >= 0; i-- {
+		scaled = fit(scaled, Sizes[i])
+
+		for _, format := range Formats {
+			var buf bytes.Buffer
+			switch format {
+			case FormatWebP:
+				err = webp.Encode(&buf, scaled, &webp.Options{Quality: webpQuality})
+			case FormatJPEG:
+				err = jpeg.Encode(&buf, scaled, &jpeg.Options{Quality: jpegQuality})
+			}
+			if err != nil {
+				return Processed{}, fmt.Errorf("encode %s %d: %w", format, Sizes[i], err)
+			}
+			p.Variants = append(p.Variants, Variant{Size: Sizes[i], Format: format, Data: buf.Bytes()})
+		}
+	}
+
+	// Самый маленький вариант: для заглушки и цвета детали не нужны
+	w, h := scaled.Bounds().Dx(), scaled.Bounds().Dy()
+	p.Blurhash, err = blurhash.Encode(components(w, max(w, h)), components(h, max(w, h)), scaled)
+	if err != nil {
+		return Processed{}, fmt.Errorf("blurhash: %w", err)
+	}
+	p.Color = dominantColor(scaled)
+
+	return p, nil
+}
+
+// Store загружает варианты под новым префиксом. Если загрузка не удалась, уже
+// загруженные варианты удаляются.
+func Store(ctx context.Context, s3 *storage.S3Client, p Processed) (Cover, error) {
+	c := Cover{
+		Prefix:   "covers/" + uuid.NewString(),
+		Blurhash: p.Blurhash,
+		Color:    p.Color,
+	}
+	c.Key = Key(c.Prefix, defaultSize, FormatJPEG)
+
+	var uploaded []string
+	for _, v := range p.Variants {
+		key := Key(c.Prefix, v.Size, v.Format)
+		if err := s3.PutObject(ctx, key, v.Data, contentTypes[v.Format]); err != nil {
+			for _, k := range uploaded {
+				if delErr := s3.DeleteObject(ctx, k); delErr != nil {
+					log.Printf("⚠ не удалось удалить вариант обложки %s из S3: %v", k, delErr)
+				}
+			}
+			return Cover{}, err
+		}
+		uploaded = append(uploaded, key)
+	}
+
+	return c, nil
+}
+
+func supported(data []byte) bool {
+	switch {
+	case bytes.HasPrefix(data, []byte{0xFF, 0xD8, 0xFF}):
+		return true
+	case bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")):
+		return true
+	case len(data) >= 12 && string(data[:4]) == "RIFF" && string(data[8:12]) == "WEBP":
+		return true
+	}
+	return false
+}
+
+// flatten кладет картинку на белый фон: у JPEG нет прозрачности, а варианты должны совпадать.
+func flatten(src image.Image) *image.RGBA {
+	b := src.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Bounds(), src, b.Min, draw.Over)
+	return dst
+}
+
+// fit вписывает картинку в квадрат size x size, сохраняя пропорции.
+func fit(src *image.RGBA, size int) *image.RGBA {
+	w, h := src.Bounds().Dx(), src.Bounds().Dy()
+	if w <= size && h <= size {
+		return src
+	}
+
+	if w >= h {
+		w, h = size, max(h*size/w, 1)
+	} else {
+		w, h = max(w*size/h, 1), size
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, src.Bounds(), draw.Src, nil)
+	return dst
+}
+
+// components — число компонент blurhash по стороне: 4 по длинной, меньше по короткой.
+func components(side, longest int) int {
+	return min(max(int(math.Round(4*float64(side)/float64(longest))), 1), 9)
+}
+
+// dominantColor — средний цвет самой частой ячейки при 16 уровнях на канал.
+func dominantColor(img *image.RGBA) string {
+	type bucket struct {
+		n       int
+		r, g, b int
+	}
+	var (
+		buckets = map[int]*bucket{}
+		best    *bucket
+	)
+
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := img.RGBAAt(x, y)
+			id := int(c.R>>4)<<8 | int(c.G>>4)<<4 | int(c.B>>4)
+
+			bk := buckets[id]
+			if bk == nil {
+				bk = &bucket{}
+				buckets[id] = bk
+			}
+			bk.n++
+			bk.r += int(c.R)
+			bk.g += int(c.G)
+			bk.b += int(c.B)
+
+			if best == nil || bk.n > best.n {
+				best = bk
+			}
+		}
+	}
+	if best == nil {
+		return "#ffffff"
+	}
+
+	return fmt.Sprintf("#%02x%02x%02x", best.r/best.n, best.g/best.n, best.b/best.n)
+}
@@ -0,0 +1,298 @@
+package covers
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"slices"
+	"testing"
+
+	"github.com/buckket/go-blurhash"
+	"github.com/chai2010/webp"
+)
+
+func solid(w, h int, c color.Color) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := range h {
+		for x := range w {
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("png.Encode() error = %v", err)
+	}
+	return buf.Bytes()
+}
+
+// pngHeader — только сигнатура и IHDR: DecodeConfig хватает, пиксели не нужны
+func pngHeader(w, h int) []byte {
+	ihdr := make([]byte, 0, 17)
+	ihdr = append(ihdr, "IHDR"...)
+	ihdr = binary.BigEndian.AppendUint32(ihdr, uint32(w))
+	ihdr = binary.BigEndian.AppendUint32(ihdr, uint32(h))
+	// 8 бит, RGBA, без интерлейса
+	ihdr = append(ihdr, 8, 6, 0, 0, 0)
+
+	data := []byte("\x89PNG\r\n\x1a\n")
+	data = binary.BigEndian.AppendUint32(data, 13)
+	data = append(data, ihdr...)
+	return binary.BigEndian.AppendUint32(data, crc32.ChecksumIEEE(ihdr))
+}
+
+func TestSupported(t *testing.T) {
+	testCases := []struct {
+		name string
+		data []byte
+		want bool
+	}{
+		{name: "jpeg", data: []byte{0xFF, 0xD8, 0xFF, 0xE0}, want: true},
+		{name: "png", data: []byte("\x89PNG\r\n\x1a\n\x00"), want: true},
+		{name: "webp", data: []byte("RIFF\x10\x00\x00\x00WEBPVP8 "), want: true},
+		{name: "gif", data: []byte("GIF89a")},
+		{name: "riff but not webp", data: []byte("RIFF\x10\x00\x00\x00WAVEfmt ")},
+		{name: "truncated jpeg", data: []byte{0xFF, 0xD8}},
+		{name: "svg", data: []byte("<svg xmlns=")},
+		{name: "empty"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := supported(tc.data); got != tc.want {
+				t.Fatalf("supported() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestProcess(t *testing.T) {
+	red := color.RGBA{R: 255, A: 255}
+
+	testCases := []struct {
+		name      string
+		img       image.Image
+		wantSizes map[int]image.Point
+		wantColor string
+		// Компоненты blurhash по горизонтали и вертикали
+		wantX, wantY int
+	}{
+		{
+			name:      "landscape",
+			img:       solid(1200, 600, red),
+			wantSizes: map[int]image.Point{1000: {1000, 500}, 300: {300, 150}, 64: {64, 32}},
+			wantColor: "#ff0000",
+			wantX:     4,
+			wantY:     2,
+		},
+		{
+			name:      "portrait",
+			img:       solid(300, 900, red),
+			wantSizes: map[int]image.Point{1000: {300, 900}, 300: {100, 300}, 64: {21, 64}},
+			wantColor: "#ff0000",
+			wantX:     1,
+			wantY:     4,
+		},
+		{
+			// Маленькие картинки не растягиваются
+			name:      "small",
+			img:       solid(50, 40, red),
+			wantSizes: map[int]image.Point{1000: {50, 40}, 300: {50, 40}, 64: {50, 40}},
+			wantColor: "#ff0000",
+			wantX:     4,
+			wantY:     3,
+		},
+		{
+			// Прозрачность ложится на белый фон
+			name:      "transparent",
+			img:       solid(100, 100, color.RGBA{}),
+			wantSizes: map[int]image.Point{1000: {100, 100}, 300: {100, 100}, 64: {64, 64}},
+			wantColor: "#ffffff",
+			wantX:     4,
+			wantY:     4,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := Process(encodePNG(t, tc.img))
+			if err != nil {
+				t.Fatalf("Process() error = %v", err)
+			}
+
+			// От большего к меньшему, в каждом размере все форматы
+			if len(p.Variants) != len(Sizes)*len(Formats) {
+				t.Fatalf("got %d variants", len(p.Variants))
+			}
+			for i, v := range p.Variants {
+				if size, format := Sizes[len(Sizes)-1-i/len(Formats)], Formats[i%len(Formats)]; v.Size != size || v.Format != format {
+					t.Fatalf("variant %d is %d %s, want %d %s", i, v.Size, v.Format, size, format)
+				}
+
+				var cfg image.Config
+				switch v.Format {
+				case FormatWebP:
+					cfg, err = webp.DecodeConfig(bytes.NewReader(v.Data))
+				case FormatJPEG:
+					cfg, err = jpeg.DecodeConfig(bytes.NewReader(v.Data))
+				}
+				if err != nil {
+					t.Fatalf("variant %d %s does not decode: %v", v.Size, v.Format, err)
+				}
+				if got := (image.Point{cfg.Width, cfg.Height}); got != tc.wantSizes[v.Size] {
+					t.Fatalf("variant %d %s is %v, want %v", v.Size, v.Format, got, tc.wantSizes[v.Size])
+				}
+			}
+
+			if p.Color != tc.wantColor {
+				t.Fatalf("color = %s, want %s", p.Color, tc.wantColor)
+			}
+			x, y, err := blurhash.Components(p.Blurhash)
+			if err != nil {
+				t.Fatalf("blurhash %q: %v", p.Blurhash, err)
+			}
+			if x != tc.wantX || y != tc.wantY {
+				t.Fatalf("blurhash components %dx%d, want %dx%d", x, y, tc.wantX, tc.wantY)
+			}
+		})
+	}
+}
+
+func TestProcessErrors(t *testing.T) {
+	testCases := []struct {
+		name    string
+		data    []byte
+		wantErr error
+	}{
+		{name: "gif", data: []byte("GIF89a\x01\x00\x01\x00"), wantErr: ErrUnsupported},
+		{name: "broken jpeg", data: []byte{0xFF, 0xD8, 0xFF, 0xE0, 0x00, 0x10, 'J', 'F'}, wantErr: ErrUnsupported},
+		{name: "side too long", data: pngHeader(maxSide+1, 10), wantErr: ErrTooLarge},
+		{name: "too many pixels", data: pngHeader(8000, 8000), wantErr: ErrTooLarge},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := Process(tc.data); !errors.Is(err, tc.wantErr) {
+				t.Fatalf("Process() error = %v, want %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestFit(t *testing.T) {
+	testCases := []struct {
+		name string
+		w, h int
+		size int
+		want image.Point
+	}{
+		{name: "landscape", w: 1200, h: 600, size: 1000, want: image.Point{1000, 500}},
+		{name: "portrait", w: 600, h: 1200, size: 300, want: image.Point{150, 300}},
+		{name: "square", w: 2000, h: 2000, size: 64, want: image.Point{64, 64}},
+		{name: "thin line keeps one pixel", w: 5000, h: 1, size: 64, want: image.Point{64, 1}},
+		{name: "exactly the size", w: 300, h: 200, size: 300, want: image.Point{300, 200}},
+		{name: "smaller than the size", w: 50, h: 40, size: 64, want: image.Point{50, 40}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			src := solid(tc.w, tc.h, color.White)
+
+			got := fit(src, tc.size)
+			if size := got.Bounds().Size(); size != tc.want {
+				t.Fatalf("fit() size %v, want %v", size, tc.want)
+			}
+			// Картинка, которая уже влезает, не копируется
+			if tc.w <= tc.size && tc.h <= tc.size && got != src {
+				t.Fatal("fit() copied an image that already fits")
+			}
+		})
+	}
+}
+
+func TestComponents(t *testing.T) {
+	testCases := []struct {
+		side, longest int
+		want          int
+	}{
+		{side: 64, longest: 64, want: 4},
+		{side: 32, longest: 64, want: 2},
+		{side: 40, longest: 64, want: 3},
+		{side: 21, longest: 64, want: 1},
+		{side: 1, longest: 64, want: 1},
+	}
+
+	for _, tc := range testCases {
+		if got := components(tc.side, tc.longest); got != tc.want {
+			t.Fatalf("components(%d, %d) = %d, want %d", tc.side, tc.longest, got, tc.want)
+		}
+	}
+}
+
+func TestDominantColor(t *testing.T) {
+	testCases := []struct {
+		name   string
+		pixels []color.RGBA
+		want   string
+	}{
+		{
+			name:   "most frequent wins",
+			pixels: []color.RGBA{{0, 0, 255, 255}, {255, 0, 0, 255}, {255, 0, 0, 255}},
+			want:   "#ff0000",
+		},
+		{
+			// Близкие оттенки попадают в одну ячейку и усредняются
+			name:   "bucket average",
+			pixels: []color.RGBA{{250, 0, 0, 255}, {246, 0, 0, 255}, {0, 0, 255, 255}},
+			want:   "#f80000",
+		},
+		{
+			// Ячейки по 16 уровней: 0x1f и 0x20 — уже разные
+			name:   "bucket boundary",
+			pixels: []color.RGBA{{0x1f, 0, 0, 255}, {0x20, 0, 0, 255}, {0x2f, 0, 0, 255}},
+			want:   "#270000",
+		},
+		{
+			name: "empty image",
+			want: "#ffffff",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			img := image.NewRGBA(image.Rect(0, 0, len(tc.pixels), 1))
+			for x, c := range tc.pixels {
+				img.SetRGBA(x, 0, c)
+			}
+
+			if got := dominantColor(img); got != tc.want {
+				t.Fatalf("dominantColor() = %s, want %s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestKeys(t *testing.T) {
+	if keys := Keys(""); keys != nil {
+		t.Fatalf("Keys(\"\") = %v, want nil", keys)
+	}
+
+	want := []string{
+		"covers/x/64.webp", "covers/x/64.jpg",
+		"covers/x/300.webp", "covers/x/300.jpg",
+		"covers/x/1000.webp", "covers/x/1000.jpg",
+	}
+	if keys := Keys("covers/x"); !slices.Equal(keys, want) {
+		t.Fatalf("Keys() = %v, want %v", keys, want)
+	}
+}
@@ -0,0 +1,175 @@
+package covers
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"slices"
+	"testing"
+)
+
+// exifSegment — APP1 с TIFF-заголовком и IFD0 из entries (тег, значение SHORT)
+func exifSegment(order binary.AppendByteOrder, entries ...[2]uint16) []byte {
+	tiff := []byte("II")
+	if order == binary.AppendByteOrder(binary.BigEndian) {
+		tiff = []byte("MM")
+	}
+	tiff = order.AppendUint16(tiff, 42)
+	tiff = order.AppendUint32(tiff, 8)
+	tiff = order.AppendUint16(tiff, uint16(len(entries)))
+	for _, e := range entries {
+		tiff = order.AppendUint16(tiff, e[0])
+		// Тип 3 (SHORT), одно значение, лежит в начале поля значения
+		tiff = order.AppendUint16(tiff, 3)
+		tiff = order.AppendUint32(tiff, 1)
+		tiff = order.AppendUint16(tiff, e[1])
+		tiff = order.AppendUint16(tiff, 0)
+	}
+	tiff = order.AppendUint32(tiff, 0)
+
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	seg := []byte{0xFF, 0xE1}
+	seg = binary.BigEndian.AppendUint16(seg, uint16(len(payload)+2))
+	return append(seg, payload...)
+}
+
+// withSegments вставляет сегменты сразу после SOI
+func withSegments(jpg []byte, segments ...[]byte) []byte {
+	out := append([]byte{}, jpg[:2]...)
+	for _, s := range segments {
+		out = append(out, s...)
+	}
+	return append(out, jpg[2:]...)
+}
+
+func encodeJPEG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, nil); err != nil {
+		t.Fatalf("jpeg.Encode() error = %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestOrientation(t *testing.T) {
+	jpg := encodeJPEG(t, solid(8, 8, color.White))
+	app0 := []byte{0xFF, 0xE0, 0x00, 0x10, 'J', 'F', 'I', 'F', 0, 1, 1, 0, 0, 1, 0, 1, 0, 0}
+
+	testCases := []struct {
+		name string
+		data []byte
+		want int
+	}{
+		{name: "no exif", data: jpg, want: 1},
+		{name: "little endian", data: withSegments(jpg, exifSegment(binary.LittleEndian, [2]uint16{0x0112, 6})), want: 6},
+		{name: "big endian", data: withSegments(jpg, exifSegment(binary.BigEndian, [2]uint16{0x0112, 8})), want: 8},
+		{name: "after jfif", data: withSegments(jpg, app0, exifSegment(binary.LittleEndian, [2]uint16{0x0112, 3})), want: 3},
+		{
+			name: "not the first tag",
+			data: withSegments(jpg, exifSegment(binary.BigEndian, [2]uint16{0x010F, 7}, [2]uint16{0x0112, 5})),
+			want: 5,
+		},
+		{name: "tag missing", data: withSegments(jpg, exifSegment(binary.LittleEndian, [2]uint16{0x010F, 6})), want: 1},
+		{name: "zero value", data: withSegments(jpg, exifSegment(binary.LittleEndian, [2]uint16{0x0112, 0})), want: 1},
+		{name: "value out of range", data: withSegments(jpg, exifSegment(binary.LittleEndian, [2]uint16{0x0112, 9})), want: 1},
+		{
+			name: "no start of image",
+			data: exifSegment(binary.LittleEndian, [2]uint16{0x0112, 6}),
+			want: 1,
+		},
+		{
+			name: "segment longer than file",
+			data: append([]byte{0xFF, 0xD8}, exifSegment(binary.LittleEndian, [2]uint16{0x0112, 6})[:20]...),
+			want: 1,
+		},
+		{name: "png", data: encodePNG(t, solid(2, 2, color.White)), want: 1},
+		{name: "empty", want: 1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := orientation(tc.data); got != tc.want {
+				t.Fatalf("orientation() = %d, want %d", got, tc.want)
+			}
+		})
+	}
+}
+
+// label — картинка 3x2 с буквами a-f в красном канале:
+//
+//	a b c
+//	d e f
+func label() *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, 3, 2))
+	for i, l := range "abcdef" {
+		img.SetRGBA(i%3, i/3, color.RGBA{R: uint8(l), A: 255})
+	}
+	return img
+}
+
+// read — строки картинки, как их увидит просмотрщик
+func read(img *image.RGBA) []string {
+	var rows []string
+	b := img.Bounds()
+	for y := range b.Dy() {
+		var row []byte
+		for x := range b.Dx() {
+			row = append(row, img.RGBAAt(x, y).R)
+		}
+		rows = append(rows, string(row))
+	}
+	return rows
+}
+
+func TestOrient(t *testing.T) {
+	testCases := []struct {
+		orientation int
+		want        []string
+	}{
+		{orientation: 0, want: []string{"abc", "def"}},
+		{orientation: 1, want: []string{"abc", "def"}},
+		{orientation: 2, want: []string{"cba", "fed"}},
+		{orientation: 3, want: []string{"fed", "cba"}},
+		{orientation: 4, want: []string{"def", "abc"}},
+		{orientation: 5, want: []string{"ad", "be", "cf"}},
+		{orientation: 6, want: []string{"da", "eb", "fc"}},
+		{orientation: 7, want: []string{"fc", "eb", "da"}},
+		{orientation: 8, want: []string{"cf", "be", "ad"}},
+		{orientation: 9, want: []string{"abc", "def"}},
+	}
+
+	for _, tc := range testCases {
+		if got := read(orient(label(), tc.orientation)); !slices.Equal(got, tc.want) {
+			t.Fatalf("orientation %d: %v, want %v", tc.orientation, got, tc.want)
+		}
+	}
+}
+
+// Поворот по EXIF применяется к вариантам: 40x20 с orientation 6 становится 20x40
+func TestProcessOrientation(t *testing.T) {
+	data := withSegments(encodeJPEG(t, solid(40, 20, color.White)), exifSegment(binary.LittleEndian, [2]uint16{0x0112, 6}))
+
+	p, err := Process(data)
+	if err != nil {
+		t.Fatalf("Process() error = %v", err)
+	}
+	for _, v := range p.Variants {
+		if v.Format != FormatJPEG {
+			continue
+		}
+		cfg, err := jpeg.DecodeConfig(bytes.NewReader(v.Data))
+		if err != nil {
+			t.Fatalf("variant %d: %v", v.Size, err)
+		}
+		if cfg.Width != 20 || cfg.Height != 40 {
+			t.Fatalf("variant %d is %dx%d, want 20x40", v.Size, cfg.Width, cfg.Height)
+		}
+		// EXIF не переносится в варианты
+		if orientation(v.Data) != 1 {
+			t.Fatalf("variant %d kept the orientation tag", v.Size)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"music/iternal/charts"
+	"music/iternal/repository"
+	"music/iternal/storage"
+)
+
+// Ссылки на обложку и поток в TrackInfo живут 15 минут, поэтому ответ кэшируется не дольше
+// chartMaxAge, а ETag меняется каждые chartMaxAge: 304 не вернет ссылки старше 2*chartMaxAge.
+const chartMaxAge = 5 * time.Minute
+
+type ChartItem struct {
+	Position         int        `json:"position"`
+	PreviousPosition *int       `json:"previous_position"`
+	Score            float64    `json:"score"`
+	Track            *TrackInfo `json:"track,omitempty"`
+	ArtistID         string     `json:"artist_id,omitempty"`
+}
+
+type ChartResponse struct {
+	Period     string      `json:"period"`
+	Kind       string      `json:"kind"`
+	ComputedAt *time.Time  `json:"computed_at"`
+	Items      []ChartItem `json:"items"`
+}
+
+type ChartSnapshotsResponse struct {
+	Period    string      `json:"period"`
+	Kind      string      `json:"kind"`
+	Snapshots []time.Time `json:"snapshots"`
+}
+
+// GetChartHandler — GET /charts/{period}?kind=tracks|artists|rising&at=&limit=
+// at (RFC3339) — последний снимок не позже этого времени, для истории позиций.
+func GetChartHandler(w http.ResponseWriter, r *http.Request, repo *repository.Repository, s3 *storage.S3Client, periodName string) {
+	period, kind, ok := chartParams(w, r, periodName)
+	if !ok {
+		return
+	}
+
+	limit, err := pageSize(r.URL.Query().Get("limit"))
+	if err != nil {
+		http.Error(w, "Неверный limit", http.StatusBadRequest)
+		return
+	}
+
+	var at *time.Time
+	if v := r.URL.Query().Get("at"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			http.Error(w, "Неверный at, нужен RFC3339", http.StatusBadRequest)
+			return
+		}
+		t = t.UTC()
+		at = &t
+	}
+
+	resp := ChartResponse{Period: period.Name, Kind: kind, Items: []ChartItem{}}
+
+	computedAt, entries, err := repo.GetChart(r.Context(), period.Name, kind, at, limit)
+	if errors.Is(err, repository.ErrChartNotFound) {
+		if at != nil {
+			http.Error(w, "Снимка на это время нет", http.StatusNotFound)
+			return
+		}
+		// Чарт еще не посчитан или событий не было
+		w.Header().Set("Cache-Control", "no-cache")
+		writeChart(w, resp)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	resp.ComputedAt = &computedAt
+
+	now := time.Now()
+	etag := fmt.Sprintf(`W/"%s-%s-%d-%d-%d"`, period.Name, kind, computedAt.UnixNano(), limit, now.Truncate(chartMaxAge).Unix())
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Last-Modified", computedAt.UTC().Format(http.TimeFormat))
+	// Свежий чарт меняется со следующим снимком, старые снимки не меняются
+	maxAge := chartMaxAge
+	if at == nil {
+		maxAge = min(maxAge, max(time.Until(period.NextAt(computedAt)), time.Minute))
+	}
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(maxAge.Seconds())))
+
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	var tracks map[string]repository.Track
+	if kind != charts.KindArtists {
+		ids := make([]string, 0, len(entries))
+		for _, e := range entries {
+			ids = append(ids, e.ItemID)
+		}
+		list, err := repo.TracksByIDs(r.Context(), ids)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		tracks = make(map[string]repository.Track, len(list))
+		for _, t := range list {
+			tracks[t.ID] = t
+		}
+	}
+
+	for _, e := range entries {
+		item := ChartItem{Position: e.Position, PreviousPosition: e.PreviousPosition, Score: e.Score}
+		if kind == charts.KindArtists {
+			item.ArtistID = e.ItemID
+		} else {
+			t, ok := tracks[e.ItemID]
+			if !ok {
+				// Трек удален после снимка
+				continue
+			}
+			info := toTrackInfo(t, s3)
+			item.Track = &info
+		}
+		resp.Items = append(resp.Items, item)
+	}
+
+	writeChart(w, resp)
+}
+
+// GetChartSnapshotsHandler — GET /charts/{period}/snapshots?kind=&limit=
+func GetChartSnapshotsHandler(w http.ResponseWriter, r *http.Request, repo *repository.Repository, periodName string) {
+	period, kind, ok := chartParams(w, r, periodName)
+	if !ok {
+		return
+	}
+
+	limit, err := pageSize(r.URL.Query().Get("limit"))
+	if err != nil {
+		http.Error(w, "Неверный limit", http.StatusBadRequest)
+		return
+	}
+
+	snapshots, err := repo.ChartSnapshots(r.Context(), period.Name, kind, limit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(chartMaxAge.Seconds())))
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ChartSnapshotsResponse{Period: period.Name, Kind: kind, Snapshots: snapshots})
+}
+
+func chartParams(w http.ResponseWriter, r *http.Request, periodName string) (charts.Period, string, bool) {
+	period, ok := charts.Periods[periodName]
+	if !ok {
+		http.Error(w, "Неизвестный период, нужен daily или weekly", http.StatusNotFound)
+		return charts.Period{}, "", false
+	}
+
+	kind := r.URL.Query().Get("kind")
+	if kind == "" {
+		kind = charts.KindTracks
+	}
+	if !slices.Contains(charts.Kinds, kind) {
+		http.Error(w, "Неверный kind, нужен tracks, artists или rising", http.StatusBadRequest)
+		return charts.Period{}, "", false
+	}
+
+	return period, kind, true
+}
+
+func writeChart(w http.ResponseWriter, resp ChartResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
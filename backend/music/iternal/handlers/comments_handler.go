@@ -0,0 +1,504 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"music/iternal/auth"
+	"music/iternal/config"
+	"music/iternal/repository"
+	"music/iternal/users"
+
+	"github.com/google/uuid"
+	"gitlab.com/Go34/Mute/shared/events"
+)
+
+const (
+	maxCommentLen = 2000
+	// Сколько жалоб скрывает комментарий до решения модератора
+	commentHideFlags = 5
+	// Сколько отметок отдается для волны плеера (timed=true)
+	maxTimedComments = 500
+)
+
+var commentFlagReasons = []string{"spam", "abuse", "other"}
+
+type CommentInfo struct {
+	ID      string `json:"id"`
+	TrackID string `json:"track_id"`
+	// Только у ответа
+	ParentID string `json:"parent_id,omitempty"`
+	// Пусто у удаленного комментария и у стертого автора
+	UserID     string     `json:"user_id,omitempty"`
+	Username   string     `json:"username,omitempty"`
+	Body       string     `json:"body"`
+	PositionMs *int64     `json:"position_ms,omitempty"`
+	ReplyCount int        `json:"reply_count"`
+	CreatedAt  time.Time  `json:"created_at"`
+	EditedAt   *time.Time `json:"edited_at,omitempty"`
+	Deleted    bool       `json:"deleted,omitempty"`
+}
+
+type CommentsResponse struct {
+	Items []CommentInfo `json:"items"`
+	// Пусто — это последняя страница
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+type CreateCommentRequest struct {
+	Body       string `json:"body"`
+	PositionMs *int64 `json:"position_ms"`
+	ParentID   string `json:"parent_id"`
+}
+
+type UpdateCommentRequest struct {
+	Body string `json:"body"`
+}
+
+type FlagCommentRequest struct {
+	Reason string `json:"reason"`
+}
+
+type ModerateCommentRequest struct {
+	Hidden bool `json:"hidden"`
+}
+
+// GetTrackCommentsHandler — GET /track/{id}/comments?limit=&cursor=, от новых к старым.
+// timed=true — все комментарии с position_ms (до 500) в порядке позиции, без страниц.
+func GetTrackCommentsHandler(w http.ResponseWriter, r *http.Request, repo *repository.Repository, usersClient *users.Client, trackID string) {
+	if _, err := uuid.Parse(trackID); err != nil {
+		http.Error(w, "Неверный track_id", http.StatusBadRequest)
+		return
+	}
+	if _, err := repo.GetPublicTrack(r.Context(), trackID); err != nil {
+		if errors.Is(err, repository.ErrTrackNotFound) {
+			http.Error(w, "Трек не найден", http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	q := r.URL.Query()
+	if q.Get("timed") == "true" {
+		comments, err := repo.ListTimedComments(r.Context(), trackID, maxTimedComments)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeComments(w, r, usersClient, comments, "")
+		return
+	}
+
+	listCommentsPage(w, r, usersClient, func(after *repository.CommentCursor, limit int) ([]repository.Comment, error) {
+		return repo.ListComments(r.Context(), trackID, after, limit)
+	})
+}
+
+// GetCommentRepliesHandler — GET /comment/{id}/replies?limit=&cursor=, от старых к новым.
+func GetCommentRepliesHandler(w http.ResponseWriter, r *http.Request, repo *repository.Repository, usersClient *users.Client, commentID string) {
+	if _, err := uuid.Parse(commentID); err != nil {
+		http.Error(w, "Неверный comment_id", http.StatusBadRequest)
+		return
+	}
+
+	listCommentsPage(w, r, usersClient, func(after *repository.CommentCursor, limit int) ([]repository.Comment, error) {
+		return repo.ListReplies(r.Context(), commentID, after, limit)
+	})
+}
+
+func listCommentsPage(w http.ResponseWriter, r *http.Request, usersClient *users.Client, list func(after *repository.CommentCursor, limit int) ([]repository.Comment, error)) {
+	q := r.URL.Query()
+
+	limit, err := pageSize(q.Get("limit"))
+	if err != nil {
+		http.Error(w, "Неверный limit", http.StatusBadRequest)
+		return
+	}
+
+	var after *repository.CommentCursor
+	if c := q.Get("cursor"); c != "" {
+		after, err = decodeCommentCursor(c)
+		if err != nil {
+			http.Error(w, "Неверный cursor", http.StatusBadRequest)
+			return
+		}
+	}
+
+	comments, err := list(after, limit+1)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Лишняя запись — признак, что есть следующая страница
+	var next string
+	if len(comments) > limit {
+		comments = comments[:limit]
+		last := comments[limit-1]
+		next = encodeCommentCursor(repository.CommentCursor{CreatedAt: last.CreatedAt, ID: last.ID})
+	}
+
+	writeComments(w, r, usersClient, comments, next)
+}
+
+// writeComments подставляет имена авторов одним запросом в users на всю страницу.
+// Если users недоступен, комментарии отдаются без имен.
+func writeComments(w http.ResponseWriter, r *http.Request, usersClient *users.Client, comments []repository.Comment, next string) {
+	ids := make([]string, 0, len(comments))
+	for _, c := range comments {
+		ids = append(ids, c.UserID)
+	}
+
+	names, err := usersClient.Usernames(r.Context(), ids)
+	if err != nil {
+		log.Printf("⚠ не удалось получить имена авторов комментариев: %v", err)
+	}
+
+	resp := CommentsResponse{Items: make([]CommentInfo, 0, len(comments)), NextCursor: next}
+	for _, c := range comments {
+		resp.Items = append(resp.Items, toCommentInfo(c, names))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func toCommentInfo(c repository.Comment, names map[string]string) CommentInfo {
+	info := CommentInfo{
+		ID:         c.ID,
+		TrackID:    c.TrackID,
+		ParentID:   c.ParentID,
+		Body:       c.Body,
+		PositionMs: c.PositionMs,
+		ReplyCount: c.ReplyCount,
+		CreatedAt:  c.CreatedAt,
+		EditedAt:   c.EditedAt,
+		Deleted:    c.Deleted,
+	}
+	if !c.Deleted {
+		info.UserID = c.UserID
+		info.Username = names[c.UserID]
+	}
+	return info
+}
+
+// CreateCommentHandler — POST /track/{id}/comments. Ответ на ответ становится ответом на
+// исходный комментарий: ветки одного уровня. Исполнитель трека получает comment_posted
+// через outbox, если комментарий не его собственный.
+func CreateCommentHandler(w http.ResponseWriter, r *http.Request, repo *repository.Repository, usersClient *users.Client, trackID string) {
+	identity, _ := auth.FromContext(r.Context())
+
+	var req CreateCommentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Неверное тело запроса", http.StatusBadRequest)
+		return
+	}
+
+	body, ok := commentBody(req.Body)
+	if !ok {
+		http.Error(w, "Текст комментария обязателен, не длиннее 2000 символов", http.StatusBadRequest)
+		return
+	}
+	if req.PositionMs != nil && *req.PositionMs < 0 {
+		http.Error(w, "position_ms не может быть отрицательным", http.StatusBadRequest)
+		return
+	}
+	if _, err := uuid.Parse(trackID); err != nil {
+		http.Error(w, "Неверный track_id", http.StatusBadRequest)
+		return
+	}
+
+	track, err := repo.GetPublicTrack(r.Context(), trackID)
+	if errors.Is(err, repository.ErrTrackNotFound) {
+		http.Error(w, "Трек не найден", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	comment := repository.Comment{
+		ID:         uuid.NewString(),
+		TrackID:    trackID,
+		ArtistID:   track.ArtistID,
+		UserID:     identity.UserID,
+		Body:       body,
+		PositionMs: req.PositionMs,
+		CreatedAt:  time.Now().UTC(),
+	}
+
+	if req.ParentID != "" {
+		if _, err := uuid.Parse(req.ParentID); err != nil {
+			http.Error(w, "Неверный parent_id", http.StatusBadRequest)
+			return
+		}
+
+		parent, err := repo.GetComment(r.Context(), req.ParentID)
+		if errors.Is(err, repository.ErrCommentNotFound) || (err == nil && (parent.TrackID != trackID || parent.Hidden)) {
+			http.Error(w, "Комментарий, на который отвечают, не найден", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		comment.ParentID = parent.ID
+		if parent.ParentID != "" {
+			comment.ParentID = parent.ParentID
+		}
+	}
+
+	var posted *events.Envelope
+	if track.ArtistID != identity.UserID {
+		e, err := events.New(events.TypeCommentPosted, "music", events.CommentPosted{
+			CommentID:  comment.ID,
+			TrackID:    trackID,
+			ArtistID:   track.ArtistID,
+			AuthorID:   identity.UserID,
+			ParentID:   comment.ParentID,
+			PositionMs: comment.PositionMs,
+		})
+		if err != nil {
+			http.Error(w, "Ошибка события comment_posted: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		posted = &e
+	}
+
+	err = repo.InTx(r.Context(), func(tx *repository.Repository) error {
+		if err := tx.CreateComment(r.Context(), comment); err != nil {
+			return err
+		}
+		if posted == nil {
+			return nil
+		}
+
+		data, err := json.Marshal(posted)
+		if err != nil {
+			return err
+		}
+		return tx.AddToOutbox(r.Context(), repository.OutboxMessage{
+			ID:    posted.ID,
+			Topic: config.MusicEventsTopic,
+			Key:   track.ArtistID,
+			Data:  data,
+		})
+	})
+	if err != nil {
+		http.Error(w, "Ошибка записи в базу: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	names, err := usersClient.Usernames(r.Context(), []string{identity.UserID})
+	if err != nil {
+		log.Printf("⚠ не удалось получить имя автора комментария: %v", err)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(toCommentInfo(comment, names))
+}
+
+// UpdateCommentHandler — PATCH /comment/{id}, только автор.
+func UpdateCommentHandler(w http.ResponseWriter, r *http.Request, repo *repository.Repository, commentID string) {
+	identity, _ := auth.FromContext(r.Context())
+
+	var req UpdateCommentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Неверное тело запроса", http.StatusBadRequest)
+		return
+	}
+
+	body, ok := commentBody(req.Body)
+	if !ok {
+		http.Error(w, "Текст комментария обязателен, не длиннее 2000 символов", http.StatusBadRequest)
+		return
+	}
+
+	comment, ok := findComment(w, r, repo, commentID)
+	if !ok {
+		return
+	}
+	if comment.UserID != identity.UserID {
+		http.Error(w, "Изменить комментарий может только автор", http.StatusForbidden)
+		return
+	}
+
+	err := repo.UpdateCommentBody(r.Context(), commentID, body)
+	if errors.Is(err, repository.ErrCommentNotFound) {
+		http.Error(w, "Комментарий не найден", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(`{"status":"success"}`))
+}
+
+// DeleteCommentHandler — DELETE /comment/{id}: автор, исполнитель трека или админ.
+func DeleteCommentHandler(w http.ResponseWriter, r *http.Request, repo *repository.Repository, commentID string) {
+	identity, _ := auth.FromContext(r.Context())
+
+	comment, ok := findComment(w, r, repo, commentID)
+	if !ok {
+		return
+	}
+	if comment.UserID != identity.UserID && comment.ArtistID != identity.UserID && identity.Role != "admin" {
+		http.Error(w, "Удалить комментарий может автор, исполнитель трека или администратор", http.StatusForbidden)
+		return
+	}
+
+	err := repo.DeleteComment(r.Context(), commentID)
+	if errors.Is(err, repository.ErrCommentNotFound) {
+		http.Error(w, "Комментарий не найден", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(`{"status":"success"}`))
+}
+
+// FlagCommentHandler — POST /comment/{id}/flag, причина: spam, abuse или other.
+// После commentHideFlags жалоб разных пользователей комментарий скрывается до решения модератора.
+func FlagCommentHandler(w http.ResponseWriter, r *http.Request, repo *repository.Repository, commentID string) {
+	identity, _ := auth.FromContext(r.Context())
+
+	var req FlagCommentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Неверное тело запроса", http.StatusBadRequest)
+		return
+	}
+	if !validFlagReason(req.Reason) {
+		http.Error(w, "reason: spam, abuse или other", http.StatusBadRequest)
+		return
+	}
+
+	comment, ok := findComment(w, r, repo, commentID)
+	if !ok {
+		return
+	}
+	if comment.UserID == identity.UserID {
+		http.Error(w, "Нельзя пожаловаться на свой комментарий", http.StatusBadRequest)
+		return
+	}
+
+	// Жалоба попадает и в общую очередь модерации
+	err := repo.InTx(r.Context(), func(tx *repository.Repository) error {
+		if _, err := tx.FlagComment(r.Context(), commentID, identity.UserID, req.Reason, commentHideFlags); err != nil {
+			return err
+		}
+
+		_, err := tx.CreateReport(r.Context(), repository.Report{
+			ID:         uuid.NewString(),
+			TargetType: repository.TargetComment,
+			TargetID:   commentID,
+			ReporterID: identity.UserID,
+			Reason:     req.Reason,
+			CreatedAt:  time.Now().UTC(),
+		})
+		return err
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(`{"status":"success"}`))
+}
+
+// ModerateCommentHandler — POST /comment/{id}/moderation {"hidden": true|false}, только админ.
+// То же, что hide/restore в POST /moderation/actions: жалобы закрываются, действие попадает в журнал.
+func ModerateCommentHandler(w http.ResponseWriter, r *http.Request, repo *repository.Repository, commentID string) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	identity, _ := auth.FromContext(r.Context())
+
+	var req ModerateCommentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Неверное тело запроса", http.StatusBadRequest)
+		return
+	}
+	if _, err := uuid.Parse(commentID); err != nil {
+		http.Error(w, "Неверный comment_id", http.StatusBadRequest)
+		return
+	}
+
+	action := ActionRestore
+	if req.Hidden {
+		action = ActionHide
+	}
+
+	// Для комментариев S3 и users не нужны
+	_, err := moderate(r.Context(), repo, nil, nil, identity.UserID, repository.TargetComment, commentID, action, "")
+	if !writeModerationError(w, err) {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(`{"status":"success"}`))
+}
+
+// findComment отвечает 400/404 сам; удаленный и скрытый комментарий считаются ненайденными.
+func findComment(w http.ResponseWriter, r *http.Request, repo *repository.Repository, commentID string) (repository.Comment, bool) {
+	if _, err := uuid.Parse(commentID); err != nil {
+		http.Error(w, "Неверный comment_id", http.StatusBadRequest)
+		return repository.Comment{}, false
+	}
+
+	comment, err := repo.GetComment(r.Context(), commentID)
+	if errors.Is(err, repository.ErrCommentNotFound) || (err == nil && (comment.Deleted || comment.Hidden)) {
+		http.Error(w, "Комментарий не найден", http.StatusNotFound)
+		return repository.Comment{}, false
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return repository.Comment{}, false
+	}
+
+	return comment, true
+}
+
+func commentBody(s string) (string, bool) {
+	s = strings.TrimSpace(s)
+	return s, s != "" && utf8.RuneCountInString(s) <= maxCommentLen
+}
+
+func validFlagReason(reason string) bool {
+	for _, r := range commentFlagReasons {
+		if r == reason {
+			return true
+		}
+	}
+	return false
+}
+
+// Формат курсора тот же, что у истории прослушиваний
+func encodeCommentCursor(c repository.CommentCursor) string {
+	return encodeTimeCursor(c.CreatedAt, c.ID)
+}
+
+func decodeCommentCursor(s string) (*repository.CommentCursor, error) {
+	at, id, err := decodeTimeCursor(s)
+	if err != nil {
+		return nil, err
+	}
+
+	return &repository.CommentCursor{CreatedAt: at, ID: id}, nil
+}
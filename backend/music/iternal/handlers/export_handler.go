@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"music/iternal/repository"
+)
+
+type ExportTrack struct {
+	ID        string    `json:"id"`
+	Title     string    `json:"title"`
+	ArtistID  string    `json:"artist_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type UserExport struct {
+	Likes   []ExportTrack `json:"likes"`
+	Uploads []ExportTrack `json:"uploads"`
+}
+
+// GetUserExportHandler отдает сервису users данные пользователя для выгрузки персональных данных.
+// Только для внутренних вызовов, через gateway не проксируется.
+func GetUserExportHandler(w http.ResponseWriter, r *http.Request, repo *repository.Repository, userID string) {
+	// Ответ уходит в сервис users, текст ошибки базы ему не нужен
+	likes, err := repo.ListLikedTracks(r.Context(), userID)
+	if err != nil {
+		log.Printf("⚠ выгрузка данных %s: лайки: %v", userID, err)
+		http.Error(w, "Не удалось собрать данные пользователя", http.StatusInternalServerError)
+		return
+	}
+
+	uploads, err := repo.ListTracksByArtist(r.Context(), userID)
+	if err != nil {
+		log.Printf("⚠ выгрузка данных %s: загрузки: %v", userID, err)
+		http.Error(w, "Не удалось собрать данные пользователя", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(UserExport{
+		Likes:   toExportTracks(likes),
+		Uploads: toExportTracks(uploads),
+	})
+}
+
+func toExportTracks(tracks []repository.Track) []ExportTrack {
+	list := make([]ExportTrack, 0, len(tracks))
+	for _, t := range tracks {
+		list = append(list, ExportTrack{
+			ID:        t.ID,
+			Title:     t.Title,
+			ArtistID:  t.ArtistID,
+			CreatedAt: t.CreatedAt,
+		})
+	}
+
+	return list
+}
@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"music/iternal/auth"
+	"music/iternal/repository"
+	"music/iternal/storage"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 200
+)
+
+type HistoryItem struct {
+	Track      TrackInfo `json:"track"`
+	PlayedAt   time.Time `json:"played_at"`
+	PositionMs *int64    `json:"position_ms,omitempty"`
+	DurationMs *int64    `json:"duration_ms,omitempty"`
+	// Только для recent=true: сколько раз пользователь слушал трек
+	TimesPlayed int64 `json:"times_played,omitempty"`
+}
+
+type HistoryResponse struct {
+	Items []HistoryItem `json:"items"`
+	// Пусто — это последняя страница
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// GetHistoryHandler — GET /me/history?limit=&cursor=&recent=true.
+// recent=true — «недавно прослушанные»: каждый трек один раз, по последнему прослушиванию.
+func GetHistoryHandler(w http.ResponseWriter, r *http.Request, repo *repository.Repository, s3 *storage.S3Client) {
+	identity, _ := auth.FromContext(r.Context())
+	q := r.URL.Query()
+
+	limit, err := pageSize(q.Get("limit"))
+	if err != nil {
+		http.Error(w, "Неверный limit", http.StatusBadRequest)
+		return
+	}
+
+	var after *repository.PlayCursor
+	if c := q.Get("cursor"); c != "" {
+		after, err = decodeCursor(c)
+		if err != nil {
+			http.Error(w, "Неверный cursor", http.StatusBadRequest)
+			return
+		}
+	}
+
+	recent := q.Get("recent") == "true"
+
+	var plays []repository.Play
+	if recent {
+		plays, err = repo.ListRecentlyPlayed(r.Context(), identity.UserID, after, limit+1)
+	} else {
+		plays, err = repo.ListPlays(r.Context(), identity.UserID, after, limit+1)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Лишняя запись — признак, что есть следующая страница
+	resp := HistoryResponse{Items: []HistoryItem{}}
+	if len(plays) > limit {
+		plays = plays[:limit]
+		last := plays[limit-1]
+		id := last.EventID
+		if recent {
+			id = last.TrackID
+		}
+		resp.NextCursor = encodeCursor(repository.PlayCursor{PlayedAt: last.PlayedAt, ID: id})
+	}
+
+	ids := make([]string, 0, len(plays))
+	for _, p := range plays {
+		ids = append(ids, p.TrackID)
+	}
+	liked, err := repo.LikedAmong(r.Context(), identity.UserID, ids)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	for _, p := range plays {
+		info := toTrackInfo(p.Track, s3)
+		likedByMe := liked[p.TrackID]
+		info.LikedByMe = &likedByMe
+
+		item := HistoryItem{
+			Track:      info,
+			PlayedAt:   p.PlayedAt,
+			PositionMs: p.PositionMs,
+			DurationMs: p.DurationMs,
+		}
+		if recent {
+			item.TimesPlayed = p.Count
+		}
+		resp.Items = append(resp.Items, item)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// pageSize разбирает limit: пусто — defaultPageSize, больше maxPageSize — maxPageSize.
+func pageSize(s string) (int, error) {
+	if s == "" {
+		return defaultPageSize, nil
+	}
+
+	n, err := strconv.Atoi(s)
+	if err != nil || n <= 0 {
+		return 0, errors.New("invalid limit")
+	}
+
+	return min(n, maxPageSize), nil
+}
+
+// Курсор непрозрачен для клиента: base64url от "<время RFC3339Nano>|<id>".
+// Так же кодируются курсоры остальных лент с порядком по (время, id).
+func encodeTimeCursor(at time.Time, id string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(at.Format(time.RFC3339Nano) + "|" + id))
+}
+
+func decodeTimeCursor(s string) (time.Time, string, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return time.Time{}, "", err
+	}
+
+	ts, id, found := strings.Cut(string(raw), "|")
+	if !found {
+		return time.Time{}, "", errors.New("invalid cursor")
+	}
+
+	at, err := time.Parse(time.RFC3339Nano, ts)
+	if err != nil {
+		return time.Time{}, "", err
+	}
+	if _, err := uuid.Parse(id); err != nil {
+		return time.Time{}, "", err
+	}
+
+	return at, id, nil
+}
+
+func encodeCursor(c repository.PlayCursor) string {
+	return encodeTimeCursor(c.PlayedAt, c.ID)
+}
+
+func decodeCursor(s string) (*repository.PlayCursor, error) {
+	playedAt, id, err := decodeTimeCursor(s)
+	if err != nil {
+		return nil, err
+	}
+
+	return &repository.PlayCursor{PlayedAt: playedAt, ID: id}, nil
+}
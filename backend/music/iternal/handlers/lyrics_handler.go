@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"music/iternal/lyrics"
+	"music/iternal/repository"
+	"music/iternal/storage"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// Тело PUT /track/{id}/lyrics: MaxChars символов с запасом на JSON
+	maxLyricsBody = 256 << 10
+
+	minSearchLen = 2
+	maxSearchLen = 200
+)
+
+type LyricsRequest struct {
+	Format string `json:"format"`
+	Body   string `json:"body"`
+}
+
+type LyricsResponse struct {
+	TrackID string `json:"track_id"`
+	Format  string `json:"format"`
+	// Строки без разметки — и для plain, и для lrc
+	Text string `json:"text"`
+	// Только у lrc: строки с моментом показа и исходный LRC
+	Lines     []lyrics.Line `json:"lines,omitempty"`
+	LRC       string        `json:"lrc,omitempty"`
+	UpdatedAt time.Time     `json:"updated_at"`
+}
+
+type SearchItem struct {
+	Track TrackInfo `json:"track"`
+	// title или lyrics
+	Match   string `json:"match"`
+	Snippet string `json:"snippet,omitempty"`
+}
+
+type SearchResponse struct {
+	Items []SearchItem `json:"items"`
+}
+
+// GetLyricsHandler — GET /track/{id}/lyrics, без авторизации.
+func GetLyricsHandler(w http.ResponseWriter, r *http.Request, repo *repository.Repository, trackID string) {
+	if _, err := uuid.Parse(trackID); err != nil {
+		http.Error(w, "Неверный track_id", http.StatusBadRequest)
+		return
+	}
+
+	if _, err := repo.GetPublicTrack(r.Context(), trackID); err != nil {
+		if errors.Is(err, repository.ErrTrackNotFound) {
+			http.Error(w, "Трек не найден", http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	tl, err := repo.GetLyrics(r.Context(), trackID)
+	if errors.Is(err, repository.ErrLyricsNotFound) {
+		http.Error(w, "У трека нет текста", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeLyrics(w, tl)
+}
+
+// PutLyricsHandler — PUT /track/{id}/lyrics {"format": "plain" | "lrc", "body": "..."}:
+// исполнитель трека или админ. Текст проверяется и разбирается до записи.
+func PutLyricsHandler(w http.ResponseWriter, r *http.Request, repo *repository.Repository, trackID string) {
+	if _, ok := ownTrack(w, r, repo, trackID); !ok {
+		return
+	}
+
+	var req LyricsRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxLyricsBody)).Decode(&req); err != nil {
+		http.Error(w, "Неверное тело запроса", http.StatusBadRequest)
+		return
+	}
+	if req.Format != lyrics.FormatPlain && req.Format != lyrics.FormatLRC {
+		http.Error(w, "format: plain или lrc", http.StatusBadRequest)
+		return
+	}
+
+	parsed, err := lyrics.Parse(req.Format, req.Body)
+	if err != nil {
+		http.Error(w, "Неверный текст песни: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	tl := repository.TrackLyrics{TrackID: trackID, Body: req.Body, UpdatedAt: time.Now().UTC(), Lyrics: parsed}
+	if err := repo.SetLyrics(r.Context(), trackID, req.Body, parsed, tl.UpdatedAt); err != nil {
+		http.Error(w, "Ошибка записи в базу: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeLyrics(w, tl)
+}
+
+// DeleteLyricsHandler — DELETE /track/{id}/lyrics: исполнитель трека или админ.
+func DeleteLyricsHandler(w http.ResponseWriter, r *http.Request, repo *repository.Repository, trackID string) {
+	if _, ok := ownTrack(w, r, repo, trackID); !ok {
+		return
+	}
+
+	err := repo.DeleteLyrics(r.Context(), trackID)
+	if errors.Is(err, repository.ErrLyricsNotFound) {
+		http.Error(w, "У трека нет текста", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(`{"status":"success"}`))
+}
+
+// SearchHandler — GET /search?q=&limit=: треки по названию и по тексту песни, без авторизации.
+func SearchHandler(w http.ResponseWriter, r *http.Request, repo *repository.Repository, s3 *storage.S3Client) {
+	q := strings.TrimSpace(r.URL.Query().Get("q"))
+	if n := utf8.RuneCountInString(q); n < minSearchLen || n > maxSearchLen {
+		http.Error(w, "q: от 2 до 200 символов", http.StatusBadRequest)
+		return
+	}
+
+	limit, err := pageSize(r.URL.Query().Get("limit"))
+	if err != nil {
+		http.Error(w, "Неверный limit", http.StatusBadRequest)
+		return
+	}
+
+	hits, err := repo.SearchTracks(r.Context(), q, limit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	resp := SearchResponse{Items: make([]SearchItem, 0, len(hits))}
+	for _, h := range hits {
+		resp.Items = append(resp.Items, SearchItem{Track: toTrackInfo(h.Track, s3), Match: h.Match, Snippet: h.Snippet})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func writeLyrics(w http.ResponseWriter, tl repository.TrackLyrics) {
+	resp := LyricsResponse{
+		TrackID:   tl.TrackID,
+		Format:    tl.Format,
+		Text:      tl.Text,
+		Lines:     tl.Lines,
+		UpdatedAt: tl.UpdatedAt,
+	}
+	if tl.Format == lyrics.FormatLRC {
+		resp.LRC = tl.Body
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,565 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"music/iternal/auth"
+	"music/iternal/repository"
+	"music/iternal/storage"
+	"music/iternal/users"
+
+	"github.com/google/uuid"
+	"gitlab.com/Go34/Mute/shared/events"
+)
+
+const (
+	maxReportDetailsLen = 1000
+	// Сколько жалоб на одну цель отдается модератору
+	maxTargetReports = 200
+	// Сколько ждать users при откате бана
+	banUndoTimeout = 10 * time.Second
+)
+
+// Действия модератора
+const (
+	ActionHide    = "hide"
+	ActionRestore = "restore"
+	ActionDelete  = "delete"
+	ActionBan     = "ban"
+	ActionDismiss = "dismiss"
+	// Только для журнала: администратор изменил чужой трек через PATCH /track/{id}
+	ActionEdit = "edit"
+)
+
+var (
+	reportTargets = []string{repository.TargetTrack, repository.TargetCover, repository.TargetComment, repository.TargetUser}
+	reportReasons = []string{"spam", "abuse", "copyright", "explicit", "other"}
+
+	// Какие действия применимы к какой цели. restore пользователя — снятие бана.
+	targetActions = map[string][]string{
+		repository.TargetTrack:   {ActionHide, ActionRestore, ActionDelete, ActionDismiss},
+		repository.TargetCover:   {ActionHide, ActionRestore, ActionDelete, ActionDismiss},
+		repository.TargetComment: {ActionHide, ActionRestore, ActionDelete, ActionDismiss},
+		repository.TargetUser:    {ActionBan, ActionRestore, ActionDismiss},
+	}
+
+	errActionNotAllowed = errors.New("action not allowed for target")
+)
+
+type CreateReportRequest struct {
+	TargetType string `json:"target_type"`
+	TargetID   string `json:"target_id"`
+	Reason     string `json:"reason"`
+	Details    string `json:"details"`
+}
+
+type QueueItemInfo struct {
+	TargetType      string    `json:"target_type"`
+	TargetID        string    `json:"target_id"`
+	Reports         int       `json:"reports"`
+	Reasons         []string  `json:"reasons"`
+	FirstReportedAt time.Time `json:"first_reported_at"`
+	LastReportedAt  time.Time `json:"last_reported_at"`
+}
+
+type QueueResponse struct {
+	Items      []QueueItemInfo `json:"items"`
+	NextCursor string          `json:"next_cursor,omitempty"`
+}
+
+type ReportInfo struct {
+	ID         string     `json:"id"`
+	ReporterID string     `json:"reporter_id"`
+	Reason     string     `json:"reason"`
+	Details    string     `json:"details,omitempty"`
+	Status     string     `json:"status"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ResolvedAt *time.Time `json:"resolved_at,omitempty"`
+	ResolvedBy string     `json:"resolved_by,omitempty"`
+	Resolution string     `json:"resolution,omitempty"`
+}
+
+type ReportsResponse struct {
+	Items []ReportInfo `json:"items"`
+}
+
+type ModerationActionRequest struct {
+	TargetType string `json:"target_type"`
+	TargetID   string `json:"target_id"`
+	Action     string `json:"action"`
+	Note       string `json:"note"`
+}
+
+type ModerationActionResponse struct {
+	Status          string `json:"status"`
+	ResolvedReports int    `json:"resolved_reports"`
+}
+
+type ModerationLogEntry struct {
+	ID              string    `json:"id"`
+	ModeratorID     string    `json:"moderator_id"`
+	TargetType      string    `json:"target_type"`
+	TargetID        string    `json:"target_id"`
+	Action          string    `json:"action"`
+	Note            string    `json:"note,omitempty"`
+	ResolvedReports int       `json:"resolved_reports"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+type ModerationLogResponse struct {
+	Items      []ModerationLogEntry `json:"items"`
+	NextCursor string               `json:"next_cursor,omitempty"`
+}
+
+// CreateReportHandler — POST /reports: жалоба на трек, обложку, комментарий или пользователя.
+// Повторная жалоба того же пользователя на ту же цель, пока первая открыта, ничего не меняет.
+func CreateReportHandler(w http.ResponseWriter, r *http.Request, repo *repository.Repository, usersClient *users.Client) {
+	identity, _ := auth.FromContext(r.Context())
+
+	var req CreateReportRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Неверное тело запроса", http.StatusBadRequest)
+		return
+	}
+	if !slices.Contains(reportTargets, req.TargetType) {
+		http.Error(w, "target_type: track, cover, comment или user", http.StatusBadRequest)
+		return
+	}
+	if _, err := uuid.Parse(req.TargetID); err != nil {
+		http.Error(w, "Неверный target_id", http.StatusBadRequest)
+		return
+	}
+	if !slices.Contains(reportReasons, req.Reason) {
+		http.Error(w, "reason: spam, abuse, copyright, explicit или other", http.StatusBadRequest)
+		return
+	}
+	details := strings.TrimSpace(req.Details)
+	if utf8.RuneCountInString(details) > maxReportDetailsLen {
+		http.Error(w, "details не длиннее 1000 символов", http.StatusBadRequest)
+		return
+	}
+
+	switch req.TargetType {
+	case repository.TargetTrack, repository.TargetCover:
+		track, err := repo.GetPublicTrack(r.Context(), req.TargetID)
+		if errors.Is(err, repository.ErrTrackNotFound) ||
+			(err == nil && req.TargetType == repository.TargetCover && (track.CoverKey == "" || track.CoverHidden)) {
+			http.Error(w, "Трек или обложка не найдены", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	case repository.TargetComment:
+		if _, ok := findComment(w, r, repo, req.TargetID); !ok {
+			return
+		}
+	case repository.TargetUser:
+		if req.TargetID == identity.UserID {
+			http.Error(w, "Нельзя пожаловаться на себя", http.StatusBadRequest)
+			return
+		}
+		names, err := usersClient.Usernames(r.Context(), []string{req.TargetID})
+		if err != nil {
+			http.Error(w, "Сервис users недоступен: "+err.Error(), http.StatusBadGateway)
+			return
+		}
+		if _, ok := names[req.TargetID]; !ok {
+			http.Error(w, "Пользователь не найден", http.StatusNotFound)
+			return
+		}
+	}
+
+	_, err := repo.CreateReport(r.Context(), repository.Report{
+		ID:         uuid.NewString(),
+		TargetType: req.TargetType,
+		TargetID:   req.TargetID,
+		ReporterID: identity.UserID,
+		Reason:     req.Reason,
+		Details:    details,
+		CreatedAt:  time.Now().UTC(),
+	})
+	if err != nil {
+		http.Error(w, "Ошибка записи в базу: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	w.Write([]byte(`{"status":"success"}`))
+}
+
+// GetModerationQueueHandler — GET /moderation/queue?target_type=&limit=&cursor=: цели с открытыми
+// жалобами, первыми — самые давние.
+func GetModerationQueueHandler(w http.ResponseWriter, r *http.Request, repo *repository.Repository) {
+	if !requireAdmin(w, r) {
+		return
+	}
+
+	q := r.URL.Query()
+	targetType := q.Get("target_type")
+	if targetType != "" && !slices.Contains(reportTargets, targetType) {
+		http.Error(w, "target_type: track, cover, comment или user", http.StatusBadRequest)
+		return
+	}
+
+	limit, err := pageSize(q.Get("limit"))
+	if err != nil {
+		http.Error(w, "Неверный limit", http.StatusBadRequest)
+		return
+	}
+
+	var after *repository.QueueCursor
+	if c := q.Get("cursor"); c != "" {
+		after, err = decodeQueueCursor(c)
+		if err != nil {
+			http.Error(w, "Неверный cursor", http.StatusBadRequest)
+			return
+		}
+	}
+
+	items, err := repo.ReportQueue(r.Context(), targetType, after, limit+1)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	resp := QueueResponse{Items: []QueueItemInfo{}}
+	if len(items) > limit {
+		items = items[:limit]
+		last := items[limit-1]
+		resp.NextCursor = encodeQueueCursor(repository.QueueCursor{FirstReportedAt: last.FirstReportedAt, FirstReportID: last.FirstReportID})
+	}
+	for _, it := range items {
+		resp.Items = append(resp.Items, QueueItemInfo{
+			TargetType:      it.TargetType,
+			TargetID:        it.TargetID,
+			Reports:         it.Reports,
+			Reasons:         it.Reasons,
+			FirstReportedAt: it.FirstReportedAt,
+			LastReportedAt:  it.LastReportedAt,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// GetReportsHandler — GET /moderation/reports?target_type=&target_id=: жалобы на цель с текстом,
+// включая закрытые.
+func GetReportsHandler(w http.ResponseWriter, r *http.Request, repo *repository.Repository) {
+	if !requireAdmin(w, r) {
+		return
+	}
+
+	q := r.URL.Query()
+	targetType, targetID := q.Get("target_type"), q.Get("target_id")
+	if !slices.Contains(reportTargets, targetType) {
+		http.Error(w, "target_type: track, cover, comment или user", http.StatusBadRequest)
+		return
+	}
+	if _, err := uuid.Parse(targetID); err != nil {
+		http.Error(w, "Неверный target_id", http.StatusBadRequest)
+		return
+	}
+
+	reports, err := repo.ListReports(r.Context(), targetType, targetID, maxTargetReports)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	resp := ReportsResponse{Items: make([]ReportInfo, 0, len(reports))}
+	for _, rep := range reports {
+		resp.Items = append(resp.Items, ReportInfo{
+			ID:         rep.ID,
+			ReporterID: rep.ReporterID,
+			Reason:     rep.Reason,
+			Details:    rep.Details,
+			Status:     rep.Status,
+			CreatedAt:  rep.CreatedAt,
+			ResolvedAt: rep.ResolvedAt,
+			ResolvedBy: rep.ResolvedBy,
+			Resolution: rep.Resolution,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// ModerationActionHandler — POST /moderation/actions: действие над целью. Закрывает все
+// открытые жалобы на нее и пишет запись в журнал.
+func ModerationActionHandler(w http.ResponseWriter, r *http.Request, repo *repository.Repository, s3 *storage.S3Client, usersClient *users.Client) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	identity, _ := auth.FromContext(r.Context())
+
+	var req ModerationActionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Неверное тело запроса", http.StatusBadRequest)
+		return
+	}
+	if _, ok := targetActions[req.TargetType]; !ok {
+		http.Error(w, "target_type: track, cover, comment или user", http.StatusBadRequest)
+		return
+	}
+	if _, err := uuid.Parse(req.TargetID); err != nil {
+		http.Error(w, "Неверный target_id", http.StatusBadRequest)
+		return
+	}
+
+	resolved, err := moderate(r.Context(), repo, s3, usersClient, identity.UserID, req.TargetType, req.TargetID, req.Action, strings.TrimSpace(req.Note))
+	if !writeModerationError(w, err) {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ModerationActionResponse{Status: "success", ResolvedReports: resolved})
+}
+
+// GetModerationLogHandler — GET /moderation/log?target_type=&target_id=&moderator_id=&limit=&cursor=,
+// от новых записей к старым.
+func GetModerationLogHandler(w http.ResponseWriter, r *http.Request, repo *repository.Repository) {
+	if !requireAdmin(w, r) {
+		return
+	}
+
+	q := r.URL.Query()
+	filter := repository.ModerationLogFilter{
+		TargetType:  q.Get("target_type"),
+		TargetID:    q.Get("target_id"),
+		ModeratorID: q.Get("moderator_id"),
+	}
+	if filter.TargetType != "" && !slices.Contains(reportTargets, filter.TargetType) {
+		http.Error(w, "target_type: track, cover, comment или user", http.StatusBadRequest)
+		return
+	}
+	for _, id := range []string{filter.TargetID, filter.ModeratorID} {
+		if _, err := uuid.Parse(id); id != "" && err != nil {
+			http.Error(w, "Неверный target_id или moderator_id", http.StatusBadRequest)
+			return
+		}
+	}
+
+	limit, err := pageSize(q.Get("limit"))
+	if err != nil {
+		http.Error(w, "Неверный limit", http.StatusBadRequest)
+		return
+	}
+
+	var after *repository.ModerationLogCursor
+	if c := q.Get("cursor"); c != "" {
+		after, err = decodeLogCursor(c)
+		if err != nil {
+			http.Error(w, "Неверный cursor", http.StatusBadRequest)
+			return
+		}
+	}
+
+	entries, err := repo.ModerationLog(r.Context(), filter, after, limit+1)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	resp := ModerationLogResponse{Items: []ModerationLogEntry{}}
+	if len(entries) > limit {
+		entries = entries[:limit]
+		last := entries[limit-1]
+		resp.NextCursor = encodeLogCursor(repository.ModerationLogCursor{CreatedAt: last.CreatedAt, ID: last.ID})
+	}
+	for _, e := range entries {
+		resp.Items = append(resp.Items, ModerationLogEntry{
+			ID:              e.ID,
+			ModeratorID:     e.ModeratorID,
+			TargetType:      e.TargetType,
+			TargetID:        e.TargetID,
+			Action:          e.Action,
+			Note:            e.Note,
+			ResolvedReports: e.ResolvedReports,
+			CreatedAt:       e.CreatedAt,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// moderate применяет действие, закрывает жалобы на цель и пишет журнал в одной транзакции.
+// Бан выполняет users: вызов идет до транзакции, чтобы не держать блокировки строк на время
+// HTTP-запроса, и откатывается к прежнему состоянию, если транзакция не прошла.
+// Файлы в S3 удаляются после коммита.
+func moderate(ctx context.Context, repo *repository.Repository, s3 *storage.S3Client, usersClient *users.Client,
+	moderatorID, targetType, targetID, action, note string) (int, error) {
+	if !slices.Contains(targetActions[targetType], action) {
+		return 0, errActionNotAllowed
+	}
+
+	var (
+		resolved int
+		s3Keys   []string
+	)
+	now := time.Now().UTC()
+
+	// Бан в users — до транзакции; если ее потом не удастся закоммитить, вернем прежнее состояние
+	banUser := targetType == repository.TargetUser && action != ActionDismiss
+	var wasBanned bool
+	if banUser {
+		var err error
+		if wasBanned, err = usersClient.SetBanned(ctx, targetID, action == ActionBan); err != nil {
+			return 0, err
+		}
+	}
+
+	err := repo.InTx(ctx, func(tx *repository.Repository) error {
+		var err error
+
+		switch targetType + ":" + action {
+		case "track:hide", "track:restore":
+			// Ленты в users следуют за видимостью трека через событие в outbox
+			var track repository.Track
+			if track, err = tx.GetTrack(ctx, targetID); err != nil {
+				break
+			}
+			visibility, eventType := repository.VisibilityHidden, events.TypeTrackHidden
+			if action == ActionRestore {
+				visibility, eventType = repository.VisibilityPublic, events.TypeTrackRestored
+			}
+			if err = tx.SetTrackVisibility(ctx, targetID, visibility); err == nil {
+				err = addTrackEvent(ctx, tx, eventType, track)
+			}
+		case "track:delete":
+			var track repository.Track
+			if track, err = tx.GetTrack(ctx, targetID); err == nil {
+				s3Keys = track.ObjectKeys()
+				if err = tx.DeleteTrack(ctx, targetID); err == nil {
+					err = addTrackEvent(ctx, tx, events.TypeTrackDeleted, track)
+				}
+			}
+		case "cover:hide", "cover:restore":
+			err = tx.SetCoverHidden(ctx, targetID, action == ActionHide)
+		case "cover:delete":
+			s3Keys, err = tx.RemoveCover(ctx, targetID)
+		case "comment:hide", "comment:restore":
+			err = tx.SetCommentHidden(ctx, targetID, action == ActionHide)
+		case "comment:delete":
+			err = tx.DeleteComment(ctx, targetID)
+		}
+		if err != nil {
+			return err
+		}
+
+		// Восстановление и отклонение — нарушения не было
+		status := repository.ReportResolved
+		if action == ActionRestore || action == ActionDismiss {
+			status = repository.ReportDismissed
+		}
+		resolved, err = tx.ResolveReports(ctx, targetType, targetID, moderatorID, status, action, now)
+		if err != nil {
+			return err
+		}
+
+		err = tx.AddModerationLog(ctx, repository.ModerationEntry{
+			ID:              uuid.NewString(),
+			ModeratorID:     moderatorID,
+			TargetType:      targetType,
+			TargetID:        targetID,
+			Action:          action,
+			Note:            note,
+			ResolvedReports: resolved,
+			CreatedAt:       now,
+		})
+		return err
+	})
+	if err != nil {
+		// Бан уже применен, а коммит не прошел — возвращаем прежнее состояние, чтобы не осталось
+		// бана без записи в журнале. Запрос мог быть отменен клиентом, поэтому контекст свой.
+		if banUser && wasBanned != (action == ActionBan) {
+			undoCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), banUndoTimeout)
+			if _, undoErr := usersClient.SetBanned(undoCtx, targetID, wasBanned); undoErr != nil {
+				log.Printf("⚠ модерация: %s пользователя %s не записан в журнал и не отменен: %v", action, targetID, undoErr)
+			}
+			cancel()
+		}
+		return 0, err
+	}
+
+	for _, key := range s3Keys {
+		if key == "" {
+			continue
+		}
+		if err := s3.DeleteObject(ctx, key); err != nil {
+			log.Printf("⚠ модерация: не удалось удалить %s из S3: %v", key, err)
+		}
+	}
+
+	return resolved, nil
+}
+
+// writeModerationError отвечает ошибкой moderate; false — ответ уже отправлен.
+func writeModerationError(w http.ResponseWriter, err error) bool {
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, errActionNotAllowed):
+		http.Error(w, "Действие недоступно для этой цели", http.StatusBadRequest)
+	case errors.Is(err, repository.ErrTrackNotFound):
+		http.Error(w, "Трек не найден", http.StatusNotFound)
+	case errors.Is(err, repository.ErrCommentNotFound):
+		http.Error(w, "Комментарий не найден", http.StatusNotFound)
+	case errors.Is(err, users.ErrUserNotFound):
+		http.Error(w, "Пользователь не найден", http.StatusNotFound)
+	case errors.Is(err, users.ErrBanForbidden):
+		http.Error(w, "Администратора забанить нельзя", http.StatusForbidden)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+	return false
+}
+
+func requireAdmin(w http.ResponseWriter, r *http.Request) bool {
+	identity, _ := auth.FromContext(r.Context())
+	if identity.Role != "admin" {
+		http.Error(w, "Только для администратора", http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+// Курсоры очереди и журнала кодируются так же, как курсор истории прослушиваний.
+func encodeQueueCursor(c repository.QueueCursor) string {
+	return encodeTimeCursor(c.FirstReportedAt, c.FirstReportID)
+}
+
+func decodeQueueCursor(s string) (*repository.QueueCursor, error) {
+	at, id, err := decodeTimeCursor(s)
+	if err != nil {
+		return nil, err
+	}
+
+	return &repository.QueueCursor{FirstReportedAt: at, FirstReportID: id}, nil
+}
+
+func encodeLogCursor(c repository.ModerationLogCursor) string {
+	return encodeTimeCursor(c.CreatedAt, c.ID)
+}
+
+func decodeLogCursor(s string) (*repository.ModerationLogCursor, error) {
+	at, id, err := decodeTimeCursor(s)
+	if err != nil {
+		return nil, err
+	}
+
+	return &repository.ModerationLogCursor{CreatedAt: at, ID: id}, nil
+}
@@ -3,16 +3,24 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
+	"music/iternal/auth"
+	"music/iternal/config"
+	"music/iternal/covers"
+	"music/iternal/repository"
 	"music/iternal/storage"
+	"music/iternal/users"
 
 	"github.com/google/uuid"
-	"github.com/jackc/pgx/v5"
+	"gitlab.com/Go34/Mute/shared/events"
 )
 
 type TrackInfo struct {
@@ -22,86 +30,150 @@ type TrackInfo struct {
 	ArtistName string `json:"artist_name"`
 	CoverURL   string `json:"coverUrl"`
 	StreamURL  string `json:"streamUrl"`
+
+	// Обработанная обложка: сторона в px → ссылки на WebP и JPEG. У обложек, загруженных
+	// до обработки, есть только coverUrl
+	Covers        map[string]CoverURLs `json:"covers,omitempty"`
+	CoverBlurhash string               `json:"cover_blurhash,omitempty"`
+	CoverColor    string               `json:"cover_color,omitempty"`
+
+	LikeCount       int64      `json:"like_count"`
+	PlayCount       int64      `json:"play_count"`
+	UniqueListeners int64      `json:"unique_listeners"`
+	LastPlayedAt    *time.Time `json:"last_played_at,omitempty"`
+
+	// Появляются после анализа аудио: волна в формате audiowaveform JSON и громкость.
+	// replay_gain_db — сколько дБ добавить при воспроизведении для выравнивания громкости
+	WaveformURL  string   `json:"waveform_url,omitempty"`
+	DurationMs   *int64   `json:"duration_ms,omitempty"`
+	LoudnessLUFS *float64 `json:"loudness_lufs,omitempty"`
+	TruePeakDBTP *float64 `json:"true_peak_dbtp,omitempty"`
+	ReplayGainDB *float64 `json:"replay_gain_db,omitempty"`
+
+	// Только если пользователь известен (user_id в запросе)
+	LikedByMe *bool `json:"liked_by_me,omitempty"`
 }
 
-func GetAllTracksHandler(w http.ResponseWriter, r *http.Request, db *pgx.Conn, s3 *storage.S3Client) {
-	rows, err := db.Query(r.Context(),
-		`SELECT id, title, artist_id, cover_s3_key, track_s3_key FROM music`)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-	defer rows.Close()
+type CoverURLs struct {
+	WebP string `json:"webp"`
+	JPEG string `json:"jpeg"`
+}
 
-	var list []TrackInfo
-	for rows.Next() {
-		var (
-			id, title, artistID string
-			coverKey, trackKey  string
-		)
-		if err := rows.Scan(&id, &title, &artistID, &coverKey, &trackKey); err != nil {
-			continue
+func toTrackInfo(t repository.Track, s3 *storage.S3Client) TrackInfo {
+	var (
+		coverURL        string
+		coverSizes      map[string]CoverURLs
+		blurhash, color string
+	)
+	if !t.CoverHidden {
+		coverURL, _ = s3.PresignGet(t.CoverKey, 15*time.Minute)
+
+		if t.CoverPrefix != "" {
+			coverSizes = make(map[string]CoverURLs, len(covers.Sizes))
+			for _, size := range covers.Sizes {
+				var urls CoverURLs
+				urls.WebP, _ = s3.PresignGet(covers.Key(t.CoverPrefix, size, covers.FormatWebP), 15*time.Minute)
+				urls.JPEG, _ = s3.PresignGet(covers.Key(t.CoverPrefix, size, covers.FormatJPEG), 15*time.Minute)
+				coverSizes[strconv.Itoa(size)] = urls
+			}
+			blurhash, color = t.CoverBlurhash, t.CoverColor
 		}
+	}
+	streamURL, _ := s3.PresignGet(t.TrackKey, 15*time.Minute)
+	var waveformURL string
+	if t.WaveformKey != "" {
+		waveformURL, _ = s3.PresignGet(t.WaveformKey, 15*time.Minute)
+	}
 
-		coverURL, _ := s3.PresignGet(coverKey, 15*time.Minute)
-		streamURL, _ := s3.PresignGet(trackKey, 15*time.Minute)
+	return TrackInfo{
+		ID:         t.ID,
+		Title:      t.Title,
+		ArtistID:   t.ArtistID,
+		ArtistName: t.ArtistID,
+		CoverURL:   coverURL,
+		StreamURL:  streamURL,
+
+		Covers:        coverSizes,
+		CoverBlurhash: blurhash,
+		CoverColor:    color,
+
+		LikeCount:       t.LikeCount,
+		PlayCount:       t.PlayCount,
+		UniqueListeners: t.UniqueListeners,
+		LastPlayedAt:    t.LastPlayedAt,
+
+		WaveformURL:  waveformURL,
+		DurationMs:   t.DurationMs,
+		LoudnessLUFS: t.LoudnessLUFS,
+		TruePeakDBTP: t.TruePeakDBTP,
+		ReplayGainDB: t.ReplayGainDB,
+	}
+}
 
-		list = append(list, TrackInfo{
-			ID:         id,
-			Title:      title,
-			ArtistID:   artistID,
-			ArtistName: artistID,
-			CoverURL:   coverURL,
-			StreamURL:  streamURL,
-		})
+// writeTrackList отдает список треков. liked == nil — пользователь неизвестен, liked_by_me не заполняется.
+func writeTrackList(w http.ResponseWriter, tracks []repository.Track, s3 *storage.S3Client, liked map[string]bool) {
+	var list []TrackInfo
+	for _, t := range tracks {
+		info := toTrackInfo(t, s3)
+		if liked != nil {
+			likedByMe := liked[t.ID]
+			info.LikedByMe = &likedByMe
+		}
+		list = append(list, info)
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(list)
 }
 
-func GetUserLikedHandler(w http.ResponseWriter, r *http.Request, db *pgx.Conn, s3 *storage.S3Client, userID string) {
+func GetAllTracksHandler(w http.ResponseWriter, r *http.Request, repo *repository.Repository, s3 *storage.S3Client) {
+	tracks, err := repo.ListTracks(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// liked_by_me — только для владельца проверенного токена
+	var liked map[string]bool
+	if identity, ok := auth.FromContext(r.Context()); ok {
+		ids := make([]string, 0, len(tracks))
+		for _, t := range tracks {
+			ids = append(ids, t.ID)
+		}
+		liked, err = repo.LikedAmong(r.Context(), identity.UserID, ids)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	writeTrackList(w, tracks, s3, liked)
+}
 
-	rows, err := db.Query(r.Context(), `
-        SELECT m.id, m.title, m.artist_id, m.cover_s3_key, m.track_s3_key
-        FROM music m
-        JOIN liked_music l ON l.track_id = m.id
-        WHERE l.user_id = $1`, userID)
+func GetUserLikedHandler(w http.ResponseWriter, r *http.Request, repo *repository.Repository, s3 *storage.S3Client, userID string) {
+	tracks, err := repo.ListLikedTracks(r.Context(), userID)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	defer rows.Close()
 
-	var list []TrackInfo
-	for rows.Next() {
-		var id, title, artistID, coverKey, trackKey string
-		rows.Scan(&id, &title, &artistID, &coverKey, &trackKey)
-
-		coverURL, _ := s3.PresignGet(coverKey, 15*time.Minute)
-		streamURL, _ := s3.PresignGet(trackKey, 15*time.Minute)
-
-		list = append(list, TrackInfo{
-			ID:         id,
-			Title:      title,
-			ArtistID:   artistID,
-			ArtistName: artistID,
-			CoverURL:   coverURL,
-			StreamURL:  streamURL,
-		})
+	// Это лайки самого пользователя
+	liked := make(map[string]bool, len(tracks))
+	for _, t := range tracks {
+		liked[t.ID] = true
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(list)
+	writeTrackList(w, tracks, s3, liked)
 }
 
-func UpdateTrackHandler(w http.ResponseWriter, r *http.Request, db *pgx.Conn, s3Client *storage.S3Client) {
-	parts := strings.Split(r.URL.Path, "/")
-	if len(parts) < 3 || parts[2] == "" {
-		http.Error(w, "Неверный URL, отсутствует track_id", http.StatusBadRequest)
+// UpdateTrackHandler — PATCH /track/{id}: название и/или обложка. Правка чужого трека
+// администратором пишется в журнал модерации.
+func UpdateTrackHandler(w http.ResponseWriter, r *http.Request, repo *repository.Repository, s3Client *storage.S3Client, trackID string) {
+	track, ok := ownTrack(w, r, repo, trackID)
+	if !ok {
 		return
 	}
-	trackID := parts[2]
+	identity, _ := auth.FromContext(r.Context())
 
 	if err := r.ParseMultipartForm(10 << 20); err != nil {
 		http.Error(w, "Ошибка разбора формы: "+err.Error(), http.StatusBadRequest)
@@ -109,18 +181,16 @@ func UpdateTrackHandler(w http.ResponseWriter, r *http.Request, db *pgx.Conn, s3
 	}
 
 	var (
-		setClauses []string
-		args       []interface{}
-		idx        = 1
+		upd     repository.TrackUpdate
+		changed []string
 	)
 
 	if title := r.FormValue("title"); title != "" {
-		setClauses = append(setClauses, fmt.Sprintf("title = $%d", idx))
-		args = append(args, title)
-		idx++
+		upd.Title = &title
+		changed = append(changed, "title")
 	}
 
-	file, header, err := r.FormFile("cover")
+	file, _, err := r.FormFile("cover")
 	if err != nil && err != http.ErrMissingFile {
 		http.Error(w, "Ошибка чтения cover: "+err.Error(), http.StatusBadRequest)
 		return
@@ -128,64 +198,114 @@ func UpdateTrackHandler(w http.ResponseWriter, r *http.Request, db *pgx.Conn, s3
 	if err == nil {
 		defer file.Close()
 		buf, _ := io.ReadAll(file)
-		contentType := header.Header.Get("Content-Type")
-		coverKey, err := s3Client.UploadObject(buf, contentType)
+
+		cover, status, err := storeCover(r.Context(), s3Client, buf)
 		if err != nil {
-			http.Error(w, "Ошибка загрузки в S3: "+err.Error(), http.StatusInternalServerError)
+			http.Error(w, "Ошибка загрузки обложки: "+err.Error(), status)
 			return
 		}
-		setClauses = append(setClauses, fmt.Sprintf("cover_s3_key = $%d", idx))
-		args = append(args, coverKey)
-		idx++
+		upd.Cover = &repository.TrackCover{Key: cover.Key, Prefix: cover.Prefix, Blurhash: cover.Blurhash, Color: cover.Color}
+		changed = append(changed, "cover")
 	}
 
-	if len(setClauses) == 0 {
+	if len(changed) == 0 {
 		http.Error(w, "Нет полей для обновления", http.StatusBadRequest)
 		return
 	}
 
-	query := fmt.Sprintf(
-		"UPDATE music SET %s WHERE id = $%d",
-		strings.Join(setClauses, ", "),
-		idx,
-	)
-	args = append(args, trackID)
-
-	if _, err := db.Exec(context.Background(), query, args...); err != nil {
+	err = repo.InTx(r.Context(), func(tx *repository.Repository) error {
+		if err := tx.UpdateTrack(r.Context(), trackID, upd); err != nil {
+			return err
+		}
+		if track.ArtistID == identity.UserID {
+			return nil
+		}
+		return tx.AddModerationLog(r.Context(), repository.ModerationEntry{
+			ID:          uuid.NewString(),
+			ModeratorID: identity.UserID,
+			TargetType:  repository.TargetTrack,
+			TargetID:    trackID,
+			Action:      ActionEdit,
+			Note:        strings.Join(changed, ", "),
+			CreatedAt:   time.Now().UTC(),
+		})
+	})
+	if err != nil {
+		// Новая обложка ни к чему не привязана
+		if upd.Cover != nil {
+			deleteObjects(r.Context(), s3Client, covers.Keys(upd.Cover.Prefix))
+		}
+		if errors.Is(err, repository.ErrTrackNotFound) {
+			http.Error(w, "Трек не найден", http.StatusNotFound)
+			return
+		}
 		http.Error(w, "Ошибка обновления в базе: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	// Файлы прежней обложки удаляются после обновления
+	if upd.Cover != nil {
+		deleteObjects(r.Context(), s3Client, track.CoverObjectKeys())
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte(`{"status":"success"}`))
 }
 
-func DeleteTrackHandler(w http.ResponseWriter, r *http.Request, db *pgx.Conn) {
-	parts := strings.Split(r.URL.Path, "/")
-	if len(parts) < 3 || parts[2] == "" {
-		http.Error(w, "Неверный формат URL, отсутствует track_id", http.StatusBadRequest)
+// DeleteTrackHandler — DELETE /track/{id}: исполнитель удаляет свой трек вместе с файлами в S3.
+// Чужой трек администратор удаляет как действие модерации: с закрытием жалоб и записью в журнал.
+func DeleteTrackHandler(w http.ResponseWriter, r *http.Request, repo *repository.Repository, s3Client *storage.S3Client, usersClient *users.Client, trackID string) {
+	track, ok := ownTrack(w, r, repo, trackID)
+	if !ok {
 		return
 	}
-	trackID := parts[2]
-	query := `DELETE FROM music WHERE id = $1`
-	_, err := db.Exec(context.Background(), query, trackID)
-	if err != nil {
-		http.Error(w, fmt.Sprintf("Ошибка удаления трека из базы: %v", err), http.StatusInternalServerError)
-		return
+	identity, _ := auth.FromContext(r.Context())
+
+	if track.ArtistID != identity.UserID {
+		_, err := moderate(r.Context(), repo, s3Client, usersClient, identity.UserID, repository.TargetTrack, trackID, ActionDelete, "")
+		if !writeModerationError(w, err) {
+			return
+		}
+	} else {
+		// Трек уходит из лент подписчиков вместе с удалением из базы
+		err := repo.InTx(r.Context(), func(tx *repository.Repository) error {
+			if err := tx.DeleteTrack(r.Context(), trackID); err != nil {
+				return err
+			}
+			return addTrackEvent(r.Context(), tx, events.TypeTrackDeleted, track)
+		})
+		if errors.Is(err, repository.ErrTrackNotFound) {
+			http.Error(w, "Трек не найден", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Ошибка удаления трека из базы: %v", err), http.StatusInternalServerError)
+			return
+		}
+		deleteObjects(r.Context(), s3Client, track.ObjectKeys())
 	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	w.Write([]byte(`{"status": "success"}`))
 }
 
-func CreateTrackHandler(w http.ResponseWriter, r *http.Request, db *pgx.Conn, s3Client *storage.S3Client) {
-	parts := strings.Split(r.URL.Path, "/")
-	if len(parts) < 3 || parts[2] == "" {
-		http.Error(w, "Неверный URL, отсутствует artist_id", http.StatusBadRequest)
+// CreateTrackHandler — POST /track/: загрузка трека от имени владельца токена. artistParam —
+// необязательный artist_id из пути прежнего API, он должен совпадать с владельцем токена.
+func CreateTrackHandler(w http.ResponseWriter, r *http.Request, repo *repository.Repository, s3Client *storage.S3Client, artistParam string) {
+	// artist_id события track_published берется только из проверенного токена: по нему
+	// подписчикам раскладывается лента и уходят уведомления
+	identity, ok := auth.FromContext(r.Context())
+	if !ok || identity.UserID == "" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if artistParam != "" && artistParam != identity.UserID {
+		http.Error(w, "Загружать треки можно только от своего имени", http.StatusForbidden)
 		return
 	}
-	artistID := parts[2]
+	artistID := identity.UserID
 
 	if err := r.ParseMultipartForm(20 << 20); err != nil {
 		http.Error(w, "Ошибка разбора формы: "+err.Error(), http.StatusBadRequest)
@@ -198,53 +318,89 @@ func CreateTrackHandler(w http.ResponseWriter, r *http.Request, db *pgx.Conn, s3
 		return
 	}
 
-	upload := func(field, defaultCT string) (string, error) {
+	newID := uuid.New().String()
+	published, err := events.New(events.TypeTrackPublished, "music", events.TrackPublished{
+		TrackID:  newID,
+		ArtistID: artistID,
+		Title:    title,
+	})
+	if err != nil {
+		http.Error(w, "Неверный artist_id или title: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	readFile := func(field string) ([]byte, string, error) {
 		file, header, err := r.FormFile(field)
 		if err != nil {
 			if err == http.ErrMissingFile {
-				return "", fmt.Errorf("поле %q не найдено", field)
+				return nil, "", fmt.Errorf("поле %q не найдено", field)
 			}
-			return "", err
+			return nil, "", err
 		}
 		defer file.Close()
 
 		data, err := io.ReadAll(file)
 		if err != nil {
-			return "", err
+			return nil, "", err
 		}
-
-		ct := header.Header.Get("Content-Type")
-		if ct == "" {
-			ct = defaultCT
-		}
-		return s3Client.UploadObject(data, ct)
+		return data, header.Header.Get("Content-Type"), nil
 	}
 
-	coverKey, err := upload("cover", "image/jpeg")
+	coverData, _, err := readFile("cover")
 	if err != nil {
 		http.Error(w, "Ошибка загрузки обложки: "+err.Error(), http.StatusBadRequest)
 		return
 	}
+	cover, status, err := storeCover(r.Context(), s3Client, coverData)
+	if err != nil {
+		http.Error(w, "Ошибка загрузки обложки: "+err.Error(), status)
+		return
+	}
 
-	trackKey, err := upload("track", "audio/mpeg")
+	trackData, ct, err := readFile("track")
+	if err != nil {
+		http.Error(w, "Ошибка загрузки трека: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if ct == "" {
+		ct = "audio/mpeg"
+	}
+	trackKey, err := s3Client.UploadObject(trackData, ct)
 	if err != nil {
 		http.Error(w, "Ошибка загрузки трека: "+err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	newID := uuid.New().String()
-	createdAt := time.Now()
-	query := `
-        INSERT INTO music
-            (id, title, artist_id, cover_s3_key, track_s3_key, created_at)
-        VALUES
-            ($1, $2, $3, $4, $5, $6)
-    `
-	if _, err := db.Exec(
-		context.Background(),
-		query,
-		newID, title, artistID, coverKey, trackKey, createdAt,
-	); err != nil {
+	// Подписчики исполнителя получат трек в ленту: событие уходит в Kafka через outbox,
+	// только если трек записан
+	err = repo.InTx(r.Context(), func(tx *repository.Repository) error {
+		if err := tx.CreateTrack(r.Context(), repository.Track{
+			ID:        newID,
+			Title:     title,
+			ArtistID:  artistID,
+			CoverKey:  cover.Key,
+			TrackKey:  trackKey,
+			CreatedAt: time.Now(),
+
+			CoverPrefix:   cover.Prefix,
+			CoverBlurhash: cover.Blurhash,
+			CoverColor:    cover.Color,
+		}); err != nil {
+			return err
+		}
+
+		data, err := json.Marshal(published)
+		if err != nil {
+			return err
+		}
+		return tx.AddToOutbox(r.Context(), repository.OutboxMessage{
+			ID:    published.ID,
+			Topic: config.MusicEventsTopic,
+			Key:   artistID,
+			Data:  data,
+		})
+	})
+	if err != nil {
 		http.Error(w, "Ошибка записи в базу: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -253,3 +409,92 @@ func CreateTrackHandler(w http.ResponseWriter, r *http.Request, db *pgx.Conn, s3
 	w.WriteHeader(http.StatusCreated)
 	w.Write([]byte(`{"status":"success","id":"` + newID + `"}`))
 }
+
+// addTrackEvent пишет в outbox событие о скрытии, возврате или удалении трека: по нему users
+// правит ленты. Ключ — исполнитель, как у track_published, чтобы события трека шли по порядку.
+func addTrackEvent(ctx context.Context, tx *repository.Repository, eventType string, track repository.Track) error {
+	var payload any
+	switch eventType {
+	case events.TypeTrackHidden:
+		payload = events.TrackHidden{TrackID: track.ID, ArtistID: track.ArtistID}
+	case events.TypeTrackRestored:
+		payload = events.TrackRestored{TrackID: track.ID, ArtistID: track.ArtistID}
+	case events.TypeTrackDeleted:
+		payload = events.TrackDeleted{TrackID: track.ID, ArtistID: track.ArtistID}
+	default:
+		return fmt.Errorf("неизвестное событие трека %q", eventType)
+	}
+
+	e, err := events.New(eventType, "music", payload)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	return tx.AddToOutbox(ctx, repository.OutboxMessage{
+		ID:    e.ID,
+		Topic: config.MusicEventsTopic,
+		Key:   track.ArtistID,
+		Data:  data,
+	})
+}
+
+// storeCover проверяет обложку и загружает ее варианты в S3; status — код ответа при ошибке.
+func storeCover(ctx context.Context, s3 *storage.S3Client, data []byte) (covers.Cover, int, error) {
+	processed, err := covers.Process(data)
+	switch {
+	case errors.Is(err, covers.ErrUnsupported):
+		return covers.Cover{}, http.StatusBadRequest, errors.New("нужен JPEG, PNG или WebP")
+	case errors.Is(err, covers.ErrTooLarge):
+		return covers.Cover{}, http.StatusBadRequest, errors.New("картинка больше 10000 px по стороне или 50 Мп")
+	case err != nil:
+		return covers.Cover{}, http.StatusInternalServerError, err
+	}
+
+	cover, err := covers.Store(ctx, s3, processed)
+	if err != nil {
+		return covers.Cover{}, http.StatusInternalServerError, err
+	}
+
+	return cover, 0, nil
+}
+
+// ownTrack отвечает 400/403/404 сам: трек должен принадлежать пользователю (админу — любой).
+func ownTrack(w http.ResponseWriter, r *http.Request, repo *repository.Repository, trackID string) (repository.Track, bool) {
+	identity, _ := auth.FromContext(r.Context())
+
+	if _, err := uuid.Parse(trackID); err != nil {
+		http.Error(w, "Неверный track_id", http.StatusBadRequest)
+		return repository.Track{}, false
+	}
+
+	track, err := repo.GetTrack(r.Context(), trackID)
+	if errors.Is(err, repository.ErrTrackNotFound) {
+		http.Error(w, "Трек не найден", http.StatusNotFound)
+		return repository.Track{}, false
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return repository.Track{}, false
+	}
+	if track.ArtistID != identity.UserID && identity.Role != "admin" {
+		http.Error(w, "Изменить трек может только его исполнитель", http.StatusForbidden)
+		return repository.Track{}, false
+	}
+
+	return track, true
+}
+
+// deleteObjects удаляет файлы из S3; ошибки только логируются — в базе ссылок на файлы уже нет.
+func deleteObjects(ctx context.Context, s3 *storage.S3Client, keys []string) {
+	for _, key := range keys {
+		if key == "" {
+			continue
+		}
+		if err := s3.DeleteObject(ctx, key); err != nil {
+			log.Printf("⚠ не удалось удалить %s из S3: %v", key, err)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"music/iternal/auth"
+	"music/iternal/radio"
+	"music/iternal/repository"
+	"music/iternal/storage"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultRadioBatch = 10
+	maxRadioBatch     = 50
+)
+
+// StartRadioRequest — ровно один из сидов.
+type StartRadioRequest struct {
+	TrackID  string   `json:"track_id"`
+	ArtistID string   `json:"artist_id"`
+	TrackIDs []string `json:"track_ids"`
+	Limit    int      `json:"limit"`
+}
+
+type RadioFeedbackRequest struct {
+	TrackID string `json:"track_id"`
+	Action  string `json:"action"`
+}
+
+type RadioResponse struct {
+	// Токен продолжения: GET /radio/{token}
+	Token string      `json:"token"`
+	Items []TrackInfo `json:"items"`
+}
+
+// StartRadioHandler — POST /radio
+func StartRadioHandler(w http.ResponseWriter, r *http.Request, repo *repository.Repository, s3 *storage.S3Client) {
+	identity, _ := auth.FromContext(r.Context())
+
+	var req StartRadioRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Неверное тело запроса", http.StatusBadRequest)
+		return
+	}
+
+	seed, ok := radioSeed(req)
+	if !ok {
+		http.Error(w, "Нужен ровно один сид: track_id, artist_id или track_ids (uuid, до 100)", http.StatusBadRequest)
+		return
+	}
+
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultRadioBatch
+	}
+	limit = min(limit, maxRadioBatch)
+
+	session, tracks, err := radio.Start(r.Context(), repo, identity.UserID, seed, limit)
+	if errors.Is(err, radio.ErrSeedNotFound) {
+		http.Error(w, "Сид не найден", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeRadio(w, http.StatusCreated, session.ID, tracks, s3)
+}
+
+// NextRadioHandler — GET /radio/{token}?limit=
+func NextRadioHandler(w http.ResponseWriter, r *http.Request, repo *repository.Repository, s3 *storage.S3Client, token string) {
+	identity, _ := auth.FromContext(r.Context())
+
+	if _, err := uuid.Parse(token); err != nil {
+		http.Error(w, "Сессия не найдена", http.StatusNotFound)
+		return
+	}
+
+	limit := defaultRadioBatch
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			http.Error(w, "Неверный limit", http.StatusBadRequest)
+			return
+		}
+		limit = min(n, maxRadioBatch)
+	}
+
+	tracks, err := radio.Next(r.Context(), repo, token, identity.UserID, limit)
+	if errors.Is(err, repository.ErrRadioSessionNotFound) {
+		http.Error(w, "Сессия не найдена", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeRadio(w, http.StatusOK, token, tracks, s3)
+}
+
+// RadioFeedbackHandler — POST /radio/{token}/feedback
+func RadioFeedbackHandler(w http.ResponseWriter, r *http.Request, repo *repository.Repository, token string) {
+	identity, _ := auth.FromContext(r.Context())
+
+	if _, err := uuid.Parse(token); err != nil {
+		http.Error(w, "Сессия не найдена", http.StatusNotFound)
+		return
+	}
+
+	var req RadioFeedbackRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Неверное тело запроса", http.StatusBadRequest)
+		return
+	}
+	if _, err := uuid.Parse(req.TrackID); err != nil {
+		http.Error(w, "Неверный track_id", http.StatusBadRequest)
+		return
+	}
+	if req.Action != repository.RadioFeedbackSkip && req.Action != repository.RadioFeedbackLike {
+		http.Error(w, "action должен быть skip или like", http.StatusBadRequest)
+		return
+	}
+
+	err := radio.Feedback(r.Context(), repo, token, identity.UserID, req.TrackID, req.Action)
+	switch {
+	case errors.Is(err, repository.ErrRadioSessionNotFound):
+		http.Error(w, "Сессия не найдена", http.StatusNotFound)
+	case errors.Is(err, repository.ErrRadioTrackNotQueued):
+		http.Error(w, "Трека нет в очереди сессии", http.StatusNotFound)
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	default:
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func radioSeed(req StartRadioRequest) (radio.Seed, bool) {
+	var seeds []radio.Seed
+	if req.TrackID != "" {
+		seeds = append(seeds, radio.Seed{Type: repository.RadioSeedTrack, TrackIDs: []string{req.TrackID}})
+	}
+	if req.ArtistID != "" {
+		seeds = append(seeds, radio.Seed{Type: repository.RadioSeedArtist, ArtistID: req.ArtistID})
+	}
+	if len(req.TrackIDs) > 0 {
+		seeds = append(seeds, radio.Seed{Type: repository.RadioSeedTracks, TrackIDs: req.TrackIDs})
+	}
+	if len(seeds) != 1 || len(req.TrackIDs) > radio.MaxSeedTracks {
+		return radio.Seed{}, false
+	}
+
+	ids := slices.Clone(seeds[0].TrackIDs)
+	if req.ArtistID != "" {
+		ids = append(ids, req.ArtistID)
+	}
+	for _, id := range ids {
+		if _, err := uuid.Parse(id); err != nil {
+			return radio.Seed{}, false
+		}
+	}
+
+	return seeds[0], true
+}
+
+func writeRadio(w http.ResponseWriter, status int, token string, tracks []repository.Track, s3 *storage.S3Client) {
+	resp := RadioResponse{Token: token, Items: []TrackInfo{}}
+	for _, t := range tracks {
+		resp.Items = append(resp.Items, toTrackInfo(t, s3))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(resp)
+}
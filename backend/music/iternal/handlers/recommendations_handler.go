@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"music/iternal/auth"
+	"music/iternal/recommendations"
+	"music/iternal/repository"
+	"music/iternal/storage"
+
+	"github.com/google/uuid"
+)
+
+type Recommendation struct {
+	Track TrackInfo `json:"track"`
+	Score float64   `json:"score"`
+	// similar — по соседям треков, popular — запасной вариант по популярности
+	Source string `json:"source"`
+}
+
+type RecommendationsResponse struct {
+	Items []Recommendation `json:"items"`
+}
+
+// GetRecommendationsHandler — GET /me/recommendations?limit=
+func GetRecommendationsHandler(w http.ResponseWriter, r *http.Request, repo *repository.Repository, s3 *storage.S3Client) {
+	identity, _ := auth.FromContext(r.Context())
+
+	limit, err := pageSize(r.URL.Query().Get("limit"))
+	if err != nil {
+		http.Error(w, "Неверный limit", http.StatusBadRequest)
+		return
+	}
+
+	items, err := recommendations.ForUser(r.Context(), repo, identity.UserID, limit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeRecommendations(w, items, s3)
+}
+
+// GetSimilarTracksHandler — GET /track/{id}/similar?limit=
+func GetSimilarTracksHandler(w http.ResponseWriter, r *http.Request, repo *repository.Repository, s3 *storage.S3Client, trackID string) {
+	if _, err := uuid.Parse(trackID); err != nil {
+		http.Error(w, "Неверный track_id", http.StatusBadRequest)
+		return
+	}
+
+	limit, err := pageSize(r.URL.Query().Get("limit"))
+	if err != nil {
+		http.Error(w, "Неверный limit", http.StatusBadRequest)
+		return
+	}
+
+	_, err = repo.GetPublicTrack(r.Context(), trackID)
+	if errors.Is(err, repository.ErrTrackNotFound) {
+		http.Error(w, "Трек не найден", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	items, err := recommendations.Similar(r.Context(), repo, trackID, limit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeRecommendations(w, items, s3)
+}
+
+func writeRecommendations(w http.ResponseWriter, items []recommendations.Item, s3 *storage.S3Client) {
+	resp := RecommendationsResponse{Items: []Recommendation{}}
+	for _, it := range items {
+		resp.Items = append(resp.Items, Recommendation{
+			Track:  toTrackInfo(it.Track, s3),
+			Score:  it.Score,
+			Source: it.Source,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
@@ -1,19 +1,15 @@
 package kafka
 
 import (
-	"log"
-
 	ckafka "github.com/confluentinc/confluent-kafka-go/v2/kafka"
 )
 
-func NewConsumer(brokers, groupID string) *ckafka.Consumer {
-	c, err := ckafka.NewConsumer(&ckafka.ConfigMap{
+func NewConsumer(brokers, groupID string) (*ckafka.Consumer, error) {
+	return ckafka.NewConsumer(&ckafka.ConfigMap{
 		"bootstrap.servers": brokers,
 		"group.id":          groupID,
 		"auto.offset.reset": "earliest",
+		// Оффсеты коммитятся вручную после коммита транзакции пачки
+		"enable.auto.commit": false,
 	})
-	if err != nil {
-		log.Fatalf("Не удалось создать Kafka consumer: %v", err)
-	}
-	return c
 }
@@ -2,87 +2,374 @@ package kafka
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"time"
 
 	ckafka "github.com/confluentinc/confluent-kafka-go/v2/kafka"
-	"github.com/google/uuid"
-	"github.com/jackc/pgx/v5"
+	"gitlab.com/Go34/Mute/shared/events"
+
+	"music/iternal/repository"
+	"music/iternal/storage"
+)
+
+const (
+	// Сколько сообщений обрабатывается в одной транзакции
+	batchSize = 500
+	// Сколько ждать первое сообщение пачки; остальные добираются без ожидания
+	batchWait = 500 * time.Millisecond
+	// Сколько хранить id обработанных событий: повторная доставка бывает только в пределах ретеншна топика
+	processedRetention = 7 * 24 * time.Hour
 )
 
-type Task struct {
-	Action  string `json:"action"`
-	UserID  string `json:"user_id"`
-	TrackID string `json:"track_id"`
+// errAlreadyProcessed — событие уже применено, повторная доставка ничего не меняет
+var errAlreadyProcessed = errors.New("event already processed")
+
+// Run запускает Start и перезапускает его с растущей задержкой, если тот завершился с ошибкой.
+// Возвращается после отмены ctx, когда текущая пачка применена и оффсеты закоммичены.
+func Run(ctx context.Context, repo *repository.Repository, s3 *storage.S3Client, brokers, groupID, topic string) error {
+	backoff := time.Second
+
+	for {
+		started := time.Now()
+		err := Start(ctx, repo, s3, brokers, groupID, topic)
+		if ctx.Err() != nil {
+			log.Println("🛑 Kafka consumer остановлен")
+			return nil
+		}
+
+		// Если консьюмер успел поработать, ошибка не связана с предыдущими, задержка сбрасывается
+		if time.Since(started) > time.Minute {
+			backoff = time.Second
+		}
+		log.Printf("❌ Kafka consumer упал: %v, перезапуск через %s", err, backoff)
+		if !sleep(ctx, backoff) {
+			return nil
+		}
+		backoff = min(backoff*2, time.Minute)
+	}
 }
 
-func Start(ctx context.Context, db *pgx.Conn, brokers, groupID, topic string) {
-	c := NewConsumer(brokers, groupID)
+// Start читает топик пачками, пока не отменен ctx или не случилась фатальная ошибка Kafka.
+// Пачка применяется в одной транзакции, оффсеты коммитятся только после коммита в БД.
+// Порядок внутри партиции сохраняется: пачки обрабатываются последовательно,
+// сообщения в пачке — в порядке чтения.
+func Start(ctx context.Context, repo *repository.Repository, s3 *storage.S3Client, brokers, groupID, topic string) error {
+	c, err := NewConsumer(brokers, groupID)
+	if err != nil {
+		return fmt.Errorf("create consumer: %w", err)
+	}
 	defer c.Close()
 
+	dlq, err := NewDeadLetters(brokers, topic+".DLQ")
+	if err != nil {
+		return fmt.Errorf("create dlq producer: %w", err)
+	}
+	defer dlq.Close()
+
 	if err := c.Subscribe(topic, nil); err != nil {
-		log.Fatalf("Не удалось подписаться на %s: %v", topic, err)
+		return fmt.Errorf("subscribe %s: %w", topic, err)
 	}
 	log.Printf("✅ Kafka consumer subscribed to %s", topic)
 
-	for {
-		msg, err := c.ReadMessage(500 * time.Millisecond)
-		if err != nil {
-			if kafkaErr, ok := err.(ckafka.Error); ok && kafkaErr.Code() == ckafka.ErrTimedOut {
-				continue
-			}
-			log.Printf("Kafka error: %v", err)
-			continue
+	router := newRouter(s3)
+	backoff := time.Second
+	lastCleanup := time.Time{}
+
+	// Начатая пачка доводится до конца и после отмены ctx
+	batchCtx := context.WithoutCancel(ctx)
+
+	for ctx.Err() == nil {
+		// Раз в час, в том числе без трафика: партиция месяца должна появиться раньше его прослушиваний
+		if time.Since(lastCleanup) > time.Hour {
+			cleanupProcessed(batchCtx, repo)
+			ensurePartitions(batchCtx, repo)
+			lastCleanup = time.Now()
 		}
 
-		var t Task
-		if err := json.Unmarshal(msg.Value, &t); err != nil {
-			log.Printf("Invalid message format: %v", err)
+		batch, err := readBatch(c)
+		if err != nil {
+			return err
+		}
+		if len(batch) == 0 {
 			continue
 		}
 
-		if t.Action != "like" && t.Action != "dislike" {
+		if err := processBatch(batchCtx, c, repo, router, dlq, batch); err != nil {
+			log.Printf("❌ Пачка из %d сообщений не обработана: %v, повтор через %s", len(batch), err, backoff)
+			rewind(c, batch)
+			sleep(ctx, backoff)
+			backoff = min(backoff*2, time.Minute)
 			continue
 		}
+		backoff = time.Second
+	}
+
+	return nil
+}
+
+// readBatch возвращает ошибку только для фатальных ошибок клиента, после которых нужен новый консьюмер.
+func readBatch(c *ckafka.Consumer) ([]*ckafka.Message, error) {
+	var batch []*ckafka.Message
 
-		tx, err := db.Begin(ctx)
+	wait := batchWait
+	for len(batch) < batchSize {
+		msg, err := c.ReadMessage(wait)
 		if err != nil {
-			log.Printf("Begin tx error: %v", err)
-			continue
+			kafkaErr, ok := err.(ckafka.Error)
+			if ok && kafkaErr.Code() == ckafka.ErrTimedOut {
+				break
+			}
+			if ok && kafkaErr.IsFatal() {
+				// Сообщения пачки не применены и не закоммичены, новый консьюмер прочитает их снова
+				return nil, kafkaErr
+			}
+			log.Printf("Kafka error: %v", err)
+			break
 		}
+		batch = append(batch, msg)
+		wait = 10 * time.Millisecond
+	}
+
+	return batch, nil
+}
+
+// offsetCommitter и deadLetterSender — то, что processBatch нужно от консьюмера и DLQ.
+type offsetCommitter interface {
+	CommitOffsets(offsets []ckafka.TopicPartition) ([]ckafka.TopicPartition, error)
+}
+
+type deadLetterSender interface {
+	Send(msg *ckafka.Message, reason error) error
+}
+
+type deadLetter struct {
+	msg    *ckafka.Message
+	reason error
+}
 
-		switch t.Action {
-		case "like":
-			_, err = tx.Exec(ctx,
-				`INSERT INTO liked_music(id, track_id, user_id)
-				 VALUES ($1, $2, $3)
-				 ON CONFLICT (track_id, user_id) DO NOTHING`,
-				uuid.New(), t.TrackID, t.UserID,
-			)
-		case "dislike":
-			_, err = tx.Exec(ctx,
-				`DELETE FROM liked_music
-				 WHERE track_id = $1 AND user_id = $2`,
-				t.TrackID, t.UserID,
-			)
+// processBatch применяет пачку. Каждое сообщение выполняется в своей точке сохранения:
+// ошибка одного сообщения откатывает только его, и оно уходит в DLQ.
+func processBatch(ctx context.Context, c offsetCommitter, repo *repository.Repository, router *events.Router, dlq deadLetterSender, batch []*ckafka.Message) error {
+	var (
+		dead        []deadLetter
+		afterCommit []func(ctx context.Context)
+		applied     int
+	)
+
+	err := repo.InTx(ctx, func(tx *repository.Repository) error {
+		for _, msg := range batch {
+			s := &scope{}
+			err := tx.InTx(ctx, func(sp *repository.Repository) error {
+				s.repo = sp
+				return handle(withScope(ctx, s), router, msg)
+			})
+			switch {
+			case err == nil:
+				afterCommit = append(afterCommit, s.afterCommit...)
+				applied++
+			case errors.Is(err, errAlreadyProcessed):
+			default:
+				log.Printf("❌ Не удалось обработать сообщение: %v", err)
+				dead = append(dead, deadLetter{msg, err})
+			}
 		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("tx: %w", err)
+	}
 
-		if err != nil {
-			_ = tx.Rollback(ctx)
-			log.Printf("DB exec error (%s): %v", t.Action, err)
-			continue
+	// БД закоммичена: при повторе пачки примененные события отсеются по processed_events,
+	// и их действия после коммита уже не выполнятся, поэтому они выполняются сразу
+	for _, fn := range afterCommit {
+		fn(ctx)
+	}
+
+	if err := settle(c, dlq, batch, dead); err != nil {
+		return err
+	}
+
+	log.Printf("✅ Пачка обработана: %d сообщений, применено %d, повторов %d, в DLQ %d",
+		len(batch), applied, len(batch)-applied-len(dead), len(dead))
+
+	return nil
+}
+
+// settle отправляет упавшие сообщения в DLQ и только потом коммитит оффсеты пачки.
+// Если DLQ недоступен, оффсеты не коммитятся и возвращается ошибка: пачка перечитается
+// после rewind, упавшие сообщения (их точки сохранения откатились) обработаются снова,
+// а уже отправленные в DLQ могут попасть туда повторно.
+func settle(c offsetCommitter, dlq deadLetterSender, batch []*ckafka.Message, dead []deadLetter) error {
+	for _, d := range dead {
+		if err := dlq.Send(d.msg, d.reason); err != nil {
+			return fmt.Errorf("dlq: %w", err)
 		}
+	}
 
-		if err := tx.Commit(ctx); err != nil {
-			log.Printf("Tx commit error: %v", err)
+	if _, err := c.CommitOffsets(nextOffsets(batch)); err != nil {
+		// БД уже закоммичена: при повторной доставке события отсеются по processed_events
+		log.Printf("⚠ не удалось закоммитить оффсеты: %v", err)
+	}
+
+	return nil
+}
+
+// nextOffsets возвращает для каждой партиции пачки оффсет, с которого читать дальше.
+func nextOffsets(batch []*ckafka.Message) []ckafka.TopicPartition {
+	last := make(map[int32]ckafka.TopicPartition)
+	for _, msg := range batch {
+		tp := msg.TopicPartition
+		tp.Offset++
+		last[tp.Partition] = tp
+	}
+
+	offsets := make([]ckafka.TopicPartition, 0, len(last))
+	for _, tp := range last {
+		offsets = append(offsets, tp)
+	}
+
+	return offsets
+}
+
+// rewind возвращает чтение каждой партиции к первому сообщению пачки, чтобы применить ее заново.
+func rewind(c *ckafka.Consumer, batch []*ckafka.Message) {
+	seen := make(map[int32]bool)
+	for _, msg := range batch {
+		if seen[msg.TopicPartition.Partition] {
 			continue
 		}
+		seen[msg.TopicPartition.Partition] = true
+
+		if err := c.Seek(msg.TopicPartition, 0); err != nil {
+			log.Printf("⚠ не удалось вернуться к оффсету %v: %v", msg.TopicPartition, err)
+		}
+	}
+}
+
+// sleep ждет d или отмены ctx; false — ctx отменен.
+func sleep(ctx context.Context, d time.Duration) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(d):
+		return true
+	}
+}
+
+func cleanupProcessed(ctx context.Context, repo *repository.Repository) {
+	if err := repo.DeleteProcessedEvents(ctx, time.Now().Add(-processedRetention)); err != nil {
+		log.Printf("⚠ не удалось почистить processed_events: %v", err)
+	}
+}
 
-		if t.Action == "like" {
-			log.Printf("🎉 Like processed: user=%s track=%s", t.UserID, t.TrackID)
-		} else {
-			log.Printf("💔 Dislike processed: user=%s track=%s", t.UserID, t.TrackID)
+// ensurePartitions создает партиции plays на текущий и следующий месяц,
+// чтобы прослушивания не копились в plays_default.
+func ensurePartitions(ctx context.Context, repo *repository.Repository) {
+	now := time.Now()
+	for _, month := range []time.Time{now, now.AddDate(0, 1, 0)} {
+		if err := repo.EnsurePlaysPartition(ctx, month); err != nil {
+			log.Printf("⚠ не удалось создать партицию plays на %s: %v", month.Format("2006-01"), err)
 		}
 	}
 }
+
+func handle(ctx context.Context, router *events.Router, msg *ckafka.Message) error {
+	e, err := events.Parse(msg.Value)
+	if err != nil {
+		return err
+	}
+	// У событий старого формата нет времени: берется время сообщения, оно одно и то же при повторной доставке
+	if e.OccurredAt.IsZero() {
+		e.OccurredAt = msg.Timestamp.UTC()
+	}
+
+	isNew, err := scopeFrom(ctx).repo.MarkEventProcessed(ctx, e.ID)
+	if err != nil {
+		return fmt.Errorf("mark processed: %w", err)
+	}
+	if !isNew {
+		return errAlreadyProcessed
+	}
+
+	return router.Dispatch(ctx, e)
+}
+
+// erased — пользователь уже удален по user_deleted. У user_deleted ключ user_id, у лайков
+// и прослушиваний — user_id:track_id, поэтому в другой партиции они могут прийти позже удаления;
+// такое событие отбрасывается, иначе данные удаленного пользователя появились бы снова.
+func erased(ctx context.Context, e events.Envelope, userID string) (bool, error) {
+	isErased, err := scopeFrom(ctx).repo.IsUserErased(ctx, userID)
+	if err != nil {
+		return false, fmt.Errorf("check erased user: %w", err)
+	}
+	if isErased {
+		log.Printf("🗑 Событие %s (%s) удаленного пользователя %s отброшено", e.ID, e.Type, userID)
+	}
+	return isErased, nil
+}
+
+// newRouter связывает типы событий с обработчиками. Новый тип события — новая строка здесь.
+// Обработчики пишут в транзакцию пачки через scopeFrom(ctx).repo.
+func newRouter(s3 *storage.S3Client) *events.Router {
+	router := events.NewRouter()
+
+	events.On(router, events.TypeTrackLiked, func(ctx context.Context, e events.Envelope, p events.TrackLiked) error {
+		if skip, err := erased(ctx, e, p.UserID); skip || err != nil {
+			return err
+		}
+		// id лайка — id события: повтор с тем же событием не создаст вторую запись
+		if err := scopeFrom(ctx).repo.LikeTrack(ctx, e.ID, p.UserID, p.TrackID, e.OccurredAt); err != nil {
+			return fmt.Errorf("db exec (like): %w", err)
+		}
+		return nil
+	})
+
+	events.On(router, events.TypeTrackUnliked, func(ctx context.Context, e events.Envelope, p events.TrackUnliked) error {
+		if skip, err := erased(ctx, e, p.UserID); skip || err != nil {
+			return err
+		}
+		if err := scopeFrom(ctx).repo.UnlikeTrack(ctx, p.UserID, p.TrackID, e.OccurredAt); err != nil {
+			return fmt.Errorf("db exec (dislike): %w", err)
+		}
+		return nil
+	})
+
+	events.On(router, events.TypeTrackPlayed, func(ctx context.Context, e events.Envelope, p events.TrackPlayed) error {
+		repo := scopeFrom(ctx).repo
+		if skip, err := erased(ctx, e, p.UserID); skip || err != nil {
+			return err
+		}
+
+		if err := repo.InsertPlay(ctx, e.ID, p.UserID, p.TrackID, p.PositionMs, p.DurationMs, e.OccurredAt); err != nil {
+			return fmt.Errorf("insert play: %w", err)
+		}
+		if err := repo.RecordPlay(ctx, p.UserID, p.TrackID, e.OccurredAt); err != nil {
+			return fmt.Errorf("record play: %w", err)
+		}
+		return nil
+	})
+
+	events.On(router, events.TypeUserDeleted, func(ctx context.Context, e events.Envelope, p events.UserDeleted) error {
+		s := scopeFrom(ctx)
+
+		keys, err := s.repo.DeleteUserData(ctx, p.UserID)
+		if err != nil {
+			return fmt.Errorf("delete user data %s: %w", p.UserID, err)
+		}
+
+		// Файлы в S3 удаляются после коммита: если это не удалось, в базе уже нет ссылок на них
+		s.afterCommit = append(s.afterCommit, func(ctx context.Context) {
+			for _, key := range keys {
+				if err := s3.DeleteObject(ctx, key); err != nil {
+					log.Printf("⚠ не удалось удалить объект %s из S3: %v", key, err)
+				}
+			}
+			log.Printf("🗑 Данные пользователя удалены: user=%s", p.UserID)
+		})
+		return nil
+	})
+
+	return router
+}
@@ -0,0 +1,164 @@
+package kafka
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	ckafka "github.com/confluentinc/confluent-kafka-go/v2/kafka"
+)
+
+type fakeCommitter struct {
+	committed []ckafka.TopicPartition
+	calls     int
+	err       error
+}
+
+func (f *fakeCommitter) CommitOffsets(offsets []ckafka.TopicPartition) ([]ckafka.TopicPartition, error) {
+	f.calls++
+	f.committed = offsets
+	return offsets, f.err
+}
+
+// fakeDLQ падает на сообщениях с оффсетами из failOn
+type fakeDLQ struct {
+	sent   []ckafka.Offset
+	failOn map[ckafka.Offset]bool
+}
+
+func (f *fakeDLQ) Send(msg *ckafka.Message, reason error) error {
+	if f.failOn[msg.TopicPartition.Offset] {
+		return errors.New("broker unavailable")
+	}
+	f.sent = append(f.sent, msg.TopicPartition.Offset)
+	return nil
+}
+
+func message(partition int32, offset ckafka.Offset) *ckafka.Message {
+	topic := "songs_actions"
+	return &ckafka.Message{TopicPartition: ckafka.TopicPartition{Topic: &topic, Partition: partition, Offset: offset}}
+}
+
+// offsets — партиция -> следующий оффсет
+func offsets(tps []ckafka.TopicPartition) map[int32]ckafka.Offset {
+	m := make(map[int32]ckafka.Offset, len(tps))
+	for _, tp := range tps {
+		m[tp.Partition] = tp.Offset
+	}
+	return m
+}
+
+func TestNextOffsets(t *testing.T) {
+	testCases := []struct {
+		name  string
+		batch []*ckafka.Message
+		want  map[int32]ckafka.Offset
+	}{
+		{
+			name: "empty batch",
+			want: map[int32]ckafka.Offset{},
+		},
+		{
+			name:  "one message",
+			batch: []*ckafka.Message{message(0, 41)},
+			want:  map[int32]ckafka.Offset{0: 42},
+		},
+		{
+			name:  "last message of the partition wins",
+			batch: []*ckafka.Message{message(0, 10), message(0, 11), message(0, 12)},
+			want:  map[int32]ckafka.Offset{0: 13},
+		},
+		{
+			name:  "partitions interleaved",
+			batch: []*ckafka.Message{message(0, 5), message(1, 100), message(0, 6), message(2, 0), message(1, 101)},
+			want:  map[int32]ckafka.Offset{0: 7, 1: 102, 2: 1},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := nextOffsets(tc.batch)
+			if len(got) != len(tc.want) {
+				t.Fatalf("got %d partitions, want %d", len(got), len(tc.want))
+			}
+			for p, off := range offsets(got) {
+				if tc.want[p] != off {
+					t.Fatalf("partition %d: offset %d, want %d", p, off, tc.want[p])
+				}
+			}
+		})
+	}
+}
+
+func TestSettle(t *testing.T) {
+	batch := []*ckafka.Message{message(0, 1), message(0, 2), message(1, 7), message(0, 3)}
+	failure := errors.New("handler failed")
+
+	testCases := []struct {
+		name       string
+		dead       []deadLetter
+		failOn     map[ckafka.Offset]bool
+		commitErr  error
+		wantErr    bool
+		wantSent   []ckafka.Offset
+		wantCommit bool
+	}{
+		{
+			name:       "no dead letters",
+			wantCommit: true,
+		},
+		{
+			name:       "dead letters sent before commit",
+			dead:       []deadLetter{{batch[1], failure}, {batch[2], failure}},
+			wantSent:   []ckafka.Offset{2, 7},
+			wantCommit: true,
+		},
+		{
+			name:    "dlq failure skips commit",
+			dead:    []deadLetter{{batch[1], failure}},
+			failOn:  map[ckafka.Offset]bool{2: true},
+			wantErr: true,
+		},
+		{
+			name:     "dlq failure after partial send skips commit",
+			dead:     []deadLetter{{batch[0], failure}, {batch[2], failure}},
+			failOn:   map[ckafka.Offset]bool{7: true},
+			wantErr:  true,
+			wantSent: []ckafka.Offset{1},
+		},
+		{
+			name:       "commit error is not fatal",
+			commitErr:  errors.New("coordinator not available"),
+			wantCommit: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := &fakeCommitter{err: tc.commitErr}
+			dlq := &fakeDLQ{failOn: tc.failOn}
+
+			err := settle(c, dlq, batch, tc.dead)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("settle() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if !slices.Equal(dlq.sent, tc.wantSent) {
+				t.Fatalf("sent to dlq %v, want %v", dlq.sent, tc.wantSent)
+			}
+
+			if !tc.wantCommit {
+				if c.calls != 0 {
+					t.Fatalf("offsets committed %v after dlq failure", c.committed)
+				}
+				return
+			}
+			if c.calls != 1 {
+				t.Fatalf("CommitOffsets called %d times, want 1", c.calls)
+			}
+			got := offsets(c.committed)
+			if len(got) != 2 || got[0] != 4 || got[1] != 8 {
+				t.Fatalf("committed %v, want partition 0 at 4 and 1 at 8", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package kafka
+
+import (
+	"fmt"
+	"log"
+
+	ckafka "github.com/confluentinc/confluent-kafka-go/v2/kafka"
+)
+
+// Заголовки DLQ-сообщения, их читает sender и сохраняет в deffered_tasks_dead
+const (
+	headerOriginalTopic = "original_topic"
+	headerError         = "error"
+)
+
+// DeadLetters публикует сообщения, которые не удалось обработать, в отдельный топик,
+// вместо того чтобы молча их пропускать.
+type DeadLetters struct {
+	producer *ckafka.Producer
+	topic    string
+}
+
+func NewDeadLetters(brokers, topic string) (*DeadLetters, error) {
+	p, err := ckafka.NewProducer(&ckafka.ConfigMap{
+		"bootstrap.servers":  brokers,
+		"enable.idempotence": true,
+		"acks":               "all",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &DeadLetters{
+		producer: p,
+		topic:    topic,
+	}, nil
+}
+
+// Send отправляет сообщение в DLQ и ждет подтверждения доставки. Ошибка значит, что
+// сообщение в DLQ не попало и его оффсет коммитить нельзя.
+func (d *DeadLetters) Send(msg *ckafka.Message, reason error) error {
+	deliveryChan := make(chan ckafka.Event, 1)
+
+	err := d.producer.Produce(&ckafka.Message{
+		TopicPartition: ckafka.TopicPartition{Topic: &d.topic, Partition: ckafka.PartitionAny},
+		Key:            msg.Key,
+		Value:          msg.Value,
+		Headers: []ckafka.Header{
+			{Key: headerOriginalTopic, Value: []byte(*msg.TopicPartition.Topic)},
+			{Key: headerError, Value: []byte(reason.Error())},
+		},
+	}, deliveryChan)
+	if err != nil {
+		return fmt.Errorf("produce to %s: %w", d.topic, err)
+	}
+
+	m := (<-deliveryChan).(*ckafka.Message)
+	if m.TopicPartition.Error != nil {
+		return fmt.Errorf("deliver to %s: %w", d.topic, m.TopicPartition.Error)
+	}
+
+	log.Printf("☠ Сообщение отправлено в %s: %v", d.topic, reason)
+	return nil
+}
+
+func (d *DeadLetters) Close() {
+	d.producer.Flush(5000)
+	d.producer.Close()
+}
@@ -0,0 +1,181 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	ckafka "github.com/confluentinc/confluent-kafka-go/v2/kafka"
+
+	"music/iternal/repository"
+)
+
+const (
+	// Сколько событий outbox отправляется за раз
+	outboxBatchSize = 100
+	// Как часто проверять outbox, когда он пуст
+	outboxPollInterval = time.Second
+	// Сколько хранить отправленные события
+	outboxRetention = 7 * 24 * time.Hour
+	// Сколько ждать подтверждения от брокера
+	outboxDeliveryTimeout = 30 * time.Second
+	// На сколько событие берется в аренду; должно быть больше outboxDeliveryTimeout
+	outboxLease = 2 * time.Minute
+	// После стольких неудачных попыток событие переносится в outbox_dead
+	outboxMaxAttempts = 10
+	// Предельная задержка между попытками одного события
+	outboxMaxRetryDelay = 10 * time.Minute
+)
+
+// RunOutbox переносит события из таблицы outbox в Kafka, пока не отменен ctx.
+// Событие помечается отправленным только после подтверждения от брокера: оно может
+// уйти повторно, но не потеряется. Экземпляры music берут события в аренду и не мешают
+// друг другу, события одного ключа уходят по порядку.
+func RunOutbox(ctx context.Context, repo *repository.Repository, brokers string) error {
+	p, err := ckafka.NewProducer(&ckafka.ConfigMap{
+		"bootstrap.servers":  brokers,
+		"enable.idempotence": true,
+		"acks":               "all",
+	})
+	if err != nil {
+		return err
+	}
+	defer p.Close()
+
+	host, _ := os.Hostname()
+	owner := fmt.Sprintf("%s-%d", host, os.Getpid())
+
+	backoff := time.Second
+	lastCleanup := time.Time{}
+
+	for ctx.Err() == nil {
+		if time.Since(lastCleanup) > time.Hour {
+			if err := repo.DeleteSentOutboxBefore(ctx, time.Now().Add(-outboxRetention)); err != nil && ctx.Err() == nil {
+				log.Printf("⚠ не удалось почистить outbox: %v", err)
+			}
+			lastCleanup = time.Now()
+		}
+
+		n, err := relayOutbox(ctx, repo, p, owner)
+		if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			log.Printf("⚠ не удалось отправить outbox: %v, повтор через %s", err, backoff)
+			sleep(ctx, backoff)
+			backoff = min(backoff*2, time.Minute)
+			continue
+		}
+		backoff = time.Second
+
+		// За раз берется одно событие на ключ, поэтому ждем, только когда брать нечего
+		if n == 0 {
+			sleep(ctx, outboxPollInterval)
+		}
+	}
+
+	log.Println("🛑 Отправка outbox остановлена")
+	return nil
+}
+
+// relayOutbox отправляет одну пачку и возвращает число взятых событий. Пока идет отправка,
+// события защищены арендой, а не блокировкой строк.
+func relayOutbox(ctx context.Context, repo *repository.Repository, p *ckafka.Producer, owner string) (int, error) {
+	messages, err := repo.ClaimOutbox(ctx, owner, outboxBatchSize, outboxLease)
+	if err != nil || len(messages) == 0 {
+		return 0, err
+	}
+
+	failed := produceOutbox(p, messages)
+
+	err = repo.InTx(ctx, func(tx *repository.Repository) error {
+		for _, m := range messages {
+			var (
+				ok  bool
+				err error
+			)
+			reason, isFailed := failed[m.ID]
+			switch {
+			case !isFailed:
+				ok, err = tx.MarkOutboxSent(ctx, owner, m.ID)
+			case m.Attempts+1 >= outboxMaxAttempts:
+				log.Printf("☠ событие %s не отправлено (попыток: %d): %s, перенос в outbox_dead", m.ID, m.Attempts+1, reason)
+				ok, err = tx.MoveOutboxToDead(ctx, owner, m.ID, reason)
+			default:
+				delay := outboxRetryDelay(m.Attempts + 1)
+				log.Printf("⚠ событие %s не доставлено (попытка %d): %s, повтор через %s", m.ID, m.Attempts+1, reason, delay)
+				ok, err = tx.RetryOutbox(ctx, owner, m.ID, reason, delay)
+			}
+			if err != nil {
+				return err
+			}
+			if !ok {
+				// Аренда истекла и событие забрал другой экземпляр: оно может уйти дважды
+				log.Printf("⚠ аренда события %s потеряна, результат не записан", m.ID)
+			}
+		}
+		return nil
+	})
+
+	return len(messages), err
+}
+
+// produceOutbox отправляет пачку и ждет отчет о доставке каждого события, но не дольше
+// outboxDeliveryTimeout. Возвращает недоставленные события с причиной.
+func produceOutbox(p *ckafka.Producer, messages []repository.OutboxMessage) map[string]string {
+	failed := make(map[string]string)
+	deliveryChan := make(chan ckafka.Event, len(messages))
+
+	pending := 0
+	for _, m := range messages {
+		err := p.Produce(&ckafka.Message{
+			TopicPartition: ckafka.TopicPartition{Topic: &m.Topic, Partition: ckafka.PartitionAny},
+			Key:            []byte(m.Key),
+			Value:          m.Data,
+			Opaque:         m.ID,
+		}, deliveryChan)
+		if err != nil {
+			failed[m.ID] = err.Error()
+			continue
+		}
+		pending++
+	}
+
+	timeout := time.After(outboxDeliveryTimeout)
+	delivered := make(map[string]bool)
+
+	for pending > 0 {
+		select {
+		case e := <-deliveryChan:
+			m, ok := e.(*ckafka.Message)
+			if !ok {
+				continue
+			}
+			pending--
+
+			id, _ := m.Opaque.(string)
+			if m.TopicPartition.Error != nil {
+				failed[id] = m.TopicPartition.Error.Error()
+				continue
+			}
+			delivered[id] = true
+		case <-timeout:
+			// Без отчета событие не считается доставленным и уйдет повторно
+			for _, m := range messages {
+				if _, ok := failed[m.ID]; !ok && !delivered[m.ID] {
+					failed[m.ID] = "delivery report timeout"
+				}
+			}
+			return failed
+		}
+	}
+
+	return failed
+}
+
+// outboxRetryDelay — задержка перед попыткой attempts: 2, 4, 8 секунд и так далее
+func outboxRetryDelay(attempts int) time.Duration {
+	return min(time.Second<<min(attempts, 20), outboxMaxRetryDelay)
+}
@@ -0,0 +1,25 @@
+package kafka
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOutboxRetryDelay(t *testing.T) {
+	testCases := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{attempts: 1, want: 2 * time.Second},
+		{attempts: 5, want: 32 * time.Second},
+		{attempts: 9, want: 512 * time.Second},
+		{attempts: 10, want: outboxMaxRetryDelay},
+		{attempts: 100, want: outboxMaxRetryDelay},
+	}
+
+	for _, tc := range testCases {
+		if got := outboxRetryDelay(tc.attempts); got != tc.want {
+			t.Fatalf("outboxRetryDelay(%d) = %s, want %s", tc.attempts, got, tc.want)
+		}
+	}
+}
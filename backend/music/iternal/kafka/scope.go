@@ -0,0 +1,24 @@
+package kafka
+
+import (
+	"context"
+
+	"music/iternal/repository"
+)
+
+// scope — то, что обработчик события получает через контекст: репозиторий в точке сохранения
+// внутри транзакции пачки и действия, которые нужно выполнить только после коммита пачки.
+type scope struct {
+	repo        *repository.Repository
+	afterCommit []func(ctx context.Context)
+}
+
+type scopeKey struct{}
+
+func withScope(ctx context.Context, s *scope) context.Context {
+	return context.WithValue(ctx, scopeKey{}, s)
+}
+
+func scopeFrom(ctx context.Context) *scope {
+	return ctx.Value(scopeKey{}).(*scope)
+}
@@ -0,0 +1,182 @@
+package lyrics
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Форматы текста песни
+const (
+	FormatPlain = "plain"
+	FormatLRC   = "lrc"
+)
+
+const (
+	// Ограничения на загружаемый текст
+	MaxChars = 20000
+	MaxLines = 2000
+)
+
+var (
+	ErrEmpty   = errors.New("lyrics are empty")
+	ErrTooLong = fmt.Errorf("lyrics: more than %d characters or %d lines", MaxChars, MaxLines)
+	ErrNoTimed = errors.New("lrc: no timed lines")
+)
+
+// Line — строка синхронизированного текста: с какого момента трека ее показывать.
+type Line struct {
+	TimeMs int64  `json:"time_ms"`
+	Text   string `json:"text"`
+}
+
+// Lyrics — разобранный текст. Text — строки без разметки (для показа без синхронизации
+// и для поиска), Lines — только у LRC, по возрастанию времени.
+type Lyrics struct {
+	Format string
+	Text   string
+	Lines  []Line
+}
+
+// LineError — строка LRC, которую не удалось разобрать (нумерация с 1).
+type LineError struct {
+	Line   int
+	Reason string
+}
+
+func (e *LineError) Error() string {
+	return fmt.Sprintf("lrc: line %d: %s", e.Line, e.Reason)
+}
+
+var (
+	// [mm:ss], [mm:ss.x], [mm:ss.xx], [mm:ss.xxx]; встречается и [mm:ss:xx]
+	timeTag = regexp.MustCompile(`^\[(\d{1,3}):([0-5]?\d)(?:[.:](\d{1,3}))?\]`)
+	// [ar:Исполнитель], [offset:+250] и прочие теги метаданных
+	metaTag = regexp.MustCompile(`^\[([a-zA-Z#]+):(.*)\]$`)
+	// Пословные отметки расширенного LRC: <mm:ss.xx>
+	wordTag = regexp.MustCompile(`<\d{1,3}:\d{1,2}(?:[.:]\d{1,3})?>`)
+)
+
+// Parse проверяет и разбирает текст в формате format.
+func Parse(format, body string) (Lyrics, error) {
+	body = strings.TrimPrefix(strings.ReplaceAll(body, "\r\n", "\n"), "\uFEFF")
+	body = strings.ReplaceAll(body, "\r", "\n")
+
+	if strings.TrimSpace(body) == "" {
+		return Lyrics{}, ErrEmpty
+	}
+	if utf8.RuneCountInString(body) > MaxChars || strings.Count(body, "\n") >= MaxLines {
+		return Lyrics{}, ErrTooLong
+	}
+
+	switch format {
+	case FormatPlain:
+		return parsePlain(body), nil
+	case FormatLRC:
+		return parseLRC(body)
+	default:
+		return Lyrics{}, fmt.Errorf("lyrics: unknown format %q", format)
+	}
+}
+
+func parsePlain(body string) Lyrics {
+	lines := strings.Split(body, "\n")
+	for i, l := range lines {
+		lines[i] = strings.TrimRightFunc(l, isSpace)
+	}
+
+	return Lyrics{Format: FormatPlain, Text: strings.Trim(strings.Join(lines, "\n"), "\n")}
+}
+
+func parseLRC(body string) (Lyrics, error) {
+	var (
+		lines  []Line
+		offset int64
+	)
+
+	for i, raw := range strings.Split(body, "\n") {
+		s := strings.TrimSpace(raw)
+		if s == "" {
+			continue
+		}
+
+		// У строки может быть несколько меток: [00:12.00][00:45.50]припев
+		var times []int64
+		for {
+			m := timeTag.FindStringSubmatch(s)
+			if m == nil {
+				break
+			}
+			times = append(times, tagMs(m[1], m[2], m[3]))
+			s = s[len(m[0]):]
+		}
+
+		if len(times) == 0 {
+			m := metaTag.FindStringSubmatch(s)
+			if m == nil {
+				return Lyrics{}, &LineError{Line: i + 1, Reason: "no time tag"}
+			}
+			if strings.EqualFold(m[1], "offset") {
+				v, err := strconv.ParseInt(strings.TrimSpace(m[2]), 10, 64)
+				if err != nil {
+					return Lyrics{}, &LineError{Line: i + 1, Reason: "bad offset"}
+				}
+				offset = v
+			}
+			continue
+		}
+
+		text := strings.TrimSpace(wordTag.ReplaceAllString(s, ""))
+		for _, t := range times {
+			lines = append(lines, Line{TimeMs: t, Text: text})
+		}
+	}
+
+	if len(lines) == 0 {
+		return Lyrics{}, ErrNoTimed
+	}
+	if len(lines) > MaxLines {
+		return Lyrics{}, ErrTooLong
+	}
+
+	// Положительный offset — текст показывается раньше
+	for i := range lines {
+		lines[i].TimeMs = max(lines[i].TimeMs-offset, 0)
+	}
+	slices.SortStableFunc(lines, func(a, b Line) int { return cmp.Compare(a.TimeMs, b.TimeMs) })
+
+	text := make([]string, 0, len(lines))
+	for _, l := range lines {
+		if l.Text != "" {
+			text = append(text, l.Text)
+		}
+	}
+
+	return Lyrics{Format: FormatLRC, Text: strings.Join(text, "\n"), Lines: lines}, nil
+}
+
+// tagMs переводит метку в миллисекунды; дробная часть — десятые, сотые или тысячные.
+func tagMs(minutes, seconds, frac string) int64 {
+	m, _ := strconv.ParseInt(minutes, 10, 64)
+	s, _ := strconv.ParseInt(seconds, 10, 64)
+
+	var ms int64
+	if frac != "" {
+		f, _ := strconv.ParseInt(frac, 10, 64)
+		for range 3 - len(frac) {
+			f *= 10
+		}
+		ms = f
+	}
+
+	return (m*60+s)*1000 + ms
+}
+
+func isSpace(r rune) bool {
+	return r == ' ' || r == '\t'
+}
@@ -0,0 +1,174 @@
+package lyrics
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParsePlain(t *testing.T) {
+	testCases := []struct {
+		name string
+		body string
+		want string
+	}{
+		{
+			name: "trailing spaces and edge blank lines trimmed",
+			body: "\n\nпервая  \t\n\nвторая\n\n",
+			want: "первая\n\nвторая",
+		},
+		{
+			name: "windows and old mac line endings",
+			body: "a\r\nb\rc",
+			want: "a\nb\nc",
+		},
+		{
+			name: "bom dropped",
+			body: "\uFEFFтекст",
+			want: "текст",
+		},
+		{
+			// Теги в plain не разбираются
+			name: "brackets kept as is",
+			body: "[00:12.00]строка",
+			want: "[00:12.00]строка",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := Parse(FormatPlain, tc.body)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if got.Format != FormatPlain || got.Text != tc.want || got.Lines != nil {
+				t.Fatalf("Parse() = %+v, want text %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseLRC(t *testing.T) {
+	testCases := []struct {
+		name      string
+		body      string
+		wantLines []Line
+		wantText  string
+	}{
+		{
+			name:      "tag precision",
+			body:      "[01:02]a\n[01:02.5]b\n[01:02.05]c\n[01:02.005]d\n[01:02:50]e",
+			wantLines: []Line{{62000, "a"}, {62005, "d"}, {62050, "c"}, {62500, "b"}, {62500, "e"}},
+			wantText:  "a\nd\nc\nb\ne",
+		},
+		{
+			name:      "long minutes",
+			body:      "[120:00.00]a",
+			wantLines: []Line{{7200000, "a"}},
+			wantText:  "a",
+		},
+		{
+			name:      "multiple tags on a line",
+			body:      "[00:10.00]куплет\n[00:05.00][00:20.00]припев",
+			wantLines: []Line{{5000, "припев"}, {10000, "куплет"}, {20000, "припев"}},
+			wantText:  "припев\nкуплет\nприпев",
+		},
+		{
+			name:      "metadata skipped",
+			body:      "[ar:Исполнитель]\n[ti:Название]\n[length: 03:20]\n[00:01.00]a",
+			wantLines: []Line{{1000, "a"}},
+			wantText:  "a",
+		},
+		{
+			name:      "positive offset shows lines earlier",
+			body:      "[offset:+250]\n[00:01.00]a\n[00:00.10]b",
+			wantLines: []Line{{0, "b"}, {750, "a"}},
+			wantText:  "b\na",
+		},
+		{
+			name:      "negative offset shows lines later",
+			body:      "[00:01.00]a\n[OFFSET:-500]",
+			wantLines: []Line{{1500, "a"}},
+			wantText:  "a",
+		},
+		{
+			name:      "word tags removed",
+			body:      "[00:01.00]<00:01.00>раз <00:01.50>два",
+			wantLines: []Line{{1000, "раз два"}},
+			wantText:  "раз два",
+		},
+		{
+			// Пустая строка с меткой — пауза: есть в Lines, но не в Text
+			name:      "empty timed line",
+			body:      "[00:01.00]a\n[00:02.00]\n\n[00:03.00]b",
+			wantLines: []Line{{1000, "a"}, {2000, ""}, {3000, "b"}},
+			wantText:  "a\nb",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := Parse(FormatLRC, tc.body)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if got.Format != FormatLRC {
+				t.Fatalf("format = %q", got.Format)
+			}
+			if !slices.Equal(got.Lines, tc.wantLines) {
+				t.Fatalf("lines = %v, want %v", got.Lines, tc.wantLines)
+			}
+			if got.Text != tc.wantText {
+				t.Fatalf("text = %q, want %q", got.Text, tc.wantText)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	testCases := []struct {
+		name     string
+		format   string
+		body     string
+		wantErr  error
+		wantLine int
+	}{
+		{name: "empty", format: FormatPlain, body: " \n\t\n", wantErr: ErrEmpty},
+		{name: "only bom", format: FormatLRC, body: "\uFEFF\r\n", wantErr: ErrEmpty},
+		{name: "too many characters", format: FormatPlain, body: strings.Repeat("я", MaxChars+1), wantErr: ErrTooLong},
+		{name: "too many lines", format: FormatPlain, body: strings.Repeat("a\n", MaxLines), wantErr: ErrTooLong},
+		{
+			// Строк в файле меньше лимита, но метки размножают их сверх него
+			name:    "too many timed lines after expanding tags",
+			format:  FormatLRC,
+			body:    strings.Repeat("[00:01][00:02][00:03]a\n", MaxLines/2),
+			wantErr: ErrTooLong,
+		},
+		{name: "lrc without timed lines", format: FormatLRC, body: "[ar:x]\n[ti:y]", wantErr: ErrNoTimed},
+		{name: "line without tag", format: FormatLRC, body: "[00:01.00]a\nпросто текст", wantLine: 2},
+		{name: "seconds out of range", format: FormatLRC, body: "[00:60.00]a", wantLine: 1},
+		{name: "bad offset", format: FormatLRC, body: "\n[offset:soon]\n[00:01.00]a", wantLine: 2},
+		{name: "unknown format", format: "srt", body: "1\n00:00:01,000 --> 00:00:02,000\na"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Parse(tc.format, tc.body)
+			if err == nil {
+				t.Fatal("Parse() error = nil")
+			}
+			if tc.wantErr != nil && !errors.Is(err, tc.wantErr) {
+				t.Fatalf("Parse() error = %v, want %v", err, tc.wantErr)
+			}
+
+			var lineErr *LineError
+			if errors.As(err, &lineErr) != (tc.wantLine != 0) {
+				t.Fatalf("Parse() error = %v, want line error %v", err, tc.wantLine != 0)
+			}
+			if tc.wantLine != 0 && lineErr.Line != tc.wantLine {
+				t.Fatalf("error on line %d, want %d", lineErr.Line, tc.wantLine)
+			}
+		})
+	}
+}
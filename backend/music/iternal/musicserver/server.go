@@ -1,14 +1,17 @@
 package musicserver
 
 import (
-	"net"
+	"crypto/subtle"
 	"net/http"
+	"os"
 	"strings"
+	"time"
 
+	"music/iternal/auth"
 	"music/iternal/handlers"
+	"music/iternal/repository"
 	"music/iternal/storage"
-
-	"github.com/jackc/pgx/v5"
+	"music/iternal/users"
 )
 
 func corsMiddleware(next http.Handler) http.Handler {
@@ -26,17 +29,84 @@ func corsMiddleware(next http.Handler) http.Handler {
 	})
 }
 
-func setupRoutes(db *pgx.Conn, s3Client *storage.S3Client) http.Handler {
+// serviceTokenMiddleware пропускает только запросы других сервисов с общим SERVICE_TOKEN.
+// Если токен не задан, внутренние эндпоинты закрыты.
+func serviceTokenMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := os.Getenv("SERVICE_TOKEN")
+		got := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+		if token == "" || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func setupRoutes(repo *repository.Repository, s3Client *storage.S3Client, authClient *auth.Client, usersClient *users.Client) http.Handler {
 	mux := http.NewServeMux()
 
 	mux.HandleFunc("/track/", func(w http.ResponseWriter, r *http.Request) {
+		path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/track/"), "/")
+
+		// GET и POST /track/{id}/comments
+		if trackID, found := strings.CutSuffix(path, "/comments"); found && trackID != "" && !strings.Contains(trackID, "/") {
+			switch r.Method {
+			case http.MethodGet:
+				handlers.GetTrackCommentsHandler(w, r, repo, usersClient, trackID)
+			case http.MethodPost:
+				authClient.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					handlers.CreateCommentHandler(w, r, repo, usersClient, trackID)
+				})).ServeHTTP(w, r)
+			default:
+				http.Error(w, "Метод не поддерживается", http.StatusMethodNotAllowed)
+			}
+			return
+		}
+
+		// GET, PUT и DELETE /track/{id}/lyrics
+		if trackID, found := strings.CutSuffix(path, "/lyrics"); found && trackID != "" && !strings.Contains(trackID, "/") {
+			switch r.Method {
+			case http.MethodGet:
+				handlers.GetLyricsHandler(w, r, repo, trackID)
+			case http.MethodPut:
+				authClient.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					handlers.PutLyricsHandler(w, r, repo, trackID)
+				})).ServeHTTP(w, r)
+			case http.MethodDelete:
+				authClient.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					handlers.DeleteLyricsHandler(w, r, repo, trackID)
+				})).ServeHTTP(w, r)
+			default:
+				http.Error(w, "Метод не поддерживается", http.StatusMethodNotAllowed)
+			}
+			return
+		}
+
 		switch r.Method {
+		case http.MethodGet:
+			// GET /track/{id}/similar
+			trackID, found := strings.CutSuffix(path, "/similar")
+			if !found || trackID == "" || strings.Contains(trackID, "/") {
+				http.NotFound(w, r)
+				return
+			}
+			handlers.GetSimilarTracksHandler(w, r, repo, s3Client, trackID)
 		case http.MethodPost:
-			handlers.CreateTrackHandler(w, r, db, s3Client)
+			// POST /track/ или /track/{artist_id}: исполнитель — владелец токена
+			authClient.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				handlers.CreateTrackHandler(w, r, repo, s3Client, path)
+			})).ServeHTTP(w, r)
 		case http.MethodPatch:
-			handlers.UpdateTrackHandler(w, r, db, s3Client)
+			authClient.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				handlers.UpdateTrackHandler(w, r, repo, s3Client, path)
+			})).ServeHTTP(w, r)
 		case http.MethodDelete:
-			handlers.DeleteTrackHandler(w, r, db)
+			authClient.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				handlers.DeleteTrackHandler(w, r, repo, s3Client, usersClient, path)
+			})).ServeHTTP(w, r)
 		default:
 			http.Error(w, "Метод не поддерживается", http.StatusMethodNotAllowed)
 		}
@@ -52,23 +122,182 @@ func setupRoutes(db *pgx.Conn, s3Client *storage.S3Client) http.Handler {
 		userID := strings.Trim(strings.TrimPrefix(r.URL.Path, "/tracks/"), "/")
 
 		if userID == "" {
-			handlers.GetAllTracksHandler(w, r, db, s3Client)
+			authClient.OptionalMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				handlers.GetAllTracksHandler(w, r, repo, s3Client)
+			})).ServeHTTP(w, r)
 		} else {
-			handlers.GetUserLikedHandler(w, r, db, s3Client, userID)
+			handlers.GetUserLikedHandler(w, r, repo, s3Client, userID)
+		}
+	})
+
+	mux.HandleFunc("/search", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Метод не поддерживается", http.StatusMethodNotAllowed)
+			return
 		}
+
+		handlers.SearchHandler(w, r, repo, s3Client)
 	})
 
+	// GET /charts/{period}, GET /charts/{period}/snapshots
+	mux.HandleFunc("/charts/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Метод не поддерживается", http.StatusMethodNotAllowed)
+			return
+		}
+
+		path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/charts/"), "/")
+		if period, found := strings.CutSuffix(path, "/snapshots"); found {
+			handlers.GetChartSnapshotsHandler(w, r, repo, period)
+			return
+		}
+		handlers.GetChartHandler(w, r, repo, s3Client, path)
+	})
+
+	// Запросы от имени пользователя: access JWT проверяется через users
+	mux.Handle("/me/history", authClient.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Метод не поддерживается", http.StatusMethodNotAllowed)
+			return
+		}
+
+		handlers.GetHistoryHandler(w, r, repo, s3Client)
+	})))
+
+	mux.Handle("/me/recommendations", authClient.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Метод не поддерживается", http.StatusMethodNotAllowed)
+			return
+		}
+
+		handlers.GetRecommendationsHandler(w, r, repo, s3Client)
+	})))
+
+	mux.Handle("/radio", authClient.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Метод не поддерживается", http.StatusMethodNotAllowed)
+			return
+		}
+
+		handlers.StartRadioHandler(w, r, repo, s3Client)
+	})))
+
+	// GET /radio/{token} — продолжение, POST /radio/{token}/feedback — реакция на трек
+	mux.Handle("/radio/", authClient.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/radio/"), "/")
+
+		if token, found := strings.CutSuffix(path, "/feedback"); found {
+			if r.Method != http.MethodPost {
+				http.Error(w, "Метод не поддерживается", http.StatusMethodNotAllowed)
+				return
+			}
+			handlers.RadioFeedbackHandler(w, r, repo, token)
+			return
+		}
+
+		if r.Method != http.MethodGet {
+			http.Error(w, "Метод не поддерживается", http.StatusMethodNotAllowed)
+			return
+		}
+		handlers.NextRadioHandler(w, r, repo, s3Client, path)
+	})))
+
+	// GET /comment/{id}/replies — публично; PATCH и DELETE /comment/{id},
+	// POST /comment/{id}/flag и /comment/{id}/moderation — от имени пользователя
+	mux.HandleFunc("/comment/", func(w http.ResponseWriter, r *http.Request) {
+		commentID, action, _ := strings.Cut(strings.Trim(strings.TrimPrefix(r.URL.Path, "/comment/"), "/"), "/")
+		if commentID == "" {
+			http.NotFound(w, r)
+			return
+		}
+
+		if action == "replies" {
+			if r.Method != http.MethodGet {
+				http.Error(w, "Метод не поддерживается", http.StatusMethodNotAllowed)
+				return
+			}
+			handlers.GetCommentRepliesHandler(w, r, repo, usersClient, commentID)
+			return
+		}
+
+		authClient.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch {
+			case action == "" && r.Method == http.MethodPatch:
+				handlers.UpdateCommentHandler(w, r, repo, commentID)
+			case action == "" && r.Method == http.MethodDelete:
+				handlers.DeleteCommentHandler(w, r, repo, commentID)
+			case action == "flag" && r.Method == http.MethodPost:
+				handlers.FlagCommentHandler(w, r, repo, commentID)
+			case action == "moderation" && r.Method == http.MethodPost:
+				handlers.ModerateCommentHandler(w, r, repo, commentID)
+			case action == "" || action == "flag" || action == "moderation":
+				http.Error(w, "Метод не поддерживается", http.StatusMethodNotAllowed)
+			default:
+				http.NotFound(w, r)
+			}
+		})).ServeHTTP(w, r)
+	})
+
+	mux.Handle("/reports", authClient.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Метод не поддерживается", http.StatusMethodNotAllowed)
+			return
+		}
+
+		handlers.CreateReportHandler(w, r, repo, usersClient)
+	})))
+
+	// Очередь модерации, жалобы на цель, действия и журнал — только для роли admin
+	mux.Handle("/moderation/", authClient.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/moderation/"), "/")
+
+		method := http.MethodGet
+		if path == "actions" {
+			method = http.MethodPost
+		}
+		if r.Method != method {
+			http.Error(w, "Метод не поддерживается", http.StatusMethodNotAllowed)
+			return
+		}
+
+		switch path {
+		case "queue":
+			handlers.GetModerationQueueHandler(w, r, repo)
+		case "reports":
+			handlers.GetReportsHandler(w, r, repo)
+		case "actions":
+			handlers.ModerationActionHandler(w, r, repo, s3Client, usersClient)
+		case "log":
+			handlers.GetModerationLogHandler(w, r, repo)
+		default:
+			http.NotFound(w, r)
+		}
+	})))
+
+	// Внутренний API для других сервисов
+	mux.Handle("/internal/export/", serviceTokenMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Метод не поддерживается", http.StatusMethodNotAllowed)
+			return
+		}
+
+		userID := strings.Trim(strings.TrimPrefix(r.URL.Path, "/internal/export/"), "/")
+		if userID == "" {
+			http.Error(w, "Неверный URL, отсутствует user_id", http.StatusBadRequest)
+			return
+		}
+
+		handlers.GetUserExportHandler(w, r, repo, userID)
+	})))
+
 	return corsMiddleware(mux)
 }
 
-func StartServer(address string, db *pgx.Conn, s3Client *storage.S3Client) error {
-	lis, err := net.Listen("tcp", address)
-	if err != nil {
-		return err
-	}
-
-	server := &http.Server{
-		Handler: setupRoutes(db, s3Client),
+// New создает HTTP-сервер. Запуск и остановка (Shutdown) — на вызывающем.
+func New(address string, repo *repository.Repository, s3Client *storage.S3Client, authClient *auth.Client, usersClient *users.Client) *http.Server {
+	return &http.Server{
+		Addr:              address,
+		Handler:           setupRoutes(repo, s3Client, authClient, usersClient),
+		ReadHeaderTimeout: 10 * time.Second,
 	}
-	return server.Serve(lis)
 }
@@ -0,0 +1,348 @@
+package radio
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"sort"
+	"time"
+
+	"music/iternal/repository"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// Трек не повторяется, пока не выдано столько других
+	repeatWindow = 50
+	// Исполнитель не повторяется среди стольких последних треков
+	artistWindow = 2
+	// Сколько популярных треков добавлять к кандидатам
+	popularPool = 100
+	// Сколько треков можно передать в сид «tracks»
+	MaxSeedTracks = 100
+	// Через сколько неактивная сессия удаляется
+	sessionTTL = 24 * time.Hour
+)
+
+var ErrSeedNotFound = errors.New("seed not found")
+
+// Seed — с чего начать радио: трек, исполнитель или список треков (содержимое плейлиста).
+type Seed struct {
+	Type     string
+	TrackIDs []string
+	ArtistID string
+}
+
+// Start создает сессию радио и возвращает ее с первыми n треками.
+func Start(ctx context.Context, repo *repository.Repository, userID string, seed Seed, n int) (repository.RadioSession, []repository.Track, error) {
+	s := repository.RadioSession{
+		ID:         uuid.New().String(),
+		UserID:     userID,
+		SeedType:   seed.Type,
+		SeedTracks: []string{},
+		CreatedAt:  time.Now(),
+	}
+
+	switch seed.Type {
+	case repository.RadioSeedTrack, repository.RadioSeedTracks:
+		tracks, err := repo.TracksByIDs(ctx, seed.TrackIDs)
+		if err != nil {
+			return s, nil, err
+		}
+		if len(tracks) == 0 {
+			return s, nil, ErrSeedNotFound
+		}
+		// Порядок сида сохраняется: первым в эфир идет первый трек
+		for _, id := range seed.TrackIDs {
+			if slices.ContainsFunc(tracks, func(t repository.Track) bool { return t.ID == id }) && !slices.Contains(s.SeedTracks, id) {
+				s.SeedTracks = append(s.SeedTracks, id)
+			}
+		}
+	case repository.RadioSeedArtist:
+		tracks, err := repo.ListTracksByArtist(ctx, seed.ArtistID)
+		if err != nil {
+			return s, nil, err
+		}
+		for _, t := range tracks {
+			if t.Visibility == repository.VisibilityPublic {
+				s.SeedTracks = append(s.SeedTracks, t.ID)
+			}
+		}
+		if len(s.SeedTracks) == 0 {
+			return s, nil, ErrSeedNotFound
+		}
+		s.SeedArtistID = &seed.ArtistID
+	default:
+		return s, nil, ErrSeedNotFound
+	}
+
+	var tracks []repository.Track
+	err := repo.InTx(ctx, func(tx *repository.Repository) error {
+		if err := tx.CreateRadioSession(ctx, s); err != nil {
+			return err
+		}
+
+		var err error
+		tracks, err = next(ctx, tx, s.ID, userID, n)
+		return err
+	})
+
+	return s, tracks, err
+}
+
+// Next выдает следующие n треков сессии. Очередь бесконечна: когда кандидаты кончаются,
+// окно без повторов сужается.
+func Next(ctx context.Context, repo *repository.Repository, sessionID, userID string, n int) ([]repository.Track, error) {
+	var tracks []repository.Track
+	err := repo.InTx(ctx, func(tx *repository.Repository) error {
+		var err error
+		tracks, err = next(ctx, tx, sessionID, userID, n)
+		return err
+	})
+
+	return tracks, err
+}
+
+// Feedback учитывает реакцию на выданный трек в следующих выдачах:
+// like — трек становится дополнительным сидом, skip — его соседи и исполнитель уходят вниз.
+func Feedback(ctx context.Context, repo *repository.Repository, sessionID, userID, trackID, feedback string) error {
+	return repo.SetRadioFeedback(ctx, sessionID, userID, trackID, feedback)
+}
+
+// RunCleanup раз в час удаляет сессии, к которым не обращались sessionTTL.
+func RunCleanup(ctx context.Context, repo *repository.Repository) error {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		if err := repo.DeleteIdleRadioSessions(ctx, time.Now().Add(-sessionTTL)); err != nil && ctx.Err() == nil {
+			log.Printf("⚠ не удалось удалить старые сессии радио: %v", err)
+		}
+	}
+}
+
+type candidate struct {
+	track repository.Track
+	score float64
+}
+
+func next(ctx context.Context, tx *repository.Repository, sessionID, userID string, n int) ([]repository.Track, error) {
+	s, err := tx.LockRadioSession(ctx, sessionID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	history, err := tx.RadioHistory(ctx, s, repeatWindow)
+	if err != nil {
+		return nil, err
+	}
+
+	// Первым в эфир идет сам трек-сид
+	var picked []repository.Track
+	if s.NextPosition == 0 && s.SeedType != repository.RadioSeedArtist {
+		first, err := tx.TracksByIDs(ctx, s.SeedTracks[:1])
+		if err != nil {
+			return nil, err
+		}
+		picked = append(picked, first...)
+	}
+
+	candidates, err := collect(ctx, tx, s, history)
+	if err != nil {
+		return nil, err
+	}
+
+	recent := recentTracks(history, s.NextPosition, repeatWindow)
+	picked = pick(candidates, history, recent, picked, n)
+
+	// Кандидатов меньше, чем окно без повторов (маленький каталог): сужаем окно
+	for window := repeatWindow / 2; len(picked) < n && window > 0; window /= 2 {
+		recent = recentTracks(history, s.NextPosition, window)
+		picked = pick(candidates, history, recent, picked, n)
+	}
+
+	if err := tx.AppendRadioQueue(ctx, s, picked); err != nil {
+		return nil, err
+	}
+
+	return picked, nil
+}
+
+// collect собирает кандидатов с оценками: соседи сидов и лайкнутых в сессии треков,
+// треки исполнителя-сида, популярные треки с небольшой оценкой как запасной вариант.
+func collect(ctx context.Context, tx *repository.Repository, s repository.RadioSession, history []repository.RadioEntry) ([]candidate, error) {
+	fb := readFeedback(s, history)
+
+	neighbours, err := tx.NeighbourScores(ctx, fb.positive)
+	if err != nil {
+		return nil, err
+	}
+
+	var penalties map[string]float64
+	if len(fb.skipped) > 0 {
+		penalties, err = tx.NeighbourScores(ctx, fb.skipped)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	scores := score(neighbours, penalties, s.SeedTracks, fb)
+
+	ids := make([]string, 0, len(scores))
+	for id := range scores {
+		ids = append(ids, id)
+	}
+	tracks, err := tx.TracksByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	popular, err := tx.PopularTracks(ctx, "", ids, popularPool)
+	if err != nil {
+		return nil, err
+	}
+
+	return rank(tracks, scores, popular, fb, rand.Float64), nil
+}
+
+// feedback — реакции слушателя в сессии.
+type feedback struct {
+	// Сиды и лайкнутые треки
+	positive       []string
+	skipped        []string
+	skippedArtists map[string]int
+}
+
+func readFeedback(s repository.RadioSession, history []repository.RadioEntry) feedback {
+	fb := feedback{
+		positive:       slices.Clone(s.SeedTracks),
+		skippedArtists: make(map[string]int),
+	}
+	for _, e := range history {
+		switch e.Feedback {
+		case repository.RadioFeedbackLike:
+			fb.positive = append(fb.positive, e.TrackID)
+		case repository.RadioFeedbackSkip:
+			fb.skipped = append(fb.skipped, e.TrackID)
+			fb.skippedArtists[e.ArtistID]++
+		}
+	}
+	return fb
+}
+
+// score — оценки кандидатов: близость к положительным трекам минус половина близости
+// к пропущенным. Пропущенные треки не предлагаются, треки сида получают +1.
+func score(neighbours, penalties map[string]float64, seeds []string, fb feedback) map[string]float64 {
+	scores := make(map[string]float64, len(neighbours)+len(seeds))
+	for id, v := range neighbours {
+		scores[id] = v
+	}
+	for id, p := range penalties {
+		scores[id] -= p / 2
+	}
+	for _, id := range fb.skipped {
+		delete(scores, id)
+	}
+
+	// Треки сида тоже кандидаты: радио по исполнителю играет и самого исполнителя
+	for _, id := range seeds {
+		scores[id] += 1
+	}
+
+	return scores
+}
+
+// rank сортирует кандидатов по убыванию оценки. jitter возвращает число в [0, 1).
+func rank(tracks []repository.Track, scores map[string]float64, popular []repository.Scored, fb feedback, jitter func() float64) []candidate {
+	candidates := make([]candidate, 0, len(tracks)+len(popular))
+	for _, t := range tracks {
+		candidates = append(candidates, candidate{track: t, score: scores[t.ID]})
+	}
+	// Популярные всегда ниже соседей, порядок между ними сохраняется
+	for i, p := range popular {
+		if slices.Contains(fb.skipped, p.Track.ID) {
+			continue
+		}
+		candidates = append(candidates, candidate{track: p.Track, score: 0.01 / float64(i+1)})
+	}
+
+	for i := range candidates {
+		c := &candidates[i]
+		// Каждый пропуск исполнителя вдвое снижает его треки
+		c.score *= math.Pow(0.5, float64(fb.skippedArtists[c.track.ArtistID]))
+		// Немного случайности, чтобы две сессии с одним сидом звучали по-разному
+		c.score *= 0.75 + jitter()/4
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].score > candidates[j].score })
+
+	return candidates
+}
+
+// recentTracks — треки, выданные в последних window позициях сессии.
+func recentTracks(history []repository.RadioEntry, nextPosition, window int) map[string]bool {
+	recent := make(map[string]bool)
+	for _, e := range history {
+		if e.Position >= nextPosition-window {
+			recent[e.TrackID] = true
+		}
+	}
+	return recent
+}
+
+// pick добавляет к picked лучших кандидатов до n треков: без повторов в окне и без
+// одного исполнителя среди artistWindow соседних треков. Если по правилу исполнителей
+// подходящих нет, берется лучший без повтора.
+func pick(candidates []candidate, history []repository.RadioEntry, recent map[string]bool, picked []repository.Track, n int) []repository.Track {
+	var artists []string
+	for _, e := range history {
+		artists = append(artists, e.ArtistID)
+	}
+	for _, t := range picked {
+		artists = append(artists, t.ArtistID)
+		recent[t.ID] = true
+	}
+
+	for len(picked) < n {
+		best := -1
+		for i, c := range candidates {
+			if recent[c.track.ID] {
+				continue
+			}
+			if best == -1 {
+				best = i
+			}
+			if !slices.Contains(lastN(artists, artistWindow), c.track.ArtistID) {
+				best = i
+				break
+			}
+		}
+		if best == -1 {
+			break
+		}
+
+		t := candidates[best].track
+		picked = append(picked, t)
+		artists = append(artists, t.ArtistID)
+		recent[t.ID] = true
+	}
+
+	return picked
+}
+
+func lastN(list []string, n int) []string {
+	if len(list) <= n {
+		return list
+	}
+	return list[len(list)-n:]
+}
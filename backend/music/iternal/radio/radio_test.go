@@ -0,0 +1,287 @@
+package radio
+
+import (
+	"maps"
+	"slices"
+	"testing"
+
+	"music/iternal/repository"
+)
+
+func track(id, artist string) repository.Track {
+	return repository.Track{ID: id, ArtistID: artist}
+}
+
+func candidates(tracks ...repository.Track) []candidate {
+	list := make([]candidate, 0, len(tracks))
+	for i, t := range tracks {
+		list = append(list, candidate{track: t, score: float64(len(tracks) - i)})
+	}
+	return list
+}
+
+func trackIDs(tracks []repository.Track) []string {
+	ids := make([]string, 0, len(tracks))
+	for _, t := range tracks {
+		ids = append(ids, t.ID)
+	}
+	return ids
+}
+
+func TestRecentTracks(t *testing.T) {
+	history := []repository.RadioEntry{
+		{Position: 0, TrackID: "a"},
+		{Position: 1, TrackID: "b"},
+		{Position: 2, TrackID: "c"},
+		{Position: 3, TrackID: "d"},
+	}
+
+	testCases := []struct {
+		name   string
+		window int
+		want   []string
+	}{
+		{name: "whole history", window: 50, want: []string{"a", "b", "c", "d"}},
+		{name: "last two", window: 2, want: []string{"c", "d"}},
+		{name: "empty window", window: 0, want: []string{}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := slices.Sorted(maps.Keys(recentTracks(history, 4, tc.window)))
+			if !slices.Equal(got, tc.want) {
+				t.Fatalf("recentTracks() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestPick(t *testing.T) {
+	testCases := []struct {
+		name       string
+		candidates []candidate
+		history    []repository.RadioEntry
+		recent     []string
+		picked     []repository.Track
+		n          int
+		want       []string
+	}{
+		{
+			name:       "best first",
+			candidates: candidates(track("a", "x"), track("b", "y"), track("c", "z")),
+			n:          2,
+			want:       []string{"a", "b"},
+		},
+		{
+			name:       "recent tracks are not repeated",
+			candidates: candidates(track("a", "x"), track("b", "y"), track("c", "z")),
+			recent:     []string{"a"},
+			n:          2,
+			want:       []string{"b", "c"},
+		},
+		{
+			// Исполнитель не повторяется среди artistWindow соседних треков
+			name:       "artist spread",
+			candidates: candidates(track("a1", "x"), track("a2", "x"), track("a3", "x"), track("b1", "y"), track("c1", "z")),
+			n:          5,
+			want:       []string{"a1", "b1", "c1", "a2", "a3"},
+		},
+		{
+			name:       "artist of the last played track waits",
+			candidates: candidates(track("a1", "x"), track("b1", "y")),
+			history:    []repository.RadioEntry{{Position: 0, TrackID: "a0", ArtistID: "x"}},
+			recent:     []string{"a0"},
+			n:          1,
+			want:       []string{"b1"},
+		},
+		{
+			name:       "single artist falls back to best unplayed",
+			candidates: candidates(track("a1", "x"), track("a2", "x")),
+			history:    []repository.RadioEntry{{Position: 0, TrackID: "a0", ArtistID: "x"}},
+			recent:     []string{"a0"},
+			n:          2,
+			want:       []string{"a1", "a2"},
+		},
+		{
+			name:       "already picked seed counts",
+			candidates: candidates(track("seed", "x"), track("a1", "x"), track("b1", "y")),
+			picked:     []repository.Track{track("seed", "x")},
+			n:          2,
+			want:       []string{"seed", "b1"},
+		},
+		{
+			name:       "candidates run out",
+			candidates: candidates(track("a", "x"), track("b", "y")),
+			recent:     []string{"b"},
+			n:          3,
+			want:       []string{"a"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			recent := make(map[string]bool)
+			for _, id := range tc.recent {
+				recent[id] = true
+			}
+
+			got := trackIDs(pick(tc.candidates, tc.history, recent, slices.Clone(tc.picked), tc.n))
+			if !slices.Equal(got, tc.want) {
+				t.Fatalf("pick() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestReadFeedback(t *testing.T) {
+	s := repository.RadioSession{SeedTracks: []string{"seed"}}
+	history := []repository.RadioEntry{
+		{TrackID: "liked", ArtistID: "x", Feedback: repository.RadioFeedbackLike},
+		{TrackID: "played", ArtistID: "y"},
+		{TrackID: "skip1", ArtistID: "z", Feedback: repository.RadioFeedbackSkip},
+		{TrackID: "skip2", ArtistID: "z", Feedback: repository.RadioFeedbackSkip},
+	}
+
+	fb := readFeedback(s, history)
+
+	if !slices.Equal(fb.positive, []string{"seed", "liked"}) {
+		t.Fatalf("positive = %v", fb.positive)
+	}
+	if !slices.Equal(fb.skipped, []string{"skip1", "skip2"}) {
+		t.Fatalf("skipped = %v", fb.skipped)
+	}
+	if len(fb.skippedArtists) != 1 || fb.skippedArtists["z"] != 2 {
+		t.Fatalf("skippedArtists = %v", fb.skippedArtists)
+	}
+	if slices.Contains(s.SeedTracks, "liked") {
+		t.Fatal("session seeds modified")
+	}
+}
+
+func TestScore(t *testing.T) {
+	testCases := []struct {
+		name       string
+		neighbours map[string]float64
+		penalties  map[string]float64
+		seeds      []string
+		skipped    []string
+		want       map[string]float64
+	}{
+		{
+			name:       "neighbours and seed bonus",
+			neighbours: map[string]float64{"a": 0.5, "seed": 0.2},
+			seeds:      []string{"seed"},
+			want:       map[string]float64{"a": 0.5, "seed": 1.2},
+		},
+		{
+			name:       "skip penalty is half of the similarity",
+			neighbours: map[string]float64{"a": 0.5, "b": 0.3},
+			penalties:  map[string]float64{"a": 0.4, "c": 0.2},
+			want:       map[string]float64{"a": 0.3, "b": 0.3, "c": -0.1},
+		},
+		{
+			name:       "skipped tracks are dropped",
+			neighbours: map[string]float64{"a": 0.5, "s": 0.9},
+			penalties:  map[string]float64{"s": 1},
+			skipped:    []string{"s"},
+			want:       map[string]float64{"a": 0.5},
+		},
+		{
+			name:  "no neighbours yet",
+			seeds: []string{"s1", "s2"},
+			want:  map[string]float64{"s1": 1, "s2": 1},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := score(tc.neighbours, tc.penalties, tc.seeds, feedback{skipped: tc.skipped})
+			if len(got) != len(tc.want) {
+				t.Fatalf("score() = %v, want %v", got, tc.want)
+			}
+			for id, want := range tc.want {
+				if diff := got[id] - want; diff > 1e-9 || diff < -1e-9 {
+					t.Fatalf("score()[%s] = %v, want %v", id, got[id], want)
+				}
+			}
+		})
+	}
+}
+
+func TestRank(t *testing.T) {
+	noJitter := func() float64 { return 1 }
+
+	testCases := []struct {
+		name    string
+		tracks  []repository.Track
+		scores  map[string]float64
+		popular []repository.Track
+		fb      feedback
+		want    []string
+	}{
+		{
+			name:   "by score",
+			tracks: []repository.Track{track("low", "x"), track("high", "y")},
+			scores: map[string]float64{"low": 0.1, "high": 0.9},
+			want:   []string{"high", "low"},
+		},
+		{
+			name:    "popular below neighbours in their own order",
+			tracks:  []repository.Track{track("n", "x")},
+			scores:  map[string]float64{"n": 0.05},
+			popular: []repository.Track{track("p1", "y"), track("p2", "z")},
+			want:    []string{"n", "p1", "p2"},
+		},
+		{
+			name:    "skipped popular are left out",
+			popular: []repository.Track{track("p1", "y"), track("p2", "z")},
+			fb:      feedback{skipped: []string{"p1"}},
+			want:    []string{"p2"},
+		},
+		{
+			// Два пропуска исполнителя — оценка /4: 0.8 → 0.2 ниже 0.3
+			name:   "skipped artist goes down",
+			tracks: []repository.Track{track("a", "skipped"), track("b", "other")},
+			scores: map[string]float64{"a": 0.8, "b": 0.3},
+			fb:     feedback{skippedArtists: map[string]int{"skipped": 2}},
+			want:   []string{"b", "a"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var popular []repository.Scored
+			for _, p := range tc.popular {
+				popular = append(popular, repository.Scored{Track: p})
+			}
+
+			got := rank(tc.tracks, tc.scores, popular, tc.fb, noJitter)
+
+			ids := make([]string, 0, len(got))
+			for _, c := range got {
+				ids = append(ids, c.track.ID)
+			}
+			if !slices.Equal(ids, tc.want) {
+				t.Fatalf("rank() = %v, want %v", ids, tc.want)
+			}
+		})
+	}
+}
+
+func TestRankJitterBounds(t *testing.T) {
+	tracks := []repository.Track{track("a", "x")}
+	scores := map[string]float64{"a": 1}
+
+	for _, tc := range []struct {
+		jitter float64
+		want   float64
+	}{
+		{jitter: 0, want: 0.75},
+		{jitter: 1, want: 1},
+	} {
+		got := rank(tracks, scores, nil, feedback{}, func() float64 { return tc.jitter })
+		if got[0].score != tc.want {
+			t.Fatalf("jitter %v: score %v, want %v", tc.jitter, got[0].score, tc.want)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package recommendations
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"music/iternal/repository"
+)
+
+const (
+	// Сколько соседей хранить на трек
+	topN = 50
+	// Пользователи, у которых больше треков, не участвуют в расчете соседей
+	maxUserTracks = 1000
+)
+
+// Run пересчитывает соседей треков сразу при старте и затем каждые interval, пока не отменен ctx.
+// При нескольких экземплярах music пересчет выполняет только один из них.
+func Run(ctx context.Context, repo *repository.Repository, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		rebuild(ctx, repo)
+
+		select {
+		case <-ctx.Done():
+			log.Println("🛑 Пересчет рекомендаций остановлен")
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func rebuild(ctx context.Context, repo *repository.Repository) {
+	started := time.Now()
+
+	ok, err := repo.RebuildSimilar(ctx, topN, maxUserTracks)
+	switch {
+	case err != nil && ctx.Err() == nil:
+		log.Printf("❌ Не удалось пересчитать соседей треков: %v", err)
+	case err != nil:
+	case !ok:
+		log.Println("⚠ Соседей треков уже пересчитывает другой экземпляр")
+	default:
+		log.Printf("✅ Соседи треков пересчитаны за %s", time.Since(started).Round(time.Millisecond))
+	}
+}
+
+// Откуда взялась рекомендация
+const (
+	SourceSimilar = "similar"
+	SourcePopular = "popular"
+)
+
+type Item struct {
+	repository.Scored
+	Source string
+}
+
+// store — выборки, из которых собираются рекомендации; реализует *repository.Repository.
+type store interface {
+	RecommendForUser(ctx context.Context, userID string, limit int) ([]repository.Scored, error)
+	SimilarTracks(ctx context.Context, trackID string, limit int) ([]repository.Scored, error)
+	PopularTracks(ctx context.Context, userID string, exclude []string, limit int) ([]repository.Scored, error)
+}
+
+// ForUser — рекомендации по соседям треков пользователя, недостающее добирается популярными
+// треками (новый пользователь или пересчет еще не видел его треков).
+func ForUser(ctx context.Context, repo store, userID string, limit int) ([]Item, error) {
+	similar, err := repo.RecommendForUser(ctx, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	items := withSource(similar, SourceSimilar)
+	if len(items) >= limit {
+		return items, nil
+	}
+
+	popular, err := repo.PopularTracks(ctx, userID, ids(items), limit-len(items))
+	if err != nil {
+		return nil, err
+	}
+
+	return append(items, withSource(popular, SourcePopular)...), nil
+}
+
+// Similar — соседи трека, для трека без соседей (новый или без слушателей) — популярные треки.
+func Similar(ctx context.Context, repo store, trackID string, limit int) ([]Item, error) {
+	similar, err := repo.SimilarTracks(ctx, trackID, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	items := withSource(similar, SourceSimilar)
+	if len(items) >= limit {
+		return items, nil
+	}
+
+	popular, err := repo.PopularTracks(ctx, "", append(ids(items), trackID), limit-len(items))
+	if err != nil {
+		return nil, err
+	}
+
+	return append(items, withSource(popular, SourcePopular)...), nil
+}
+
+func withSource(list []repository.Scored, source string) []Item {
+	items := make([]Item, 0, len(list))
+	for _, s := range list {
+		items = append(items, Item{Scored: s, Source: source})
+	}
+	return items
+}
+
+func ids(items []Item) []string {
+	list := make([]string, 0, len(items))
+	for _, it := range items {
+		list = append(list, it.Track.ID)
+	}
+	return list
+}
@@ -0,0 +1,184 @@
+package recommendations
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"music/iternal/repository"
+)
+
+type popularCall struct {
+	userID  string
+	exclude []string
+	limit   int
+}
+
+// fakeStore отдает заранее заданные списки и запоминает запрос популярных
+type fakeStore struct {
+	similar    []repository.Scored
+	popular    []repository.Scored
+	err        error
+	popularReq *popularCall
+}
+
+func (f *fakeStore) RecommendForUser(ctx context.Context, userID string, limit int) ([]repository.Scored, error) {
+	return limited(f.similar, limit), f.err
+}
+
+func (f *fakeStore) SimilarTracks(ctx context.Context, trackID string, limit int) ([]repository.Scored, error) {
+	return limited(f.similar, limit), f.err
+}
+
+func (f *fakeStore) PopularTracks(ctx context.Context, userID string, exclude []string, limit int) ([]repository.Scored, error) {
+	f.popularReq = &popularCall{userID: userID, exclude: exclude, limit: limit}
+	return limited(f.popular, limit), nil
+}
+
+func limited(list []repository.Scored, limit int) []repository.Scored {
+	return list[:min(len(list), limit)]
+}
+
+func scoredTracks(ids ...string) []repository.Scored {
+	list := make([]repository.Scored, 0, len(ids))
+	for i, id := range ids {
+		list = append(list, repository.Scored{Track: repository.Track{ID: id}, Score: float64(len(ids) - i)})
+	}
+	return list
+}
+
+// sources — id и источник каждой рекомендации в виде "id:source"
+func sources(items []Item) []string {
+	list := make([]string, 0, len(items))
+	for _, it := range items {
+		list = append(list, it.Track.ID+":"+it.Source)
+	}
+	return list
+}
+
+func TestForUser(t *testing.T) {
+	testCases := []struct {
+		name        string
+		similar     []repository.Scored
+		popular     []repository.Scored
+		limit       int
+		want        []string
+		wantPopular *popularCall
+	}{
+		{
+			name:    "similar fill the limit",
+			similar: scoredTracks("a", "b", "c"),
+			popular: scoredTracks("p1"),
+			limit:   2,
+			want:    []string{"a:similar", "b:similar"},
+		},
+		{
+			name:        "popular top up the rest",
+			similar:     scoredTracks("a"),
+			popular:     scoredTracks("p1", "p2", "p3"),
+			limit:       3,
+			want:        []string{"a:similar", "p1:popular", "p2:popular"},
+			wantPopular: &popularCall{userID: "user", exclude: []string{"a"}, limit: 2},
+		},
+		{
+			name:        "new user gets popular only",
+			popular:     scoredTracks("p1", "p2"),
+			limit:       5,
+			want:        []string{"p1:popular", "p2:popular"},
+			wantPopular: &popularCall{userID: "user", exclude: []string{}, limit: 5},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := &fakeStore{similar: tc.similar, popular: tc.popular}
+
+			items, err := ForUser(context.Background(), repo, "user", tc.limit)
+			if err != nil {
+				t.Fatalf("ForUser() error = %v", err)
+			}
+			if got := sources(items); !slices.Equal(got, tc.want) {
+				t.Fatalf("ForUser() = %v, want %v", got, tc.want)
+			}
+			checkPopularCall(t, repo.popularReq, tc.wantPopular)
+		})
+	}
+}
+
+func TestSimilar(t *testing.T) {
+	testCases := []struct {
+		name        string
+		similar     []repository.Scored
+		popular     []repository.Scored
+		limit       int
+		want        []string
+		wantPopular *popularCall
+	}{
+		{
+			name:    "neighbours fill the limit",
+			similar: scoredTracks("a", "b"),
+			limit:   2,
+			want:    []string{"a:similar", "b:similar"},
+		},
+		{
+			// Популярные не персональные и не содержат сам трек и уже выданных соседей
+			name:        "popular exclude the track and its neighbours",
+			similar:     scoredTracks("a"),
+			popular:     scoredTracks("p1", "p2"),
+			limit:       3,
+			want:        []string{"a:similar", "p1:popular", "p2:popular"},
+			wantPopular: &popularCall{userID: "", exclude: []string{"a", "seed"}, limit: 2},
+		},
+		{
+			name:        "track without neighbours",
+			popular:     scoredTracks("p1"),
+			limit:       2,
+			want:        []string{"p1:popular"},
+			wantPopular: &popularCall{userID: "", exclude: []string{"seed"}, limit: 2},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := &fakeStore{similar: tc.similar, popular: tc.popular}
+
+			items, err := Similar(context.Background(), repo, "seed", tc.limit)
+			if err != nil {
+				t.Fatalf("Similar() error = %v", err)
+			}
+			if got := sources(items); !slices.Equal(got, tc.want) {
+				t.Fatalf("Similar() = %v, want %v", got, tc.want)
+			}
+			checkPopularCall(t, repo.popularReq, tc.wantPopular)
+		})
+	}
+}
+
+func TestForUserError(t *testing.T) {
+	repo := &fakeStore{err: errors.New("db down")}
+
+	if _, err := ForUser(context.Background(), repo, "user", 10); err == nil {
+		t.Fatal("ForUser() error = nil, want db error")
+	}
+	if repo.popularReq != nil {
+		t.Fatal("popular tracks requested after similar failed")
+	}
+}
+
+func checkPopularCall(t *testing.T, got, want *popularCall) {
+	t.Helper()
+
+	if want == nil {
+		if got != nil {
+			t.Fatalf("popular tracks requested: %+v", *got)
+		}
+		return
+	}
+	if got == nil {
+		t.Fatalf("popular tracks not requested, want %+v", *want)
+	}
+	if got.userID != want.userID || got.limit != want.limit || !slices.Equal(got.exclude, want.exclude) {
+		t.Fatalf("popular request %+v, want %+v", *got, *want)
+	}
+}
@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Состояния анализа аудио трека
+const (
+	AnalysisPending    = "pending"
+	AnalysisProcessing = "processing"
+	AnalysisDone       = "done"
+	AnalysisFailed     = "failed"
+)
+
+// AnalysisJob — трек, взятый на анализ. Attempts уже учитывает эту попытку.
+type AnalysisJob struct {
+	TrackID  string
+	TrackKey string
+	Attempts int
+}
+
+// AnalysisResult — посчитанная волна и громкость трека.
+type AnalysisResult struct {
+	WaveformKey  string
+	DurationMs   int64
+	LoudnessLUFS float64
+	TruePeakDBTP float64
+	ReplayGainDB float64
+	AnalyzedAt   time.Time
+}
+
+// QueueAnalysis ставит трек в очередь на анализ, в том числе повторно.
+func (r *Repository) QueueAnalysis(ctx context.Context, trackID string, at time.Time) error {
+	_, err := r.db.Exec(ctx, `
+        INSERT INTO track_analysis (track_id, status, next_attempt_at) VALUES ($1, 'pending', $2)
+        ON CONFLICT (track_id) DO UPDATE SET
+            status = 'pending', attempts = 0, next_attempt_at = EXCLUDED.next_attempt_at, error = NULL`,
+		trackID, at,
+	)
+	return err
+}
+
+// ClaimAnalysis берет самый давний трек из очереди до leaseUntil. Если обработчик упадет,
+// после leaseUntil трек возьмет другой. ok == false — очередь пуста.
+func (r *Repository) ClaimAnalysis(ctx context.Context, now, leaseUntil time.Time) (job AnalysisJob, ok bool, err error) {
+	err = r.db.QueryRow(ctx, `
+        UPDATE track_analysis an
+        SET status = 'processing', attempts = an.attempts + 1, next_attempt_at = $2
+        FROM music m
+        WHERE an.track_id = (
+                SELECT track_id FROM track_analysis
+                WHERE status IN ('pending', 'processing') AND next_attempt_at <= $1
+                  AND EXISTS (SELECT 1 FROM music WHERE id = track_id)
+                ORDER BY next_attempt_at
+                LIMIT 1
+                FOR UPDATE SKIP LOCKED
+            )
+          AND m.id = an.track_id
+        RETURNING an.track_id, COALESCE(m.track_s3_key, ''), an.attempts`,
+		now, leaseUntil,
+	).Scan(&job.TrackID, &job.TrackKey, &job.Attempts)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return AnalysisJob{}, false, nil
+	}
+	if err != nil {
+		return AnalysisJob{}, false, err
+	}
+
+	return job, true, nil
+}
+
+// CompleteAnalysis сохраняет результат и возвращает ключ прежней волны, если анализ повторный.
+// ErrTrackNotFound — трек удалили, пока шел анализ.
+func (r *Repository) CompleteAnalysis(ctx context.Context, trackID string, res AnalysisResult) (string, error) {
+	var oldKey string
+	err := r.db.QueryRow(ctx, `
+        WITH old AS (
+            SELECT track_id, COALESCE(waveform_key, '') AS waveform_key FROM track_analysis
+            WHERE track_id = $1
+            FOR UPDATE
+        )
+        UPDATE track_analysis an
+        SET status = 'done', error = NULL, waveform_key = $2, duration_ms = $3,
+            loudness_lufs = $4, true_peak_dbtp = $5, replay_gain_db = $6, analyzed_at = $7
+        FROM old
+        WHERE an.track_id = old.track_id
+        RETURNING old.waveform_key`,
+		trackID, res.WaveformKey, res.DurationMs, res.LoudnessLUFS, res.TruePeakDBTP, res.ReplayGainDB, res.AnalyzedAt,
+	).Scan(&oldKey)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", ErrTrackNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return oldKey, nil
+}
+
+// RetryAnalysis возвращает трек в очередь не раньше at.
+func (r *Repository) RetryAnalysis(ctx context.Context, trackID, reason string, at time.Time) error {
+	_, err := r.db.Exec(ctx,
+		`UPDATE track_analysis SET status = 'pending', error = $2, next_attempt_at = $3 WHERE track_id = $1`,
+		trackID, reason, at,
+	)
+	return err
+}
+
+// FailAnalysis снимает трек с очереди: без волны и громкости клиент играет его как есть.
+func (r *Repository) FailAnalysis(ctx context.Context, trackID, reason string) error {
+	_, err := r.db.Exec(ctx,
+		`UPDATE track_analysis SET status = 'failed', error = $2 WHERE track_id = $1`,
+		trackID, reason,
+	)
+	return err
+}
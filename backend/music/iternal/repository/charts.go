@@ -0,0 +1,163 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var ErrChartNotFound = errors.New("chart not found")
+
+// TrackScore — взвешенная активность по треку за окно чарта.
+type TrackScore struct {
+	TrackID  string
+	ArtistID string
+	Score    float64
+}
+
+type ChartEntry struct {
+	Position int
+	ItemID   string
+	Score    float64
+	// nil — в предыдущем снимке позиции не было
+	PreviousPosition *int
+}
+
+// ChartScores считает активность по трекам за (from, to]: прослушивание весит 1, лайк 3,
+// снятие лайка -3. Вес события затухает вдвое каждые halfLife до момента to.
+func (r *Repository) ChartScores(ctx context.Context, from, to time.Time, halfLife time.Duration) ([]TrackScore, error) {
+	rows, err := r.db.Query(ctx, `
+        WITH ev AS (
+            SELECT track_id, played_at AS at, 1.0::float8 AS w
+            FROM plays
+            WHERE played_at > $1 AND played_at <= $2
+            UNION ALL
+            SELECT track_id, occurred_at, (3 * delta)::float8
+            FROM like_events
+            WHERE occurred_at > $1 AND occurred_at <= $2
+        )
+        SELECT ev.track_id, m.artist_id,
+               SUM(ev.w * power(0.5::float8, EXTRACT(EPOCH FROM ($2::timestamp - ev.at))::float8 / $3::float8))::float8
+        FROM ev
+        JOIN music m ON m.id = ev.track_id AND `+publicTrack+`
+        GROUP BY ev.track_id, m.artist_id`,
+		from, to, halfLife.Seconds(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []TrackScore{}
+	for rows.Next() {
+		var s TrackScore
+		if err := rows.Scan(&s.TrackID, &s.ArtistID, &s.Score); err != nil {
+			return nil, err
+		}
+		list = append(list, s)
+	}
+
+	return list, rows.Err()
+}
+
+// SaveChart записывает снимок чарта. Вызывать в транзакции.
+func (r *Repository) SaveChart(ctx context.Context, period, kind string, computedAt time.Time, entries []ChartEntry) error {
+	for _, e := range entries {
+		if _, err := r.db.Exec(ctx, `
+            INSERT INTO charts (period, kind, computed_at, position, item_id, score, previous_position)
+            VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+			period, kind, computedAt, e.Position, e.ItemID, e.Score, e.PreviousPosition,
+		); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// LatestChartAt — время последнего снимка чарта периода; нулевое — снимков еще нет.
+func (r *Repository) LatestChartAt(ctx context.Context, period string) (time.Time, error) {
+	var at *time.Time
+	err := r.db.QueryRow(ctx, `SELECT MAX(computed_at) FROM charts WHERE period = $1`, period).Scan(&at)
+	if err != nil || at == nil {
+		return time.Time{}, err
+	}
+
+	return *at, nil
+}
+
+// GetChart возвращает последний снимок, сделанный не позже at (nil — самый свежий).
+func (r *Repository) GetChart(ctx context.Context, period, kind string, at *time.Time, limit int) (time.Time, []ChartEntry, error) {
+	var computedAt *time.Time
+	err := r.db.QueryRow(ctx, `
+        SELECT MAX(computed_at) FROM charts
+        WHERE period = $1 AND kind = $2 AND ($3::timestamp IS NULL OR computed_at <= $3)`,
+		period, kind, at,
+	).Scan(&computedAt)
+	if err != nil {
+		return time.Time{}, nil, err
+	}
+	if computedAt == nil {
+		return time.Time{}, nil, ErrChartNotFound
+	}
+
+	rows, err := r.db.Query(ctx, `
+        SELECT position, item_id, score, previous_position
+        FROM charts
+        WHERE period = $1 AND kind = $2 AND computed_at = $3
+        ORDER BY position
+        LIMIT $4`,
+		period, kind, *computedAt, limit,
+	)
+	if err != nil {
+		return time.Time{}, nil, err
+	}
+	defer rows.Close()
+
+	list := []ChartEntry{}
+	for rows.Next() {
+		var e ChartEntry
+		if err := rows.Scan(&e.Position, &e.ItemID, &e.Score, &e.PreviousPosition); err != nil {
+			return time.Time{}, nil, err
+		}
+		list = append(list, e)
+	}
+
+	return *computedAt, list, rows.Err()
+}
+
+// ChartSnapshots — времена снимков чарта, от новых к старым.
+func (r *Repository) ChartSnapshots(ctx context.Context, period, kind string, limit int) ([]time.Time, error) {
+	rows, err := r.db.Query(ctx, `
+        SELECT DISTINCT computed_at FROM charts
+        WHERE period = $1 AND kind = $2
+        ORDER BY computed_at DESC
+        LIMIT $3`,
+		period, kind, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []time.Time{}
+	for rows.Next() {
+		var at time.Time
+		if err := rows.Scan(&at); err != nil {
+			return nil, err
+		}
+		list = append(list, at)
+	}
+
+	return list, rows.Err()
+}
+
+func (r *Repository) DeleteChartsBefore(ctx context.Context, period string, before time.Time) error {
+	_, err := r.db.Exec(ctx, `DELETE FROM charts WHERE period = $1 AND computed_at < $2`, period, before)
+	return err
+}
+
+func (r *Repository) DeleteLikeEventsBefore(ctx context.Context, before time.Time) error {
+	_, err := r.db.Exec(ctx, `DELETE FROM like_events WHERE occurred_at < $1`, before)
+	return err
+}
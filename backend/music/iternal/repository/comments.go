@@ -0,0 +1,294 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var ErrCommentNotFound = errors.New("comment not found")
+
+// Comment — комментарий к треку. UserID пустой — автор стерт. Deleted — заглушка удаленного
+// комментария, который остался ради ответов на него.
+type Comment struct {
+	ID         string
+	TrackID    string
+	ArtistID   string // исполнитель трека, из music
+	UserID     string
+	ParentID   string
+	Body       string
+	PositionMs *int64
+	ReplyCount int
+	FlagCount  int
+	Hidden     bool
+	CreatedAt  time.Time
+	EditedAt   *time.Time
+	Deleted    bool
+}
+
+// CommentCursor — последний комментарий предыдущей страницы.
+type CommentCursor struct {
+	CreatedAt time.Time
+	ID        string
+}
+
+const commentColumns = `c.id, c.track_id, m.artist_id, COALESCE(c.user_id::text, ''), COALESCE(c.parent_id::text, ''),
+	c.body, c.position_ms, c.reply_count, c.flag_count, c.hidden_at IS NOT NULL, c.created_at, c.edited_at, c.deleted_at IS NOT NULL`
+
+func scanComments(rows pgx.Rows) ([]Comment, error) {
+	defer rows.Close()
+
+	list := []Comment{}
+	for rows.Next() {
+		var c Comment
+		if err := rows.Scan(&c.ID, &c.TrackID, &c.ArtistID, &c.UserID, &c.ParentID,
+			&c.Body, &c.PositionMs, &c.ReplyCount, &c.FlagCount, &c.Hidden, &c.CreatedAt, &c.EditedAt, &c.Deleted); err != nil {
+			return nil, err
+		}
+		list = append(list, c)
+	}
+
+	return list, rows.Err()
+}
+
+// CreateComment сохраняет комментарий; у ответа увеличивает reply_count родителя.
+// Вызывать в транзакции.
+func (r *Repository) CreateComment(ctx context.Context, c Comment) error {
+	var parentID *string
+	if c.ParentID != "" {
+		parentID = &c.ParentID
+	}
+
+	if _, err := r.db.Exec(ctx, `
+        INSERT INTO comments (id, track_id, user_id, parent_id, body, position_ms, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		c.ID, c.TrackID, c.UserID, parentID, c.Body, c.PositionMs, c.CreatedAt,
+	); err != nil {
+		return err
+	}
+
+	if parentID == nil {
+		return nil
+	}
+	_, err := r.db.Exec(ctx, `UPDATE comments SET reply_count = reply_count + 1 WHERE id = $1`, c.ParentID)
+	return err
+}
+
+// GetComment возвращает комментарий, в том числе скрытый и удаленный.
+func (r *Repository) GetComment(ctx context.Context, id string) (Comment, error) {
+	rows, err := r.db.Query(ctx, `SELECT `+commentColumns+` FROM comments c JOIN music m ON m.id = c.track_id WHERE c.id = $1`, id)
+	if err != nil {
+		return Comment{}, err
+	}
+
+	list, err := scanComments(rows)
+	if err != nil {
+		return Comment{}, err
+	}
+	if len(list) == 0 {
+		return Comment{}, ErrCommentNotFound
+	}
+
+	return list[0], nil
+}
+
+// ListComments — комментарии верхнего уровня, от новых к старым, после after (nil — с начала).
+// Скрытые не показываются, удаленные — только если на них есть ответы.
+func (r *Repository) ListComments(ctx context.Context, trackID string, after *CommentCursor, limit int) ([]Comment, error) {
+	var afterAt *time.Time
+	var afterID *string
+	if after != nil {
+		afterAt, afterID = &after.CreatedAt, &after.ID
+	}
+
+	rows, err := r.db.Query(ctx, `
+        SELECT `+commentColumns+`
+        FROM comments c JOIN music m ON m.id = c.track_id
+        WHERE c.track_id = $1 AND c.parent_id IS NULL
+          AND c.hidden_at IS NULL AND (c.deleted_at IS NULL OR c.reply_count > 0)
+          AND ($2::timestamp IS NULL OR (c.created_at, c.id) < ($2, $3::uuid))
+        ORDER BY c.created_at DESC, c.id DESC
+        LIMIT $4`,
+		trackID, afterAt, afterID, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanComments(rows)
+}
+
+// ListTimedComments — комментарии, привязанные к моменту трека, в порядке position_ms:
+// для отметок на волне плеера.
+func (r *Repository) ListTimedComments(ctx context.Context, trackID string, limit int) ([]Comment, error) {
+	rows, err := r.db.Query(ctx, `
+        SELECT `+commentColumns+`
+        FROM comments c JOIN music m ON m.id = c.track_id
+        WHERE c.track_id = $1 AND c.parent_id IS NULL AND c.position_ms IS NOT NULL
+          AND c.hidden_at IS NULL AND c.deleted_at IS NULL
+        ORDER BY c.position_ms, c.created_at
+        LIMIT $2`,
+		trackID, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanComments(rows)
+}
+
+// ListReplies — ответы на комментарий, от старых к новым, после after.
+func (r *Repository) ListReplies(ctx context.Context, parentID string, after *CommentCursor, limit int) ([]Comment, error) {
+	var afterAt *time.Time
+	var afterID *string
+	if after != nil {
+		afterAt, afterID = &after.CreatedAt, &after.ID
+	}
+
+	rows, err := r.db.Query(ctx, `
+        SELECT `+commentColumns+`
+        FROM comments c JOIN music m ON m.id = c.track_id
+        WHERE c.parent_id = $1 AND c.hidden_at IS NULL AND c.deleted_at IS NULL AND `+publicTrack+`
+          AND ($2::timestamp IS NULL OR (c.created_at, c.id) > ($2, $3::uuid))
+        ORDER BY c.created_at, c.id
+        LIMIT $4`,
+		parentID, afterAt, afterID, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanComments(rows)
+}
+
+// UpdateCommentBody меняет текст и отмечает время правки.
+func (r *Repository) UpdateCommentBody(ctx context.Context, id, body string) error {
+	tag, err := r.db.Exec(ctx,
+		`UPDATE comments SET body = $2, edited_at = NOW() WHERE id = $1 AND deleted_at IS NULL`,
+		id, body,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrCommentNotFound
+	}
+
+	return nil
+}
+
+// DeleteComment удаляет комментарий. Комментарий с ответами становится заглушкой без текста,
+// чтобы ответы не потеряли контекст; у удаленного ответа уменьшается reply_count родителя.
+func (r *Repository) DeleteComment(ctx context.Context, id string) error {
+	return r.InTx(ctx, func(tx *Repository) error {
+		var (
+			parentID   *string
+			replyCount int
+		)
+		err := tx.db.QueryRow(ctx,
+			`SELECT parent_id::text, reply_count FROM comments WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`, id,
+		).Scan(&parentID, &replyCount)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrCommentNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		if replyCount > 0 {
+			_, err := tx.db.Exec(ctx, `UPDATE comments SET body = '', deleted_at = NOW() WHERE id = $1`, id)
+			return err
+		}
+
+		if _, err := tx.db.Exec(ctx, `DELETE FROM comments WHERE id = $1`, id); err != nil {
+			return err
+		}
+		if parentID == nil {
+			return nil
+		}
+		_, err = tx.db.Exec(ctx, `UPDATE comments SET reply_count = GREATEST(reply_count - 1, 0) WHERE id = $1`, *parentID)
+		return err
+	})
+}
+
+// FlagComment записывает жалобу пользователя (повторная не считается) и скрывает комментарий,
+// когда жалоб набирается hideAt. Возвращает, скрыт ли комментарий теперь.
+func (r *Repository) FlagComment(ctx context.Context, commentID, userID, reason string, hideAt int) (bool, error) {
+	var hidden bool
+
+	err := r.InTx(ctx, func(tx *Repository) error {
+		tag, err := tx.db.Exec(ctx,
+			`INSERT INTO comment_flags (comment_id, user_id, reason) VALUES ($1, $2, $3) ON CONFLICT DO NOTHING`,
+			commentID, userID, reason,
+		)
+		if err != nil {
+			return err
+		}
+
+		if tag.RowsAffected() == 0 {
+			return tx.db.QueryRow(ctx, `SELECT hidden_at IS NOT NULL FROM comments WHERE id = $1`, commentID).Scan(&hidden)
+		}
+
+		return tx.db.QueryRow(ctx, `
+            UPDATE comments SET
+                flag_count = flag_count + 1,
+                hidden_at = CASE WHEN hidden_at IS NULL AND flag_count + 1 >= $2 THEN NOW() ELSE hidden_at END
+            WHERE id = $1
+            RETURNING hidden_at IS NOT NULL`,
+			commentID, hideAt,
+		).Scan(&hidden)
+	})
+
+	return hidden, err
+}
+
+// SetCommentHidden — решение модератора. Восстановленный комментарий теряет накопленные жалобы,
+// иначе первая же новая жалоба снова скрыла бы его.
+func (r *Repository) SetCommentHidden(ctx context.Context, id string, hidden bool) error {
+	return r.InTx(ctx, func(tx *Repository) error {
+		query := `UPDATE comments SET hidden_at = COALESCE(hidden_at, NOW()) WHERE id = $1`
+		if !hidden {
+			query = `UPDATE comments SET hidden_at = NULL, flag_count = 0 WHERE id = $1`
+		}
+
+		tag, err := tx.db.Exec(ctx, query, id)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return ErrCommentNotFound
+		}
+
+		if hidden {
+			return nil
+		}
+		_, err = tx.db.Exec(ctx, `DELETE FROM comment_flags WHERE comment_id = $1`, id)
+		return err
+	})
+}
+
+// deleteUserComments стирает комментарии и жалобы пользователя и комментарии к его трекам.
+// Его комментарии с чужими ответами остаются заглушками без автора.
+func (r *Repository) deleteUserComments(ctx context.Context, userID string) error {
+	queries := []string{
+		`DELETE FROM comments WHERE track_id IN (SELECT id FROM music WHERE artist_id = $1)`,
+		`UPDATE comments SET flag_count = GREATEST(flag_count - 1, 0)
+		 WHERE id IN (SELECT comment_id FROM comment_flags WHERE user_id = $1)`,
+		`DELETE FROM comment_flags WHERE user_id = $1`,
+		`UPDATE comments p SET reply_count = GREATEST(p.reply_count - c.n, 0)
+		 FROM (SELECT parent_id, COUNT(*) AS n FROM comments WHERE user_id = $1 AND parent_id IS NOT NULL GROUP BY parent_id) c
+		 WHERE p.id = c.parent_id`,
+		`DELETE FROM comments WHERE user_id = $1 AND (parent_id IS NOT NULL OR reply_count = 0)`,
+		`UPDATE comments SET user_id = NULL, body = '', deleted_at = COALESCE(deleted_at, NOW()) WHERE user_id = $1`,
+	}
+
+	for _, q := range queries {
+		if _, err := r.db.Exec(ctx, q, userID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
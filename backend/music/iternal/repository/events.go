@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"context"
+	"time"
+)
+
+// MarkEventProcessed возвращает false, если событие уже было обработано.
+func (r *Repository) MarkEventProcessed(ctx context.Context, eventID string) (bool, error) {
+	tag, err := r.db.Exec(ctx,
+		`INSERT INTO processed_events(event_id) VALUES ($1) ON CONFLICT DO NOTHING`,
+		eventID,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	return tag.RowsAffected() == 1, nil
+}
+
+func (r *Repository) DeleteProcessedEvents(ctx context.Context, before time.Time) error {
+	_, err := r.db.Exec(ctx, `DELETE FROM processed_events WHERE processed_at < $1`, before)
+	return err
+}
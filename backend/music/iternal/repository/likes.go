@@ -0,0 +1,197 @@
+package repository
+
+import (
+	"context"
+	"time"
+)
+
+// LikeTrack идемпотентен: повторный лайк той же пары ничего не меняет, в том числе счетчик.
+// Вызывать в транзакции.
+func (r *Repository) LikeTrack(ctx context.Context, id, userID, trackID string, at time.Time) error {
+	tag, err := r.db.Exec(ctx,
+		`INSERT INTO liked_music(id, track_id, user_id)
+		 VALUES ($1, $2, $3)
+		 ON CONFLICT (track_id, user_id) DO NOTHING`,
+		id, trackID, userID,
+	)
+	if err != nil || tag.RowsAffected() == 0 {
+		return err
+	}
+
+	_, err = r.db.Exec(ctx,
+		`INSERT INTO track_stats(track_id, like_count) VALUES ($1, 1)
+		 ON CONFLICT (track_id) DO UPDATE SET like_count = track_stats.like_count + 1`,
+		trackID,
+	)
+	if err != nil {
+		return err
+	}
+
+	return r.insertLikeEvent(ctx, userID, trackID, 1, at)
+}
+
+// UnlikeTrack уменьшает счетчик, только если лайк действительно был. Вызывать в транзакции.
+func (r *Repository) UnlikeTrack(ctx context.Context, userID, trackID string, at time.Time) error {
+	tag, err := r.db.Exec(ctx,
+		`DELETE FROM liked_music
+		 WHERE track_id = $1 AND user_id = $2`,
+		trackID, userID,
+	)
+	if err != nil || tag.RowsAffected() == 0 {
+		return err
+	}
+
+	_, err = r.db.Exec(ctx,
+		`UPDATE track_stats SET like_count = GREATEST(like_count - 1, 0) WHERE track_id = $1`,
+		trackID,
+	)
+	if err != nil {
+		return err
+	}
+
+	return r.insertLikeEvent(ctx, userID, trackID, -1, at)
+}
+
+// insertLikeEvent пишет изменение лайков для чартов.
+func (r *Repository) insertLikeEvent(ctx context.Context, userID, trackID string, delta int, at time.Time) error {
+	_, err := r.db.Exec(ctx,
+		`INSERT INTO like_events(track_id, user_id, delta, occurred_at) VALUES ($1, $2, $3, $4)`,
+		trackID, userID, delta, at,
+	)
+	return err
+}
+
+// LikedAmong возвращает, какие из trackIDs лайкнул пользователь.
+func (r *Repository) LikedAmong(ctx context.Context, userID string, trackIDs []string) (map[string]bool, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT track_id FROM liked_music WHERE user_id = $1 AND track_id = ANY($2::uuid[])`,
+		userID, trackIDs,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	liked := make(map[string]bool)
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		liked[id] = true
+	}
+
+	return liked, rows.Err()
+}
+
+// IsUserErased — данные пользователя уже удалены по user_deleted: его запоздавшие лайки
+// и прослушивания нужно отбросить, а не записывать заново.
+func (r *Repository) IsUserErased(ctx context.Context, userID string) (bool, error) {
+	var erased bool
+	err := r.db.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM erased_users WHERE user_id = $1)`, userID).Scan(&erased)
+	return erased, err
+}
+
+// DeleteUserData удаляет лайки и историю прослушиваний пользователя и загруженные им треки (вместе с лайками на них)
+// и возвращает ключи их файлов в S3. Пользователь запоминается в erased_users (IsUserErased). Вызывать в транзакции.
+func (r *Repository) DeleteUserData(ctx context.Context, userID string) ([]string, error) {
+	if _, err := r.db.Exec(ctx, `INSERT INTO erased_users (user_id) VALUES ($1) ON CONFLICT DO NOTHING`, userID); err != nil {
+		return nil, err
+	}
+
+	uploads, err := r.ListTracksByArtist(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []string
+	for _, t := range uploads {
+		for _, key := range append(t.CoverObjectKeys(), t.TrackKey, t.WaveformKey) {
+			if key != "" {
+				keys = append(keys, key)
+			}
+		}
+	}
+
+	// Счетчики треков, которые пользователь лайкал и слушал; прослушивания остаются в play_count
+	if _, err := r.db.Exec(ctx,
+		`UPDATE track_stats SET like_count = GREATEST(like_count - 1, 0)
+		 WHERE track_id IN (SELECT track_id FROM liked_music WHERE user_id = $1)`,
+		userID,
+	); err != nil {
+		return nil, err
+	}
+
+	if _, err := r.db.Exec(ctx,
+		`UPDATE track_stats SET unique_listeners = GREATEST(unique_listeners - 1, 0)
+		 WHERE track_id IN (SELECT track_id FROM track_listeners WHERE user_id = $1)`,
+		userID,
+	); err != nil {
+		return nil, err
+	}
+
+	if _, err := r.db.Exec(ctx,
+		`DELETE FROM track_listeners
+		 WHERE user_id = $1
+		    OR track_id IN (SELECT id FROM music WHERE artist_id = $1)`,
+		userID,
+	); err != nil {
+		return nil, err
+	}
+
+	if _, err := r.db.Exec(ctx,
+		`DELETE FROM track_stats WHERE track_id IN (SELECT id FROM music WHERE artist_id = $1)`,
+		userID,
+	); err != nil {
+		return nil, err
+	}
+
+	if _, err := r.db.Exec(ctx,
+		`DELETE FROM liked_music
+		 WHERE user_id = $1
+		    OR track_id IN (SELECT id FROM music WHERE artist_id = $1)`,
+		userID,
+	); err != nil {
+		return nil, err
+	}
+
+	if _, err := r.db.Exec(ctx, `DELETE FROM plays WHERE user_id = $1`, userID); err != nil {
+		return nil, err
+	}
+
+	if _, err := r.db.Exec(ctx, `DELETE FROM radio_sessions WHERE user_id = $1`, userID); err != nil {
+		return nil, err
+	}
+
+	if _, err := r.db.Exec(ctx, `DELETE FROM like_events WHERE user_id = $1`, userID); err != nil {
+		return nil, err
+	}
+
+	if err := r.deleteUserComments(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	if err := r.deleteUserReports(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	if _, err := r.db.Exec(ctx,
+		`DELETE FROM track_lyrics WHERE track_id IN (SELECT id FROM music WHERE artist_id = $1)`,
+		userID,
+	); err != nil {
+		return nil, err
+	}
+
+	if _, err := r.db.Exec(ctx,
+		`DELETE FROM track_analysis WHERE track_id IN (SELECT id FROM music WHERE artist_id = $1)`,
+		userID,
+	); err != nil {
+		return nil, err
+	}
+
+	if _, err := r.db.Exec(ctx, `DELETE FROM music WHERE artist_id = $1`, userID); err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"music/iternal/lyrics"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var ErrLyricsNotFound = errors.New("lyrics not found")
+
+type TrackLyrics struct {
+	TrackID   string
+	Body      string
+	UpdatedAt time.Time
+	lyrics.Lyrics
+}
+
+// SearchHit — трек, найденный по названию или тексту песни. Snippet — фрагмент текста
+// с совпадением, только для Match == "lyrics".
+type SearchHit struct {
+	Track   Track
+	Match   string
+	Snippet string
+}
+
+// SetLyrics сохраняет текст песни, заменяя прежний.
+func (r *Repository) SetLyrics(ctx context.Context, trackID, body string, l lyrics.Lyrics, at time.Time) error {
+	var lines []byte
+	if l.Lines != nil {
+		var err error
+		if lines, err = json.Marshal(l.Lines); err != nil {
+			return err
+		}
+	}
+
+	_, err := r.db.Exec(ctx, `
+        INSERT INTO track_lyrics (track_id, format, body, text, lines, updated_at)
+        VALUES ($1, $2, $3, $4, $5, $6)
+        ON CONFLICT (track_id) DO UPDATE SET
+            format = EXCLUDED.format, body = EXCLUDED.body, text = EXCLUDED.text,
+            lines = EXCLUDED.lines, updated_at = EXCLUDED.updated_at`,
+		trackID, l.Format, body, l.Text, lines, at,
+	)
+	return err
+}
+
+func (r *Repository) GetLyrics(ctx context.Context, trackID string) (TrackLyrics, error) {
+	var (
+		tl    = TrackLyrics{TrackID: trackID}
+		lines []byte
+	)
+	err := r.db.QueryRow(ctx,
+		`SELECT format, body, text, lines, updated_at FROM track_lyrics WHERE track_id = $1`, trackID,
+	).Scan(&tl.Format, &tl.Body, &tl.Text, &lines, &tl.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return TrackLyrics{}, ErrLyricsNotFound
+	}
+	if err != nil {
+		return TrackLyrics{}, err
+	}
+
+	if lines != nil {
+		if err := json.Unmarshal(lines, &tl.Lines); err != nil {
+			return TrackLyrics{}, err
+		}
+	}
+
+	return tl, nil
+}
+
+func (r *Repository) DeleteLyrics(ctx context.Context, trackID string) error {
+	tag, err := r.db.Exec(ctx, `DELETE FROM track_lyrics WHERE track_id = $1`, trackID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrLyricsNotFound
+	}
+
+	return nil
+}
+
+// SearchTracks ищет видимые треки по подстроке в названии и по словам текста песни
+// (полнотекстовый поиск без морфологии: тексты на разных языках). Совпадение в названии
+// выше любого совпадения в тексте.
+func (r *Repository) SearchTracks(ctx context.Context, query string, limit int) ([]SearchHit, error) {
+	rows, err := r.db.Query(ctx, `
+        WITH q AS (SELECT websearch_to_tsquery('simple', $1) AS q),
+        hits AS (
+            SELECT m.id, 2.0::float8 AS score, 'title' AS match
+            FROM music m
+            WHERE strpos(lower(m.title), lower($1)) > 0
+            UNION ALL
+            SELECT l.track_id, ts_rank(l.search_vector, q.q)::float8, 'lyrics'
+            FROM track_lyrics l, q
+            WHERE l.search_vector @@ q.q
+        ),
+        best AS (
+            SELECT DISTINCT ON (id) id, score, match FROM hits ORDER BY id, score DESC
+        )
+        SELECT `+trackColumns+`, b.match,
+               CASE WHEN b.match = 'lyrics'
+                    THEN ts_headline('simple', l.text, q.q, 'MaxFragments=1, MaxWords=12, MinWords=4, StartSel=«, StopSel=»')
+                    ELSE '' END
+        FROM best b
+        CROSS JOIN q
+        JOIN `+trackFrom+` ON m.id = b.id
+        LEFT JOIN track_lyrics l ON l.track_id = b.id
+        WHERE `+publicTrack+`
+        ORDER BY b.score DESC, COALESCE(s.like_count, 0) DESC, m.id
+        LIMIT $2`, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []SearchHit{}
+	for rows.Next() {
+		var (
+			h         SearchHit
+			createdAt *time.Time
+		)
+		t := &h.Track
+		if err := rows.Scan(append(trackDest(t, &createdAt), &h.Match, &h.Snippet)...); err != nil {
+			return nil, err
+		}
+		if createdAt != nil {
+			t.CreatedAt = *createdAt
+		}
+		list = append(list, h)
+	}
+
+	return list, rows.Err()
+}
@@ -0,0 +1,285 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// На что можно пожаловаться. У обложки target_id — id трека.
+const (
+	TargetTrack   = "track"
+	TargetCover   = "cover"
+	TargetComment = "comment"
+	TargetUser    = "user"
+)
+
+// Статусы жалоб: resolved — модератор принял меры, dismissed — нарушения нет.
+const (
+	ReportOpen      = "open"
+	ReportResolved  = "resolved"
+	ReportDismissed = "dismissed"
+)
+
+type Report struct {
+	ID         string
+	TargetType string
+	TargetID   string
+	ReporterID string
+	Reason     string
+	Details    string
+	Status     string
+	CreatedAt  time.Time
+	ResolvedAt *time.Time
+	ResolvedBy string
+	Resolution string // действие модератора
+}
+
+// QueueItem — цель с открытыми жалобами, одна строка очереди модерации.
+type QueueItem struct {
+	TargetType      string
+	TargetID        string
+	Reports         int
+	Reasons         []string
+	FirstReportedAt time.Time
+	LastReportedAt  time.Time
+	// Самая старая жалоба — для курсора: у трека и его обложки один target_id
+	FirstReportID string
+}
+
+// QueueCursor — последняя строка предыдущей страницы очереди.
+type QueueCursor struct {
+	FirstReportedAt time.Time
+	FirstReportID   string
+}
+
+// ModerationEntry — запись журнала действий модераторов.
+type ModerationEntry struct {
+	ID              string
+	ModeratorID     string
+	TargetType      string
+	TargetID        string
+	Action          string
+	Note            string
+	ResolvedReports int
+	CreatedAt       time.Time
+}
+
+// ModerationLogFilter — пустые поля не фильтруют.
+type ModerationLogFilter struct {
+	TargetType  string
+	TargetID    string
+	ModeratorID string
+}
+
+// ModerationLogCursor — последняя запись предыдущей страницы журнала.
+type ModerationLogCursor struct {
+	CreatedAt time.Time
+	ID        string
+}
+
+// CreateReport сохраняет жалобу. false — у пользователя уже есть открытая жалоба на эту цель.
+func (r *Repository) CreateReport(ctx context.Context, rep Report) (bool, error) {
+	tag, err := r.db.Exec(ctx, `
+        INSERT INTO reports (id, target_type, target_id, reporter_id, reason, details, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
+        ON CONFLICT (target_type, target_id, reporter_id) WHERE status = 'open' DO NOTHING`,
+		rep.ID, rep.TargetType, rep.TargetID, rep.ReporterID, rep.Reason, rep.Details, rep.CreatedAt,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
+
+// ReportQueue — цели с открытыми жалобами, первыми — те, что ждут дольше.
+// targetType пустой — все типы.
+func (r *Repository) ReportQueue(ctx context.Context, targetType string, after *QueueCursor, limit int) ([]QueueItem, error) {
+	var afterAt *time.Time
+	var afterID *string
+	if after != nil {
+		afterAt, afterID = &after.FirstReportedAt, &after.FirstReportID
+	}
+
+	rows, err := r.db.Query(ctx, `
+        WITH q AS (
+            SELECT target_type, target_id, COUNT(*) AS n,
+                   array_agg(DISTINCT reason::text) AS reasons,
+                   MIN(created_at) AS first_at, MAX(created_at) AS last_at,
+                   (array_agg(id ORDER BY created_at, id))[1] AS first_id
+            FROM reports
+            WHERE status = 'open' AND ($1 = '' OR target_type = $1)
+            GROUP BY target_type, target_id
+        )
+        SELECT target_type, target_id::text, n, reasons, first_at, last_at, first_id::text
+        FROM q
+        WHERE $2::timestamp IS NULL OR (first_at, first_id) > ($2, $3::uuid)
+        ORDER BY first_at, first_id
+        LIMIT $4`,
+		targetType, afterAt, afterID, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []QueueItem{}
+	for rows.Next() {
+		var it QueueItem
+		if err := rows.Scan(&it.TargetType, &it.TargetID, &it.Reports, &it.Reasons,
+			&it.FirstReportedAt, &it.LastReportedAt, &it.FirstReportID); err != nil {
+			return nil, err
+		}
+		list = append(list, it)
+	}
+
+	return list, rows.Err()
+}
+
+// ListReports — все жалобы на цель, включая закрытые, от новых к старым.
+func (r *Repository) ListReports(ctx context.Context, targetType, targetID string, limit int) ([]Report, error) {
+	rows, err := r.db.Query(ctx, `
+        SELECT id, target_type, target_id::text, reporter_id::text, reason, details, status,
+               created_at, resolved_at, COALESCE(resolved_by::text, ''), COALESCE(resolution, '')
+        FROM reports
+        WHERE target_type = $1 AND target_id = $2
+        ORDER BY created_at DESC, id DESC
+        LIMIT $3`,
+		targetType, targetID, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []Report{}
+	for rows.Next() {
+		var rep Report
+		if err := rows.Scan(&rep.ID, &rep.TargetType, &rep.TargetID, &rep.ReporterID, &rep.Reason, &rep.Details, &rep.Status,
+			&rep.CreatedAt, &rep.ResolvedAt, &rep.ResolvedBy, &rep.Resolution); err != nil {
+			return nil, err
+		}
+		list = append(list, rep)
+	}
+
+	return list, rows.Err()
+}
+
+// ResolveReports закрывает открытые жалобы на цель и возвращает, сколько закрыто.
+func (r *Repository) ResolveReports(ctx context.Context, targetType, targetID, moderatorID, status, resolution string, at time.Time) (int, error) {
+	tag, err := r.db.Exec(ctx, `
+        UPDATE reports SET status = $4, resolution = $5, resolved_by = $3, resolved_at = $6
+        WHERE target_type = $1 AND target_id = $2 AND status = 'open'`,
+		targetType, targetID, moderatorID, status, resolution, at,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return int(tag.RowsAffected()), nil
+}
+
+func (r *Repository) AddModerationLog(ctx context.Context, e ModerationEntry) error {
+	_, err := r.db.Exec(ctx, `
+        INSERT INTO moderation_log (id, moderator_id, target_type, target_id, action, note, resolved_reports, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		e.ID, e.ModeratorID, e.TargetType, e.TargetID, e.Action, e.Note, e.ResolvedReports, e.CreatedAt,
+	)
+	return err
+}
+
+// ModerationLog — журнал от новых записей к старым, после after (nil — с начала).
+func (r *Repository) ModerationLog(ctx context.Context, f ModerationLogFilter, after *ModerationLogCursor, limit int) ([]ModerationEntry, error) {
+	var afterAt *time.Time
+	var afterID *string
+	if after != nil {
+		afterAt, afterID = &after.CreatedAt, &after.ID
+	}
+
+	rows, err := r.db.Query(ctx, `
+        SELECT id, moderator_id::text, target_type, target_id::text, action, note, resolved_reports, created_at
+        FROM moderation_log
+        WHERE ($1 = '' OR target_type = $1)
+          AND ($2 = '' OR target_id::text = $2)
+          AND ($3 = '' OR moderator_id::text = $3)
+          AND ($4::timestamp IS NULL OR (created_at, id) < ($4, $5::uuid))
+        ORDER BY created_at DESC, id DESC
+        LIMIT $6`,
+		f.TargetType, f.TargetID, f.ModeratorID, afterAt, afterID, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []ModerationEntry{}
+	for rows.Next() {
+		var e ModerationEntry
+		if err := rows.Scan(&e.ID, &e.ModeratorID, &e.TargetType, &e.TargetID, &e.Action, &e.Note,
+			&e.ResolvedReports, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		list = append(list, e)
+	}
+
+	return list, rows.Err()
+}
+
+func (r *Repository) SetTrackVisibility(ctx context.Context, id, visibility string) error {
+	tag, err := r.db.Exec(ctx, `UPDATE music SET visibility = $2 WHERE id = $1`, id, visibility)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrTrackNotFound
+	}
+
+	return nil
+}
+
+func (r *Repository) SetCoverHidden(ctx context.Context, id string, hidden bool) error {
+	tag, err := r.db.Exec(ctx, `UPDATE music SET cover_hidden = $2 WHERE id = $1`, id, hidden)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrTrackNotFound
+	}
+
+	return nil
+}
+
+// RemoveCover отвязывает обложку от трека и возвращает ключи ее файлов в S3 (пусто — обложки не было).
+func (r *Repository) RemoveCover(ctx context.Context, id string) ([]string, error) {
+	var key, prefix string
+	err := r.db.QueryRow(ctx, `
+        UPDATE music m SET cover_s3_key = NULL, cover_prefix = NULL, cover_blurhash = NULL, cover_color = NULL,
+            cover_hidden = FALSE
+        FROM (SELECT id, cover_s3_key, cover_prefix FROM music WHERE id = $1 FOR UPDATE) old
+        WHERE m.id = old.id
+        RETURNING COALESCE(old.cover_s3_key, ''), COALESCE(old.cover_prefix, '')`, id,
+	).Scan(&key, &prefix)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrTrackNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return coverObjectKeys(key, prefix), nil
+}
+
+// deleteUserReports стирает жалобы пользователя, жалобы на него и открытые жалобы на его треки.
+// Журнал модерации остается.
+func (r *Repository) deleteUserReports(ctx context.Context, userID string) error {
+	_, err := r.db.Exec(ctx, `
+        DELETE FROM reports
+        WHERE reporter_id = $1
+           OR (target_type = 'user' AND target_id = $1)
+           OR (status = 'open' AND target_type IN ('track', 'cover')
+               AND target_id IN (SELECT id FROM music WHERE artist_id = $1))`, userID)
+	return err
+}
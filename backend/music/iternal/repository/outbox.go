@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"time"
+)
+
+// OutboxMessage — событие, ожидающее отправки в Kafka. ID — id события в конверте,
+// Attempts — сколько раз его уже пытались отправить.
+type OutboxMessage struct {
+	ID       string
+	Topic    string
+	Key      string
+	Data     []byte
+	Attempts int
+}
+
+// AddToOutbox сохраняет событие для отправки. Вызывать в транзакции изменения, которое его породило.
+func (r *Repository) AddToOutbox(ctx context.Context, m OutboxMessage) error {
+	_, err := r.db.Exec(ctx,
+		`INSERT INTO outbox (id, topic, key, data) VALUES ($1, $2, $3, $4)`,
+		m.ID, m.Topic, m.Key, m.Data,
+	)
+	return err
+}
+
+// ClaimOutbox берет в аренду на lease до limit событий, готовых к отправке. Для каждого ключа
+// берется только самое старое неотправленное событие, и только если его никто не держит:
+// следующее событие ключа станет доступно после отправки предыдущего, так порядок по ключу
+// сохраняется при любом числе экземпляров music. Транзакция на время отправки не нужна.
+func (r *Repository) ClaimOutbox(ctx context.Context, owner string, limit int, lease time.Duration) ([]OutboxMessage, error) {
+	rows, err := r.db.Query(ctx, `
+        WITH candidates AS (
+            SELECT id FROM outbox o
+            WHERE sent_at IS NULL AND next_attempt_at <= NOW()
+              AND (locked_until IS NULL OR locked_until < NOW())
+              AND NOT EXISTS (
+                SELECT 1 FROM outbox p
+                WHERE p.key = o.key AND p.sent_at IS NULL AND (p.created_at, p.id) < (o.created_at, o.id)
+              )
+            ORDER BY created_at
+            LIMIT $1
+            FOR UPDATE SKIP LOCKED
+        )
+        UPDATE outbox o SET locked_by = $2, locked_until = NOW() + $3::interval
+        FROM candidates c WHERE o.id = c.id
+        RETURNING o.id, o.topic, o.key, o.data, o.attempts`,
+		limit, owner, lease.String(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []OutboxMessage{}
+	for rows.Next() {
+		var m OutboxMessage
+		if err := rows.Scan(&m.ID, &m.Topic, &m.Key, &m.Data, &m.Attempts); err != nil {
+			return nil, err
+		}
+		list = append(list, m)
+	}
+
+	return list, rows.Err()
+}
+
+// MarkOutboxSent отмечает событие отправленным. Как и RetryOutbox с MoveOutboxToDead, пишет
+// результат, только если аренда все еще у owner: false — ее забрал другой экземпляр.
+func (r *Repository) MarkOutboxSent(ctx context.Context, owner, id string) (bool, error) {
+	tag, err := r.db.Exec(ctx, `
+        UPDATE outbox SET sent_at = NOW(), attempts = attempts + 1, last_error = '', locked_by = NULL, locked_until = NULL
+        WHERE id = $1 AND locked_by = $2`,
+		id, owner,
+	)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// RetryOutbox возвращает событие в очередь: следующая попытка не раньше чем через delay.
+func (r *Repository) RetryOutbox(ctx context.Context, owner, id, reason string, delay time.Duration) (bool, error) {
+	tag, err := r.db.Exec(ctx, `
+        UPDATE outbox SET attempts = attempts + 1, last_error = $3, next_attempt_at = NOW() + $4::interval,
+            locked_by = NULL, locked_until = NULL
+        WHERE id = $1 AND locked_by = $2`,
+		id, owner, reason, delay.String(),
+	)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// MoveOutboxToDead переносит событие, исчерпавшее попытки, в outbox_dead. Следующие события
+// того же ключа после этого уходят без него.
+func (r *Repository) MoveOutboxToDead(ctx context.Context, owner, id, reason string) (bool, error) {
+	tag, err := r.db.Exec(ctx, `
+        WITH moved AS (
+            DELETE FROM outbox WHERE id = $1 AND locked_by = $2
+            RETURNING id, topic, key, data, attempts, created_at
+        )
+        INSERT INTO outbox_dead (id, topic, key, data, attempts, last_error, created_at)
+        SELECT id, topic, key, data, attempts + 1, $3, created_at FROM moved`,
+		id, owner, reason,
+	)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+func (r *Repository) DeleteSentOutboxBefore(ctx context.Context, before time.Time) error {
+	_, err := r.db.Exec(ctx, `DELETE FROM outbox WHERE sent_at < $1`, before)
+	return err
+}
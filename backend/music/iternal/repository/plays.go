@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Play — одно прослушивание из журнала plays.
+type Play struct {
+	EventID    string
+	TrackID    string
+	PositionMs *int64
+	DurationMs *int64
+	PlayedAt   time.Time
+	// Сколько раз трек прослушан в режиме «недавно прослушанные», в полной истории всегда 1
+	Count int64
+
+	Track Track
+}
+
+// PlayCursor — позиция в истории: следующая страница начинается строго после нее.
+// ID — event_id для полной истории и track_id для «недавно прослушанных».
+type PlayCursor struct {
+	PlayedAt time.Time
+	ID       string
+}
+
+// EnsurePlaysPartition создает партицию plays на месяц, в который попадает t, если ее еще нет.
+func (r *Repository) EnsurePlaysPartition(ctx context.Context, t time.Time) error {
+	_, err := r.db.Exec(ctx, `SELECT ensure_plays_partition($1)`, t)
+	return err
+}
+
+// InsertPlay пишет прослушивание в журнал. id записи — id события.
+func (r *Repository) InsertPlay(ctx context.Context, eventID, userID, trackID string, positionMs, durationMs *int64, playedAt time.Time) error {
+	_, err := r.db.Exec(ctx, `
+        INSERT INTO plays (event_id, user_id, track_id, position_ms, duration_ms, played_at)
+        VALUES ($1, $2, $3, $4, $5, $6)
+        ON CONFLICT DO NOTHING`,
+		eventID, userID, trackID, positionMs, durationMs, playedAt,
+	)
+	return err
+}
+
+// ListPlays — полная история пользователя, от новых к старым. after == nil — с начала.
+// Прослушивания удаленных треков пропускаются.
+func (r *Repository) ListPlays(ctx context.Context, userID string, after *PlayCursor, limit int) ([]Play, error) {
+	args := []any{userID, limit}
+	cond := ""
+	if after != nil {
+		args = append(args, after.PlayedAt, after.ID)
+		cond = `AND (p.played_at, p.event_id) < ($3, $4::uuid)`
+	}
+
+	rows, err := r.db.Query(ctx, `
+        SELECT p.event_id, p.position_ms, p.duration_ms, p.played_at, 1::bigint, `+trackColumns+`
+        FROM plays p
+        JOIN `+trackFrom+` ON m.id = p.track_id
+        WHERE p.user_id = $1 AND `+publicTrack+` `+cond+`
+        ORDER BY p.played_at DESC, p.event_id DESC
+        LIMIT $2`, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanPlays(rows)
+}
+
+// ListRecentlyPlayed — по одной записи на трек с последним прослушиванием, от новых к старым.
+func (r *Repository) ListRecentlyPlayed(ctx context.Context, userID string, after *PlayCursor, limit int) ([]Play, error) {
+	args := []any{userID, limit}
+	cond := ""
+	if after != nil {
+		args = append(args, after.PlayedAt, after.ID)
+		cond = `WHERE (last.played_at, last.track_id) < ($3, $4::uuid)`
+	}
+
+	rows, err := r.db.Query(ctx, `
+        WITH last AS (
+            SELECT DISTINCT ON (track_id)
+                   event_id, track_id, position_ms, duration_ms, played_at,
+                   COUNT(*) OVER (PARTITION BY track_id) AS cnt
+            FROM plays
+            WHERE user_id = $1
+            ORDER BY track_id, played_at DESC
+        )
+        SELECT last.event_id, last.position_ms, last.duration_ms, last.played_at, last.cnt, `+trackColumns+`
+        FROM last
+        JOIN `+trackFrom+` ON m.id = last.track_id AND `+publicTrack+`
+        `+cond+`
+        ORDER BY last.played_at DESC, last.track_id DESC
+        LIMIT $2`, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanPlays(rows)
+}
+
+func scanPlays(rows pgx.Rows) ([]Play, error) {
+	defer rows.Close()
+
+	list := []Play{}
+	for rows.Next() {
+		var (
+			p         Play
+			createdAt *time.Time
+		)
+		dest := append([]any{&p.EventID, &p.PositionMs, &p.DurationMs, &p.PlayedAt, &p.Count}, trackDest(&p.Track, &createdAt)...)
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		if createdAt != nil {
+			p.Track.CreatedAt = *createdAt
+		}
+		p.TrackID = p.Track.ID
+		list = append(list, p)
+	}
+
+	return list, rows.Err()
+}
@@ -0,0 +1,191 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	ErrRadioSessionNotFound = errors.New("radio session not found")
+	ErrRadioTrackNotQueued  = errors.New("track is not in the radio queue")
+)
+
+// Сиды радио
+const (
+	RadioSeedTrack  = "track"
+	RadioSeedArtist = "artist"
+	RadioSeedTracks = "tracks"
+)
+
+// Реакции на трек радио
+const (
+	RadioFeedbackSkip = "skip"
+	RadioFeedbackLike = "like"
+)
+
+type RadioSession struct {
+	ID           string
+	UserID       string
+	SeedType     string
+	SeedTracks   []string
+	SeedArtistID *string
+	NextPosition int
+	CreatedAt    time.Time
+}
+
+// RadioEntry — трек, уже выданный в сессии.
+type RadioEntry struct {
+	Position int
+	TrackID  string
+	ArtistID string
+	// "", RadioFeedbackSkip или RadioFeedbackLike
+	Feedback string
+}
+
+func (r *Repository) CreateRadioSession(ctx context.Context, s RadioSession) error {
+	_, err := r.db.Exec(ctx, `
+        INSERT INTO radio_sessions (id, user_id, seed_type, seed_tracks, seed_artist_id, created_at, updated_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $6)`,
+		s.ID, s.UserID, s.SeedType, s.SeedTracks, s.SeedArtistID, s.CreatedAt,
+	)
+	return err
+}
+
+// LockRadioSession возвращает сессию пользователя и блокирует ее строку до конца транзакции,
+// чтобы параллельные запросы продолжения не выдали одни и те же позиции. Вызывать в транзакции.
+func (r *Repository) LockRadioSession(ctx context.Context, id, userID string) (RadioSession, error) {
+	var s RadioSession
+	err := r.db.QueryRow(ctx, `
+        SELECT id, user_id, seed_type, seed_tracks::text[], seed_artist_id, next_position, created_at
+        FROM radio_sessions
+        WHERE id = $1 AND user_id = $2
+        FOR UPDATE`, id, userID,
+	).Scan(&s.ID, &s.UserID, &s.SeedType, &s.SeedTracks, &s.SeedArtistID, &s.NextPosition, &s.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return RadioSession{}, ErrRadioSessionNotFound
+	}
+
+	return s, err
+}
+
+// RadioHistory — последние window выданных треков сессии и все треки с реакцией, по порядку выдачи.
+func (r *Repository) RadioHistory(ctx context.Context, s RadioSession, window int) ([]RadioEntry, error) {
+	rows, err := r.db.Query(ctx, `
+        SELECT position, track_id, artist_id, COALESCE(feedback, '')
+        FROM radio_queue
+        WHERE session_id = $1 AND (position >= $2 OR feedback IS NOT NULL)
+        ORDER BY position`, s.ID, s.NextPosition-window,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []RadioEntry{}
+	for rows.Next() {
+		var e RadioEntry
+		if err := rows.Scan(&e.Position, &e.TrackID, &e.ArtistID, &e.Feedback); err != nil {
+			return nil, err
+		}
+		list = append(list, e)
+	}
+
+	return list, rows.Err()
+}
+
+// AppendRadioQueue добавляет треки в конец очереди сессии. Вызывать в транзакции после LockRadioSession.
+func (r *Repository) AppendRadioQueue(ctx context.Context, s RadioSession, tracks []Track) error {
+	for i, t := range tracks {
+		if _, err := r.db.Exec(ctx,
+			`INSERT INTO radio_queue (session_id, position, track_id, artist_id) VALUES ($1, $2, $3, $4)`,
+			s.ID, s.NextPosition+i, t.ID, t.ArtistID,
+		); err != nil {
+			return err
+		}
+	}
+
+	_, err := r.db.Exec(ctx,
+		`UPDATE radio_sessions SET next_position = next_position + $2, updated_at = NOW() WHERE id = $1`,
+		s.ID, len(tracks),
+	)
+	return err
+}
+
+// SetRadioFeedback ставит реакцию на последнюю выдачу трека в сессии пользователя.
+func (r *Repository) SetRadioFeedback(ctx context.Context, sessionID, userID, trackID, feedback string) error {
+	tag, err := r.db.Exec(ctx, `
+        UPDATE radio_queue q SET feedback = $4
+        FROM radio_sessions s
+        WHERE s.id = q.session_id AND s.id = $1 AND s.user_id = $2
+          AND q.position = (
+              SELECT MAX(position) FROM radio_queue WHERE session_id = $1 AND track_id = $3
+          )`,
+		sessionID, userID, trackID, feedback,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		// Отличаем чужую или несуществующую сессию от трека, которого не было в очереди
+		var exists bool
+		err := r.db.QueryRow(ctx,
+			`SELECT EXISTS (SELECT 1 FROM radio_sessions WHERE id = $1 AND user_id = $2)`,
+			sessionID, userID,
+		).Scan(&exists)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return ErrRadioSessionNotFound
+		}
+		return ErrRadioTrackNotQueued
+	}
+
+	_, err = r.db.Exec(ctx, `UPDATE radio_sessions SET updated_at = NOW() WHERE id = $1`, sessionID)
+	return err
+}
+
+func (r *Repository) DeleteIdleRadioSessions(ctx context.Context, before time.Time) error {
+	_, err := r.db.Exec(ctx, `DELETE FROM radio_sessions WHERE updated_at < $1`, before)
+	return err
+}
+
+// NeighbourScores — сумма близостей соседей треков trackIDs (по track_similar).
+func (r *Repository) NeighbourScores(ctx context.Context, trackIDs []string) (map[string]float64, error) {
+	rows, err := r.db.Query(ctx, `
+        SELECT similar_id, SUM(score)::float8
+        FROM track_similar
+        WHERE track_id = ANY($1::uuid[])
+        GROUP BY similar_id`, trackIDs,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	scores := make(map[string]float64)
+	for rows.Next() {
+		var (
+			id    string
+			score float64
+		)
+		if err := rows.Scan(&id, &score); err != nil {
+			return nil, err
+		}
+		scores[id] = score
+	}
+
+	return scores, rows.Err()
+}
+
+func (r *Repository) TracksByIDs(ctx context.Context, ids []string) ([]Track, error) {
+	rows, err := r.db.Query(ctx, `SELECT `+trackColumns+` FROM `+trackFrom+` WHERE m.id = ANY($1::uuid[]) AND `+publicTrack, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanTracks(rows)
+}
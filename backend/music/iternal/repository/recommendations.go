@@ -0,0 +1,181 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Вес взаимодействия пользователя с треком: лайк весит больше, чем просто прослушивание.
+// Пользователи с большим числом треков не участвуют в расчете соседей: пары их треков
+// растут квадратично и почти ничего не говорят о схожести.
+const interactionsSQL = `
+    interactions AS (
+        SELECT user_id, track_id, MAX(w) AS w
+        FROM (
+            SELECT user_id, track_id, 1.0 AS w FROM liked_music
+            UNION ALL
+            SELECT user_id, track_id, 0.5 AS w FROM track_listeners
+        ) x
+        GROUP BY user_id, track_id
+    )`
+
+// Scored — трек с оценкой рекомендации.
+type Scored struct {
+	Track Track
+	Score float64
+}
+
+// RebuildSimilar пересчитывает track_similar: косинусная близость треков по векторам
+// взаимодействий пользователей, по topN соседей на трек. false — пересчет уже идет
+// в другом экземпляре сервиса.
+func (r *Repository) RebuildSimilar(ctx context.Context, topN, maxUserTracks int) (bool, error) {
+	started := false
+
+	err := r.InTx(ctx, func(tx *Repository) error {
+		// Блокировка до конца транзакции: второй экземпляр не будет считать то же самое
+		var err error
+		started, err = tx.TryLock(ctx, "track_similar")
+		if err != nil || !started {
+			return err
+		}
+
+		if _, err := tx.db.Exec(ctx, `DELETE FROM track_similar`); err != nil {
+			return err
+		}
+
+		_, err = tx.db.Exec(ctx, `
+            WITH `+interactionsSQL+`,
+            active AS (
+                SELECT i.user_id, i.track_id, i.w
+                FROM interactions i
+                JOIN music m ON m.id = i.track_id
+                WHERE i.user_id IN (
+                    SELECT user_id FROM interactions GROUP BY user_id HAVING COUNT(*) <= $2
+                )
+            ),
+            norms AS (
+                SELECT track_id, sqrt(SUM(w * w)) AS n FROM active GROUP BY track_id
+            ),
+            pairs AS (
+                SELECT a.track_id, b.track_id AS similar_id, SUM(a.w * b.w) AS dot
+                FROM active a
+                JOIN active b ON b.user_id = a.user_id AND b.track_id <> a.track_id
+                GROUP BY a.track_id, b.track_id
+            ),
+            ranked AS (
+                SELECT p.track_id, p.similar_id, p.dot / (na.n * nb.n) AS score,
+                       ROW_NUMBER() OVER (PARTITION BY p.track_id ORDER BY p.dot / (na.n * nb.n) DESC, p.similar_id) AS rn
+                FROM pairs p
+                JOIN norms na ON na.track_id = p.track_id
+                JOIN norms nb ON nb.track_id = p.similar_id
+            )
+            INSERT INTO track_similar (track_id, similar_id, score)
+            SELECT track_id, similar_id, score FROM ranked WHERE rn <= $1`,
+			topN, maxUserTracks,
+		)
+		return err
+	})
+
+	return started, err
+}
+
+// SimilarTracks — соседи трека из последнего пересчета, от самых близких.
+func (r *Repository) SimilarTracks(ctx context.Context, trackID string, limit int) ([]Scored, error) {
+	rows, err := r.db.Query(ctx, `
+        SELECT `+trackColumns+`, ts.score::float8
+        FROM track_similar ts
+        JOIN `+trackFrom+` ON m.id = ts.similar_id
+        WHERE ts.track_id = $1 AND `+publicTrack+`
+        ORDER BY ts.score DESC, ts.similar_id
+        LIMIT $2`, trackID, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanScored(rows)
+}
+
+// RecommendForUser суммирует соседей всех треков пользователя с весом его взаимодействия.
+// Уже лайкнутые и прослушанные треки не предлагаются.
+func (r *Repository) RecommendForUser(ctx context.Context, userID string, limit int) ([]Scored, error) {
+	rows, err := r.db.Query(ctx, `
+        WITH seeds AS (
+            SELECT track_id, MAX(w) AS w
+            FROM (
+                SELECT track_id, 1.0 AS w FROM liked_music WHERE user_id = $1
+                UNION ALL
+                SELECT track_id, 0.5 AS w FROM track_listeners WHERE user_id = $1
+            ) x
+            GROUP BY track_id
+        ),
+        candidates AS (
+            SELECT ts.similar_id AS track_id, SUM(seeds.w * ts.score) AS score
+            FROM seeds
+            JOIN track_similar ts ON ts.track_id = seeds.track_id
+            WHERE ts.similar_id NOT IN (SELECT track_id FROM seeds)
+            GROUP BY ts.similar_id
+        )
+        SELECT `+trackColumns+`, c.score::float8
+        FROM candidates c
+        JOIN `+trackFrom+` ON m.id = c.track_id
+        WHERE `+publicTrack+`
+        ORDER BY c.score DESC, m.id
+        LIMIT $2`, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanScored(rows)
+}
+
+// PopularTracks — запасной вариант для новых пользователей и треков без соседей:
+// самые лайкаемые и слушаемые треки. userID (может быть пустым) — не предлагать его
+// лайкнутые и прослушанные треки, exclude — уже выбранные.
+func (r *Repository) PopularTracks(ctx context.Context, userID string, exclude []string, limit int) ([]Scored, error) {
+	if exclude == nil {
+		exclude = []string{}
+	}
+	// NULL вместо пустого userID: с ним NOT EXISTS ничего не отсекает
+	var user *string
+	if userID != "" {
+		user = &userID
+	}
+
+	rows, err := r.db.Query(ctx, `
+        SELECT `+trackColumns+`, (COALESCE(s.like_count, 0) * 2 + COALESCE(s.unique_listeners, 0))::float8 AS score
+        FROM `+trackFrom+`
+        WHERE m.id <> ALL($2::uuid[]) AND `+publicTrack+`
+          AND NOT EXISTS (SELECT 1 FROM liked_music l WHERE l.user_id = $1 AND l.track_id = m.id)
+          AND NOT EXISTS (SELECT 1 FROM track_listeners tl WHERE tl.user_id = $1 AND tl.track_id = m.id)
+        ORDER BY score DESC, m.created_at DESC, m.id
+        LIMIT $3`, user, exclude, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanScored(rows)
+}
+
+func scanScored(rows pgx.Rows) ([]Scored, error) {
+	defer rows.Close()
+
+	list := []Scored{}
+	for rows.Next() {
+		var (
+			s         Scored
+			createdAt *time.Time
+		)
+		t := &s.Track
+		if err := rows.Scan(append(trackDest(t, &createdAt), &s.Score)...); err != nil {
+			return nil, err
+		}
+		if createdAt != nil {
+			t.CreatedAt = *createdAt
+		}
+		list = append(list, s)
+	}
+
+	return list, rows.Err()
+}
@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var ErrTrackNotFound = errors.New("track not found")
+
+// DBTX — общее у пула и транзакции, чтобы одни и те же запросы работали в обоих случаях.
+type DBTX interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// Repository — весь SQL сервиса music. Через пул безопасен для конкурентного использования.
+type Repository struct {
+	db DBTX
+}
+
+func New(pool *pgxpool.Pool) *Repository {
+	return &Repository{db: pool}
+}
+
+// InTx выполняет fn в транзакции и коммитит ее, если fn не вернула ошибку.
+// Внутри транзакции InTx открывает точку сохранения (SAVEPOINT).
+func (r *Repository) InTx(ctx context.Context, fn func(tx *Repository) error) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(&Repository{db: tx}); err != nil {
+		return errors.Join(err, tx.Rollback(ctx))
+	}
+
+	return tx.Commit(ctx)
+}
+
+// TryLock берет advisory-блокировку name до конца транзакции: false — ее держит другой
+// экземпляр сервиса. Вызывать в транзакции.
+func (r *Repository) TryLock(ctx context.Context, name string) (bool, error) {
+	var locked bool
+	err := r.db.QueryRow(ctx, `SELECT pg_try_advisory_xact_lock(hashtext($1))`, name).Scan(&locked)
+	return locked, err
+}
@@ -0,0 +1,29 @@
+package repository
+
+import (
+	"context"
+	"time"
+)
+
+// RecordPlay учитывает прослушивание: play_count растет всегда, unique_listeners — только
+// на первое прослушивание трека пользователем. Вызывать в транзакции.
+func (r *Repository) RecordPlay(ctx context.Context, userID, trackID string, playedAt time.Time) error {
+	tag, err := r.db.Exec(ctx,
+		`INSERT INTO track_listeners(track_id, user_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`,
+		trackID, userID,
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Exec(ctx,
+		`INSERT INTO track_stats(track_id, play_count, unique_listeners, last_played_at)
+		 VALUES ($1, 1, $2, $3)
+		 ON CONFLICT (track_id) DO UPDATE SET
+		     play_count = track_stats.play_count + 1,
+		     unique_listeners = track_stats.unique_listeners + EXCLUDED.unique_listeners,
+		     last_played_at = GREATEST(track_stats.last_played_at, EXCLUDED.last_played_at)`,
+		trackID, tag.RowsAffected(), playedAt,
+	)
+	return err
+}
@@ -0,0 +1,277 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"music/iternal/covers"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type Track struct {
+	ID        string
+	Title     string
+	ArtistID  string
+	CoverKey  string
+	TrackKey  string
+	CreatedAt time.Time
+
+	// Из track_stats, нули — если статистики еще нет
+	LikeCount       int64
+	PlayCount       int64
+	UniqueListeners int64
+	LastPlayedAt    *time.Time
+
+	// Решения модератора
+	Visibility  string
+	CoverHidden bool
+
+	// Обработанная обложка, пустые — обложка загружена до обработки или ее нет
+	CoverPrefix   string
+	CoverBlurhash string
+	CoverColor    string
+
+	// Из track_analysis, nil и пустая строка — пока трек не проанализирован
+	WaveformKey  string
+	DurationMs   *int64
+	LoudnessLUFS *float64
+	TruePeakDBTP *float64
+	ReplayGainDB *float64
+}
+
+// Видимость трека: hidden-трек не попадает ни в один список и не открывается по id.
+const (
+	VisibilityPublic = "public"
+	VisibilityHidden = "hidden"
+)
+
+// TrackUpdate — поля для частичного обновления, nil — не менять.
+type TrackUpdate struct {
+	Title *string
+	Cover *TrackCover
+}
+
+// TrackCover — новая обработанная обложка, см. covers.Cover.
+type TrackCover struct {
+	Key      string
+	Prefix   string
+	Blurhash string
+	Color    string
+}
+
+// CoverObjectKeys — все файлы обложки в S3: исходный ключ и варианты.
+func (t Track) CoverObjectKeys() []string {
+	return coverObjectKeys(t.CoverKey, t.CoverPrefix)
+}
+
+// ObjectKeys — все файлы трека в S3: аудио, волна и обложка.
+func (t Track) ObjectKeys() []string {
+	keys := t.CoverObjectKeys()
+	for _, key := range []string{t.TrackKey, t.WaveformKey} {
+		if key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+func coverObjectKeys(key, prefix string) []string {
+	keys := covers.Keys(prefix)
+	if key != "" && !slices.Contains(keys, key) {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+const (
+	trackColumns = `m.id, m.title, m.artist_id, COALESCE(m.cover_s3_key, ''), COALESCE(m.track_s3_key, ''), m.created_at,
+	COALESCE(s.like_count, 0), COALESCE(s.play_count, 0), COALESCE(s.unique_listeners, 0), s.last_played_at,
+	m.visibility, m.cover_hidden,
+	COALESCE(m.cover_prefix, ''), COALESCE(m.cover_blurhash, ''), COALESCE(m.cover_color, ''),
+	COALESCE(an.waveform_key, ''), an.duration_ms, an.loudness_lufs, an.true_peak_dbtp, an.replay_gain_db`
+	trackFrom = `music m LEFT JOIN track_stats s ON s.track_id = m.id LEFT JOIN track_analysis an ON an.track_id = m.id`
+	// Условие для всех списков треков
+	publicTrack = `m.visibility = 'public'`
+)
+
+func scanTracks(rows pgx.Rows) ([]Track, error) {
+	defer rows.Close()
+
+	list := []Track{}
+	for rows.Next() {
+		var (
+			t         Track
+			createdAt *time.Time
+		)
+		if err := rows.Scan(trackDest(&t, &createdAt)...); err != nil {
+			return nil, err
+		}
+		if createdAt != nil {
+			t.CreatedAt = *createdAt
+		}
+		list = append(list, t)
+	}
+
+	return list, rows.Err()
+}
+
+// trackDest — куда сканировать trackColumns; после них в строке могут быть свои колонки.
+func trackDest(t *Track, createdAt **time.Time) []any {
+	return []any{&t.ID, &t.Title, &t.ArtistID, &t.CoverKey, &t.TrackKey, createdAt,
+		&t.LikeCount, &t.PlayCount, &t.UniqueListeners, &t.LastPlayedAt, &t.Visibility, &t.CoverHidden,
+		&t.CoverPrefix, &t.CoverBlurhash, &t.CoverColor,
+		&t.WaveformKey, &t.DurationMs, &t.LoudnessLUFS, &t.TruePeakDBTP, &t.ReplayGainDB}
+}
+
+func (r *Repository) ListTracks(ctx context.Context) ([]Track, error) {
+	rows, err := r.db.Query(ctx, `SELECT `+trackColumns+` FROM `+trackFrom+` WHERE `+publicTrack)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanTracks(rows)
+}
+
+// GetTrack находит трек независимо от видимости: проверка — на вызывающем.
+func (r *Repository) GetTrack(ctx context.Context, id string) (Track, error) {
+	rows, err := r.db.Query(ctx, `SELECT `+trackColumns+` FROM `+trackFrom+` WHERE m.id = $1`, id)
+	if err != nil {
+		return Track{}, err
+	}
+
+	list, err := scanTracks(rows)
+	if err != nil {
+		return Track{}, err
+	}
+	if len(list) == 0 {
+		return Track{}, ErrTrackNotFound
+	}
+
+	return list[0], nil
+}
+
+// GetPublicTrack — GetTrack для публичных эндпоинтов: скрытый трек считается ненайденным.
+func (r *Repository) GetPublicTrack(ctx context.Context, id string) (Track, error) {
+	t, err := r.GetTrack(ctx, id)
+	if err == nil && t.Visibility != VisibilityPublic {
+		return Track{}, ErrTrackNotFound
+	}
+	return t, err
+}
+
+// ListTracksByArtist — все загрузки исполнителя, в том числе скрытые (выгрузка и удаление данных).
+func (r *Repository) ListTracksByArtist(ctx context.Context, artistID string) ([]Track, error) {
+	rows, err := r.db.Query(ctx, `SELECT `+trackColumns+` FROM `+trackFrom+` WHERE m.artist_id = $1`, artistID)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanTracks(rows)
+}
+
+func (r *Repository) ListLikedTracks(ctx context.Context, userID string) ([]Track, error) {
+	rows, err := r.db.Query(ctx, `
+        SELECT `+trackColumns+`
+        FROM `+trackFrom+`
+        JOIN liked_music l ON l.track_id = m.id
+        WHERE l.user_id = $1 AND `+publicTrack, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanTracks(rows)
+}
+
+func (r *Repository) CreateTrack(ctx context.Context, t Track) error {
+	_, err := r.db.Exec(ctx, `
+        INSERT INTO music
+            (id, title, artist_id, cover_s3_key, track_s3_key, created_at, cover_prefix, cover_blurhash, cover_color)
+        VALUES
+            ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), NULLIF($8, ''), NULLIF($9, ''))`,
+		t.ID, t.Title, t.ArtistID, t.CoverKey, t.TrackKey, t.CreatedAt, t.CoverPrefix, t.CoverBlurhash, t.CoverColor,
+	)
+	if err != nil {
+		return err
+	}
+
+	// Волну и громкость посчитает analysis.Run
+	return r.QueueAnalysis(ctx, t.ID, t.CreatedAt)
+}
+
+func (r *Repository) UpdateTrack(ctx context.Context, id string, upd TrackUpdate) error {
+	var (
+		setClauses []string
+		args       []any
+	)
+
+	if upd.Title != nil {
+		args = append(args, *upd.Title)
+		setClauses = append(setClauses, fmt.Sprintf("title = $%d", len(args)))
+	}
+	if upd.Cover != nil {
+		args = append(args, upd.Cover.Key, upd.Cover.Prefix, upd.Cover.Blurhash, upd.Cover.Color)
+		n := len(args)
+		setClauses = append(setClauses,
+			fmt.Sprintf("cover_s3_key = $%d", n-3),
+			fmt.Sprintf("cover_prefix = NULLIF($%d, '')", n-2),
+			fmt.Sprintf("cover_blurhash = NULLIF($%d, '')", n-1),
+			fmt.Sprintf("cover_color = NULLIF($%d, '')", n),
+		)
+	}
+	if len(setClauses) == 0 {
+		return nil
+	}
+
+	args = append(args, id)
+	query := fmt.Sprintf("UPDATE music SET %s WHERE id = $%d", strings.Join(setClauses, ", "), len(args))
+
+	tag, err := r.db.Exec(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrTrackNotFound
+	}
+
+	return nil
+}
+
+func (r *Repository) DeleteTrack(ctx context.Context, id string) error {
+	return r.InTx(ctx, func(tx *Repository) error {
+		tag, err := tx.db.Exec(ctx, `DELETE FROM music WHERE id = $1`, id)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return ErrTrackNotFound
+		}
+
+		if _, err := tx.db.Exec(ctx, `DELETE FROM track_listeners WHERE track_id = $1`, id); err != nil {
+			return err
+		}
+		if _, err := tx.db.Exec(ctx, `DELETE FROM track_lyrics WHERE track_id = $1`, id); err != nil {
+			return err
+		}
+		if _, err := tx.db.Exec(ctx, `DELETE FROM track_analysis WHERE track_id = $1`, id); err != nil {
+			return err
+		}
+		// Открытые жалобы на трек, обложку и комментарии к нему больше не к чему применить
+		if _, err := tx.db.Exec(ctx, `
+            DELETE FROM reports
+            WHERE status = 'open'
+              AND ((target_type IN ('track', 'cover') AND target_id = $1)
+                OR (target_type = 'comment' AND target_id IN (SELECT id FROM comments WHERE track_id = $1)))`, id); err != nil {
+			return err
+		}
+		// Ответы и жалобы удаляются каскадом
+		if _, err := tx.db.Exec(ctx, `DELETE FROM comments WHERE track_id = $1`, id); err != nil {
+			return err
+		}
+		_, err = tx.db.Exec(ctx, `DELETE FROM track_stats WHERE track_id = $1`, id)
+		return err
+	})
+}
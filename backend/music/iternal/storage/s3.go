@@ -92,3 +92,30 @@ func (c *S3Client) UploadObject(objectData []byte, contentType string) (string,
 
 	return key, nil
 }
+
+// PutObject загружает объект под заданным ключом, например варианты обложки под общим префиксом.
+func (c *S3Client) PutObject(ctx context.Context, key string, objectData []byte, contentType string) error {
+	_, err := c.svc.PutObjectWithContext(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(c.bucket),
+		Key:         aws.String(key),
+		Body:        bytes.NewReader(objectData),
+		ContentType: aws.String(contentType),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upload object %q: %w", key, err)
+	}
+
+	return nil
+}
+
+func (c *S3Client) DeleteObject(ctx context.Context, key string) error {
+	_, err := c.svc.DeleteObjectWithContext(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(c.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete object %q: %w", key, err)
+	}
+
+	return nil
+}
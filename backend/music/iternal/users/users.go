@@ -0,0 +1,180 @@
+package users
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	usersv1 "github.com/Cwby333/user-microservice/pkg/api/users/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	// Имя может смениться; устаревшее показывается не дольше cacheTTL
+	cacheTTL     = 5 * time.Minute
+	cacheMaxSize = 10000
+
+	// Столько id users принимает за один вызов FindUsersByIDs
+	lookupChunk = 500
+)
+
+var (
+	ErrUserNotFound = errors.New("user not found")
+	ErrBanForbidden = errors.New("user can not be banned")
+)
+
+type cachedName struct {
+	username string
+	until    time.Time
+}
+
+// Client получает имена пользователей из users одним gRPC-вызовом FindUsersByIDs на страницу,
+// а не по запросу на каждого автора, и передает в users баны (HTTP).
+type Client struct {
+	url          string
+	serviceToken string
+	http         *http.Client
+	conn         *grpc.ClientConn
+	grpc         usersv1.UsersServiceClient
+
+	mu    sync.Mutex
+	cache map[string]cachedName
+}
+
+// New не подключается сразу: соединение с grpcAddr устанавливается при первом вызове.
+func New(usersURL, grpcAddr, serviceToken string) (*Client, error) {
+	conn, err := grpc.NewClient(grpcAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, fmt.Errorf("users grpc %s: %w", grpcAddr, err)
+	}
+
+	return &Client{
+		url:          strings.TrimRight(usersURL, "/"),
+		serviceToken: serviceToken,
+		http:         &http.Client{Timeout: 5 * time.Second},
+		conn:         conn,
+		grpc:         usersv1.NewUsersServiceClient(conn),
+		cache:        make(map[string]cachedName),
+	}, nil
+}
+
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// Usernames возвращает имена по id. Удаленных и неизвестных пользователей в ответе нет
+// (запоминается и это). Повторы и пустые id допустимы.
+func (c *Client) Usernames(ctx context.Context, ids []string) (map[string]string, error) {
+	now := time.Now()
+	names := make(map[string]string, len(ids))
+
+	var missing []string
+	seen := make(map[string]bool, len(ids))
+
+	c.mu.Lock()
+	for _, id := range ids {
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		entry, found := c.cache[id]
+		if !found || !now.Before(entry.until) {
+			missing = append(missing, id)
+			continue
+		}
+		if entry.username != "" {
+			names[id] = entry.username
+		}
+	}
+	c.mu.Unlock()
+
+	for start := 0; start < len(missing); start += lookupChunk {
+		chunk := missing[start:min(start+lookupChunk, len(missing))]
+
+		found, err := c.lookup(ctx, chunk)
+		if err != nil {
+			return names, err
+		}
+
+		c.mu.Lock()
+		if len(c.cache)+len(chunk) > cacheMaxSize {
+			c.cache = make(map[string]cachedName)
+		}
+		for _, id := range chunk {
+			c.cache[id] = cachedName{username: found[id], until: now.Add(cacheTTL)}
+			if found[id] != "" {
+				names[id] = found[id]
+			}
+		}
+		c.mu.Unlock()
+	}
+
+	return names, nil
+}
+
+func (c *Client) lookup(ctx context.Context, ids []string) (map[string]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+c.serviceToken)
+
+	resp, err := c.grpc.FindUsersByIDs(ctx, &usersv1.FindUsersByIDsRequest{Ids: ids})
+	if err != nil {
+		return nil, fmt.Errorf("users lookup: %w", err)
+	}
+
+	found := make(map[string]string, len(resp.GetUsers()))
+	for _, u := range resp.GetUsers() {
+		found[u.GetId()] = u.GetUsername()
+	}
+	return found, nil
+}
+
+// SetBanned банит пользователя или снимает бан (PUT /internal/users/{id}/ban) и возвращает
+// состояние до вызова — по нему откатывают изменение. Бан отзывает все токены пользователя.
+// Админа забанить нельзя — ErrBanForbidden.
+func (c *Client) SetBanned(ctx context.Context, userID string, banned bool) (wasBanned bool, err error) {
+	body, err := json.Marshal(map[string]bool{"banned": banned})
+	if err != nil {
+		return false, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, c.url+"/internal/users/"+userID+"/ban", bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.serviceToken)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return false, ErrUserNotFound
+	case http.StatusForbidden:
+		return false, ErrBanForbidden
+	default:
+		return false, fmt.Errorf("users ban: status %d", resp.StatusCode)
+	}
+
+	var out struct {
+		WasBanned bool `json:"was_banned"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return false, fmt.Errorf("users ban: %w", err)
+	}
+	return out.WasBanned, nil
+}
@@ -11,6 +11,7 @@ import (
 	pgxmigrate "github.com/golang-migrate/migrate/v4/database/pgx"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/jackc/pgx/v5/stdlib"
 )
 
@@ -20,6 +21,8 @@ type Config struct {
 	Username string `env:"DB_USER"`
 	Password string `env:"DB_PASSWORD"`
 	Database string `env:"DB_NAME"`
+	MaxConns int    `env:"DB_MAX_CONNS"`
+	MinConns int    `env:"DB_MIN_CONNS"`
 }
 
 func runMigrations(connString string) {
@@ -60,7 +63,7 @@ func runMigrations(connString string) {
 	log.Println("Миграции успешно применены!")
 }
 
-func New(config Config) (*pgx.Conn, error) {
+func New(config Config) (*pgxpool.Pool, error) {
 	connString := fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable",
 		config.Username,
 		config.Password,
@@ -71,10 +74,16 @@ func New(config Config) (*pgx.Conn, error) {
 
 	runMigrations(connString)
 
-	conn, err := pgx.Connect(context.Background(), connString)
+	pool, err := pgxpool.New(context.Background(),
+		fmt.Sprintf("%s&pool_max_conns=%d&pool_min_conns=%d", connString, config.MaxConns, config.MinConns))
 	if err != nil {
 		return nil, fmt.Errorf("unable to connect to database: %w", err)
 	}
 
-	return conn, nil
+	if err := pool.Ping(context.Background()); err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("unable to connect to database: %w", err)
+	}
+
+	return pool, nil
 }
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	notifications "notifications/iternal"
+)
+
+// Сколько ждать завершения начатых запросов при остановке
+const shutdownTimeout = 5 * time.Second
+
+func main() {
+	cfg := notifications.LoadConfig()
+	if cfg.ServiceToken == "" {
+		log.Fatal("❌ SERVICE_TOKEN не задан: без него users не ответит на интроспекцию")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	users := notifications.NewUsersClient(cfg.UsersURL, cfg.ServiceToken)
+	hub := notifications.NewHub(cfg.BufferSize, cfg.BufferTTL)
+	consumer := notifications.NewConsumer(cfg.KafkaBrokers, cfg.Topics, hub, users)
+
+	g, gCtx := errgroup.WithContext(ctx)
+
+	// Без WriteTimeout: соединения /events живут долго, время записи ограничивает сам хаб.
+	// Контекст запросов отменяется при остановке, так открытые потоки закрываются сами.
+	srv := &http.Server{
+		Addr:              cfg.Addr,
+		Handler:           notifications.NewServer(hub, users, cfg.RecheckInterval).Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+		IdleTimeout:       time.Minute,
+		BaseContext:       func(net.Listener) context.Context { return gCtx },
+	}
+
+	g.Go(func() error {
+		hub.Run(gCtx)
+		return nil
+	})
+
+	g.Go(func() error {
+		consumer.Run(gCtx)
+		return nil
+	})
+
+	g.Go(func() error {
+		log.Printf("🚀 Notifications запущен на %s", cfg.Addr)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return fmt.Errorf("HTTP сервер: %w", err)
+		}
+		return nil
+	})
+
+	// Остановка: новые соединения не принимаются, начатые дорабатывают до shutdownTimeout
+	g.Go(func() error {
+		<-gCtx.Done()
+		log.Println("🛑 Остановка notifications")
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+
+		return srv.Shutdown(shutdownCtx)
+	})
+
+	if err := g.Wait(); err != nil {
+		log.Printf("❌ Сервис остановлен с ошибкой: %v", err)
+	}
+}
@@ -0,0 +1,18 @@
+module notifications
+
+go 1.24
+
+require (
+	github.com/confluentinc/confluent-kafka-go/v2 v2.10.0
+	github.com/gorilla/websocket v1.5.3
+	gitlab.com/Go34/Mute/shared v0.0.0-00010101000000-000000000000
+	golang.org/x/sync v0.13.0
+)
+
+require (
+	github.com/google/uuid v1.6.0 // indirect
+	github.com/santhosh-tekuri/jsonschema/v6 v6.0.2 // indirect
+	golang.org/x/text v0.18.0 // indirect
+)
+
+replace gitlab.com/Go34/Mute/shared => ../../shared
@@ -0,0 +1,53 @@
+package iternal
+
+import (
+	"os"
+	"time"
+)
+
+// Config — настройки хаба, все берутся из окружения.
+type Config struct {
+	Addr         string
+	UsersURL     string
+	ServiceToken string
+	KafkaBrokers string
+
+	// Топики, из которых берутся события для пользователей
+	Topics []string
+
+	// Сколько последних событий пользователя и как долго хранить для переподключения (Last-Event-ID)
+	BufferSize int
+	BufferTTL  time.Duration
+
+	// Как часто перепроверять токен открытого соединения: так до клиента доходит отзыв сессии
+	RecheckInterval time.Duration
+}
+
+// LoadConfig читает Config из окружения, незаданные значения берет по умолчанию.
+func LoadConfig() Config {
+	return Config{
+		Addr:            ":" + getEnv("PORT", "8080"),
+		UsersURL:        getEnv("USERS_SERVICE_URL", "http://users:8888"),
+		ServiceToken:    os.Getenv("SERVICE_TOKEN"),
+		KafkaBrokers:    getEnv("KAFKA_BOOTSTRAP_SERVERS", "kafka:9092"),
+		Topics:          []string{SongsActionsTopic, MusicEventsTopic},
+		BufferSize:      100,
+		BufferTTL:       getDuration("EVENTS_BUFFER_TTL", 10*time.Minute),
+		RecheckInterval: getDuration("TOKEN_RECHECK_INTERVAL", 30*time.Second),
+	}
+}
+
+func getEnv(key, def string) string {
+	if v, ok := os.LookupEnv(key); ok && v != "" {
+		return v
+	}
+	return def
+}
+
+func getDuration(key string, def time.Duration) time.Duration {
+	v, err := time.ParseDuration(os.Getenv(key))
+	if err != nil || v <= 0 {
+		return def
+	}
+	return v
+}
@@ -0,0 +1,193 @@
+package iternal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"gitlab.com/Go34/Mute/shared/events"
+)
+
+const (
+	SongsActionsTopic = "songs_actions"
+	MusicEventsTopic  = "music_events"
+)
+
+// Consumer читает топики и превращает события в сообщения пользователям.
+type Consumer struct {
+	brokers string
+	topics  []string
+	hub     *Hub
+	router  *events.Router
+}
+
+func NewConsumer(brokers string, topics []string, hub *Hub, users *UsersClient) *Consumer {
+	c := &Consumer{
+		brokers: brokers,
+		topics:  topics,
+		hub:     hub,
+		router:  events.NewRouter(),
+	}
+
+	events.On(c.router, events.TypeTrackLiked, func(ctx context.Context, e events.Envelope, p events.TrackLiked) error {
+		c.publish(p.UserID, e, map[string]string{"track_id": p.TrackID})
+		return nil
+	})
+	events.On(c.router, events.TypeTrackUnliked, func(ctx context.Context, e events.Envelope, p events.TrackUnliked) error {
+		c.publish(p.UserID, e, map[string]string{"track_id": p.TrackID})
+		return nil
+	})
+	// Комментарий к треку — исполнителю; music не шлет событие, если автор он сам
+	events.On(c.router, events.TypeCommentPosted, func(ctx context.Context, e events.Envelope, p events.CommentPosted) error {
+		c.publish(p.ArtistID, e, p)
+		return nil
+	})
+	events.On(c.router, events.TypeUserDeleted, func(ctx context.Context, e events.Envelope, p events.UserDeleted) error {
+		c.hub.Kick(p.UserID, "user_deleted")
+		return nil
+	})
+
+	// В ленту релиз раскладывает users, здесь — только тем подписчикам, кто сейчас на связи
+	events.On(c.router, events.TypeTrackPublished, func(ctx context.Context, e events.Envelope, p events.TrackPublished) error {
+		connected := c.hub.Users()
+		if len(connected) == 0 {
+			return nil
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		defer cancel()
+
+		followers, err := users.FilterFollowers(ctx, p.ArtistID, connected)
+		if err != nil {
+			return err
+		}
+
+		for _, userID := range followers {
+			c.publish(userID, e, p)
+		}
+		return nil
+	})
+
+	return c
+}
+
+// Run читает топики, пока не отменен ctx, и пересоздает консьюмер после фатальных ошибок.
+func (c *Consumer) Run(ctx context.Context) {
+	backoff := time.Second
+
+	for {
+		err := c.consume(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+
+		log.Printf("⚠ консьюмер остановился: %v, перезапуск через %s", err, backoff)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, time.Minute)
+	}
+}
+
+func (c *Consumer) consume(ctx context.Context) error {
+	// Каждому инстансу нужны все события (соединения пользователя могут быть на любом),
+	// и только новые: уведомления о прошлом не нужны. Поэтому партиции назначаются вручную
+	// с конца, без группы: оффсеты не коммитятся и на брокере не остаются группы от рестартов.
+	// group.id обязателен для клиента, но не используется.
+	consumer, err := kafka.NewConsumer(&kafka.ConfigMap{
+		"bootstrap.servers":  c.brokers,
+		"group.id":           "notifications",
+		"enable.auto.commit": false,
+	})
+	if err != nil {
+		return err
+	}
+	defer consumer.Close()
+
+	partitions, err := c.partitions(consumer)
+	if err != nil {
+		return err
+	}
+	if err := consumer.Assign(partitions); err != nil {
+		return err
+	}
+	log.Printf("👂 Чтение %v: партиций %d", c.topics, len(partitions))
+
+	for ctx.Err() == nil {
+		msg, err := consumer.ReadMessage(500 * time.Millisecond)
+		if err != nil {
+			var kafkaErr kafka.Error
+			if errors.As(err, &kafkaErr) && (kafkaErr.Code() == kafka.ErrTimedOut || !kafkaErr.IsFatal()) {
+				continue
+			}
+			return err
+		}
+
+		c.handle(ctx, msg)
+	}
+
+	return nil
+}
+
+// partitions — все партиции топиков с позиции «только новые». Топик, которого еще нет,
+// — ошибка: Run пересоздаст консьюмер и попробует снова.
+func (c *Consumer) partitions(consumer *kafka.Consumer) ([]kafka.TopicPartition, error) {
+	var list []kafka.TopicPartition
+	for _, topic := range c.topics {
+		md, err := consumer.GetMetadata(&topic, false, 10000)
+		if err != nil {
+			return nil, fmt.Errorf("метаданные %s: %w", topic, err)
+		}
+
+		t, ok := md.Topics[topic]
+		if !ok || t.Error.Code() != kafka.ErrNoError || len(t.Partitions) == 0 {
+			return nil, fmt.Errorf("топик %s недоступен: %v", topic, t.Error)
+		}
+		for _, p := range t.Partitions {
+			list = append(list, kafka.TopicPartition{Topic: &topic, Partition: p.ID, Offset: kafka.OffsetEnd})
+		}
+	}
+
+	return list, nil
+}
+
+// handle не повторяет событие при ошибке: уведомление best-effort, состояние клиент всегда может перечитать.
+func (c *Consumer) handle(ctx context.Context, msg *kafka.Message) {
+	e, err := events.Parse(msg.Value)
+	if err != nil {
+		if !errors.Is(err, events.ErrUnknownEvent) {
+			log.Printf("⚠ Невалидное событие %s: %v", msg.TopicPartition, err)
+		}
+		return
+	}
+	// У событий старого формата нет времени: берется время сообщения в Kafka
+	if e.OccurredAt.IsZero() {
+		e.OccurredAt = msg.Timestamp.UTC()
+	}
+
+	err = c.router.Dispatch(ctx, e)
+	if err != nil && !errors.Is(err, events.ErrUnknownEvent) {
+		log.Printf("❌ Событие %s (%s) не доставлено: %v", e.ID, e.Type, err)
+	}
+}
+
+func (c *Consumer) publish(userID string, e events.Envelope, data any) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		log.Printf("❌ Событие %s: %v", e.ID, err)
+		return
+	}
+
+	c.hub.Publish(userID, Message{
+		ID:         e.ID,
+		Type:       e.Type,
+		OccurredAt: e.OccurredAt,
+		Data:       raw,
+	})
+}
@@ -0,0 +1,219 @@
+package iternal
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// Размер очереди соединения. Кто не успевает читать, того хаб отключает: клиент
+// переподключится с Last-Event-ID и дочитает пропущенное из буфера.
+const subscriptionQueue = 64
+
+// Message — событие, которое уходит клиенту. У служебных сообщений (session_revoked,
+// token_expired, reset) ID пустой: их нет в буфере и по ним нельзя продолжить.
+type Message struct {
+	ID         string          `json:"id,omitempty"`
+	Type       string          `json:"type"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Data       json.RawMessage `json:"data,omitempty"`
+}
+
+// Subscription — одно открытое соединение пользователя.
+type Subscription struct {
+	UserID string
+	C      <-chan Message
+	// Закрывается, когда хаб сам отключил соединение. Reason пустой — клиент не успевал читать.
+	Done <-chan struct{}
+
+	c      chan Message
+	done   chan struct{}
+	reason string
+}
+
+// Reason — почему хаб закрыл соединение; читать только после Done.
+func (s *Subscription) Reason() string {
+	return s.reason
+}
+
+type buffered struct {
+	msg Message
+	at  time.Time
+}
+
+// Hub раздает события соединениям пользователя и помнит последние события каждого
+// пользователя, чтобы после переподключения отдать пропущенное.
+// Буфер в памяти инстанса: после рестарта клиент получит reset.
+type Hub struct {
+	size int
+	ttl  time.Duration
+	// Часы хаба; в тестах подменяются
+	now func() time.Time
+
+	mu      sync.Mutex
+	subs    map[string]map[*Subscription]struct{}
+	buffers map[string][]buffered
+}
+
+func NewHub(size int, ttl time.Duration) *Hub {
+	return &Hub{
+		size:    size,
+		ttl:     ttl,
+		now:     time.Now,
+		subs:    make(map[string]map[*Subscription]struct{}),
+		buffers: make(map[string][]buffered),
+	}
+}
+
+// Subscribe регистрирует соединение и возвращает события после lastEventID.
+// reset == true — lastEventID уже нет в буфере, клиенту нужно перечитать состояние целиком.
+// Подписка и выборка из буфера под одной блокировкой, поэтому между ними ничего не теряется.
+func (h *Hub) Subscribe(userID, lastEventID string) (sub *Subscription, replay []Message, reset bool) {
+	c := make(chan Message, subscriptionQueue)
+	done := make(chan struct{})
+	sub = &Subscription{UserID: userID, C: c, Done: done, c: c, done: done}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if lastEventID != "" {
+		buf := h.prune(userID, h.now())
+		reset = true
+		for i := range buf {
+			if buf[i].msg.ID == lastEventID {
+				reset = false
+				for _, b := range buf[i+1:] {
+					replay = append(replay, b.msg)
+				}
+				break
+			}
+		}
+	}
+
+	if h.subs[userID] == nil {
+		h.subs[userID] = make(map[*Subscription]struct{})
+	}
+	h.subs[userID][sub] = struct{}{}
+
+	return sub, replay, reset
+}
+
+// Unsubscribe убирает соединение, которое закрылось само.
+func (h *Hub) Unsubscribe(sub *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.remove(sub)
+}
+
+// Publish кладет событие в буфер пользователя и отправляет его открытым соединениям.
+// Повторно доставленное из Kafka событие (тот же ID) пропускается.
+func (h *Hub) Publish(userID string, msg Message) {
+	now := h.now()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	buf := h.prune(userID, now)
+	for _, b := range buf {
+		if b.msg.ID == msg.ID {
+			return
+		}
+	}
+
+	buf = append(buf, buffered{msg: msg, at: now})
+	if len(buf) > h.size {
+		buf = buf[len(buf)-h.size:]
+	}
+	h.buffers[userID] = buf
+
+	for sub := range h.subs[userID] {
+		select {
+		case sub.c <- msg:
+		default:
+			h.drop(sub, "")
+		}
+	}
+}
+
+// Kick закрывает все соединения пользователя и забывает его события.
+func (h *Hub) Kick(userID, reason string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for sub := range h.subs[userID] {
+		h.drop(sub, reason)
+	}
+	delete(h.buffers, userID)
+}
+
+// Users возвращает пользователей, у которых сейчас есть соединения с этим инстансом.
+func (h *Hub) Users() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	users := make([]string, 0, len(h.subs))
+	for userID := range h.subs {
+		users = append(users, userID)
+	}
+	return users
+}
+
+// Run раз в минуту выбрасывает из буферов устаревшие события.
+func (h *Hub) Run(ctx context.Context) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			h.mu.Lock()
+			for userID := range h.buffers {
+				h.prune(userID, now)
+			}
+			h.mu.Unlock()
+		}
+	}
+}
+
+// prune убирает события старше ttl; вызывать под h.mu.
+func (h *Hub) prune(userID string, now time.Time) []buffered {
+	buf := h.buffers[userID]
+
+	i := 0
+	for i < len(buf) && now.Sub(buf[i].at) > h.ttl {
+		i++
+	}
+	if i == len(buf) {
+		delete(h.buffers, userID)
+		return nil
+	}
+
+	buf = buf[i:]
+	h.buffers[userID] = buf
+	return buf
+}
+
+// drop отключает соединение по инициативе хаба; вызывать под h.mu.
+func (h *Hub) drop(sub *Subscription, reason string) {
+	if h.remove(sub) {
+		sub.reason = reason
+		close(sub.done)
+	}
+}
+
+func (h *Hub) remove(sub *Subscription) bool {
+	subs := h.subs[sub.UserID]
+	if _, ok := subs[sub]; !ok {
+		return false
+	}
+
+	delete(subs, sub)
+	if len(subs) == 0 {
+		delete(h.subs, sub.UserID)
+	}
+	return true
+}
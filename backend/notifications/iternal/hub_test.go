@@ -0,0 +1,271 @@
+package iternal
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+// clock — ручные часы для хаба
+type clock struct {
+	t time.Time
+}
+
+func (c *clock) now() time.Time { return c.t }
+
+func (c *clock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func newTestHub(size int, ttl time.Duration) (*Hub, *clock) {
+	c := &clock{t: time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)}
+	h := NewHub(size, ttl)
+	h.now = c.now
+	return h, c
+}
+
+func msg(id string) Message {
+	return Message{ID: id, Type: "track_liked"}
+}
+
+func ids(list []Message) []string {
+	out := make([]string, 0, len(list))
+	for _, m := range list {
+		out = append(out, m.ID)
+	}
+	return out
+}
+
+// received — все, что уже лежит в очереди соединения
+func received(sub *Subscription) []string {
+	var out []string
+	for {
+		select {
+		case m := <-sub.C:
+			out = append(out, m.ID)
+		default:
+			return out
+		}
+	}
+}
+
+func closed(sub *Subscription) bool {
+	select {
+	case <-sub.Done:
+		return true
+	default:
+		return false
+	}
+}
+
+func TestSubscribeReplay(t *testing.T) {
+	testCases := []struct {
+		name        string
+		published   []string
+		lastEventID string
+		wantReplay  []string
+		wantReset   bool
+	}{
+		{
+			name:      "first connection",
+			published: []string{"1", "2"},
+		},
+		{
+			name:        "continue after the last seen event",
+			published:   []string{"1", "2", "3"},
+			lastEventID: "1",
+			wantReplay:  []string{"2", "3"},
+		},
+		{
+			name:        "nothing missed",
+			published:   []string{"1", "2"},
+			lastEventID: "2",
+		},
+		{
+			name:        "unknown id",
+			published:   []string{"1", "2"},
+			lastEventID: "x",
+			wantReset:   true,
+		},
+		{
+			// После рестарта инстанса буфер пуст
+			name:        "empty buffer",
+			lastEventID: "1",
+			wantReset:   true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			h, _ := newTestHub(10, time.Hour)
+			for _, id := range tc.published {
+				h.Publish("u", msg(id))
+			}
+
+			_, replay, reset := h.Subscribe("u", tc.lastEventID)
+			if reset != tc.wantReset {
+				t.Fatalf("reset = %v, want %v", reset, tc.wantReset)
+			}
+			if got := ids(replay); !slices.Equal(got, tc.wantReplay) {
+				t.Fatalf("replay = %v, want %v", got, tc.wantReplay)
+			}
+		})
+	}
+}
+
+func TestSubscribeOtherUser(t *testing.T) {
+	h, _ := newTestHub(10, time.Hour)
+	h.Publish("u1", msg("1"))
+	h.Publish("u1", msg("2"))
+
+	// Буферы пользователей раздельные
+	_, replay, reset := h.Subscribe("u2", "1")
+	if !reset || len(replay) != 0 {
+		t.Fatalf("replay = %v, reset = %v, want reset without replay", ids(replay), reset)
+	}
+}
+
+func TestPublishDeduplicates(t *testing.T) {
+	h, _ := newTestHub(10, time.Hour)
+	sub, _, _ := h.Subscribe("u", "")
+
+	h.Publish("u", msg("1"))
+	h.Publish("u", msg("2"))
+	h.Publish("u", msg("1"))
+
+	if got := received(sub); !slices.Equal(got, []string{"1", "2"}) {
+		t.Fatalf("received %v, want [1 2]", got)
+	}
+
+	_, replay, _ := h.Subscribe("u", "1")
+	if got := ids(replay); !slices.Equal(got, []string{"2"}) {
+		t.Fatalf("buffer after duplicate = %v, want [2]", got)
+	}
+}
+
+func TestBufferSize(t *testing.T) {
+	h, _ := newTestHub(3, time.Hour)
+	for _, id := range []string{"1", "2", "3", "4", "5"} {
+		h.Publish("u", msg(id))
+	}
+
+	testCases := []struct {
+		name        string
+		lastEventID string
+		wantReplay  []string
+		wantReset   bool
+	}{
+		{name: "oldest kept", lastEventID: "3", wantReplay: []string{"4", "5"}},
+		{name: "evicted", lastEventID: "2", wantReset: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, replay, reset := h.Subscribe("u", tc.lastEventID)
+			if reset != tc.wantReset {
+				t.Fatalf("reset = %v, want %v", reset, tc.wantReset)
+			}
+			if got := ids(replay); !slices.Equal(got, tc.wantReplay) {
+				t.Fatalf("replay = %v, want %v", got, tc.wantReplay)
+			}
+		})
+	}
+}
+
+func TestTTLPruning(t *testing.T) {
+	h, c := newTestHub(10, time.Hour)
+
+	h.Publish("u", msg("old"))
+	c.advance(30 * time.Minute)
+	h.Publish("u", msg("mid"))
+	c.advance(31 * time.Minute)
+	h.Publish("u", msg("new"))
+
+	// "old" старше часа и выброшен, продолжить с него нельзя
+	if _, _, reset := h.Subscribe("u", "old"); !reset {
+		t.Fatal("expired event still in buffer")
+	}
+	_, replay, reset := h.Subscribe("u", "mid")
+	if reset || !slices.Equal(ids(replay), []string{"new"}) {
+		t.Fatalf("replay = %v, reset = %v, want [new]", ids(replay), reset)
+	}
+
+	// Выброшенное событие можно доставить снова: дедупликация только по буферу
+	sub, _, _ := h.Subscribe("u", "")
+	h.Publish("u", msg("old"))
+	if got := received(sub); !slices.Equal(got, []string{"old"}) {
+		t.Fatalf("received %v, want [old]", got)
+	}
+
+	// Когда устарело все, буфер пользователя удаляется
+	c.advance(2 * time.Hour)
+	h.mu.Lock()
+	h.prune("u", c.now())
+	_, kept := h.buffers["u"]
+	h.mu.Unlock()
+	if kept {
+		t.Fatal("empty buffer not removed")
+	}
+}
+
+func TestSlowSubscriberDropped(t *testing.T) {
+	h, _ := newTestHub(subscriptionQueue*2, time.Hour)
+
+	slow, _, _ := h.Subscribe("u", "")
+	fast, _, _ := h.Subscribe("u", "")
+
+	for i := range subscriptionQueue + 1 {
+		id := string(rune('a'+i/26)) + string(rune('a'+i%26))
+		h.Publish("u", msg(id))
+		// fast читает сразу, slow — нет
+		received(fast)
+	}
+
+	if !closed(slow) {
+		t.Fatal("slow subscriber not dropped")
+	}
+	if slow.Reason() != "" {
+		t.Fatalf("reason = %q, want empty for a slow reader", slow.Reason())
+	}
+	if closed(fast) {
+		t.Fatal("fast subscriber dropped")
+	}
+
+	// Отключенному больше ничего не отправляется, остальные получают события
+	queued := len(slow.C)
+	h.Publish("u", msg("after"))
+	if len(slow.C) != queued {
+		t.Fatal("message sent to dropped subscriber")
+	}
+	if got := received(fast); !slices.Equal(got, []string{"after"}) {
+		t.Fatalf("fast received %v, want [after]", got)
+	}
+
+	// Unsubscribe после отключения хабом не паникует
+	h.Unsubscribe(slow)
+}
+
+func TestKick(t *testing.T) {
+	h, _ := newTestHub(10, time.Hour)
+	h.Publish("u", msg("1"))
+
+	a, _, _ := h.Subscribe("u", "")
+	b, _, _ := h.Subscribe("u", "")
+	other, _, _ := h.Subscribe("v", "")
+
+	h.Kick("u", "session_revoked")
+
+	for _, sub := range []*Subscription{a, b} {
+		if !closed(sub) || sub.Reason() != "session_revoked" {
+			t.Fatalf("subscription closed = %v, reason = %q", closed(sub), sub.Reason())
+		}
+	}
+	if closed(other) {
+		t.Fatal("other user's subscription closed")
+	}
+	if users := h.Users(); !slices.Equal(users, []string{"v"}) {
+		t.Fatalf("Users() = %v, want [v]", users)
+	}
+	// Буфер забыт
+	if _, _, reset := h.Subscribe("u", "1"); !reset {
+		t.Fatal("buffer kept after kick")
+	}
+}
@@ -0,0 +1,287 @@
+package iternal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// Пинги не дают прокси закрыть молчащее соединение
+	pingInterval = 25 * time.Second
+	writeTimeout = 10 * time.Second
+
+	// Через сколько браузер переподключается к SSE после обрыва
+	sseRetry = 3 * time.Second
+)
+
+// Server отдает GET /events: SSE по умолчанию, WebSocket — если пришел Upgrade.
+type Server struct {
+	hub      *Hub
+	users    *UsersClient
+	recheck  time.Duration
+	upgrader websocket.Upgrader
+}
+
+func NewServer(hub *Hub, users *UsersClient, recheck time.Duration) *Server {
+	return &Server{
+		hub:     hub,
+		users:   users,
+		recheck: recheck,
+		upgrader: websocket.Upgrader{
+			// Токен проверяется сам по себе, куки не используются — Origin можно не сверять,
+			// как и CORS в gateway (AllowedOrigins: *)
+			CheckOrigin: func(r *http.Request) bool { return true },
+		},
+	}
+}
+
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /events", s.Events)
+	mux.HandleFunc("GET /health", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	return mux
+}
+
+// conn — транспорт одного соединения: SSE или WebSocket.
+type conn interface {
+	Send(msg Message) error
+	Ping() error
+	// Closed закрывается, когда клиент ушел
+	Closed() <-chan struct{}
+}
+
+// Events — GET /events. Токен в Authorization: Bearer или в ?access_token= (EventSource и
+// WebSocket в браузере не умеют свои заголовки). Продолжить с места обрыва —
+// заголовок Last-Event-ID (EventSource шлет его сам) или ?last_event_id=.
+func (s *Server) Events(w http.ResponseWriter, r *http.Request) {
+	token, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !found || token == "" {
+		token = r.URL.Query().Get("access_token")
+	}
+	if token == "" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	identity, ok, err := s.users.Introspect(r.Context(), token)
+	if err != nil {
+		log.Printf("❌ Интроспекция: %v", err)
+		http.Error(w, "Сервис пользователей недоступен", http.StatusServiceUnavailable)
+		return
+	}
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("last_event_id")
+	}
+
+	var c conn
+	if websocket.IsWebSocketUpgrade(r) {
+		ws, err := s.upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			// Upgrade сам ответил клиенту
+			return
+		}
+		wc := newWSConn(ws)
+		defer wc.close()
+		c = wc
+	} else {
+		sc, err := newSSEConn(w, r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		c = sc
+	}
+
+	sub, replay, reset := s.hub.Subscribe(identity.UserID, lastEventID)
+	defer s.hub.Unsubscribe(sub)
+
+	s.serve(r.Context(), c, sub, token, identity, replay, reset)
+}
+
+func (s *Server) serve(ctx context.Context, c conn, sub *Subscription, token string, identity Identity, replay []Message, reset bool) {
+	if reset {
+		if c.Send(Message{Type: "reset", OccurredAt: time.Now().UTC()}) != nil {
+			return
+		}
+	}
+	for _, msg := range replay {
+		if c.Send(msg) != nil {
+			return
+		}
+	}
+
+	ping := time.NewTicker(pingInterval)
+	defer ping.Stop()
+	recheck := time.NewTicker(s.recheck)
+	defer recheck.Stop()
+
+	// Соединение живет не дольше токена: клиент обновит токен и переподключится с last_event_id
+	var expired <-chan time.Time
+	if !identity.ExpiresAt.IsZero() {
+		timer := time.NewTimer(time.Until(identity.ExpiresAt))
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-c.Closed():
+			return
+		case <-sub.Done:
+			if reason := sub.Reason(); reason != "" {
+				c.Send(control("session_revoked", reason))
+			}
+			return
+		case msg := <-sub.C:
+			if c.Send(msg) != nil {
+				return
+			}
+		case <-ping.C:
+			if c.Ping() != nil {
+				return
+			}
+		case <-expired:
+			c.Send(control("token_expired", ""))
+			return
+		case <-recheck.C:
+			// Отзыв токена, logout со сменой версии, смена пароля или роли — токен перестает быть активным
+			_, ok, err := s.users.Introspect(ctx, token)
+			if err != nil {
+				// users недоступен — не рвем соединение из-за него
+				log.Printf("⚠ Перепроверка токена: %v", err)
+				continue
+			}
+			if !ok {
+				c.Send(control("session_revoked", "token_revoked"))
+				return
+			}
+		}
+	}
+}
+
+func control(msgType, reason string) Message {
+	msg := Message{Type: msgType, OccurredAt: time.Now().UTC()}
+	if reason != "" {
+		msg.Data, _ = json.Marshal(map[string]string{"reason": reason})
+	}
+	return msg
+}
+
+type sseConn struct {
+	w      http.ResponseWriter
+	rc     *http.ResponseController
+	closed <-chan struct{}
+}
+
+func newSSEConn(w http.ResponseWriter, r *http.Request) (*sseConn, error) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	// nginx не должен копить поток в буфере
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	c := &sseConn{w: w, rc: http.NewResponseController(w), closed: r.Context().Done()}
+	if _, err := fmt.Fprintf(w, "retry: %d\n\n", sseRetry.Milliseconds()); err != nil {
+		return nil, err
+	}
+	return c, c.flush()
+}
+
+// Send пишет событие в формате text/event-stream; id есть только у событий из буфера.
+func (c *sseConn) Send(msg Message) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	if msg.ID != "" {
+		fmt.Fprintf(&b, "id: %s\n", msg.ID)
+	}
+	fmt.Fprintf(&b, "event: %s\ndata: %s\n\n", msg.Type, data)
+
+	c.rc.SetWriteDeadline(time.Now().Add(writeTimeout))
+	if _, err := c.w.Write([]byte(b.String())); err != nil {
+		return err
+	}
+	return c.flush()
+}
+
+func (c *sseConn) Ping() error {
+	c.rc.SetWriteDeadline(time.Now().Add(writeTimeout))
+	if _, err := c.w.Write([]byte(": ping\n\n")); err != nil {
+		return err
+	}
+	return c.flush()
+}
+
+func (c *sseConn) Closed() <-chan struct{} {
+	return c.closed
+}
+
+func (c *sseConn) flush() error {
+	return c.rc.Flush()
+}
+
+type wsConn struct {
+	ws     *websocket.Conn
+	closed chan struct{}
+}
+
+func newWSConn(ws *websocket.Conn) *wsConn {
+	c := &wsConn{ws: ws, closed: make(chan struct{})}
+
+	// Клиент ничего не шлет, но читать нужно: так обрабатываются pong и close
+	ws.SetReadLimit(1024)
+	ws.SetReadDeadline(time.Now().Add(2 * pingInterval))
+	ws.SetPongHandler(func(string) error {
+		return ws.SetReadDeadline(time.Now().Add(2 * pingInterval))
+	})
+	go func() {
+		defer close(c.closed)
+		for {
+			if _, _, err := ws.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	return c
+}
+
+// Send отправляет событие текстовым JSON-фреймом.
+func (c *wsConn) Send(msg Message) error {
+	c.ws.SetWriteDeadline(time.Now().Add(writeTimeout))
+	return c.ws.WriteJSON(msg)
+}
+
+func (c *wsConn) Ping() error {
+	return c.ws.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeTimeout))
+}
+
+func (c *wsConn) Closed() <-chan struct{} {
+	return c.closed
+}
+
+func (c *wsConn) close() {
+	c.ws.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(writeTimeout))
+	c.ws.Close()
+}
@@ -0,0 +1,132 @@
+package iternal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Сколько id отправлять в users за один запрос (users принимает до 1000)
+const filterChunk = 500
+
+// Identity — владелец access-токена и время, до которого токен действует.
+type Identity struct {
+	UserID    string
+	ExpiresAt time.Time
+}
+
+// UsersClient ходит во внутренние ручки сервиса users с SERVICE_TOKEN.
+type UsersClient struct {
+	baseURL      string
+	serviceToken string
+	http         *http.Client
+}
+
+func NewUsersClient(baseURL, serviceToken string) *UsersClient {
+	return &UsersClient{
+		baseURL:      strings.TrimRight(baseURL, "/"),
+		serviceToken: serviceToken,
+		http:         &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+type introspectResponse struct {
+	Active    bool   `json:"active"`
+	TokenType string `json:"token_type"`
+	Sub       string `json:"sub"`
+	EXP       int64  `json:"exp"`
+}
+
+// Introspect проверяет access JWT через POST /oauth/introspect. ok == false — токен невалиден,
+// просрочен, отозван (в том числе сменой пароля или роли) или это не access.
+// Кэша нет: хаб зовет его при подключении и раз в RecheckInterval на соединение.
+func (c *UsersClient) Introspect(ctx context.Context, token string) (Identity, bool, error) {
+	form := url.Values{"token": {token}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/oauth/introspect", strings.NewReader(form.Encode()))
+	if err != nil {
+		return Identity{}, false, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Authorization", "Bearer "+c.serviceToken)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return Identity{}, false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return Identity{}, false, fmt.Errorf("introspect: status %d", resp.StatusCode)
+	}
+
+	var body introspectResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return Identity{}, false, fmt.Errorf("introspect: %w", err)
+	}
+
+	if !body.Active || body.TokenType != "access" || body.Sub == "" {
+		return Identity{}, false, nil
+	}
+
+	identity := Identity{UserID: body.Sub}
+	if body.EXP > 0 {
+		identity.ExpiresAt = time.Unix(body.EXP, 0)
+	}
+
+	return identity, true, nil
+}
+
+type filterFollowersRequest struct {
+	FolloweeID string   `json:"followee_id"`
+	Kind       string   `json:"kind"`
+	UserIDs    []string `json:"user_ids"`
+}
+
+type filterFollowersResponse struct {
+	UserIDs []string `json:"user_ids"`
+}
+
+// FilterFollowers возвращает тех из userIDs, кто подписан на исполнителя artistID.
+func (c *UsersClient) FilterFollowers(ctx context.Context, artistID string, userIDs []string) ([]string, error) {
+	var followers []string
+
+	for start := 0; start < len(userIDs); start += filterChunk {
+		chunk := userIDs[start:min(start+filterChunk, len(userIDs))]
+
+		body, err := json.Marshal(filterFollowersRequest{FolloweeID: artistID, Kind: "artist", UserIDs: chunk})
+		if err != nil {
+			return nil, err
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/internal/social/followers", bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+c.serviceToken)
+
+		resp, err := c.http.Do(req)
+		if err != nil {
+			return nil, err
+		}
+
+		var page filterFollowersResponse
+		err = json.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("filter followers: status %d", resp.StatusCode)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("filter followers: %w", err)
+		}
+
+		followers = append(followers, page.UserIDs...)
+	}
+
+	return followers, nil
+}
@@ -0,0 +1,147 @@
+// deadletters — утилита для разбора упавших задач из deffered_tasks_dead.
+//
+//	deadletters list [-source outbox|consumer] [-limit 50]
+//	deadletters show <id>
+//	deadletters edit <id> <data>   (data "-" — прочитать из stdin)
+//	deadletters replay <id>...
+//	deadletters delete <id>...
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+
+	sender "sender/iternal"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/joho/godotenv"
+)
+
+func usage() {
+	fmt.Fprintln(os.Stderr, `usage:
+  deadletters list [-source outbox|consumer] [-limit 50]
+  deadletters show <id>
+  deadletters edit <id> <data|->
+  deadletters replay <id>...
+  deadletters delete <id>...`)
+	os.Exit(2)
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	_ = godotenv.Load(filepath.Join("..", ".env"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	db, err := pgxpool.New(ctx, sender.DatabaseURL())
+	if err != nil {
+		log.Fatalf("❌ Неверные параметры подключения к БД: %v", err)
+	}
+	defer db.Close()
+
+	cmd, args := os.Args[1], os.Args[2:]
+	switch cmd {
+	case "list":
+		err = list(ctx, db, args)
+	case "show":
+		err = show(ctx, db, args)
+	case "edit":
+		err = edit(ctx, db, args)
+	case "replay":
+		err = forEachID(args, func(id string) error { return sender.ReplayDeadLetter(ctx, db, id) }, "возвращена в deffered_tasks")
+	case "delete":
+		err = forEachID(args, func(id string) error { return sender.DeleteDeadLetter(ctx, db, id) }, "удалена")
+	default:
+		usage()
+	}
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+}
+
+func list(ctx context.Context, db *pgxpool.Pool, args []string) error {
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+	source := fs.String("source", "", "outbox или consumer, по умолчанию все")
+	limit := fs.Int("limit", 50, "сколько записей показать")
+	fs.Parse(args)
+
+	letters, err := sender.ListDeadLetters(ctx, db, *source, *limit)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tTOPIC\tSOURCE\tATTEMPTS\tFAILED AT\tERROR")
+	for _, d := range letters {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\t%s\n", d.ID, d.Topic, d.Source, d.Attempts, d.FailedAt.Format(time.DateTime), d.LastError)
+	}
+
+	return w.Flush()
+}
+
+func show(ctx context.Context, db *pgxpool.Pool, args []string) error {
+	if len(args) != 1 {
+		usage()
+	}
+
+	d, err := sender.GetDeadLetter(ctx, db, args[0])
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("id:         %s\ntopic:      %s\nkey:        %s\nsource:     %s\nattempts:   %d\ncreated at: %s\nfailed at:  %s\nerror:      %s\n\n%s\n",
+		d.ID, d.Topic, d.Key, d.Source, d.Attempts, d.CreatedAt.Format(time.DateTime), d.FailedAt.Format(time.DateTime), d.LastError, d.Data)
+
+	return nil
+}
+
+func edit(ctx context.Context, db *pgxpool.Pool, args []string) error {
+	if len(args) != 2 {
+		usage()
+	}
+
+	data := args[1]
+	if data == "-" {
+		b, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return err
+		}
+		data = string(b)
+	}
+
+	if err := sender.EditDeadLetter(ctx, db, args[0], data); err != nil {
+		return err
+	}
+	fmt.Printf("✅ %s изменена\n", args[0])
+
+	return nil
+}
+
+func forEachID(ids []string, fn func(id string) error, done string) error {
+	if len(ids) == 0 {
+		usage()
+	}
+
+	var errs []error
+	for _, id := range ids {
+		if err := fn(id); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", id, err))
+			continue
+		}
+		fmt.Printf("✅ %s %s\n", id, done)
+	}
+
+	return errors.Join(errs...)
+}
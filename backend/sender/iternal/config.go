@@ -0,0 +1,26 @@
+package iternal
+
+import (
+	"fmt"
+	"os"
+)
+
+// DatabaseURL собирает строку подключения к БД сервиса users из окружения.
+func DatabaseURL() string {
+	dbHost := os.Getenv("DB_HOST")
+	dbPort := os.Getenv("DB_PORT")
+	dbUser := os.Getenv("DB_USER")
+	if dbUser == "" {
+		dbUser = os.Getenv("POSTGRES_USER")
+	}
+	dbPass := os.Getenv("DB_PASSWORD")
+	if dbPass == "" {
+		dbPass = os.Getenv("POSTGRES_PASSWORD")
+	}
+	dbName := os.Getenv("DB_NAME")
+	if dbName == "" {
+		dbName = os.Getenv("POSTGRES_DB")
+	}
+
+	return fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable", dbUser, dbPass, dbHost, dbPort, dbName)
+}
@@ -0,0 +1,119 @@
+package iternal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var ErrDeadLetterNotExists = errors.New("dead letter not exists")
+
+type DeadLetter struct {
+	ID        string
+	Topic     string
+	Key       string
+	Data      string
+	Source    string
+	Attempts  int
+	LastError string
+	CreatedAt time.Time
+	FailedAt  time.Time
+}
+
+const deadLetterColumns = `id, topic, key, data, source, attempts, last_error, created_at, failed_at`
+
+func scanDeadLetter(row pgx.Row) (DeadLetter, error) {
+	var d DeadLetter
+	err := row.Scan(&d.ID, &d.Topic, &d.Key, &d.Data, &d.Source, &d.Attempts, &d.LastError, &d.CreatedAt, &d.FailedAt)
+	return d, err
+}
+
+// ListDeadLetters возвращает последние упавшие задачи, новые первыми. Пустой source — все источники.
+func ListDeadLetters(ctx context.Context, db *pgxpool.Pool, source string, limit int) ([]DeadLetter, error) {
+	rows, err := db.Query(ctx,
+		`SELECT `+deadLetterColumns+` FROM deffered_tasks_dead
+		 WHERE $1 = '' OR source = $1
+		 ORDER BY failed_at DESC
+		 LIMIT $2`, source, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list dead letters: %w", err)
+	}
+	defer rows.Close()
+
+	var list []DeadLetter
+	for rows.Next() {
+		d, err := scanDeadLetter(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan dead letter: %w", err)
+		}
+		list = append(list, d)
+	}
+
+	return list, rows.Err()
+}
+
+func GetDeadLetter(ctx context.Context, db *pgxpool.Pool, id string) (DeadLetter, error) {
+	d, err := scanDeadLetter(db.QueryRow(ctx,
+		`SELECT `+deadLetterColumns+` FROM deffered_tasks_dead WHERE id = $1`, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return DeadLetter{}, ErrDeadLetterNotExists
+	}
+	if err != nil {
+		return DeadLetter{}, fmt.Errorf("get dead letter: %w", err)
+	}
+
+	return d, nil
+}
+
+// EditDeadLetter заменяет тело сообщения, например чтобы исправить битый JSON перед повтором.
+func EditDeadLetter(ctx context.Context, db *pgxpool.Pool, id, data string) error {
+	tag, err := db.Exec(ctx, `UPDATE deffered_tasks_dead SET data = $2 WHERE id = $1`, id, data)
+	if err != nil {
+		return fmt.Errorf("edit dead letter: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrDeadLetterNotExists
+	}
+
+	return nil
+}
+
+// ReplayDeadLetter возвращает сообщение в deffered_tasks: relay отправит его в исходный топик
+// с обычными ретраями. Как и при создании задачи в users, relay будится через NOTIFY, а не
+// ждет очередного опроса. Тело должно быть валидным JSON, иначе вставка не пройдет.
+func ReplayDeadLetter(ctx context.Context, db *pgxpool.Pool, id string) error {
+	tag, err := db.Exec(ctx,
+		`WITH moved AS (
+			DELETE FROM deffered_tasks_dead WHERE id = $1
+			RETURNING topic, key, data
+		), task AS (
+			INSERT INTO deffered_tasks(topic, key, data, created_at)
+			SELECT topic, key, data::json, now() FROM moved
+			RETURNING id
+		)
+		SELECT pg_notify($2, id::text) FROM task`, id, DefferedTasksChannel)
+	if err != nil {
+		return fmt.Errorf("replay dead letter: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrDeadLetterNotExists
+	}
+
+	return nil
+}
+
+func DeleteDeadLetter(ctx context.Context, db *pgxpool.Pool, id string) error {
+	tag, err := db.Exec(ctx, `DELETE FROM deffered_tasks_dead WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("delete dead letter: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrDeadLetterNotExists
+	}
+
+	return nil
+}
@@ -0,0 +1,109 @@
+package iternal
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Заголовки, с которыми консьюмеры публикуют сообщения в DLQ-топик
+const (
+	HeaderOriginalTopic = "original_topic"
+	HeaderError         = "error"
+)
+
+// DLQCollector переносит сообщения из DLQ-топика в deffered_tasks_dead,
+// чтобы их можно было посмотреть, поправить и переиграть тем же инструментом, что и задачи outbox.
+type DLQCollector struct {
+	db       *pgxpool.Pool
+	consumer *kafka.Consumer
+	topic    string
+}
+
+func NewDLQCollector(db *pgxpool.Pool, brokers, groupID, topic string) (*DLQCollector, error) {
+	c, err := kafka.NewConsumer(&kafka.ConfigMap{
+		"bootstrap.servers":  brokers,
+		"group.id":           groupID,
+		"auto.offset.reset":  "earliest",
+		"enable.auto.commit": false,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &DLQCollector{
+		db:       db,
+		consumer: c,
+		topic:    topic,
+	}, nil
+}
+
+// Run читает DLQ, пока не отменен ctx. Оффсет коммитится только после записи в БД.
+func (c *DLQCollector) Run(ctx context.Context) {
+	defer c.consumer.Close()
+
+	if err := c.consumer.Subscribe(c.topic, nil); err != nil {
+		log.Printf("❌ Не удалось подписаться на %s: %v", c.topic, err)
+		return
+	}
+	log.Printf("✅ DLQ collector subscribed to %s", c.topic)
+
+	backoff := time.Second
+	for ctx.Err() == nil {
+		msg, err := c.consumer.ReadMessage(500 * time.Millisecond)
+		if err != nil {
+			if kErr, ok := err.(kafka.Error); ok && kErr.Code() == kafka.ErrTimedOut {
+				continue
+			}
+			log.Printf("Kafka error: %v", err)
+			continue
+		}
+
+		for ctx.Err() == nil {
+			if err = c.store(ctx, msg); err == nil {
+				break
+			}
+			log.Printf("⚠ не удалось сохранить сообщение из DLQ: %v, повтор через %s", err, backoff)
+			sleep(ctx, backoff)
+			backoff = min(backoff*2, time.Minute)
+		}
+		if err != nil {
+			return
+		}
+		backoff = time.Second
+
+		if _, err := c.consumer.CommitMessage(msg); err != nil {
+			log.Printf("⚠ не удалось закоммитить оффсет DLQ: %v", err)
+		}
+	}
+}
+
+func (c *DLQCollector) store(ctx context.Context, msg *kafka.Message) error {
+	topic, reason := "", ""
+	for _, h := range msg.Headers {
+		switch h.Key {
+		case HeaderOriginalTopic:
+			topic = string(h.Value)
+		case HeaderError:
+			reason = string(h.Value)
+		}
+	}
+	if topic == "" {
+		topic = *msg.TopicPartition.Topic
+	}
+
+	_, err := c.db.Exec(ctx,
+		`INSERT INTO deffered_tasks_dead(topic, key, data, source, last_error) VALUES ($1, $2, $3, 'consumer', $4)`,
+		topic, string(msg.Key), string(msg.Value), reason)
+	if err != nil {
+		return fmt.Errorf("insert dead letter: %w", err)
+	}
+
+	log.Printf("☠ Сообщение из DLQ сохранено: topic=%s error=%s", topic, reason)
+
+	return nil
+}
@@ -0,0 +1,54 @@
+package iternal
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// DefferedTasksChannel — канал NOTIFY, в который сервис users пишет при создании задачи
+const DefferedTasksChannel = "deffered_tasks"
+
+// Listen держит отдельное соединение (не из пула: LISTEN привязан к сессии) и вызывает
+// onNotify на каждое уведомление. При обрыве переподключается с задержкой, а после
+// подключения тоже вызывает onNotify, чтобы не пропустить задачи, созданные без слушателя.
+func Listen(ctx context.Context, dbURL, channel string, onNotify func()) {
+	backoff := time.Second
+
+	for ctx.Err() == nil {
+		err := listen(ctx, dbURL, channel, func() {
+			backoff = time.Second
+			onNotify()
+		})
+		if ctx.Err() != nil {
+			return
+		}
+
+		log.Printf("⚠ LISTEN %s прерван: %v, переподключение через %s", channel, err, backoff)
+		sleep(ctx, backoff)
+		backoff = min(backoff*2, time.Minute)
+	}
+}
+
+func listen(ctx context.Context, dbURL, channel string, onNotify func()) error {
+	conn, err := pgx.Connect(ctx, dbURL)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{channel}.Sanitize()); err != nil {
+		return err
+	}
+	log.Printf("👂 LISTEN %s", channel)
+	onNotify()
+
+	for {
+		if _, err := conn.WaitForNotification(ctx); err != nil {
+			return err
+		}
+		onNotify()
+	}
+}
@@ -1 +1,369 @@
 package iternal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"gitlab.com/Go34/Mute/shared/events"
+)
+
+// Relay переносит задачи из deffered_tasks (outbox сервиса users) в Kafka.
+// Строка помечается отправленной только после подтверждения доставки от брокера,
+// поэтому сообщение может уйти повторно, но не потеряется (at-least-once).
+type Relay struct {
+	db       *pgxpool.Pool
+	producer *kafka.Producer
+	cfg      Config
+	wakeup   chan struct{}
+}
+
+type Config struct {
+	BatchSize       int
+	PollInterval    time.Duration
+	DeliveryTimeout time.Duration
+	MaxBackoff      time.Duration
+	// После стольких неудачных попыток задача переносится в deffered_tasks_dead
+	MaxAttempts int
+	// Имя экземпляра, под которым он берет задачи в аренду
+	InstanceID string
+	// На сколько берется задача; должно быть больше DeliveryTimeout
+	LeaseDuration time.Duration
+	// Сколько хранить отправленные задачи
+	Retention time.Duration
+}
+
+type task struct {
+	id, topic, key, data string
+	attempts             int
+}
+
+// messageKey — ключ сообщения в Kafka: сообщения с одним ключом попадают в одну партицию
+func (t task) messageKey() []byte {
+	if t.key == "" {
+		return []byte(t.id)
+	}
+	return []byte(t.key)
+}
+
+func NewRelay(db *pgxpool.Pool, producer *kafka.Producer, cfg Config) *Relay {
+	return &Relay{
+		db:       db,
+		producer: producer,
+		cfg:      cfg,
+		wakeup:   make(chan struct{}, 1),
+	}
+}
+
+// Wakeup будит Run, если он ждет PollInterval. Не блокируется: несколько вызовов подряд
+// схлопываются в одну дополнительную выборку.
+func (r *Relay) Wakeup() {
+	select {
+	case r.wakeup <- struct{}{}:
+	default:
+	}
+}
+
+// NewProducer создает идемпотентный продьюсер: ретраи внутри librdkafka не дают дублей и не меняют порядок.
+func NewProducer(brokers string) (*kafka.Producer, error) {
+	return kafka.NewProducer(&kafka.ConfigMap{
+		"bootstrap.servers":  brokers,
+		"enable.idempotence": true,
+		"acks":               "all",
+	})
+}
+
+// Run обрабатывает пачки, пока не отменен ctx. Ошибки БД и Kafka не останавливают
+// процесс: пачка повторяется с экспоненциальной задержкой.
+func (r *Relay) Run(ctx context.Context) {
+	backoff := time.Second
+	lastCleanup := time.Time{}
+
+	for ctx.Err() == nil {
+		n, err := r.processBatch(ctx)
+		if err != nil {
+			log.Printf("⚠ ошибка обработки пачки: %v, повтор через %s", err, backoff)
+			sleep(ctx, backoff)
+			backoff = min(backoff*2, r.cfg.MaxBackoff)
+			continue
+		}
+		backoff = time.Second
+
+		if time.Since(lastCleanup) > time.Hour {
+			r.cleanup(ctx)
+			lastCleanup = time.Now()
+		}
+
+		if n == 0 {
+			r.wait(ctx)
+		}
+	}
+}
+
+func (r *Relay) processBatch(ctx context.Context) (int, error) {
+	tasks, err := r.claim(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(tasks) == 0 {
+		return 0, nil
+	}
+
+	// Невалидное событие не станет валидным при повторе, оно сразу уходит в deffered_tasks_dead
+	invalid := make(map[string]string)
+	valid := make([]task, 0, len(tasks))
+	for i := range tasks {
+		data, err := normalize(tasks[i].data)
+		if err != nil {
+			invalid[tasks[i].id] = err.Error()
+			continue
+		}
+		tasks[i].data = data
+		valid = append(valid, tasks[i])
+	}
+
+	failed := r.produce(valid)
+	for id, reason := range invalid {
+		failed[id] = reason
+	}
+
+	if err := r.record(ctx, tasks, failed, invalid); err != nil {
+		return 0, err
+	}
+
+	log.Printf("✅ Пачка обработана: отправлено %d, с ошибкой %d", len(tasks)-len(failed), len(failed))
+
+	return len(tasks), nil
+}
+
+// outcome — что делать с задачей после попытки отправки
+type outcome int
+
+const (
+	outcomeSent outcome = iota
+	outcomeRetry
+	outcomeDead
+)
+
+// resolve решает судьбу задачи: невалидная или исчерпавшая MaxAttempts уходит в deffered_tasks_dead,
+// остальные неотправленные повторяются через retryDelay.
+func (r *Relay) resolve(t task, failed, invalid map[string]string) (outcome, string) {
+	reason, isFailed := failed[t.id]
+	if !isFailed {
+		return outcomeSent, ""
+	}
+	if _, isInvalid := invalid[t.id]; isInvalid || t.attempts+1 >= r.cfg.MaxAttempts {
+		return outcomeDead, reason
+	}
+	return outcomeRetry, reason
+}
+
+// record записывает результаты пачки. Транзакция только на запись: пока идет отправка,
+// строки защищены арендой, а не блокировкой, поэтому каждая строка обновляется только
+// если аренда все еще наша.
+func (r *Relay) record(ctx context.Context, tasks []task, failed, invalid map[string]string) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(context.Background())
+
+	for _, t := range tasks {
+		var tag pgconn.CommandTag
+
+		switch result, reason := r.resolve(t, failed, invalid); result {
+		case outcomeSent:
+			tag, err = tx.Exec(ctx,
+				`UPDATE deffered_tasks SET status = 'sent', sent_at = now(), attempts = attempts + 1, last_error = '',
+				 locked_by = NULL, locked_until = NULL
+				 WHERE id = $1 AND locked_by = $2`,
+				t.id, r.cfg.InstanceID)
+		case outcomeDead:
+			log.Printf("☠ задача %s не отправлена (попыток: %d): %s, перенос в deffered_tasks_dead", t.id, t.attempts+1, reason)
+
+			tag, err = tx.Exec(ctx,
+				`WITH moved AS (
+					DELETE FROM deffered_tasks WHERE id = $1 AND locked_by = $3
+					RETURNING id, topic, key, data, created_at, attempts
+				)
+				INSERT INTO deffered_tasks_dead(id, topic, key, data, source, attempts, last_error, created_at)
+				SELECT id, topic, key, data::text, 'outbox', attempts + 1, $2, COALESCE(created_at, now()) FROM moved`,
+				t.id, reason, r.cfg.InstanceID)
+		case outcomeRetry:
+			delay := r.retryDelay(t.attempts + 1)
+			log.Printf("⚠ задача %s не доставлена (попытка %d): %s, повтор через %s", t.id, t.attempts+1, reason, delay)
+
+			tag, err = tx.Exec(ctx,
+				`UPDATE deffered_tasks SET attempts = attempts + 1, last_error = $2, next_attempt_at = now() + $3::interval,
+				 locked_by = NULL, locked_until = NULL
+				 WHERE id = $1 AND locked_by = $4`,
+				t.id, reason, delay.String(), r.cfg.InstanceID)
+		}
+		if err != nil {
+			return fmt.Errorf("update task %s: %w", t.id, err)
+		}
+		if tag.RowsAffected() == 0 {
+			// Аренда истекла и задачу забрал другой экземпляр: он ее и отметит, сообщение может уйти дважды
+			log.Printf("⚠ аренда задачи %s потеряна, результат не записан", t.id)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+
+	return nil
+}
+
+// produce отправляет пачку и ждет отчет о доставке по каждому сообщению.
+// Возвращает задачи, которые не доставлены, с причиной.
+func (r *Relay) produce(tasks []task) map[string]string {
+	failed := make(map[string]string)
+	deliveryChan := make(chan kafka.Event, len(tasks))
+
+	pending := 0
+	for i := range tasks {
+		t := tasks[i]
+		err := r.producer.Produce(&kafka.Message{
+			TopicPartition: kafka.TopicPartition{Topic: &t.topic, Partition: kafka.PartitionAny},
+			Key:            t.messageKey(),
+			Value:          []byte(t.data),
+			Opaque:         t.id,
+		}, deliveryChan)
+		if err != nil {
+			failed[t.id] = err.Error()
+			continue
+		}
+		pending++
+	}
+
+	timeout := time.After(r.cfg.DeliveryTimeout)
+	delivered := make(map[string]bool)
+
+	for pending > 0 {
+		select {
+		case e := <-deliveryChan:
+			m, ok := e.(*kafka.Message)
+			if !ok {
+				continue
+			}
+			pending--
+
+			id, _ := m.Opaque.(string)
+			if m.TopicPartition.Error != nil {
+				failed[id] = m.TopicPartition.Error.Error()
+				continue
+			}
+			delivered[id] = true
+		case <-timeout:
+			// Без отчета нельзя считать сообщение доставленным, оно уйдет повторно.
+			for _, t := range tasks {
+				if _, ok := failed[t.id]; !ok && !delivered[t.id] {
+					failed[t.id] = "delivery report timeout"
+				}
+			}
+			return failed
+		}
+	}
+
+	return failed
+}
+
+// wait ждет уведомления о новой задаче или истечения PollInterval. Опрос остается
+// на случай потери LISTEN-соединения и для задач, отложенных до next_attempt_at.
+func (r *Relay) wait(ctx context.Context) {
+	select {
+	case <-ctx.Done():
+	case <-r.wakeup:
+	case <-time.After(r.cfg.PollInterval):
+	}
+}
+
+// claim берет в аренду до BatchSize задач. Для каждого ключа берется только самая старая
+// неотправленная задача, и только если ее никто не держит: следующая задача того же ключа
+// станет доступна после того, как отправлена предыдущая. Так порядок по ключу сохраняется
+// при любом числе экземпляров sender. Задачи без ключа не упорядочиваются.
+func (r *Relay) claim(ctx context.Context) ([]task, error) {
+	rows, err := r.db.Query(ctx,
+		`WITH candidates AS (
+			SELECT id FROM deffered_tasks t
+			WHERE status = 'pending' AND next_attempt_at <= now()
+			  AND (locked_until IS NULL OR locked_until < now())
+			  AND (key = '' OR NOT EXISTS (
+				SELECT 1 FROM deffered_tasks p
+				WHERE p.key = t.key AND p.status = 'pending' AND (p.created_at, p.id) < (t.created_at, t.id)
+			  ))
+			ORDER BY created_at ASC
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE deffered_tasks d SET locked_by = $2, locked_until = now() + $3::interval
+		FROM candidates c WHERE d.id = c.id
+		RETURNING d.id, d.topic, d.key, d.data, d.attempts`,
+		r.cfg.BatchSize, r.cfg.InstanceID, r.cfg.LeaseDuration.String())
+	if err != nil {
+		return nil, fmt.Errorf("claim tasks: %w", err)
+	}
+	defer rows.Close()
+
+	var tasks []task
+	for rows.Next() {
+		var t task
+		if err := rows.Scan(&t.id, &t.topic, &t.key, &t.data, &t.attempts); err != nil {
+			return nil, fmt.Errorf("scan task: %w", err)
+		}
+		tasks = append(tasks, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("claim tasks: %w", err)
+	}
+
+	return tasks, nil
+}
+
+// normalize проверяет событие по схеме и возвращает его в виде конверта:
+// задачи старого формата ({"action": ...}) отправляются уже в новом.
+func normalize(data string) (string, error) {
+	event, err := events.Parse([]byte(data))
+	if err != nil {
+		return "", err
+	}
+
+	out, err := json.Marshal(event)
+	if err != nil {
+		return "", err
+	}
+
+	return string(out), nil
+}
+
+func (r *Relay) retryDelay(attempts int) time.Duration {
+	delay := time.Second << min(attempts, 20)
+	return min(delay, r.cfg.MaxBackoff)
+}
+
+func (r *Relay) cleanup(ctx context.Context) {
+	tag, err := r.db.Exec(ctx,
+		`DELETE FROM deffered_tasks WHERE status = 'sent' AND sent_at < $1`,
+		time.Now().Add(-r.cfg.Retention))
+	if err != nil {
+		log.Printf("⚠ не удалось удалить отправленные задачи: %v", err)
+		return
+	}
+	if tag.RowsAffected() > 0 {
+		log.Printf("🧹 Удалено отправленных задач: %d", tag.RowsAffected())
+	}
+}
+
+func sleep(ctx context.Context, d time.Duration) {
+	select {
+	case <-ctx.Done():
+	case <-time.After(d):
+	}
+}
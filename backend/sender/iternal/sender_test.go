@@ -0,0 +1,344 @@
+package iternal
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func TestRetryDelay(t *testing.T) {
+	r := &Relay{cfg: Config{MaxBackoff: 5 * time.Minute}}
+
+	testCases := []struct {
+		name     string
+		attempts int
+		want     time.Duration
+	}{
+		{name: "first retry", attempts: 1, want: 2 * time.Second},
+		{name: "doubles", attempts: 2, want: 4 * time.Second},
+		{name: "below max", attempts: 8, want: 256 * time.Second},
+		{name: "capped by max backoff", attempts: 9, want: 5 * time.Minute},
+		{name: "shift does not overflow", attempts: 1000, want: 5 * time.Minute},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := r.retryDelay(tc.attempts); got != tc.want {
+				t.Fatalf("retryDelay(%d) = %s, want %s", tc.attempts, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestMessageKey(t *testing.T) {
+	testCases := []struct {
+		name string
+		task task
+		want string
+	}{
+		{name: "key", task: task{id: "id-1", key: "user-1"}, want: "user-1"},
+		{name: "no key falls back to id", task: task{id: "id-1"}, want: "id-1"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := string(tc.task.messageKey()); got != tc.want {
+				t.Fatalf("messageKey() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestResolve(t *testing.T) {
+	r := &Relay{cfg: Config{MaxAttempts: 3}}
+
+	testCases := []struct {
+		name       string
+		task       task
+		failed     map[string]string
+		invalid    map[string]string
+		want       outcome
+		wantReason string
+	}{
+		{
+			name: "delivered",
+			task: task{id: "a"},
+			want: outcomeSent,
+		},
+		{
+			name:       "delivery failed, attempts left",
+			task:       task{id: "a", attempts: 1},
+			failed:     map[string]string{"a": "broker down"},
+			want:       outcomeRetry,
+			wantReason: "broker down",
+		},
+		{
+			name:       "delivery failed on last attempt",
+			task:       task{id: "a", attempts: 2},
+			failed:     map[string]string{"a": "broker down"},
+			want:       outcomeDead,
+			wantReason: "broker down",
+		},
+		{
+			name:       "invalid event is not retried",
+			task:       task{id: "a"},
+			failed:     map[string]string{"a": "bad schema"},
+			invalid:    map[string]string{"a": "bad schema"},
+			want:       outcomeDead,
+			wantReason: "bad schema",
+		},
+		{
+			name:   "other task failed",
+			task:   task{id: "a", attempts: 2},
+			failed: map[string]string{"b": "broker down"},
+			want:   outcomeSent,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, reason := r.resolve(tc.task, tc.failed, tc.invalid)
+			if got != tc.want || reason != tc.wantReason {
+				t.Fatalf("resolve() = (%d, %q), want (%d, %q)", got, reason, tc.want, tc.wantReason)
+			}
+		})
+	}
+}
+
+// Тесты ниже идут на БД сервиса users с примененными миграциями (переменные DB_*),
+// без нее пропускаются.
+func testDB(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+
+	if os.Getenv("DB_HOST") == "" {
+		t.Skip("DB_HOST is not set")
+	}
+
+	ctx := context.Background()
+	db, err := pgxpool.New(ctx, DatabaseURL())
+	if err != nil {
+		t.Skipf("connect: %v", err)
+	}
+	if err := db.Ping(ctx); err != nil {
+		db.Close()
+		t.Skipf("ping: %v", err)
+	}
+	t.Cleanup(db.Close)
+
+	clean := func() {
+		if _, err := db.Exec(ctx, `TRUNCATE deffered_tasks, deffered_tasks_dead`); err != nil {
+			t.Fatalf("truncate: %v", err)
+		}
+	}
+	clean()
+	t.Cleanup(clean)
+
+	return db
+}
+
+func insertTask(t *testing.T, db *pgxpool.Pool, key string, createdAt time.Time) string {
+	t.Helper()
+
+	var id string
+	err := db.QueryRow(context.Background(),
+		`INSERT INTO deffered_tasks(topic, key, data, created_at) VALUES ('songs_actions', $1, '{}', $2) RETURNING id`,
+		key, createdAt).Scan(&id)
+	if err != nil {
+		t.Fatalf("insert task: %v", err)
+	}
+
+	return id
+}
+
+func newTestRelay(db *pgxpool.Pool, instance string) *Relay {
+	return NewRelay(db, nil, Config{
+		BatchSize:     10,
+		MaxBackoff:    time.Minute,
+		MaxAttempts:   3,
+		InstanceID:    instance,
+		LeaseDuration: time.Minute,
+	})
+}
+
+func claimedIDs(t *testing.T, r *Relay) map[string]bool {
+	t.Helper()
+
+	tasks, err := r.claim(context.Background())
+	if err != nil {
+		t.Fatalf("claim: %v", err)
+	}
+
+	ids := make(map[string]bool, len(tasks))
+	for _, task := range tasks {
+		ids[task.id] = true
+	}
+
+	return ids
+}
+
+func TestClaimOrderAndLease(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+
+	base := time.Now().Add(-time.Hour)
+	first := insertTask(t, db, "user-1", base)
+	second := insertTask(t, db, "user-1", base.Add(time.Second))
+	other := insertTask(t, db, "user-2", base.Add(2*time.Second))
+	noKeyA := insertTask(t, db, "", base.Add(3*time.Second))
+	noKeyB := insertTask(t, db, "", base.Add(4*time.Second))
+
+	a := newTestRelay(db, "a")
+	b := newTestRelay(db, "b")
+
+	// Из ключа берется только самая старая задача, задачи без ключа берутся все
+	got := claimedIDs(t, a)
+	want := []string{first, other, noKeyA, noKeyB}
+	if len(got) != len(want) {
+		t.Fatalf("claimed %d tasks, want %d", len(got), len(want))
+	}
+	for _, id := range want {
+		if !got[id] {
+			t.Fatalf("task %s not claimed", id)
+		}
+	}
+	if got[second] {
+		t.Fatal("second task of a key claimed before the first is sent")
+	}
+
+	// Пока аренда действует, другой экземпляр ничего не получает
+	if got := claimedIDs(t, b); len(got) != 0 {
+		t.Fatalf("claimed %d leased tasks", len(got))
+	}
+
+	// Истекшую аренду забирает другой экземпляр; следующая задача ключа все еще ждет
+	if _, err := db.Exec(ctx, `UPDATE deffered_tasks SET locked_until = now() - interval '1 second' WHERE id = $1`, first); err != nil {
+		t.Fatalf("expire lease: %v", err)
+	}
+	got = claimedIDs(t, b)
+	if len(got) != 1 || !got[first] {
+		t.Fatalf("claimed %v after lease expired, want only %s", got, first)
+	}
+
+	// После отправки первой задачи ключа доступна вторая
+	if err := b.record(ctx, []task{{id: first}}, map[string]string{}, nil); err != nil {
+		t.Fatalf("record: %v", err)
+	}
+	got = claimedIDs(t, b)
+	if len(got) != 1 || !got[second] {
+		t.Fatalf("claimed %v after first sent, want only %s", got, second)
+	}
+}
+
+func TestRecord(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+
+	base := time.Now().Add(-time.Hour)
+	sent := insertTask(t, db, "", base)
+	retry := insertTask(t, db, "", base.Add(time.Second))
+	exhausted := insertTask(t, db, "", base.Add(2*time.Second))
+	invalid := insertTask(t, db, "", base.Add(3*time.Second))
+	if _, err := db.Exec(ctx, `UPDATE deffered_tasks SET attempts = 2 WHERE id = $1`, exhausted); err != nil {
+		t.Fatalf("set attempts: %v", err)
+	}
+
+	r := newTestRelay(db, "a")
+	tasks, err := r.claim(ctx)
+	if err != nil {
+		t.Fatalf("claim: %v", err)
+	}
+	if len(tasks) != 4 {
+		t.Fatalf("claimed %d tasks, want 4", len(tasks))
+	}
+
+	failed := map[string]string{retry: "timeout", exhausted: "timeout", invalid: "bad schema"}
+	if err := r.record(ctx, tasks, failed, map[string]string{invalid: "bad schema"}); err != nil {
+		t.Fatalf("record: %v", err)
+	}
+
+	var (
+		status    string
+		attempts  int
+		lockedBy  *string
+		delayed   bool
+		lastError string
+	)
+	err = db.QueryRow(ctx,
+		`SELECT status, attempts, locked_by, next_attempt_at > now(), last_error FROM deffered_tasks WHERE id = $1`,
+		sent).Scan(&status, &attempts, &lockedBy, &delayed, &lastError)
+	if err != nil {
+		t.Fatalf("select sent: %v", err)
+	}
+	if status != "sent" || attempts != 1 || lockedBy != nil {
+		t.Fatalf("sent task: status=%s attempts=%d locked_by=%v", status, attempts, lockedBy)
+	}
+
+	err = db.QueryRow(ctx,
+		`SELECT status, attempts, locked_by, next_attempt_at > now(), last_error FROM deffered_tasks WHERE id = $1`,
+		retry).Scan(&status, &attempts, &lockedBy, &delayed, &lastError)
+	if err != nil {
+		t.Fatalf("select retry: %v", err)
+	}
+	if status != "pending" || attempts != 1 || lockedBy != nil || !delayed || lastError != "timeout" {
+		t.Fatalf("retried task: status=%s attempts=%d locked_by=%v delayed=%v error=%q", status, attempts, lockedBy, delayed, lastError)
+	}
+
+	for id, reason := range map[string]string{exhausted: "timeout", invalid: "bad schema"} {
+		var left int
+		if err := db.QueryRow(ctx, `SELECT count(*) FROM deffered_tasks WHERE id = $1`, id).Scan(&left); err != nil {
+			t.Fatalf("count: %v", err)
+		}
+		if left != 0 {
+			t.Fatalf("dead task %s left in deffered_tasks", id)
+		}
+
+		var source string
+		err := db.QueryRow(ctx, `SELECT source, last_error FROM deffered_tasks_dead WHERE id = $1`, id).Scan(&source, &lastError)
+		if err != nil {
+			t.Fatalf("select dead %s: %v", id, err)
+		}
+		if source != "outbox" || lastError != reason {
+			t.Fatalf("dead task %s: source=%s error=%q", id, source, lastError)
+		}
+	}
+}
+
+func TestRecordLostLease(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+
+	id := insertTask(t, db, "", time.Now().Add(-time.Hour))
+
+	a := newTestRelay(db, "a")
+	tasks, err := a.claim(ctx)
+	if err != nil || len(tasks) != 1 {
+		t.Fatalf("claim: %d tasks, %v", len(tasks), err)
+	}
+
+	// Аренда истекла, задачу забрал b
+	if _, err := db.Exec(ctx, `UPDATE deffered_tasks SET locked_until = now() - interval '1 second' WHERE id = $1`, id); err != nil {
+		t.Fatalf("expire lease: %v", err)
+	}
+	if got := claimedIDs(t, newTestRelay(db, "b")); !got[id] {
+		t.Fatal("expired task not claimed by another instance")
+	}
+
+	// Результат a не затирает аренду b
+	if err := a.record(ctx, tasks, map[string]string{}, nil); err != nil {
+		t.Fatalf("record: %v", err)
+	}
+
+	var (
+		status   string
+		lockedBy string
+	)
+	if err := db.QueryRow(ctx, `SELECT status, locked_by FROM deffered_tasks WHERE id = $1`, id).Scan(&status, &lockedBy); err != nil {
+		t.Fatalf("select: %v", err)
+	}
+	if status != "pending" || lockedBy != "b" {
+		t.Fatalf("status=%s locked_by=%s, want pending and b", status, lockedBy)
+	}
+}
@@ -2,16 +2,23 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
+	musicclient "github.com/Cwby333/user-microservice/internal/adapters/musicClient"
 	"github.com/Cwby333/user-microservice/internal/adapters/repository/postgres"
+	s3storage "github.com/Cwby333/user-microservice/internal/adapters/storage/s3"
 	"github.com/Cwby333/user-microservice/internal/adapters/tokenStorage/redis"
+	grpcserver "github.com/Cwby333/user-microservice/internal/adapters/transport/grpc/server"
+	usershandler "github.com/Cwby333/user-microservice/internal/adapters/transport/grpc/usersHandler"
 	"github.com/Cwby333/user-microservice/internal/adapters/transport/http/server"
 	userrouter "github.com/Cwby333/user-microservice/internal/adapters/transport/http/userRouter"
+	musicevents "github.com/Cwby333/user-microservice/internal/adapters/transport/kafka/musicEvents"
 	"github.com/Cwby333/user-microservice/internal/config"
 	"github.com/Cwby333/user-microservice/internal/migrations"
 	userservice "github.com/Cwby333/user-microservice/internal/service/userService"
@@ -83,7 +90,34 @@ func main() {
 	}
 	userService := userservice.New(pg, pg, redis, redis, cfgJWT)
 
-	userRouter := userrouter.New(userService, userService, logger)
+	storage, err := s3storage.New(s3storage.Config{
+		AccessKey: cfg.Export.S3.AccessKey,
+		SecretKey: cfg.Export.S3.SecretKey,
+		Region:    cfg.Export.S3.Region,
+		Endpoint:  cfg.Export.S3.Endpoint,
+		Bucket:    cfg.Export.S3.Bucket,
+	})
+	if err != nil {
+		logger.Error("s3 storage", slog.String("error", err.Error()))
+		return
+	}
+
+	music := musicclient.New(musicclient.Config{
+		BaseURL:      cfg.Export.MusicURL,
+		ServiceToken: cfg.GRPC.ServiceToken,
+		Timeout:      30 * time.Second,
+	})
+
+	cfgExport := userservice.ExportConfig{
+		Interval:   cfg.Export.Interval,
+		LinkTTL:    cfg.Export.LinkTTL,
+		StaleAfter: cfg.Export.StaleAfter,
+	}
+	exporter := userservice.NewExporter(pg, music, storage, cfgExport)
+
+	social := userservice.NewSocial(pg)
+
+	userRouter := userrouter.New(userService, userService, exporter, social, logger)
 	userRouter.Run()
 
 	cfgServer := server.Config{
@@ -94,13 +128,62 @@ func main() {
 
 	serv := server.New(cfgServer, userRouter.Mux)
 
+	cfgGRPC := grpcserver.Config{
+		Address:      cfg.GRPC.Address,
+		ServiceToken: cfg.GRPC.ServiceToken,
+		CertFile:     cfg.GRPC.TLS.CertFile,
+		KeyFile:      cfg.GRPC.TLS.KeyFile,
+		CAFile:       cfg.GRPC.TLS.CAFile,
+	}
+
+	grpcServ, err := grpcserver.New(cfgGRPC, usershandler.New(userService))
+	if err != nil {
+		logger.Error("grpc server", slog.String("error", err.Error()))
+		return
+	}
+
+	cfgDeletion := userservice.DeletionConfig{
+		GracePeriod: cfg.Deletion.GracePeriod,
+		Interval:    cfg.Deletion.EraseInterval,
+		BatchSize:   cfg.Deletion.BatchSize,
+	}
+	eraser, err := userservice.NewEraser(pg, storage, cfgDeletion)
+	if err != nil {
+		logger.Error("eraser", slog.String("error", err.Error()))
+		return
+	}
+
+	cfgConsumer := musicevents.Config{
+		Brokers: cfg.Kafka.Brokers,
+		GroupID: cfg.Kafka.GroupID,
+		Topic:   cfg.Kafka.MusicEventsTopic,
+	}
+	consumer := musicevents.New(cfgConsumer, social, logger)
+
 	g, gCtx := errgroup.WithContext(ctx)
 
+	g.Go(func() error {
+		return consumer.Run(gCtx)
+	})
+
+	g.Go(func() error {
+		return eraser.Run(gCtx)
+	})
+
+	g.Go(func() error {
+		return exporter.Run(gCtx)
+	})
+
 	g.Go(func() error {
 		logger.Info("server start", slog.String("address", cfg.Server.Address))
 		return serv.Server.ListenAndServe()
 	})
 
+	g.Go(func() error {
+		logger.Info("grpc server start", slog.String("address", cfg.GRPC.Address))
+		return grpcServ.ListenAndServe()
+	})
+
 	g.Go(func() error {
 		<-gCtx.Done()
 		logger.Info("start shutdown")
@@ -108,7 +191,7 @@ func main() {
 		ctxShutdown, canc := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
 		defer canc()
 
-		return serv.Server.Shutdown(ctxShutdown)
+		return errors.Join(serv.Server.Shutdown(ctxShutdown), grpcServ.Shutdown(ctxShutdown))
 	})
 
 	if err := g.Wait(); err != nil {
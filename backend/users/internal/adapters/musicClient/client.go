@@ -0,0 +1,91 @@
+package musicclient
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Cwby333/user-microservice/internal/models"
+
+	gojson "github.com/goccy/go-json"
+)
+
+type Config struct {
+	BaseURL      string
+	ServiceToken string
+	Timeout      time.Duration
+}
+
+// Client calls internal endpoints of the music service, they are not proxied by the gateway.
+type Client struct {
+	http         *http.Client
+	baseURL      string
+	serviceToken string
+}
+
+type trackDTO struct {
+	ID        string    `json:"id"`
+	Title     string    `json:"title"`
+	ArtistID  string    `json:"artist_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type userDataResponse struct {
+	Likes   []trackDTO `json:"likes"`
+	Uploads []trackDTO `json:"uploads"`
+}
+
+func New(cfg Config) Client {
+	return Client{
+		http:         &http.Client{Timeout: cfg.Timeout},
+		baseURL:      cfg.BaseURL,
+		serviceToken: cfg.ServiceToken,
+	}
+}
+
+func (c Client) GetUserData(ctx context.Context, userID string) (models.MusicData, error) {
+	const op = "./internal/adapters/musicClient/client.go.GetUserData"
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/internal/export/%s", c.baseURL, userID), nil)
+	if err != nil {
+		return models.MusicData{}, fmt.Errorf("%s: %w", op, err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.serviceToken)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return models.MusicData{}, fmt.Errorf("%s: %w", op, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return models.MusicData{}, fmt.Errorf("%s: unexpected status %d", op, resp.StatusCode)
+	}
+
+	var data userDataResponse
+	err = gojson.NewDecoder(resp.Body).Decode(&data)
+	if err != nil {
+		return models.MusicData{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return models.MusicData{
+		Likes:   toTracks(data.Likes),
+		Uploads: toTracks(data.Uploads),
+	}, nil
+}
+
+func toTracks(dto []trackDTO) []models.Track {
+	tracks := make([]models.Track, 0, len(dto))
+
+	for i := range dto {
+		tracks = append(tracks, models.Track{
+			ID:        dto[i].ID,
+			Title:     dto[i].Title,
+			ArtistID:  dto[i].ArtistID,
+			CreatedAt: dto[i].CreatedAt,
+		})
+	}
+
+	return tracks
+}
@@ -7,11 +7,20 @@ import (
 	"github.com/Cwby333/user-microservice/internal/models"
 )
 
+// DefferedTasksChannel is the NOTIFY channel the sender listens on to pick up new tasks
+// without waiting for its next poll.
+const DefferedTasksChannel = "deffered_tasks"
+
+// queryCreateTask inserts a task and notifies DefferedTasksChannel. The notification is
+// delivered on commit, so the sender never wakes up before the row is visible.
+const queryCreateTask = `WITH task AS (
+	INSERT INTO deffered_tasks(topic, data, created_at, key) VALUES($1, $2, $3, $4) RETURNING id
+) SELECT pg_notify('` + DefferedTasksChannel + `', id::text) FROM task`
+
 func (pg Postgres) Create(ctx context.Context, task models.DefferedTask) error {
 	const op = "./internal/adapter/repository/postgres/defferedTasks.go.Create"
-	const query = `INSERT INTO deffered_tasks(topic, data, created_at) VALUES($1, $2, $3)`
 
-	_, err := pg.Pool.Exec(ctx, query, task.Topic, task.Data, task.CreatedAt)
+	_, err := pg.Pool.Exec(ctx, queryCreateTask, task.Topic, task.Data, task.CreatedAt, task.Key)
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
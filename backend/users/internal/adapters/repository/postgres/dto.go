@@ -1,6 +1,8 @@
 package postgres
 
 import (
+	"time"
+
 	"github.com/Cwby333/user-microservice/internal/models"
 )
 
@@ -11,6 +13,8 @@ type UserDTO struct {
 	Role     string `db:"role"`
 	Email    string `db:"email"`
 	VersionCredentials int `db:"version_credentials"`
+	DeletedAt *time.Time `db:"deleted_at"`
+	BannedAt *time.Time `db:"banned_at"`
 }
 
 func ToUserDTO(u models.User) UserDTO {
@@ -22,6 +26,12 @@ func ToUserDTO(u models.User) UserDTO {
 		Email:    u.Email,
 		VersionCredentials: u.VersionCredentials,
 	}
+	if !u.DeletedAt.IsZero() {
+		userDTO.DeletedAt = &u.DeletedAt
+	}
+	if !u.BannedAt.IsZero() {
+		userDTO.BannedAt = &u.BannedAt
+	}
 
 	return userDTO
 }
@@ -35,6 +45,12 @@ func DTOToUser(u UserDTO) models.User {
 		Email:    u.Email,
 		VersionCredentials: u.VersionCredentials,
 	}
+	if u.DeletedAt != nil {
+		user.DeletedAt = *u.DeletedAt
+	}
+	if u.BannedAt != nil {
+		user.BannedAt = *u.BannedAt
+	}
 
 	return user
 }
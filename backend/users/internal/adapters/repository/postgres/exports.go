@@ -0,0 +1,149 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	allerrors "github.com/Cwby333/user-microservice/internal/allErrors"
+	"github.com/Cwby333/user-microservice/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+const exportColumns = `id, user_id, status, file_key, error, created_at, finished_at`
+
+type ExportDTO struct {
+	ID         string     `db:"id"`
+	UserID     string     `db:"user_id"`
+	Status     string     `db:"status"`
+	FileKey    string     `db:"file_key"`
+	Error      string     `db:"error"`
+	CreatedAt  time.Time  `db:"created_at"`
+	FinishedAt *time.Time `db:"finished_at"`
+}
+
+func DTOToExport(e ExportDTO) models.DataExport {
+	export := models.DataExport{
+		ID:        e.ID,
+		UserID:    e.UserID,
+		Status:    e.Status,
+		FileKey:   e.FileKey,
+		Error:     e.Error,
+		CreatedAt: e.CreatedAt,
+	}
+	if e.FinishedAt != nil {
+		export.FinishedAt = *e.FinishedAt
+	}
+
+	return export
+}
+
+// CreateExport returns the user's unfinished export if there is one, so repeated
+// requests don't start several jobs. The active export can finish between the insert
+// and the select, then the insert is tried again.
+func (pg Postgres) CreateExport(ctx context.Context, userID string) (models.DataExport, error) {
+	const op = "./internal/adapters/postgres/exports.go.CreateExport"
+	const query = `INSERT INTO data_exports(user_id) VALUES($1)
+		ON CONFLICT (user_id) WHERE status IN ('pending', 'running') DO NOTHING
+		RETURNING ` + exportColumns
+	const queryActive = `SELECT ` + exportColumns + ` FROM data_exports WHERE user_id = $1 AND status IN ('pending', 'running')`
+	const maxAttempts = 3
+
+	for range maxAttempts {
+		export, err := pg.collectExport(ctx, query, userID)
+		if err == nil {
+			return export, nil
+		}
+		if !errors.Is(err, allerrors.ErrExportNotExists) {
+			return models.DataExport{}, fmt.Errorf("%s: %w", op, err)
+		}
+
+		export, err = pg.collectExport(ctx, queryActive, userID)
+		if err == nil {
+			return export, nil
+		}
+		if !errors.Is(err, allerrors.ErrExportNotExists) {
+			return models.DataExport{}, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return models.DataExport{}, fmt.Errorf("%s: %w", op, errors.New("active export keeps changing, try again"))
+}
+
+func (pg Postgres) GetExport(ctx context.Context, ID string) (models.DataExport, error) {
+	const op = "./internal/adapters/postgres/exports.go.GetExport"
+	const query = `SELECT ` + exportColumns + ` FROM data_exports WHERE id = $1`
+
+	export, err := pg.collectExport(ctx, query, ID)
+	if err != nil {
+		return models.DataExport{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return export, nil
+}
+
+// ClaimExport marks the oldest pending export as running and returns it. Exports left
+// running longer than staleAfter (the worker died) are picked up again.
+func (pg Postgres) ClaimExport(ctx context.Context, staleAfter time.Duration) (models.DataExport, error) {
+	const op = "./internal/adapters/postgres/exports.go.ClaimExport"
+	const query = `UPDATE data_exports SET status = 'running', started_at = now()
+		WHERE id = (
+			SELECT id FROM data_exports
+			WHERE status = 'pending' OR (status = 'running' AND started_at < $1)
+			ORDER BY created_at LIMIT 1 FOR UPDATE SKIP LOCKED)
+		RETURNING ` + exportColumns
+
+	export, err := pg.collectExport(ctx, query, time.Now().Add(-staleAfter))
+	if err != nil {
+		if errors.Is(err, allerrors.ErrExportNotExists) {
+			return models.DataExport{}, fmt.Errorf("%s: %w", op, allerrors.ErrNoPendingExports)
+		}
+
+		return models.DataExport{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return export, nil
+}
+
+func (pg Postgres) FinishExport(ctx context.Context, ID string, fileKey string) error {
+	const op = "./internal/adapters/postgres/exports.go.FinishExport"
+	const query = `UPDATE data_exports SET status = 'done', file_key = $1, finished_at = now() WHERE id = $2`
+
+	_, err := pg.Pool.Exec(ctx, query, fileKey, ID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (pg Postgres) FailExport(ctx context.Context, ID string, reason string) error {
+	const op = "./internal/adapters/postgres/exports.go.FailExport"
+	const query = `UPDATE data_exports SET status = 'failed', error = $1, finished_at = now() WHERE id = $2`
+
+	_, err := pg.Pool.Exec(ctx, query, reason, ID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (pg Postgres) collectExport(ctx context.Context, query string, args ...any) (models.DataExport, error) {
+	rows, err := pg.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return models.DataExport{}, err
+	}
+
+	DTO, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[ExportDTO])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.DataExport{}, allerrors.ErrExportNotExists
+		}
+
+		return models.DataExport{}, err
+	}
+
+	return DTOToExport(DTO), nil
+}
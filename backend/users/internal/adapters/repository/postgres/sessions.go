@@ -0,0 +1,57 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Cwby333/user-microservice/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+type SessionDTO struct {
+	ID        string    `db:"id"`
+	UserID    string    `db:"user_id"`
+	TokenID   string    `db:"token_id"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+func (pg Postgres) CreateSession(ctx context.Context, session models.Session) error {
+	const op = "./internal/adapters/postgres/sessions.go.CreateSession"
+	const query = `INSERT INTO user_sessions(user_id, token_id, created_at) VALUES($1, $2, $3)`
+
+	_, err := pg.Pool.Exec(ctx, query, session.UserID, session.TokenID, session.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (pg Postgres) GetSessionsByUserID(ctx context.Context, userID string) ([]models.Session, error) {
+	const op = "./internal/adapters/postgres/sessions.go.GetSessionsByUserID"
+	const query = `SELECT id, user_id, token_id, created_at FROM user_sessions WHERE user_id = $1 ORDER BY created_at`
+
+	rows, err := pg.Pool.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	sliceDTO, err := pgx.CollectRows(rows, pgx.RowToStructByName[SessionDTO])
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	sessions := make([]models.Session, 0, len(sliceDTO))
+
+	for i := range sliceDTO {
+		sessions = append(sessions, models.Session{
+			ID:        sliceDTO[i].ID,
+			UserID:    sliceDTO[i].UserID,
+			TokenID:   sliceDTO[i].TokenID,
+			CreatedAt: sliceDTO[i].CreatedAt,
+		})
+	}
+
+	return sessions, nil
+}
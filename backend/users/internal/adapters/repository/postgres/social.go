@@ -0,0 +1,253 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Cwby333/user-microservice/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+type FollowDTO struct {
+	UserID    string    `db:"user_id"`
+	Username  string    `db:"username"`
+	Kind      string    `db:"kind"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+type FeedItemDTO struct {
+	ID        int64     `db:"id"`
+	EventID   string    `db:"event_id"`
+	Kind      string    `db:"kind"`
+	ActorID   string    `db:"actor_id"`
+	ActorName *string   `db:"actor_name"`
+	ObjectID  string    `db:"object_id"`
+	Title     string    `db:"title"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+func DTOToFeedItem(f FeedItemDTO) models.FeedItem {
+	item := models.FeedItem{
+		ID:        f.ID,
+		EventID:   f.EventID,
+		Kind:      f.Kind,
+		ActorID:   f.ActorID,
+		ObjectID:  f.ObjectID,
+		Title:     f.Title,
+		CreatedAt: f.CreatedAt,
+	}
+	if f.ActorName != nil {
+		item.ActorName = *f.ActorName
+	}
+
+	return item
+}
+
+// Follow is idempotent: following twice keeps the first created_at.
+func (pg Postgres) Follow(ctx context.Context, followerID string, followeeID string, kind string) error {
+	const op = "./internal/adapters/postgres/social.go.Follow"
+	const query = `INSERT INTO follows(follower_id, followee_id, kind) VALUES($1, $2, $3) ON CONFLICT DO NOTHING`
+
+	_, err := pg.Pool.Exec(ctx, query, followerID, followeeID, kind)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (pg Postgres) Unfollow(ctx context.Context, followerID string, followeeID string, kind string) error {
+	const op = "./internal/adapters/postgres/social.go.Unfollow"
+	const query = `DELETE FROM follows WHERE follower_id = $1 AND followee_id = $2 AND kind = $3`
+
+	_, err := pg.Pool.Exec(ctx, query, followerID, followeeID, kind)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// CountFollows counts only accounts that are not deleted, like the lists do.
+func (pg Postgres) CountFollows(ctx context.Context, userID string) (models.FollowCounts, error) {
+	const op = "./internal/adapters/postgres/social.go.CountFollows"
+	const queryFollowers = `SELECT f.kind, COUNT(*) FROM follows f
+		JOIN users u ON u.id = f.follower_id AND u.deleted_at IS NULL
+		WHERE f.followee_id = $1 GROUP BY f.kind`
+	const queryFollowing = `SELECT f.kind, COUNT(*) FROM follows f
+		JOIN users u ON u.id = f.followee_id AND u.deleted_at IS NULL
+		WHERE f.follower_id = $1 GROUP BY f.kind`
+
+	counts := models.FollowCounts{
+		Followers: map[string]int{models.FollowUser: 0, models.FollowArtist: 0},
+		Following: map[string]int{models.FollowUser: 0, models.FollowArtist: 0},
+	}
+
+	for query, dst := range map[string]map[string]int{queryFollowers: counts.Followers, queryFollowing: counts.Following} {
+		rows, err := pg.Pool.Query(ctx, query, userID)
+		if err != nil {
+			return models.FollowCounts{}, fmt.Errorf("%s: %w", op, err)
+		}
+
+		var kind string
+		var count int
+		_, err = pgx.ForEachRow(rows, []any{&kind, &count}, func() error {
+			dst[kind] = count
+			return nil
+		})
+		if err != nil {
+			return models.FollowCounts{}, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return counts, nil
+}
+
+// GetFollowers returns the followers of kind, newest first, that followed before (beforeAt, beforeID).
+// Zero beforeAt means the first page.
+func (pg Postgres) GetFollowers(ctx context.Context, userID string, kind string, beforeAt time.Time, beforeID string, limit int) ([]models.Follow, error) {
+	const op = "./internal/adapters/postgres/social.go.GetFollowers"
+	const query = `SELECT f.follower_id AS user_id, u.username, f.kind, f.created_at FROM follows f
+		JOIN users u ON u.id = f.follower_id AND u.deleted_at IS NULL
+		WHERE f.followee_id = $1 AND f.kind = $2
+		AND ($3::timestamp IS NULL OR (f.created_at, f.follower_id) < ($3, $4::uuid))
+		ORDER BY f.created_at DESC, f.follower_id DESC LIMIT $5`
+
+	follows, err := pg.collectFollows(ctx, query, userID, kind, beforeAt, beforeID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return follows, nil
+}
+
+// GetFollowing is GetFollowers the other way round: whom the user follows.
+func (pg Postgres) GetFollowing(ctx context.Context, userID string, kind string, beforeAt time.Time, beforeID string, limit int) ([]models.Follow, error) {
+	const op = "./internal/adapters/postgres/social.go.GetFollowing"
+	const query = `SELECT f.followee_id AS user_id, u.username, f.kind, f.created_at FROM follows f
+		JOIN users u ON u.id = f.followee_id AND u.deleted_at IS NULL
+		WHERE f.follower_id = $1 AND f.kind = $2
+		AND ($3::timestamp IS NULL OR (f.created_at, f.followee_id) < ($3, $4::uuid))
+		ORDER BY f.created_at DESC, f.followee_id DESC LIMIT $5`
+
+	follows, err := pg.collectFollows(ctx, query, userID, kind, beforeAt, beforeID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return follows, nil
+}
+
+func (pg Postgres) collectFollows(ctx context.Context, query string, userID string, kind string, beforeAt time.Time, beforeID string, limit int) ([]models.Follow, error) {
+	var at *time.Time
+	var id *string
+	if !beforeAt.IsZero() {
+		at, id = &beforeAt, &beforeID
+	}
+
+	rows, err := pg.Pool.Query(ctx, query, userID, kind, at, id, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	sliceDTO, err := pgx.CollectRows(rows, pgx.RowToStructByName[FollowDTO])
+	if err != nil {
+		return nil, err
+	}
+
+	follows := make([]models.Follow, 0, len(sliceDTO))
+	for i := range sliceDTO {
+		follows = append(follows, models.Follow(sliceDTO[i]))
+	}
+
+	return follows, nil
+}
+
+// FilterFollowers returns those of userIDs that follow followeeID as kind.
+func (pg Postgres) FilterFollowers(ctx context.Context, followeeID string, kind string, userIDs []string) ([]string, error) {
+	const op = "./internal/adapters/postgres/social.go.FilterFollowers"
+	const query = `SELECT follower_id::text FROM follows WHERE followee_id = $1 AND kind = $2 AND follower_id = ANY($3::uuid[])`
+
+	rows, err := pg.Pool.Query(ctx, query, followeeID, kind, userIDs)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	followers, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return followers, nil
+}
+
+// AddToFeed puts the item into the feed of everyone following its actor as an artist
+// and returns how many feeds got it. A redelivered event is not added twice.
+func (pg Postgres) AddToFeed(ctx context.Context, item models.FeedItem) (int64, error) {
+	const op = "./internal/adapters/postgres/social.go.AddToFeed"
+	const query = `INSERT INTO feed_items(user_id, event_id, kind, actor_id, object_id, title, created_at)
+		SELECT follower_id, $1, $2, $3, $4, $5, $6 FROM follows
+		WHERE followee_id = $3 AND kind = 'artist'
+		ON CONFLICT (user_id, event_id) DO NOTHING`
+
+	tag, err := pg.Pool.Exec(ctx, query, item.EventID, item.Kind, item.ActorID, item.ObjectID, item.Title, item.CreatedAt)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+// SetFeedObjectHidden hides or shows again the feed items about the object in every feed.
+func (pg Postgres) SetFeedObjectHidden(ctx context.Context, objectID string, hidden bool) (int64, error) {
+	const op = "./internal/adapters/postgres/social.go.SetFeedObjectHidden"
+	const query = `UPDATE feed_items SET hidden = $2 WHERE object_id = $1 AND hidden <> $2`
+
+	tag, err := pg.Pool.Exec(ctx, query, objectID, hidden)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+// DeleteFeedObject removes the feed items about the object from every feed.
+func (pg Postgres) DeleteFeedObject(ctx context.Context, objectID string) (int64, error) {
+	const op = "./internal/adapters/postgres/social.go.DeleteFeedObject"
+	const query = `DELETE FROM feed_items WHERE object_id = $1`
+
+	tag, err := pg.Pool.Exec(ctx, query, objectID)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+// GetFeed returns the user's feed, newest first, with items older than beforeID (0 — first page).
+func (pg Postgres) GetFeed(ctx context.Context, userID string, beforeID int64, limit int) ([]models.FeedItem, error) {
+	const op = "./internal/adapters/postgres/social.go.GetFeed"
+	const query = `SELECT f.id, f.event_id, f.kind, f.actor_id, u.username AS actor_name, f.object_id, f.title, f.created_at
+		FROM feed_items f
+		LEFT JOIN users u ON u.id = f.actor_id AND u.deleted_at IS NULL
+		WHERE f.user_id = $1 AND NOT f.hidden AND ($2 = 0 OR f.id < $2)
+		ORDER BY f.id DESC LIMIT $3`
+
+	rows, err := pg.Pool.Query(ctx, query, userID, beforeID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	sliceDTO, err := pgx.CollectRows(rows, pgx.RowToStructByName[FeedItemDTO])
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	items := make([]models.FeedItem, 0, len(sliceDTO))
+	for i := range sliceDTO {
+		items = append(items, DTOToFeedItem(sliceDTO[i]))
+	}
+
+	return items, nil
+}
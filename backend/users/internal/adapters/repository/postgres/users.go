@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	allerrors "github.com/Cwby333/user-microservice/internal/allErrors"
 	"github.com/Cwby333/user-microservice/internal/models"
@@ -53,7 +54,7 @@ func (pg Postgres) CreateUser(ctx context.Context, user models.User) (models.Use
 
 func (pg Postgres) GetUserByID(ctx context.Context, ID string) (models.User, error) {
 	const op = "./internal/adapters/postgres/users.go.GetUserByUsername"
-	const query = `SELECT id, username, password, email, role, version_credentials FROM users WHERE id = $1`
+	const query = `SELECT id, username, password, email, role, version_credentials, deleted_at, banned_at FROM users WHERE id = $1 AND deleted_at IS NULL`
 
 	rows, err := pg.Pool.Query(ctx, query, ID)
 	if err != nil {
@@ -78,9 +79,32 @@ func (pg Postgres) GetUserByID(ctx context.Context, ID string) (models.User, err
 	return user, nil
 }
 
+func (pg Postgres) GetUsersByIDs(ctx context.Context, IDs []string) ([]models.User, error) {
+	const op = "./internal/adapters/postgres/users.go.GetUsersByIDs"
+	const query = `SELECT id, username, password, email, role, version_credentials, deleted_at, banned_at FROM users WHERE id = ANY($1) AND deleted_at IS NULL`
+
+	rows, err := pg.Pool.Query(ctx, query, IDs)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	sliceDTO, err := pgx.CollectRows(rows, pgx.RowToStructByName[UserDTO])
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	sliceUsers := make([]models.User, 0, len(sliceDTO))
+
+	for i := range sliceDTO {
+		sliceUsers = append(sliceUsers, DTOToUser(sliceDTO[i]))
+	}
+
+	return sliceUsers, nil
+}
+
 func (pg Postgres) GetUserByUsername(ctx context.Context, username string) (models.User, error) {
 	const op = "./internal/adapters/postgres/users.go.GetUserByUsername"
-	const query = `SELECT id, username, password, email, role, version_credentials FROM users WHERE username = $1`
+	const query = `SELECT id, username, password, email, role, version_credentials, deleted_at, banned_at FROM users WHERE username = $1`
 
 	rows, err := pg.Pool.Query(ctx, query, username)
 	if err != nil {
@@ -107,7 +131,7 @@ func (pg Postgres) GetUserByUsername(ctx context.Context, username string) (mode
 
 func (pg Postgres) GetAllUsers(ctx context.Context) ([]models.User, error) {
 	const op = "./internal/adapters/postgres/users.go.GetUserByUsername"
-	const query = `SELECT id, username, password, email, role, version_credentials FROM users`
+	const query = `SELECT id, username, password, email, role, version_credentials, deleted_at, banned_at FROM users WHERE deleted_at IS NULL`
 
 	rows, err := pg.Pool.Query(ctx, query)
 	if err != nil {
@@ -136,9 +160,11 @@ func (pg Postgres) GetAllUsers(ctx context.Context) ([]models.User, error) {
 	return sliceUsers, nil
 }
 
+// DeleteUserByID only marks the user as deleted, the row is removed by EraseDeletedUsers
+// after the grace period. Bumping the version invalidates all issued tokens.
 func (pg Postgres) DeleteUserByID(ctx context.Context, ID string) error {
 	const op = "./internal/adapters/postgres/users.go.DeleteUserByID"
-	const query = `DELETE FROM users WHERE id = $1`
+	const query = `UPDATE users SET deleted_at = now(), version_credentials = version_credentials + 1 WHERE id = $1 AND deleted_at IS NULL`
 
 	rows, err := pg.Pool.Query(ctx, query, ID)
 	if err != nil {
@@ -155,9 +181,9 @@ func (pg Postgres) DeleteUserByID(ctx context.Context, ID string) error {
 
 func (pg Postgres) UpdateUserByID(ctx context.Context, ID string, newUserInfo models.User) (models.User, error) {
 	const op = "./internal/adapters/postgres/users.go.UpdateUserByID"
-	const query = `UPDATE users SET username = $1, role = $2, password = $3, email = $4, version_credentials = $5 WHERE id = $5`
+	const query = `UPDATE users SET username = $1, role = $2, password = $3, email = $4, version_credentials = $5 WHERE id = $6`
 
-	rows, err := pg.Pool.Query(ctx, query,
+	tag, err := pg.Pool.Exec(ctx, query,
 		newUserInfo.Username,
 		newUserInfo.Role,
 		newUserInfo.Password,
@@ -165,14 +191,145 @@ func (pg Postgres) UpdateUserByID(ctx context.Context, ID string, newUserInfo mo
 		newUserInfo.VersionCredentials,
 		ID,
 	)
+	if err != nil {
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return models.User{}, fmt.Errorf("%s: %w", op, allerrors.ErrUserNotExists)
+	}
+
+	return newUserInfo, nil
+}
+
+func (pg Postgres) RestoreUserByID(ctx context.Context, ID string) error {
+	const op = "./internal/adapters/postgres/users.go.RestoreUserByID"
+	const query = `UPDATE users SET deleted_at = NULL, version_credentials = version_credentials + 1 WHERE id = $1 AND deleted_at IS NOT NULL`
+
+	tag, err := pg.Pool.Exec(ctx, query, ID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %w", op, allerrors.ErrUserNotExists)
+	}
+
+	return nil
+}
+
+// EraseDeletedUsers removes up to limit users deleted before the given time. In the same
+// transaction it enqueues a user_deleted task for other services and writes an audit record,
+// so either all three happen or none. eraseFiles runs before the commit: if it fails the
+// user stays in place and is erased on the next run, so it must be safe to repeat.
+func (pg Postgres) EraseDeletedUsers(ctx context.Context, deletedBefore time.Time, limit int, newTask func(userID string) (models.DefferedTask, error), eraseFiles func(ctx context.Context, userID string) error) ([]string, error) {
+	const op = "./internal/adapters/postgres/users.go.EraseDeletedUsers"
+	const query = `DELETE FROM users WHERE id IN (
+		SELECT id FROM users WHERE deleted_at IS NOT NULL AND deleted_at < $1
+		ORDER BY deleted_at LIMIT $2 FOR UPDATE SKIP LOCKED)
+		RETURNING id, deleted_at`
+	const queryAudit = `INSERT INTO user_erasures(user_id, deleted_at) VALUES($1, $2)`
+	const querySessions = `DELETE FROM user_sessions WHERE user_id = $1`
+	const queryExports = `DELETE FROM data_exports WHERE user_id = $1`
+	const queryFollows = `DELETE FROM follows WHERE follower_id = $1 OR followee_id = $1`
+	const queryFeed = `DELETE FROM feed_items WHERE user_id = $1 OR actor_id = $1`
+
+	tx, err := pg.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, query, deletedBefore, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	type erased struct {
+		ID        string    `db:"id"`
+		DeletedAt time.Time `db:"deleted_at"`
+	}
+
+	users, err := pgx.CollectRows(rows, pgx.RowToStructByName[erased])
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	IDs := make([]string, 0, len(users))
+
+	for i := range users {
+		task, err := newTask(users[i].ID)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		_, err = tx.Exec(ctx, queryCreateTask, task.Topic, task.Data, task.CreatedAt, task.Key)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		_, err = tx.Exec(ctx, queryAudit, users[i].ID, users[i].DeletedAt)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		_, err = tx.Exec(ctx, querySessions, users[i].ID)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		_, err = tx.Exec(ctx, queryExports, users[i].ID)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		_, err = tx.Exec(ctx, queryFollows, users[i].ID)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		_, err = tx.Exec(ctx, queryFeed, users[i].ID)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		err = eraseFiles(ctx, users[i].ID)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		IDs = append(IDs, users[i].ID)
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return IDs, nil
+}
+
+// BanUserByID sets or clears the ban. Bumping the version invalidates all issued tokens,
+// so a banned user is logged out everywhere at once.
+func (pg Postgres) BanUserByID(ctx context.Context, ID string, banned bool) (models.User, error) {
+	const op = "./internal/adapters/postgres/users.go.BanUserByID"
+	const query = `UPDATE users SET banned_at = CASE WHEN $2 THEN now() ELSE NULL END, version_credentials = version_credentials + 1
+		WHERE id = $1 AND deleted_at IS NULL
+		RETURNING id, username, password, email, role, version_credentials, deleted_at, banned_at`
+
+	rows, err := pg.Pool.Query(ctx, query, ID, banned)
+	if err != nil {
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	DTO, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[UserDTO])
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return newUserInfo, nil
+			return models.User{}, fmt.Errorf("%s: %w", op, allerrors.ErrUserNotExists)
 		}
 
 		return models.User{}, fmt.Errorf("%s: %w", op, err)
 	}
-	rows.Close()
 
-	return newUserInfo, nil
+	return DTOToUser(DTO), nil
 }
@@ -0,0 +1,124 @@
+package s3storage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// Same S3-compatible storage the music service uses.
+type Config struct {
+	AccessKey string
+	SecretKey string
+	Region    string
+	Endpoint  string
+	Bucket    string
+}
+
+type Storage struct {
+	svc    *s3.S3
+	bucket string
+}
+
+func New(cfg Config) (*Storage, error) {
+	const op = "./internal/adapters/storage/s3/s3.go.New"
+
+	sess, err := session.NewSession(&aws.Config{
+		Credentials:      credentials.NewStaticCredentials(cfg.AccessKey, cfg.SecretKey, ""),
+		Region:           aws.String(cfg.Region),
+		Endpoint:         aws.String(cfg.Endpoint),
+		S3ForcePathStyle: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &Storage{
+		svc:    s3.New(sess),
+		bucket: cfg.Bucket,
+	}, nil
+}
+
+func (st *Storage) Upload(ctx context.Context, key string, data []byte, contentType string) error {
+	const op = "./internal/adapters/storage/s3/s3.go.Upload"
+
+	_, err := st.svc.PutObjectWithContext(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(st.bucket),
+		Key:         aws.String(key),
+		Body:        bytes.NewReader(data),
+		ContentType: aws.String(contentType),
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (st *Storage) PresignGet(key string, expires time.Duration) (string, error) {
+	const op = "./internal/adapters/storage/s3/s3.go.PresignGet"
+
+	req, _ := st.svc.GetObjectRequest(&s3.GetObjectInput{
+		Bucket: aws.String(st.bucket),
+		Key:    aws.String(key),
+	})
+
+	url, err := req.Presign(expires)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return url, nil
+}
+
+// DeletePrefix removes every object whose key starts with prefix. Missing objects are not an error.
+func (st *Storage) DeletePrefix(ctx context.Context, prefix string) error {
+	const op = "./internal/adapters/storage/s3/s3.go.DeletePrefix"
+
+	var deleteErr error
+
+	err := st.svc.ListObjectsV2PagesWithContext(ctx, &s3.ListObjectsV2Input{
+		Bucket: aws.String(st.bucket),
+		Prefix: aws.String(prefix),
+	}, func(page *s3.ListObjectsV2Output, _ bool) bool {
+		if len(page.Contents) == 0 {
+			return true
+		}
+
+		objects := make([]*s3.ObjectIdentifier, 0, len(page.Contents))
+		for _, obj := range page.Contents {
+			objects = append(objects, &s3.ObjectIdentifier{Key: obj.Key})
+		}
+
+		out, err := st.svc.DeleteObjectsWithContext(ctx, &s3.DeleteObjectsInput{
+			Bucket: aws.String(st.bucket),
+			Delete: &s3.Delete{Objects: objects, Quiet: aws.Bool(true)},
+		})
+		if err != nil {
+			deleteErr = err
+			return false
+		}
+
+		if len(out.Errors) > 0 {
+			deleteErr = fmt.Errorf("delete %s: %s", aws.StringValue(out.Errors[0].Key), aws.StringValue(out.Errors[0].Message))
+			return false
+		}
+
+		return true
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if deleteErr != nil {
+		return fmt.Errorf("%s: %w", op, deleteErr)
+	}
+
+	return nil
+}
@@ -12,6 +12,7 @@ import (
 
 const (
 	refreshStorage = "refresh:"
+	accessStorage  = "access:"
 )
 
 func (r Redis) InvalidRefresh(ctx context.Context, tokenID string, unixTime time.Time) error {
@@ -44,3 +45,34 @@ func (r Redis) CheckTokenInBlackList(ctx context.Context, tokenID string) error
 
 	return allerrors.ErrTokenInBlackList
 }
+
+func (r Redis) InvalidAccess(ctx context.Context, tokenID string, unixTime time.Time) error {
+	const op = "./internal/adapters/tokenStorage/redis/tokens.go.InvalidAccess"
+
+	cmd := r.client.HSet(ctx, accessStorage, tokenID, 1)
+	if cmd.Err() != nil {
+		return fmt.Errorf("%s: %w", op, cmd.Err())
+	}
+
+	cmd2 := r.client.HExpireAt(ctx, accessStorage, unixTime, tokenID)
+	if cmd2.Err() != nil {
+		return fmt.Errorf("%s: %w", op, cmd2.Err())
+	}
+
+	return nil
+}
+
+func (r Redis) CheckAccessInBlackList(ctx context.Context, tokenID string) error {
+	const op = "./internal/adapters/tokenStorage/redis/tokens.go.CheckAccessInBlackList"
+
+	cmd := r.client.HGet(ctx, accessStorage, tokenID)
+	if cmd.Err() != nil {
+		if errors.Is(cmd.Err(), redis.Nil) {
+			return nil
+		}
+
+		return fmt.Errorf("%s: %w", op, cmd.Err())
+	}
+
+	return allerrors.ErrAccessTokenInBlackList
+}
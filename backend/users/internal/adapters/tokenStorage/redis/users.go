@@ -23,6 +23,8 @@ type DTO struct {
 	Password string `json:"password"`
 	Email    string `json:"email"`
 	Role     string `json:"role"`
+
+	VersionCredentials int `json:"version_credentials"`
 }
 
 func (r Redis) Set(ctx context.Context, userID string, user models.User) error {
@@ -34,6 +36,8 @@ func (r Redis) Set(ctx context.Context, userID string, user models.User) error {
 		Password: user.Password,
 		Email:    user.Email,
 		Role:     user.Role,
+
+		VersionCredentials: user.VersionCredentials,
 	}
 	data, err := gojson.Marshal(dto)
 	if err != nil {
@@ -73,12 +77,19 @@ func (r Redis) Get(ctx context.Context, userID string) (models.User, error) {
 		slog.Info("gojson unmarshal", slog.String("error", err.Error()))
 	}
 
+	// versions start from 1, entries cached before the field existed are treated as a miss
+	if dto.VersionCredentials == 0 {
+		return models.User{}, fmt.Errorf("%s: %w", op, allerrors.ErrNotFoundInCache)
+	}
+
 	user := models.User{
 		ID:       dto.ID,
 		Username: dto.Username,
 		Password: dto.Password,
 		Email:    dto.Email,
 		Role:     dto.Role,
+
+		VersionCredentials: dto.VersionCredentials,
 	}
 
 	return user, nil
@@ -0,0 +1,46 @@
+package interceptor
+
+import (
+	"context"
+	"crypto/subtle"
+	"log/slog"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// ServiceToken checks the "authorization: Bearer <token>" metadata of every call.
+// An empty token disables the check, the server then relies on client certificates.
+func ServiceToken(token string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if token == "" {
+			return handler(ctx, req)
+		}
+
+		md, ok := metadata.FromIncomingContext(ctx)
+		if !ok {
+			slog.Info("grpc auth", slog.String("error", "missing metadata"), slog.String("method", info.FullMethod))
+
+			return nil, status.Error(codes.Unauthenticated, "missing service token")
+		}
+
+		values := md.Get("authorization")
+		if len(values) == 0 {
+			slog.Info("grpc auth", slog.String("error", "missing service token"), slog.String("method", info.FullMethod))
+
+			return nil, status.Error(codes.Unauthenticated, "missing service token")
+		}
+
+		got := strings.TrimPrefix(values[0], "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			slog.Info("grpc auth", slog.String("error", "wrong service token"), slog.String("method", info.FullMethod))
+
+			return nil, status.Error(codes.Unauthenticated, "wrong service token")
+		}
+
+		return handler(ctx, req)
+	}
+}
@@ -0,0 +1,66 @@
+package interceptor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestServiceToken(t *testing.T) {
+	const token = "service-token"
+
+	handler := func(ctx context.Context, req any) (any, error) {
+		return "ok", nil
+	}
+	info := &grpc.UnaryServerInfo{FullMethod: "/users.v1.UsersService/FindUserByID"}
+
+	testCases := []struct {
+		name         string
+		token        string
+		md           metadata.MD
+		expectedCode codes.Code
+	}{
+		{
+			name:         "valid token",
+			token:        token,
+			md:           metadata.Pairs("authorization", "Bearer "+token),
+			expectedCode: codes.OK,
+		},
+		{
+			name:         "wrong token",
+			token:        token,
+			md:           metadata.Pairs("authorization", "Bearer wrong"),
+			expectedCode: codes.Unauthenticated,
+		},
+		{
+			name:         "missing token",
+			token:        token,
+			md:           metadata.MD{},
+			expectedCode: codes.Unauthenticated,
+		},
+		{
+			name:         "check disabled",
+			token:        "",
+			md:           metadata.MD{},
+			expectedCode: codes.OK,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := metadata.NewIncomingContext(context.Background(), tc.md)
+
+			resp, err := ServiceToken(tc.token)(ctx, nil, info, handler)
+			require.Equal(t, tc.expectedCode, status.Code(err))
+
+			if tc.expectedCode == codes.OK {
+				require.Equal(t, "ok", resp)
+			}
+		})
+	}
+}
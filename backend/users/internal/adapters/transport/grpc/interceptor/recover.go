@@ -0,0 +1,24 @@
+package interceptor
+
+import (
+	"context"
+	"log/slog"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func Recover(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+	defer func() {
+		r := recover()
+		if r != nil {
+			slog.Info("recover interceptor", slog.Any("recover", r), slog.String("method", info.FullMethod))
+
+			resp = nil
+			err = status.Error(codes.Internal, "server error")
+		}
+	}()
+
+	return handler(ctx, req)
+}
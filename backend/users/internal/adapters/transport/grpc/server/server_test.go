@@ -0,0 +1,46 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewConfig(t *testing.T) {
+	testCases := []struct {
+		name    string
+		cfg     Config
+		wantErr bool
+	}{
+		{
+			name: "service token only",
+			cfg:  Config{Address: ":0", ServiceToken: "token"},
+		},
+		{
+			name:    "nothing configured",
+			cfg:     Config{Address: ":0"},
+			wantErr: true,
+		},
+		{
+			name:    "client CA without server cert",
+			cfg:     Config{Address: ":0", CAFile: "ca.pem"},
+			wantErr: true,
+		},
+		{
+			name:    "client CA without server key",
+			cfg:     Config{Address: ":0", ServiceToken: "token", CAFile: "ca.pem", CertFile: "cert.pem"},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := New(tc.cfg, nil)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package usershandler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	allerrors "github.com/Cwby333/user-microservice/internal/allErrors"
+	"github.com/Cwby333/user-microservice/internal/models"
+	usersv1 "github.com/Cwby333/user-microservice/pkg/api/users/v1"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// maxBatchSize limits FindUsersByIDs so one call can't scan the whole table.
+const maxBatchSize = 500
+
+type UserService interface {
+	FindUserByID(ctx context.Context, ID string) (models.User, error)
+	FindUsersByIDs(ctx context.Context, IDs []string) ([]models.User, error)
+
+	IntrospectToken(ctx context.Context, token string) (models.TokenIntrospection, error)
+	CheckRole(ctx context.Context, userID string, role string) (bool, error)
+}
+
+type Handler struct {
+	usersv1.UnimplementedUsersServiceServer
+	userService UserService
+}
+
+func New(userService UserService) *Handler {
+	return &Handler{
+		userService: userService,
+	}
+}
+
+func (h *Handler) FindUserByID(ctx context.Context, req *usersv1.FindUserByIDRequest) (*usersv1.FindUserByIDResponse, error) {
+	user, err := h.userService.FindUserByID(ctx, req.GetId())
+	if err != nil {
+		slog.Info("grpc findUserByID", slog.String("error", err.Error()))
+
+		return nil, toStatus(err)
+	}
+
+	return &usersv1.FindUserByIDResponse{
+		User: userToProto(user),
+	}, nil
+}
+
+func (h *Handler) FindUsersByIDs(ctx context.Context, req *usersv1.FindUsersByIDsRequest) (*usersv1.FindUsersByIDsResponse, error) {
+	if len(req.GetIds()) > maxBatchSize {
+		return nil, status.Errorf(codes.InvalidArgument, "too many ids, max %d", maxBatchSize)
+	}
+
+	users, err := h.userService.FindUsersByIDs(ctx, req.GetIds())
+	if err != nil {
+		slog.Info("grpc findUsersByIDs", slog.String("error", err.Error()))
+
+		return nil, toStatus(err)
+	}
+
+	out := make([]*usersv1.User, 0, len(users))
+	for i := range users {
+		out = append(out, userToProto(users[i]))
+	}
+
+	return &usersv1.FindUsersByIDsResponse{
+		Users: out,
+	}, nil
+}
+
+func (h *Handler) IntrospectToken(ctx context.Context, req *usersv1.IntrospectTokenRequest) (*usersv1.IntrospectTokenResponse, error) {
+	if req.GetToken() == "" {
+		return nil, status.Error(codes.InvalidArgument, "missing token")
+	}
+
+	info, err := h.userService.IntrospectToken(ctx, req.GetToken())
+	if err != nil {
+		slog.Info("grpc introspectToken", slog.String("error", err.Error()))
+
+		return nil, toStatus(err)
+	}
+
+	if !info.Active {
+		return &usersv1.IntrospectTokenResponse{Active: false}, nil
+	}
+
+	return &usersv1.IntrospectTokenResponse{
+		Active:    true,
+		Type:      info.Type,
+		Subject:   info.Subject,
+		Role:      info.Role,
+		TokenId:   info.TokenID,
+		IssuedAt:  info.IssuedAt.Unix(),
+		ExpiresAt: info.ExpiresAt.Unix(),
+	}, nil
+}
+
+func (h *Handler) CheckRole(ctx context.Context, req *usersv1.CheckRoleRequest) (*usersv1.CheckRoleResponse, error) {
+	if req.GetRole() == "" {
+		return nil, status.Error(codes.InvalidArgument, "missing role")
+	}
+
+	allowed, err := h.userService.CheckRole(ctx, req.GetUserId(), req.GetRole())
+	if err != nil {
+		slog.Info("grpc checkRole", slog.String("error", err.Error()))
+
+		return nil, toStatus(err)
+	}
+
+	return &usersv1.CheckRoleResponse{
+		Allowed: allowed,
+	}, nil
+}
+
+func userToProto(u models.User) *usersv1.User {
+	return &usersv1.User{
+		Id:       u.ID,
+		Username: u.Username,
+		Email:    u.Email,
+		Role:     u.Role,
+	}
+}
+
+func toStatus(err error) error {
+	switch {
+	case errors.Is(err, allerrors.ErrWrongUUID):
+		return status.Error(codes.InvalidArgument, "wrong user ID")
+	case errors.Is(err, allerrors.ErrUserNotExists):
+		return status.Error(codes.NotFound, "user not found")
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, "deadline exceeded")
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, "canceled")
+	default:
+		return status.Error(codes.Internal, "server error")
+	}
+}
@@ -0,0 +1,173 @@
+package usershandler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Cwby333/user-microservice/internal/adapters/transport/grpc/usersHandler/usersHandlerMocks"
+	allerrors "github.com/Cwby333/user-microservice/internal/allErrors"
+	"github.com/Cwby333/user-microservice/internal/models"
+	usersv1 "github.com/Cwby333/user-microservice/pkg/api/users/v1"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestFindUserByID(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserService := usersHandlerMocks.NewMockUserService(ctrl)
+	handler := New(mockUserService)
+
+	userID := uuid.NewString()
+
+	testCases := []struct {
+		name         string
+		mockSetup    func()
+		expectedCode codes.Code
+	}{
+		{
+			name: "success",
+			mockSetup: func() {
+				mockUserService.EXPECT().FindUserByID(gomock.Any(), userID).Return(models.User{
+					ID:       userID,
+					Username: "username",
+					Password: "hash",
+					Role:     "user",
+				}, nil)
+			},
+			expectedCode: codes.OK,
+		},
+		{
+			name: "user not found",
+			mockSetup: func() {
+				mockUserService.EXPECT().FindUserByID(gomock.Any(), userID).Return(models.User{}, allerrors.ErrUserNotExists)
+			},
+			expectedCode: codes.NotFound,
+		},
+		{
+			name: "wrong uuid",
+			mockSetup: func() {
+				mockUserService.EXPECT().FindUserByID(gomock.Any(), userID).Return(models.User{}, allerrors.ErrWrongUUID)
+			},
+			expectedCode: codes.InvalidArgument,
+		},
+		{
+			name: "server error",
+			mockSetup: func() {
+				mockUserService.EXPECT().FindUserByID(gomock.Any(), userID).Return(models.User{}, errors.New("db down"))
+			},
+			expectedCode: codes.Internal,
+		},
+		{
+			name: "deadline exceeded",
+			mockSetup: func() {
+				mockUserService.EXPECT().FindUserByID(gomock.Any(), userID).Return(models.User{}, context.DeadlineExceeded)
+			},
+			expectedCode: codes.DeadlineExceeded,
+		},
+		{
+			name: "canceled",
+			mockSetup: func() {
+				mockUserService.EXPECT().FindUserByID(gomock.Any(), userID).Return(models.User{}, context.Canceled)
+			},
+			expectedCode: codes.Canceled,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.mockSetup()
+
+			resp, err := handler.FindUserByID(context.Background(), &usersv1.FindUserByIDRequest{Id: userID})
+			require.Equal(t, tc.expectedCode, status.Code(err))
+
+			if tc.expectedCode == codes.OK {
+				require.Equal(t, userID, resp.GetUser().GetId())
+				require.Equal(t, "username", resp.GetUser().GetUsername())
+			}
+		})
+	}
+}
+
+func TestFindUsersByIDs(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserService := usersHandlerMocks.NewMockUserService(ctrl)
+	handler := New(mockUserService)
+
+	IDs := []string{uuid.NewString(), uuid.NewString()}
+
+	mockUserService.EXPECT().FindUsersByIDs(gomock.Any(), IDs).Return([]models.User{
+		{ID: IDs[0], Username: "first"},
+		{ID: IDs[1], Username: "second"},
+	}, nil)
+
+	resp, err := handler.FindUsersByIDs(context.Background(), &usersv1.FindUsersByIDsRequest{Ids: IDs})
+	require.NoError(t, err)
+	require.Len(t, resp.GetUsers(), 2)
+	require.Equal(t, "first", resp.GetUsers()[0].GetUsername())
+
+	tooMany := make([]string, maxBatchSize+1)
+	_, err = handler.FindUsersByIDs(context.Background(), &usersv1.FindUsersByIDsRequest{Ids: tooMany})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestIntrospectToken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserService := usersHandlerMocks.NewMockUserService(ctrl)
+	handler := New(mockUserService)
+
+	expiresAt := time.Unix(time.Now().Add(time.Minute).Unix(), 0)
+
+	mockUserService.EXPECT().IntrospectToken(gomock.Any(), "active").Return(models.TokenIntrospection{
+		Active:    true,
+		Type:      "access",
+		Subject:   "user-id",
+		Role:      "user",
+		ExpiresAt: expiresAt,
+	}, nil)
+	mockUserService.EXPECT().IntrospectToken(gomock.Any(), "inactive").Return(models.TokenIntrospection{Active: false}, nil)
+
+	resp, err := handler.IntrospectToken(context.Background(), &usersv1.IntrospectTokenRequest{Token: "active"})
+	require.NoError(t, err)
+	require.True(t, resp.GetActive())
+	require.Equal(t, "user-id", resp.GetSubject())
+	require.Equal(t, expiresAt.Unix(), resp.GetExpiresAt())
+
+	resp, err = handler.IntrospectToken(context.Background(), &usersv1.IntrospectTokenRequest{Token: "inactive"})
+	require.NoError(t, err)
+	require.False(t, resp.GetActive())
+	require.Empty(t, resp.GetSubject())
+
+	_, err = handler.IntrospectToken(context.Background(), &usersv1.IntrospectTokenRequest{})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestCheckRole(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserService := usersHandlerMocks.NewMockUserService(ctrl)
+	handler := New(mockUserService)
+
+	userID := uuid.NewString()
+
+	mockUserService.EXPECT().CheckRole(gomock.Any(), userID, "admin").Return(true, nil)
+
+	resp, err := handler.CheckRole(context.Background(), &usersv1.CheckRoleRequest{UserId: userID, Role: "admin"})
+	require.NoError(t, err)
+	require.True(t, resp.GetAllowed())
+
+	_, err = handler.CheckRole(context.Background(), &usersv1.CheckRoleRequest{UserId: userID})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
@@ -0,0 +1,96 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./internal/adapters/transport/grpc/usersHandler/handler.go
+
+// Package usersHandlerMocks is a generated GoMock package.
+package usersHandlerMocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	models "github.com/Cwby333/user-microservice/internal/models"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockUserService is a mock of UserService interface.
+type MockUserService struct {
+	ctrl     *gomock.Controller
+	recorder *MockUserServiceMockRecorder
+}
+
+// MockUserServiceMockRecorder is the mock recorder for MockUserService.
+type MockUserServiceMockRecorder struct {
+	mock *MockUserService
+}
+
+// NewMockUserService creates a new mock instance.
+func NewMockUserService(ctrl *gomock.Controller) *MockUserService {
+	mock := &MockUserService{ctrl: ctrl}
+	mock.recorder = &MockUserServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUserService) EXPECT() *MockUserServiceMockRecorder {
+	return m.recorder
+}
+
+// CheckRole mocks base method.
+func (m *MockUserService) CheckRole(ctx context.Context, userID, role string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheckRole", ctx, userID, role)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CheckRole indicates an expected call of CheckRole.
+func (mr *MockUserServiceMockRecorder) CheckRole(ctx, userID, role interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckRole", reflect.TypeOf((*MockUserService)(nil).CheckRole), ctx, userID, role)
+}
+
+// FindUserByID mocks base method.
+func (m *MockUserService) FindUserByID(ctx context.Context, ID string) (models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindUserByID", ctx, ID)
+	ret0, _ := ret[0].(models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindUserByID indicates an expected call of FindUserByID.
+func (mr *MockUserServiceMockRecorder) FindUserByID(ctx, ID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindUserByID", reflect.TypeOf((*MockUserService)(nil).FindUserByID), ctx, ID)
+}
+
+// FindUsersByIDs mocks base method.
+func (m *MockUserService) FindUsersByIDs(ctx context.Context, IDs []string) ([]models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindUsersByIDs", ctx, IDs)
+	ret0, _ := ret[0].([]models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindUsersByIDs indicates an expected call of FindUsersByIDs.
+func (mr *MockUserServiceMockRecorder) FindUsersByIDs(ctx, IDs interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindUsersByIDs", reflect.TypeOf((*MockUserService)(nil).FindUsersByIDs), ctx, IDs)
+}
+
+// IntrospectToken mocks base method.
+func (m *MockUserService) IntrospectToken(ctx context.Context, token string) (models.TokenIntrospection, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IntrospectToken", ctx, token)
+	ret0, _ := ret[0].(models.TokenIntrospection)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IntrospectToken indicates an expected call of IntrospectToken.
+func (mr *MockUserServiceMockRecorder) IntrospectToken(ctx, token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IntrospectToken", reflect.TypeOf((*MockUserService)(nil).IntrospectToken), ctx, token)
+}
@@ -0,0 +1,96 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/Cwby333/user-microservice/internal/adapters/transport/http/lib"
+	allerrors "github.com/Cwby333/user-microservice/internal/allErrors"
+	"github.com/Cwby333/user-microservice/internal/models"
+
+	gojson "github.com/goccy/go-json"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+type AccessChecker interface {
+	CheckAccess(ctx context.Context, access models.JWTAccess) error
+}
+
+// RevokedAccess must run after AccessJWT: it takes the verified claims from the
+// request context and rejects tokens that were revoked before they expired or
+// were issued before the user's credentials or role changed.
+func RevokedAccess(checker AccessChecker) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := r.Context().Value("claims").(jwt.MapClaims)
+			if !ok {
+				resp := lib.Response{
+					StatusCode: http.StatusUnauthorized,
+					Message:    "unauthorized",
+				}
+				data, err := gojson.Marshal(resp)
+				if err != nil {
+					http.Error(w, "unauthorized", http.StatusUnauthorized)
+					return
+				}
+
+				http.Error(w, string(data), http.StatusUnauthorized)
+				return
+			}
+
+			access := models.JWTAccess{}
+			access.Subject, _ = claims["sub"].(string)
+			access.Role, _ = claims["role"].(string)
+			access.TokenID, _ = claims["jti"].(string)
+			access.VersionCredentials = VersionCredentials(claims)
+
+			err := checker.CheckAccess(r.Context(), access)
+			if err != nil {
+				slog.Info("revoked access middleware", slog.String("error", err.Error()))
+
+				status := http.StatusUnauthorized
+				message := "token revoked"
+				switch {
+				case errors.Is(err, allerrors.ErrAccessTokenInBlackList):
+				case errors.Is(err, allerrors.ErrDifferentVersionCredentials),
+					errors.Is(err, allerrors.ErrUserNotExists),
+					errors.Is(err, allerrors.ErrWrongUUID):
+					message = "credentials changed, please, login again"
+				default:
+					status = http.StatusInternalServerError
+					message = "server error"
+				}
+
+				resp := lib.Response{
+					StatusCode: status,
+					Message:    message,
+				}
+				data, err := gojson.Marshal(resp)
+				if err != nil {
+					http.Error(w, message, status)
+					return
+				}
+
+				http.Error(w, string(data), status)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// VersionCredentials reads the version_credentials claim. Parsed tokens carry
+// numbers as float64, claims built in code may hold an int.
+func VersionCredentials(claims jwt.MapClaims) int {
+	switch v := claims["version_credentials"].(type) {
+	case float64:
+		return int(v)
+	case int:
+		return v
+	}
+
+	return 0
+}
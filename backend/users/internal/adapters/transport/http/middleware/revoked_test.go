@@ -0,0 +1,91 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	allerrors "github.com/Cwby333/user-microservice/internal/allErrors"
+	"github.com/Cwby333/user-microservice/internal/models"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+)
+
+type accessCheckerFunc func(ctx context.Context, access models.JWTAccess) error
+
+func (f accessCheckerFunc) CheckAccess(ctx context.Context, access models.JWTAccess) error {
+	return f(ctx, access)
+}
+
+func TestRevokedAccess(t *testing.T) {
+	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	testCases := []struct {
+		name           string
+		claims         any
+		checkErr       error
+		expectedStatus int
+	}{
+		{
+			name:           "token not revoked",
+			claims:         jwt.MapClaims{"jti": "token-id", "sub": "user-id", "version_credentials": float64(2)},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "credentials changed",
+			claims:         jwt.MapClaims{"jti": "token-id", "sub": "user-id", "version_credentials": float64(2)},
+			checkErr:       allerrors.ErrDifferentVersionCredentials,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "user deleted",
+			claims:         jwt.MapClaims{"jti": "token-id", "sub": "user-id", "version_credentials": float64(2)},
+			checkErr:       allerrors.ErrUserNotExists,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "token revoked",
+			claims:         jwt.MapClaims{"jti": "token-id", "sub": "user-id"},
+			checkErr:       allerrors.ErrAccessTokenInBlackList,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "storage error",
+			claims:         jwt.MapClaims{"jti": "token-id", "sub": "user-id"},
+			checkErr:       errors.New("redis down"),
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{
+			name:           "no claims in context",
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			checker := accessCheckerFunc(func(ctx context.Context, access models.JWTAccess) error {
+				require.Equal(t, "token-id", access.TokenID)
+				require.Equal(t, "user-id", access.Subject)
+				if tc.claims.(jwt.MapClaims)["version_credentials"] != nil {
+					require.Equal(t, 2, access.VersionCredentials)
+				}
+
+				return tc.checkErr
+			})
+
+			req := httptest.NewRequest("GET", "/user/get", nil)
+			if tc.claims != nil {
+				req = req.WithContext(context.WithValue(req.Context(), "claims", tc.claims))
+			}
+
+			rr := httptest.NewRecorder()
+			RevokedAccess(checker)(nextHandler).ServeHTTP(rr, req)
+
+			require.Equal(t, tc.expectedStatus, rr.Code)
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/Cwby333/user-microservice/internal/adapters/transport/http/lib"
+	gojson "github.com/goccy/go-json"
+)
+
+// ServiceToken protects endpoints meant for other services only.
+// Without SERVICE_TOKEN in the environment every request is rejected.
+func ServiceToken(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		expected := os.Getenv("SERVICE_TOKEN")
+
+		token := r.Header.Get("Authorization")
+		token = strings.TrimPrefix(token, "Bearer ")
+
+		if expected == "" || subtle.ConstantTimeCompare([]byte(token), []byte(expected)) != 1 {
+			slog.Info("service token middleware", slog.String("error", "wrong or missing service token"))
+
+			resp := lib.Response{
+				StatusCode: http.StatusUnauthorized,
+				Message:    "unauthorized",
+			}
+			data, err := gojson.Marshal(resp)
+			if err != nil {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+
+			http.Error(w, string(data), http.StatusUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
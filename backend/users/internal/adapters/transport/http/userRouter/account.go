@@ -0,0 +1,174 @@
+package userrouter
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/Cwby333/user-microservice/internal/adapters/transport/http/lib"
+	allerrors "github.com/Cwby333/user-microservice/internal/allErrors"
+	"github.com/Cwby333/user-microservice/internal/models"
+
+	gojson "github.com/goccy/go-json"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+type ReactivateRequest struct {
+	Username string `json:"username" validate:"required"`
+	Password string `json:"password" validate:"required"`
+}
+
+// ReactivateUser restores an account deleted with DELETE /user/delete before it is erased.
+// After that the user logs in as usual.
+func (router *Router) ReactivateUser(w http.ResponseWriter, r *http.Request) {
+	var req ReactivateRequest
+
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		slog.Info("reactivate read body", slog.String("error", err.Error()))
+
+		router.accountError(w, http.StatusInternalServerError, "server error")
+		return
+	}
+	r.Body.Close()
+
+	err = gojson.Unmarshal(data, &req)
+	if err != nil {
+		slog.Info("gojson unmarshal", slog.String("error", err.Error()))
+
+		router.accountError(w, http.StatusBadRequest, "bad request")
+		return
+	}
+
+	err = router.validator.Struct(req)
+	if err != nil {
+		slog.Info("reactivate validate", slog.String("error", err.Error()))
+
+		router.accountError(w, http.StatusBadRequest, "bad request")
+		return
+	}
+
+	err = router.userService.ReactivateUser(r.Context(), models.User{
+		Username: req.Username,
+		Password: req.Password,
+	})
+	if err != nil {
+		slog.Info("reactivate handler", slog.String("error", err.Error()))
+
+		switch {
+		case errors.Is(err, allerrors.ErrUserNotExists):
+			router.accountError(w, http.StatusNotFound, "username not found")
+		case errors.Is(err, allerrors.ErrWrongPass):
+			router.accountError(w, http.StatusBadRequest, "wrong password")
+		default:
+			router.accountError(w, http.StatusInternalServerError, "server error")
+		}
+		return
+	}
+
+	resp := lib.Response{
+		StatusCode: http.StatusOK,
+		Message:    "success",
+	}
+	data, err = gojson.Marshal(resp)
+	if err != nil {
+		slog.Info("gojson marshal", slog.String("error", err.Error()))
+
+		w.Write([]byte("success"))
+		return
+	}
+
+	_, err = w.Write(data)
+	if err != nil {
+		slog.Info("response write", slog.String("error", err.Error()))
+	}
+}
+
+func (router *Router) accountError(w http.ResponseWriter, status int, message string) {
+	resp := lib.Response{
+		StatusCode: status,
+		Message:    message,
+	}
+	data, err := gojson.Marshal(resp)
+	if err != nil {
+		slog.Info("gojson marshal", slog.String("error", err.Error()))
+
+		http.Error(w, message, status)
+		return
+	}
+
+	http.Error(w, string(data), status)
+}
+
+type ExportResponse struct {
+	Response lib.Response `json:"response"`
+	ID       string       `json:"id,omitempty"`
+	Status   string       `json:"status,omitempty"`
+	Error    string       `json:"error,omitempty"`
+	URL      string       `json:"url,omitempty"`
+}
+
+// RequestExport starts building the user's data export, the result is polled with GetExport.
+func (router *Router) RequestExport(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value("claims").(jwt.MapClaims)
+	userID, _ := claims["sub"].(string)
+
+	export, err := router.exportService.RequestExport(r.Context(), userID)
+	if err != nil {
+		slog.Info("requestExport handler", slog.String("error", err.Error()))
+
+		router.accountError(w, http.StatusInternalServerError, "server error")
+		return
+	}
+
+	router.writeExport(w, http.StatusAccepted, export, "")
+}
+
+func (router *Router) GetExport(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value("claims").(jwt.MapClaims)
+	userID, _ := claims["sub"].(string)
+
+	export, url, err := router.exportService.GetExport(r.Context(), userID, r.PathValue("id"))
+	if err != nil {
+		slog.Info("getExport handler", slog.String("error", err.Error()))
+
+		if errors.Is(err, allerrors.ErrExportNotExists) || errors.Is(err, allerrors.ErrWrongUUID) {
+			router.accountError(w, http.StatusNotFound, "export not found")
+			return
+		}
+
+		router.accountError(w, http.StatusInternalServerError, "server error")
+		return
+	}
+
+	router.writeExport(w, http.StatusOK, export, url)
+}
+
+func (router *Router) writeExport(w http.ResponseWriter, status int, export models.DataExport, url string) {
+	resp := ExportResponse{
+		Response: lib.Response{
+			StatusCode: status,
+			Message:    "success",
+		},
+		ID:     export.ID,
+		Status: export.Status,
+		Error:  export.Error,
+		URL:    url,
+	}
+	data, err := gojson.Marshal(resp)
+	if err != nil {
+		slog.Info("gojson marshal", slog.String("error", err.Error()))
+
+		http.Error(w, "server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	_, err = w.Write(data)
+	if err != nil {
+		slog.Info("response write", slog.String("error", err.Error()))
+	}
+}
@@ -0,0 +1,81 @@
+package userrouter
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/Cwby333/user-microservice/internal/adapters/transport/http/lib"
+	allerrors "github.com/Cwby333/user-microservice/internal/allErrors"
+
+	gojson "github.com/goccy/go-json"
+)
+
+type BanUserRequest struct {
+	Banned *bool `json:"banned" validate:"required"`
+}
+
+type BanUserResponse struct {
+	Response  lib.Response `json:"response"`
+	ID        string       `json:"id,omitempty"`
+	Banned    bool         `json:"banned"`
+	WasBanned bool         `json:"was_banned"`
+}
+
+// BanUser handles PUT /internal/users/{id}/ban: sets or lifts a ban.
+// Only for other services, the moderation queue lives in music.
+func (router *Router) BanUser(w http.ResponseWriter, r *http.Request) {
+	var req BanUserRequest
+
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		slog.Info("banUser read body", slog.String("error", err.Error()))
+
+		router.accountError(w, http.StatusInternalServerError, "server error")
+		return
+	}
+	r.Body.Close()
+
+	err = gojson.Unmarshal(data, &req)
+	if err != nil {
+		slog.Info("gojson unmarshal", slog.String("error", err.Error()))
+
+		router.accountError(w, http.StatusBadRequest, "bad request")
+		return
+	}
+
+	err = router.validator.Struct(req)
+	if err != nil {
+		slog.Info("banUser validate", slog.String("error", err.Error()))
+
+		router.accountError(w, http.StatusBadRequest, "bad request")
+		return
+	}
+
+	user, wasBanned, err := router.userService.BanUser(r.Context(), r.PathValue("id"), *req.Banned)
+	if err != nil {
+		slog.Info("banUser handler", slog.String("error", err.Error()))
+
+		switch {
+		case errors.Is(err, allerrors.ErrWrongUUID):
+			router.accountError(w, http.StatusBadRequest, "wrong uuid")
+		case errors.Is(err, allerrors.ErrUserNotExists):
+			router.accountError(w, http.StatusNotFound, "user not found")
+		case errors.Is(err, allerrors.ErrBanAdmin):
+			router.accountError(w, http.StatusForbidden, "can not ban an admin")
+		default:
+			router.accountError(w, http.StatusInternalServerError, "server error")
+		}
+		return
+	}
+
+	resp := BanUserResponse{
+		Response:  lib.Response{StatusCode: http.StatusOK, Message: "success"},
+		ID:        user.ID,
+		Banned:    !user.BannedAt.IsZero(),
+		WasBanned: wasBanned,
+	}
+
+	router.writeSocial(w, http.StatusOK, resp)
+}
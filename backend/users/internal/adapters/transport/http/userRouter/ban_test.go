@@ -0,0 +1,81 @@
+package userrouter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Cwby333/user-microservice/internal/adapters/transport/http/userRouter/userRouterMocks"
+	allerrors "github.com/Cwby333/user-microservice/internal/allErrors"
+	"github.com/Cwby333/user-microservice/internal/models"
+	gojson "github.com/goccy/go-json"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBanUserHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserService := userRouterMocks.NewMockUserService(ctrl)
+
+	router := New(mockUserService, nil, nil, nil, nil)
+
+	userID := uuid.NewString()
+
+	serve := func(id, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("PUT", "/internal/users/"+id+"/ban", strings.NewReader(body))
+		req.SetPathValue("id", id)
+		rr := httptest.NewRecorder()
+		http.HandlerFunc(router.BanUser).ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("ban", func(t *testing.T) {
+		mockUserService.EXPECT().BanUser(gomock.Any(), userID, true).
+			Return(models.User{ID: userID, BannedAt: time.Now()}, false, nil)
+
+		rr := serve(userID, `{"banned":true}`)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var resp BanUserResponse
+		require.NoError(t, gojson.Unmarshal(rr.Body.Bytes(), &resp))
+		require.Equal(t, userID, resp.ID)
+		require.True(t, resp.Banned)
+		require.False(t, resp.WasBanned)
+	})
+
+	t.Run("lift ban", func(t *testing.T) {
+		mockUserService.EXPECT().BanUser(gomock.Any(), userID, false).Return(models.User{ID: userID}, true, nil)
+
+		rr := serve(userID, `{"banned":false}`)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var resp BanUserResponse
+		require.NoError(t, gojson.Unmarshal(rr.Body.Bytes(), &resp))
+		require.False(t, resp.Banned)
+		require.True(t, resp.WasBanned)
+	})
+
+	t.Run("no banned field", func(t *testing.T) {
+		rr := serve(userID, `{}`)
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("admin", func(t *testing.T) {
+		mockUserService.EXPECT().BanUser(gomock.Any(), userID, true).Return(models.User{}, false, allerrors.ErrBanAdmin)
+
+		rr := serve(userID, `{"banned":true}`)
+		require.Equal(t, http.StatusForbidden, rr.Code)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		mockUserService.EXPECT().BanUser(gomock.Any(), userID, true).Return(models.User{}, false, allerrors.ErrUserNotExists)
+
+		rr := serve(userID, `{"banned":true}`)
+		require.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
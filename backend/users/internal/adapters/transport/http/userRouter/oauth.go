@@ -0,0 +1,159 @@
+package userrouter
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/Cwby333/user-microservice/internal/adapters/transport/http/lib"
+
+	gojson "github.com/goccy/go-json"
+)
+
+// IntrospectResponse follows RFC 7662: inactive tokens carry no other fields.
+type IntrospectResponse struct {
+	Active    bool   `json:"active"`
+	TokenType string `json:"token_type,omitempty"`
+	Sub       string `json:"sub,omitempty"`
+	Role      string `json:"role,omitempty"`
+	JTI       string `json:"jti,omitempty"`
+	IAT       int64  `json:"iat,omitempty"`
+	EXP       int64  `json:"exp,omitempty"`
+}
+
+// IntrospectToken handles POST /oauth/introspect, form field "token".
+func (router *Router) IntrospectToken(w http.ResponseWriter, r *http.Request) {
+	token, ok := router.tokenFromForm(w, r)
+	if !ok {
+		return
+	}
+
+	info, err := router.userService.IntrospectToken(r.Context(), token)
+	if err != nil {
+		slog.Info("introspect handler", slog.String("error", err.Error()))
+
+		resp := lib.Response{
+			StatusCode: http.StatusInternalServerError,
+			Message:    "server error",
+		}
+		data, err := gojson.Marshal(resp)
+		if err != nil {
+			http.Error(w, "server error", http.StatusInternalServerError)
+			return
+		}
+
+		http.Error(w, string(data), http.StatusInternalServerError)
+		return
+	}
+
+	resp := IntrospectResponse{Active: info.Active}
+	if info.Active {
+		resp.TokenType = info.Type
+		resp.Sub = info.Subject
+		resp.Role = info.Role
+		resp.JTI = info.TokenID
+		resp.IAT = info.IssuedAt.Unix()
+		resp.EXP = info.ExpiresAt.Unix()
+	}
+
+	data, err := gojson.Marshal(resp)
+	if err != nil {
+		slog.Info("gojson marshal", slog.String("error", err.Error()))
+
+		http.Error(w, "server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	_, err = w.Write(data)
+	if err != nil {
+		slog.Info("response write", slog.String("error", err.Error()))
+	}
+}
+
+// RevokeToken handles POST /oauth/revoke, form field "token". As RFC 7009 requires,
+// unknown or already invalid tokens are answered with success too.
+func (router *Router) RevokeToken(w http.ResponseWriter, r *http.Request) {
+	token, ok := router.tokenFromForm(w, r)
+	if !ok {
+		return
+	}
+
+	err := router.userService.RevokeToken(r.Context(), token)
+	if err != nil {
+		slog.Info("revoke handler", slog.String("error", err.Error()))
+
+		resp := lib.Response{
+			StatusCode: http.StatusServiceUnavailable,
+			Message:    "server error",
+		}
+		data, err := gojson.Marshal(resp)
+		if err != nil {
+			http.Error(w, "server error", http.StatusServiceUnavailable)
+			return
+		}
+
+		http.Error(w, string(data), http.StatusServiceUnavailable)
+		return
+	}
+
+	resp := lib.Response{
+		StatusCode: http.StatusOK,
+		Message:    "success",
+	}
+	data, err := gojson.Marshal(resp)
+	if err != nil {
+		slog.Info("gojson marshal", slog.String("error", err.Error()))
+
+		w.Write([]byte("success"))
+		return
+	}
+
+	_, err = w.Write(data)
+	if err != nil {
+		slog.Info("response write", slog.String("error", err.Error()))
+	}
+}
+
+func (router *Router) tokenFromForm(w http.ResponseWriter, r *http.Request) (string, bool) {
+	err := r.ParseForm()
+	if err == nil && r.PostForm.Get("token") != "" {
+		return r.PostForm.Get("token"), true
+	}
+
+	resp := lib.Response{
+		StatusCode: http.StatusBadRequest,
+		Message:    "missing {token} parameter",
+	}
+	data, err := gojson.Marshal(resp)
+	if err != nil {
+		http.Error(w, "missing {token} parameter", http.StatusBadRequest)
+		return "", false
+	}
+
+	http.Error(w, string(data), http.StatusBadRequest)
+	return "", false
+}
+
+// revokeRequestAccess kills the access token the client sent along with the request, if any.
+func (router *Router) revokeRequestAccess(r *http.Request) {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if token == "" {
+		cookie, err := r.Cookie("jwt-access")
+		if err != nil {
+			return
+		}
+
+		token = cookie.Value
+	}
+
+	if token == "" {
+		return
+	}
+
+	err := router.userService.RevokeToken(r.Context(), token)
+	if err != nil {
+		slog.Info("revoke access", slog.String("error", err.Error()))
+	}
+}
@@ -0,0 +1,136 @@
+package userrouter
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Cwby333/user-microservice/internal/adapters/transport/http/userRouter/userRouterMocks"
+	"github.com/Cwby333/user-microservice/internal/models"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func formRequest(target string, values url.Values) *http.Request {
+	req := httptest.NewRequest("POST", target, strings.NewReader(values.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	return req
+}
+
+func TestIntrospectTokenHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserService := userRouterMocks.NewMockUserService(ctrl)
+	mockTaskService := userRouterMocks.NewMockDefferedTaskService(ctrl)
+
+	router := New(mockUserService, mockTaskService, nil, nil, nil)
+
+	expiresAt := time.Unix(time.Now().Add(time.Minute).Unix(), 0)
+
+	testCases := []struct {
+		name           string
+		form           url.Values
+		mockSetup      func()
+		expectedStatus int
+		expectedBody   IntrospectResponse
+	}{
+		{
+			name: "active token",
+			form: url.Values{"token": {"active-token"}},
+			mockSetup: func() {
+				mockUserService.EXPECT().IntrospectToken(gomock.Any(), "active-token").Return(models.TokenIntrospection{
+					Active:    true,
+					Type:      "access",
+					Subject:   "user-id",
+					Role:      "user",
+					TokenID:   "token-id",
+					ExpiresAt: expiresAt,
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody: IntrospectResponse{
+				Active:    true,
+				TokenType: "access",
+				Sub:       "user-id",
+				Role:      "user",
+				JTI:       "token-id",
+				IAT:       time.Time{}.Unix(),
+				EXP:       expiresAt.Unix(),
+			},
+		},
+		{
+			name: "inactive token",
+			form: url.Values{"token": {"revoked-token"}},
+			mockSetup: func() {
+				mockUserService.EXPECT().IntrospectToken(gomock.Any(), "revoked-token").Return(models.TokenIntrospection{Active: false}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   IntrospectResponse{Active: false},
+		},
+		{
+			name:           "missing token",
+			form:           url.Values{},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "server error",
+			form: url.Values{"token": {"some-token"}},
+			mockSetup: func() {
+				mockUserService.EXPECT().IntrospectToken(gomock.Any(), "some-token").Return(models.TokenIntrospection{}, errors.New("redis down"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.mockSetup != nil {
+				tc.mockSetup()
+			}
+
+			rr := httptest.NewRecorder()
+			http.HandlerFunc(router.IntrospectToken).ServeHTTP(rr, formRequest("/oauth/introspect", tc.form))
+
+			require.Equal(t, tc.expectedStatus, rr.Code)
+
+			if tc.expectedStatus == http.StatusOK {
+				var resp IntrospectResponse
+				require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+				require.Equal(t, tc.expectedBody, resp)
+			}
+		})
+	}
+}
+
+func TestRevokeTokenHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserService := userRouterMocks.NewMockUserService(ctrl)
+	mockTaskService := userRouterMocks.NewMockDefferedTaskService(ctrl)
+
+	router := New(mockUserService, mockTaskService, nil, nil, nil)
+
+	mockUserService.EXPECT().RevokeToken(gomock.Any(), "some-token").Return(nil)
+
+	rr := httptest.NewRecorder()
+	http.HandlerFunc(router.RevokeToken).ServeHTTP(rr, formRequest("/oauth/revoke", url.Values{"token": {"some-token"}}))
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	mockUserService.EXPECT().RevokeToken(gomock.Any(), "other-token").Return(errors.New("redis down"))
+
+	rr = httptest.NewRecorder()
+	http.HandlerFunc(router.RevokeToken).ServeHTTP(rr, formRequest("/oauth/revoke", url.Values{"token": {"other-token"}}))
+	require.Equal(t, http.StatusServiceUnavailable, rr.Code)
+
+	rr = httptest.NewRecorder()
+	http.HandlerFunc(router.RevokeToken).ServeHTTP(rr, formRequest("/oauth/revoke", url.Values{}))
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+}
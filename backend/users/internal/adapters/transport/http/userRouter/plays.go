@@ -0,0 +1,112 @@
+package userrouter
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/Cwby333/user-microservice/internal/adapters/transport/http/lib"
+	"github.com/Cwby333/user-microservice/internal/models"
+	"gitlab.com/Go34/Mute/shared/events"
+
+	gojson "github.com/goccy/go-json"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// PlayRequest: position_ms and duration_ms are optional, 0 is a real value
+type PlayRequest struct {
+	TrackID    string `json:"track_id" validate:"required"`
+	PositionMs *int64 `json:"position_ms" validate:"omitempty,gte=0"`
+	DurationMs *int64 `json:"duration_ms" validate:"omitempty,gte=0"`
+}
+
+// RecordPlay handles POST /me/plays. The play goes to music through the outbox
+// like likes do, so the answer is 202 before it lands in the history.
+func (router *Router) RecordPlay(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value("claims").(jwt.MapClaims)
+	userID, _ := claims["sub"].(string)
+
+	var req PlayRequest
+
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		slog.Info("recordPlay read body", slog.String("error", err.Error()))
+
+		router.accountError(w, http.StatusInternalServerError, "server error")
+		return
+	}
+	r.Body.Close()
+
+	err = gojson.Unmarshal(data, &req)
+	if err != nil {
+		slog.Info("gojson unmarshal", slog.String("error", err.Error()))
+
+		router.accountError(w, http.StatusBadRequest, "bad request")
+		return
+	}
+
+	err = router.validator.Struct(req)
+	if err != nil {
+		slog.Info("recordPlay validate", slog.String("error", err.Error()))
+
+		router.accountError(w, http.StatusBadRequest, "bad request")
+		return
+	}
+
+	event, err := events.New(events.TypeTrackPlayed, "users", events.TrackPlayed{
+		UserID:     userID,
+		TrackID:    req.TrackID,
+		PositionMs: req.PositionMs,
+		DurationMs: req.DurationMs,
+	})
+	if err != nil {
+		slog.Info("recordPlay handler", slog.String("error", err.Error()))
+
+		router.accountError(w, http.StatusBadRequest, "invalid track_id")
+		return
+	}
+
+	rawData, err := gojson.Marshal(event)
+	if err != nil {
+		slog.Info("gojson marshal", slog.String("error", err.Error()))
+
+		router.accountError(w, http.StatusInternalServerError, "server error")
+		return
+	}
+
+	// Same key as likes: plays and likes of one track by one user stay in order
+	task := models.DefferedTask{
+		Topic:     "songs_actions",
+		Key:       userID + ":" + req.TrackID,
+		Data:      rawData,
+		CreatedAt: time.Now(),
+	}
+	err = router.taskService.ActionWithSong(r.Context(), task)
+	if err != nil {
+		slog.Info("recordPlay handler", slog.String("error", err.Error()))
+
+		router.accountError(w, http.StatusInternalServerError, "server error")
+		return
+	}
+
+	resp := lib.Response{
+		StatusCode: http.StatusAccepted,
+		Message:    "accepted",
+	}
+	data, err = gojson.Marshal(resp)
+	if err != nil {
+		slog.Info("gojson marshal", slog.String("error", err.Error()))
+
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+
+	_, err = w.Write(data)
+	if err != nil {
+		slog.Info("response write", slog.String("error", err.Error()))
+	}
+}
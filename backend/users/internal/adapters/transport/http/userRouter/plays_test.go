@@ -0,0 +1,117 @@
+package userrouter
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Cwby333/user-microservice/internal/adapters/transport/http/userRouter/userRouterMocks"
+	"github.com/Cwby333/user-microservice/internal/models"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"gitlab.com/Go34/Mute/shared/events"
+)
+
+func TestRecordPlayHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserService := userRouterMocks.NewMockUserService(ctrl)
+	mockTaskService := userRouterMocks.NewMockDefferedTaskService(ctrl)
+
+	router := New(mockUserService, mockTaskService, nil, nil, nil)
+
+	userID := uuid.NewString()
+	trackID := uuid.NewString()
+	ctx := context.WithValue(context.Background(), "claims", jwt.MapClaims{"sub": userID})
+
+	int64p := func(v int64) *int64 { return &v }
+	// expectPlay checks the queued event against want
+	expectPlay := func(want events.TrackPlayed) func() {
+		return func() {
+			mockTaskService.EXPECT().ActionWithSong(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ context.Context, task models.DefferedTask) error {
+					require.Equal(t, "songs_actions", task.Topic)
+					require.Equal(t, userID+":"+trackID, task.Key)
+
+					e, err := events.Parse(task.Data)
+					require.NoError(t, err)
+					require.Equal(t, events.TypeTrackPlayed, e.Type)
+
+					var p events.TrackPlayed
+					require.NoError(t, e.Decode(&p))
+					require.Equal(t, want, p)
+					return nil
+				})
+		}
+	}
+
+	testCases := []struct {
+		name           string
+		body           string
+		mockSetup      func()
+		expectedStatus int
+	}{
+		{
+			name:           "play enqueued",
+			body:           `{"track_id":"` + trackID + `","position_ms":61000,"duration_ms":45000}`,
+			mockSetup:      expectPlay(events.TrackPlayed{UserID: userID, TrackID: trackID, PositionMs: int64p(61000), DurationMs: int64p(45000)}),
+			expectedStatus: http.StatusAccepted,
+		},
+		{
+			// 0 is a real position, not a missing one
+			name:           "zero position kept",
+			body:           `{"track_id":"` + trackID + `","position_ms":0,"duration_ms":0}`,
+			mockSetup:      expectPlay(events.TrackPlayed{UserID: userID, TrackID: trackID, PositionMs: int64p(0), DurationMs: int64p(0)}),
+			expectedStatus: http.StatusAccepted,
+		},
+		{
+			name:           "no position",
+			body:           `{"track_id":"` + trackID + `"}`,
+			mockSetup:      expectPlay(events.TrackPlayed{UserID: userID, TrackID: trackID}),
+			expectedStatus: http.StatusAccepted,
+		},
+		{
+			name:           "missing track id",
+			body:           `{"position_ms":1000}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "track id is not uuid",
+			body:           `{"track_id":"not-a-uuid"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "negative duration",
+			body:           `{"track_id":"` + trackID + `","duration_ms":-1}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "outbox error",
+			body: `{"track_id":"` + trackID + `"}`,
+			mockSetup: func() {
+				mockTaskService.EXPECT().ActionWithSong(gomock.Any(), gomock.Any()).Return(errors.New("db down"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.mockSetup != nil {
+				tc.mockSetup()
+			}
+
+			req := httptest.NewRequest("POST", "/me/plays", strings.NewReader(tc.body)).WithContext(ctx)
+			rr := httptest.NewRecorder()
+			http.HandlerFunc(router.RecordPlay).ServeHTTP(rr, req)
+
+			require.Equal(t, tc.expectedStatus, rr.Code)
+		})
+	}
+}
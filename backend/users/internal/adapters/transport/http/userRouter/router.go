@@ -18,6 +18,7 @@ import (
 	"github.com/go-playground/validator/v10"
 	gojson "github.com/goccy/go-json"
 	"github.com/golang-jwt/jwt/v5"
+	"gitlab.com/Go34/Mute/shared/events"
 )
 
 type UserService interface {
@@ -26,34 +27,62 @@ type UserService interface {
 	Logout(ctx context.Context, tokenID string, unixTimeExpired time.Time) error
 
 	FindUserByID(ctx context.Context, ID string) (models.User, error)
+	FindUsersByIDs(ctx context.Context, IDs []string) ([]models.User, error)
 	GetAllUsers(ctx context.Context) ([]models.User, error)
 
 	DeleteUser(ctx context.Context, ID string) error
+	ReactivateUser(ctx context.Context, user models.User) error
 
 	UpdateUser(ctx context.Context, ID string, newUserInfo models.User) (models.User, error)
+	ChangeRole(ctx context.Context, ID string, role string) (models.User, error)
+	BanUser(ctx context.Context, ID string, banned bool) (models.User, bool, error)
 
 	RefreshTokens(ctx context.Context, tokenID string, refreshVersionCredentials int, expTime time.Time, user models.User) (access models.JWTAccess, refresh models.JWTRefresh, err error)
+
+	IntrospectToken(ctx context.Context, token string) (models.TokenIntrospection, error)
+	RevokeToken(ctx context.Context, token string) error
+	RevokeAccess(ctx context.Context, tokenID string, expTime time.Time) error
+	CheckAccess(ctx context.Context, access models.JWTAccess) error
 }
 
 type DefferedTaskService interface {
 	ActionWithSong(ctx context.Context, task models.DefferedTask) error
 }
 
+type ExportService interface {
+	RequestExport(ctx context.Context, userID string) (models.DataExport, error)
+	GetExport(ctx context.Context, userID string, exportID string) (models.DataExport, string, error)
+}
+
+type SocialService interface {
+	Follow(ctx context.Context, followerID string, kind string, followeeID string) error
+	Unfollow(ctx context.Context, followerID string, kind string, followeeID string) error
+	Counts(ctx context.Context, userID string) (models.FollowCounts, error)
+	Followers(ctx context.Context, userID string, kind string, cursor string, limit int) ([]models.Follow, string, error)
+	Following(ctx context.Context, userID string, kind string, cursor string, limit int) ([]models.Follow, string, error)
+	Feed(ctx context.Context, userID string, cursor string, limit int) ([]models.FeedItem, string, error)
+	FilterFollowers(ctx context.Context, followeeID string, kind string, userIDs []string) ([]string, error)
+}
+
 type Router struct {
-	Mux         *http.ServeMux
-	userService UserService
-	taskService DefferedTaskService
-	logger      *slog.Logger
-	validator   *validator.Validate
+	Mux           *http.ServeMux
+	userService   UserService
+	taskService   DefferedTaskService
+	exportService ExportService
+	socialService SocialService
+	logger        *slog.Logger
+	validator     *validator.Validate
 }
 
-func New(userService UserService, taskService DefferedTaskService, logger *slog.Logger) Router {
+func New(userService UserService, taskService DefferedTaskService, exportService ExportService, socialService SocialService, logger *slog.Logger) Router {
 	return Router{
-		Mux:         http.NewServeMux(),
-		userService: userService,
-		taskService: taskService,
-		logger:      logger,
-		validator:   validator.New(validator.WithRequiredStructEnabled()),
+		Mux:           http.NewServeMux(),
+		userService:   userService,
+		taskService:   taskService,
+		exportService: exportService,
+		socialService: socialService,
+		logger:        logger,
+		validator:     validator.New(validator.WithRequiredStructEnabled()),
 	}
 }
 
@@ -85,6 +114,10 @@ func (router *Router) Run() {
 	router.Handle("POST /user/login", http.HandlerFunc(router.Login), CORS, middleware.Recover, middleware.Logging)
 	router.Handle("POST /user/logout", http.HandlerFunc(router.Logout), CORS, middleware.Recover, middleware.Logging)
 	router.Handle("POST /user/refresh", http.HandlerFunc(router.RefreshTokens), CORS, middleware.Recover, middleware.Logging, middleware.RefreshJWT)
+	router.Handle("POST /user/reactivate", http.HandlerFunc(router.ReactivateUser), CORS, middleware.Recover, middleware.Logging)
+
+	router.Handle("POST /oauth/introspect", http.HandlerFunc(router.IntrospectToken), middleware.Recover, middleware.Logging, middleware.ServiceToken)
+	router.Handle("POST /oauth/revoke", http.HandlerFunc(router.RevokeToken), CORS, middleware.Recover, middleware.Logging)
 
 	router.Handle("OPTIONS /user/get", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
@@ -92,11 +125,15 @@ func (router *Router) Run() {
 		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
 		w.WriteHeader(http.StatusOK)
 	}), middleware.Recover, middleware.Logging)
-	router.Handle("GET /user/get", http.HandlerFunc(router.GetUserByID), CORS, middleware.Recover, middleware.Logging, middleware.AccessJWT)
-	router.Handle("GET /user/all", http.HandlerFunc(router.GetAllUsers), CORS, middleware.Recover, middleware.Logging, middleware.AccessJWT)
+	router.Handle("GET /user/get", http.HandlerFunc(router.GetUserByID), CORS, middleware.Recover, middleware.Logging, middleware.RevokedAccess(router.userService), middleware.AccessJWT)
+	router.Handle("GET /user/all", http.HandlerFunc(router.GetAllUsers), CORS, middleware.Recover, middleware.Logging, middleware.RevokedAccess(router.userService), middleware.AccessJWT)
+
+	router.Handle("DELETE /user/delete", http.HandlerFunc(router.DeleteUser), CORS, middleware.Recover, middleware.Logging, middleware.RevokedAccess(router.userService), middleware.AccessJWT)
+	router.Handle("PUT /user/update", http.HandlerFunc(router.UpdateUser), CORS, middleware.Recover, middleware.Logging, middleware.RevokedAccess(router.userService), middleware.AccessJWT)
+	router.Handle("PUT /user/role", http.HandlerFunc(router.ChangeRole), CORS, middleware.Recover, middleware.Logging, middleware.RevokedAccess(router.userService), middleware.AccessJWT)
 
-	router.Handle("DELETE /user/delete", http.HandlerFunc(router.DeleteUser), CORS, middleware.Recover, middleware.Logging, middleware.AccessJWT)
-	router.Handle("PUT /user/update", http.HandlerFunc(router.UpdateUser), CORS, middleware.Recover, middleware.Logging, middleware.AccessJWT)
+	router.Handle("POST /user/export", http.HandlerFunc(router.RequestExport), CORS, middleware.Recover, middleware.Logging, middleware.RevokedAccess(router.userService), middleware.AccessJWT)
+	router.Handle("GET /user/export/{id}", http.HandlerFunc(router.GetExport), CORS, middleware.Recover, middleware.Logging, middleware.RevokedAccess(router.userService), middleware.AccessJWT)
 
 	// Handle OPTIONS requests for tracks/favorite separately to allow preflight without JWT
 	router.Handle("OPTIONS /user/track/favorite", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -107,8 +144,48 @@ func (router *Router) Run() {
 	}), middleware.Recover, middleware.Logging)
 
 	// Regular non-OPTIONS requests still need JWT auth
-	router.Handle("POST /user/track/favorite", http.HandlerFunc(router.ActionWithSong), CORS, middleware.Recover, middleware.Logging, middleware.AccessJWT)
-	router.Handle("DELETE /user/track/favorite", http.HandlerFunc(router.ActionWithSong), CORS, middleware.Recover, middleware.Logging, middleware.AccessJWT)
+	router.Handle("POST /user/track/favorite", http.HandlerFunc(router.ActionWithSong), CORS, middleware.Recover, middleware.Logging, middleware.RevokedAccess(router.userService), middleware.AccessJWT)
+	router.Handle("DELETE /user/track/favorite", http.HandlerFunc(router.ActionWithSong), CORS, middleware.Recover, middleware.Logging, middleware.RevokedAccess(router.userService), middleware.AccessJWT)
+
+	router.Handle("OPTIONS /me/plays", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.WriteHeader(http.StatusOK)
+	}), middleware.Recover, middleware.Logging)
+	router.Handle("POST /me/plays", http.HandlerFunc(router.RecordPlay), CORS, middleware.Recover, middleware.Logging, middleware.RevokedAccess(router.userService), middleware.AccessJWT)
+
+	router.Handle("OPTIONS /me/following/{kind}/{id}", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Access-Control-Allow-Methods", "POST, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.WriteHeader(http.StatusOK)
+	}), middleware.Recover, middleware.Logging)
+	router.Handle("POST /me/following/{kind}/{id}", http.HandlerFunc(router.Follow), CORS, middleware.Recover, middleware.Logging, middleware.RevokedAccess(router.userService), middleware.AccessJWT)
+	router.Handle("DELETE /me/following/{kind}/{id}", http.HandlerFunc(router.Unfollow), CORS, middleware.Recover, middleware.Logging, middleware.RevokedAccess(router.userService), middleware.AccessJWT)
+
+	router.Handle("OPTIONS /me/feed", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.WriteHeader(http.StatusOK)
+	}), middleware.Recover, middleware.Logging)
+	router.Handle("GET /me/feed", http.HandlerFunc(router.Feed), CORS, middleware.Recover, middleware.Logging, middleware.RevokedAccess(router.userService), middleware.AccessJWT)
+
+	// /user/{id}/followers would conflict with /user/export/{id}, so the social graph lives under /social
+	router.Handle("OPTIONS /social/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.WriteHeader(http.StatusOK)
+	}), middleware.Recover, middleware.Logging)
+	router.Handle("GET /social/{id}", http.HandlerFunc(router.FollowCounts), CORS, middleware.Recover, middleware.Logging, middleware.RevokedAccess(router.userService), middleware.AccessJWT)
+	router.Handle("GET /social/{id}/followers", http.HandlerFunc(router.Followers), CORS, middleware.Recover, middleware.Logging, middleware.RevokedAccess(router.userService), middleware.AccessJWT)
+	router.Handle("GET /social/{id}/following", http.HandlerFunc(router.Following), CORS, middleware.Recover, middleware.Logging, middleware.RevokedAccess(router.userService), middleware.AccessJWT)
+
+	// used by other services (notifications, music), not proxied by the gateway
+	router.Handle("POST /internal/social/followers", http.HandlerFunc(router.FilterFollowers), middleware.Recover, middleware.Logging, middleware.ServiceToken)
+	router.Handle("PUT /internal/users/{id}/ban", http.HandlerFunc(router.BanUser), middleware.Recover, middleware.Logging, middleware.ServiceToken)
 
 	// Добавляем обработку OPTIONS запросов для нового эндпоинта
 	router.Handle("OPTIONS /user/{id}", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -478,6 +555,42 @@ func (router *Router) Login(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, string(data), http.StatusBadRequest)
 			return
 		}
+		if errors.Is(err, allerrors.ErrUserDeactivated) {
+			resp := LoginResponse{
+				Response: lib.Response{
+					StatusCode: http.StatusForbidden,
+					Message:    "account deactivated, use /user/reactivate to restore it",
+				},
+			}
+			data, err := gojson.Marshal(resp)
+			if err != nil {
+				slog.Info("gojson marshal", slog.String("error", err.Error()))
+
+				http.Error(w, "account deactivated", http.StatusForbidden)
+				return
+			}
+
+			http.Error(w, string(data), http.StatusForbidden)
+			return
+		}
+		if errors.Is(err, allerrors.ErrUserBanned) {
+			resp := LoginResponse{
+				Response: lib.Response{
+					StatusCode: http.StatusForbidden,
+					Message:    "account banned",
+				},
+			}
+			data, err := gojson.Marshal(resp)
+			if err != nil {
+				slog.Info("gojson marshal", slog.String("error", err.Error()))
+
+				http.Error(w, "account banned", http.StatusForbidden)
+				return
+			}
+
+			http.Error(w, string(data), http.StatusForbidden)
+			return
+		}
 
 		slog.Error("server error")
 		resp := LoginResponse{
@@ -663,6 +776,8 @@ func (router *Router) Logout(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	router.revokeRequestAccess(r)
+
 	http.SetCookie(w, &http.Cookie{
 		Name:     "jwt-access",
 		Value:    "",
@@ -732,8 +847,8 @@ func (router *Router) RefreshTokens(w http.ResponseWriter, r *http.Request) {
 	if !ok {
 		slog.Info("refreshTokens exp missed")
 	}
-	versionCredentials, ok := claims["version_credentials"].(int)
-	if !ok {
+	versionCredentials := middleware.VersionCredentials(claims)
+	if versionCredentials == 0 {
 		slog.Info("refreshTokens version_credentials missed")
 	}
 	unixExp := time.Unix(int64(exp), 0)
@@ -770,6 +885,25 @@ func (router *Router) RefreshTokens(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
+		if errors.Is(err, allerrors.ErrDifferentVersionCredentials) {
+			slog.Info("refresh token issued before credentials change")
+
+			resp := lib.Response{
+				StatusCode: http.StatusUnauthorized,
+				Message:    "credentials changed, please, login again",
+			}
+			data, err := gojson.Marshal(resp)
+			if err != nil {
+				slog.Info("gojson marshal", slog.String("error", err.Error()))
+
+				http.Error(w, "credentials changed, please, login again", http.StatusUnauthorized)
+				return
+			}
+
+			http.Error(w, string(data), http.StatusUnauthorized)
+			return
+		}
+
 		slog.Info("create tokens", slog.String("error", err.Error()))
 
 		resp := lib.Response{
@@ -1182,6 +1316,110 @@ func (router *Router) UpdateUser(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+type ChangeRoleRequest struct {
+	UserID string `json:"user_id" validate:"required,uuid"`
+	Role   string `json:"role" validate:"required,oneof=user admin"`
+}
+
+type ChangeRoleResponse struct {
+	Response lib.Response `json:"response"`
+	ID       string       `json:"id" omitempty:"true"`
+	Role     string       `json:"role" omitempty:"true"`
+}
+
+func (router *Router) ChangeRole(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value("claims").(jwt.MapClaims)
+	role, _ := claims["role"].(string)
+
+	if role != "admin" {
+		slog.Info("change role", slog.String("error", fmt.Sprintf("not a admin, ID: %v", claims["sub"])))
+
+		router.changeRoleError(w, http.StatusForbidden, "forbidden")
+		return
+	}
+
+	var req ChangeRoleRequest
+
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		slog.Info("changeRole read body", slog.String("error", err.Error()))
+
+		router.changeRoleError(w, http.StatusInternalServerError, "server error")
+		return
+	}
+	r.Body.Close()
+
+	err = gojson.Unmarshal(data, &req)
+	if err != nil {
+		slog.Info("gojson unmarshal", slog.String("error", err.Error()))
+
+		router.changeRoleError(w, http.StatusBadRequest, "bad request")
+		return
+	}
+
+	err = router.validator.Struct(req)
+	if err != nil {
+		slog.Info("changeRole validate", slog.String("error", err.Error()))
+
+		router.changeRoleError(w, http.StatusBadRequest, "bad request")
+		return
+	}
+
+	user, err := router.userService.ChangeRole(r.Context(), req.UserID, req.Role)
+	if err != nil {
+		slog.Info("changeRole handler", slog.String("error", err.Error()))
+
+		switch {
+		case errors.Is(err, allerrors.ErrUserNotExists):
+			router.changeRoleError(w, http.StatusNotFound, "user not found")
+		case errors.Is(err, allerrors.ErrWrongUUID):
+			router.changeRoleError(w, http.StatusBadRequest, "bad request")
+		default:
+			router.changeRoleError(w, http.StatusInternalServerError, "server error")
+		}
+		return
+	}
+
+	resp := ChangeRoleResponse{
+		Response: lib.Response{
+			StatusCode: http.StatusOK,
+			Message:    "success",
+		},
+		ID:   user.ID,
+		Role: user.Role,
+	}
+	data, err = gojson.Marshal(resp)
+	if err != nil {
+		slog.Info("gojson marshal", slog.String("error", err.Error()))
+
+		w.Write([]byte("success"))
+		return
+	}
+
+	_, err = w.Write(data)
+	if err != nil {
+		slog.Info("response write", slog.String("error", err.Error()))
+	}
+}
+
+func (router *Router) changeRoleError(w http.ResponseWriter, status int, message string) {
+	resp := ChangeRoleResponse{
+		Response: lib.Response{
+			StatusCode: status,
+			Message:    message,
+		},
+	}
+	data, err := gojson.Marshal(resp)
+	if err != nil {
+		slog.Info("gojson marshal", slog.String("error", err.Error()))
+
+		http.Error(w, message, status)
+		return
+	}
+
+	http.Error(w, string(data), status)
+}
+
 func (router *Router) DeleteUser(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Access-Control-Allow-Origin", "*")
 	w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
@@ -1213,6 +1451,16 @@ func (router *Router) DeleteUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// The account is deactivated, the token used for this request must not outlive it.
+	jti, okJTI := claims["jti"].(string)
+	exp, okExp := claims["exp"].(float64)
+	if okJTI && okExp {
+		err = router.userService.RevokeAccess(r.Context(), jti, time.Unix(int64(exp), 0))
+		if err != nil {
+			slog.Info("deleteUser handler revoke access", slog.String("error", err.Error()))
+		}
+	}
+
 	resp := lib.Response{
 		StatusCode: http.StatusOK,
 		Message:    "success",
@@ -1246,12 +1494,6 @@ func (router *Router) DeleteUser(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-type SongAction struct {
-	Action  string `json:"action"`
-	UserID  string `json:"user_id"`
-	TrackID string `json:"track_id"`
-}
-
 func (router *Router) ActionWithSong(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Access-Control-Allow-Origin", "*")
 	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, DELETE, OPTIONS")
@@ -1280,21 +1522,29 @@ func (router *Router) ActionWithSong(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// 3) Определяем действие: like или unlike
-	action := "like"
+	// 3) Определяем событие: like или unlike
+	var payload any = events.TrackLiked{UserID: userID, TrackID: songID}
+	eventType := events.TypeTrackLiked
 	if r.Method == http.MethodDelete {
-		action = "dislike"
+		payload = events.TrackUnliked{UserID: userID, TrackID: songID}
+		eventType = events.TypeTrackUnliked
 	}
 
-	// 4) Формируем задачу
-	songAction := SongAction{
-		Action:  action,
-		UserID:  userID,
-		TrackID: songID,
+	// 4) Формируем событие, payload проверяется по схеме
+	event, err := events.New(eventType, "users", payload)
+	if err != nil {
+		slog.Info("actionWithSong handler", slog.String("error", err.Error()))
+		resp := lib.Response{
+			StatusCode: http.StatusBadRequest,
+			Message:    "invalid {track_id} parameter",
+		}
+		data, _ := gojson.Marshal(resp)
+		http.Error(w, string(data), http.StatusBadRequest)
+		return
 	}
 
 	// 5) Сериализуем в JSON и логируем
-	rawData, err := gojson.Marshal(songAction)
+	rawData, err := gojson.Marshal(event)
 	if err != nil {
 		slog.Info("gojson marshal", slog.String("error", err.Error()))
 		http.Error(w, "server error", http.StatusInternalServerError)
@@ -1305,6 +1555,7 @@ func (router *Router) ActionWithSong(w http.ResponseWriter, r *http.Request) {
 	// 6) Пушим в таблицу deferred_tasks
 	task := models.DefferedTask{
 		Topic:     "songs_actions",
+		Key:       userID + ":" + songID,
 		Data:      rawData,
 		CreatedAt: time.Now(),
 	}
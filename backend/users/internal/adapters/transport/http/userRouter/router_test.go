@@ -30,7 +30,7 @@ func TestRegister(t *testing.T) {
 	mockUserService := userRouterMocks.NewMockUserService(ctrl)
 	mockTaskService := userRouterMocks.NewMockDefferedTaskService(ctrl)
 
-	router := New(mockUserService, mockTaskService, nil)
+	router := New(mockUserService, mockTaskService, nil, nil, nil)
 
 	testCases := []struct {
 		name           string
@@ -230,7 +230,7 @@ func TestLogin(t *testing.T) {
 	mockUserService := userRouterMocks.NewMockUserService(ctrl)
 	mockTaskService := userRouterMocks.NewMockDefferedTaskService(ctrl)
 
-	router := New(mockUserService, mockTaskService, nil)
+	router := New(mockUserService, mockTaskService, nil, nil, nil)
 
 	testCases := []struct {
 		name           string
@@ -396,7 +396,7 @@ func TestGetUserByIDHandler(t *testing.T) {
 	mockUserService := userRouterMocks.NewMockUserService(ctrl)
 	mockTaskService := userRouterMocks.NewMockDefferedTaskService(ctrl)
 
-	router := New(mockUserService, mockTaskService, nil)
+	router := New(mockUserService, mockTaskService, nil, nil, nil)
 
 	testCases := []struct {
 		name                 string
@@ -564,7 +564,7 @@ func TestGetAllUsersHandler(t *testing.T) {
 	mockTaskService := userRouterMocks.NewMockDefferedTaskService(ctrl)
 
 	// Инициализируем роутер с использованием моков
-	router := New(mockUserService, mockTaskService, nil)
+	router := New(mockUserService, mockTaskService, nil, nil, nil)
 
 	// Несколько фиктивных пользователей для тестов
 	fakeUsers := []models.User{
@@ -680,7 +680,7 @@ func TestUpdateUser(t *testing.T) {
 	mockTaskService := userRouterMocks.NewMockDefferedTaskService(ctrl)
 
 	// Инициализируем роутер с использованием моков
-	router := New(mockUserService, mockTaskService, nil)
+	router := New(mockUserService, mockTaskService, nil, nil, nil)
 
 	// Тестовые пользователи
 	testUser := models.User{
@@ -822,6 +822,217 @@ func TestUpdateUser(t *testing.T) {
 	}
 }
 
+func TestChangeRole(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserService := userRouterMocks.NewMockUserService(ctrl)
+	mockTaskService := userRouterMocks.NewMockDefferedTaskService(ctrl)
+
+	router := New(mockUserService, mockTaskService, nil, nil, nil)
+
+	targetID := uuid.NewString()
+	adminClaims := jwt.MapClaims{"role": "admin", "sub": uuid.NewString()}
+
+	testCases := []struct {
+		name            string
+		claims          jwt.MapClaims
+		body            string
+		mockSetup       func()
+		expectedStatus  int
+		expectedMessage string
+	}{
+		{
+			name:   "success",
+			claims: adminClaims,
+			body:   fmt.Sprintf(`{"user_id":"%s","role":"admin"}`, targetID),
+			mockSetup: func() {
+				mockUserService.EXPECT().ChangeRole(gomock.Any(), targetID, "admin").
+					Return(models.User{ID: targetID, Role: "admin", VersionCredentials: 2}, nil)
+			},
+			expectedStatus:  http.StatusOK,
+			expectedMessage: "success",
+		},
+		{
+			name:            "not an admin",
+			claims:          jwt.MapClaims{"role": "user", "sub": uuid.NewString()},
+			body:            fmt.Sprintf(`{"user_id":"%s","role":"admin"}`, targetID),
+			expectedStatus:  http.StatusForbidden,
+			expectedMessage: "forbidden",
+		},
+		{
+			name:            "unknown role",
+			claims:          adminClaims,
+			body:            fmt.Sprintf(`{"user_id":"%s","role":"root"}`, targetID),
+			expectedStatus:  http.StatusBadRequest,
+			expectedMessage: "bad request",
+		},
+		{
+			name:   "user not found",
+			claims: adminClaims,
+			body:   fmt.Sprintf(`{"user_id":"%s","role":"user"}`, targetID),
+			mockSetup: func() {
+				mockUserService.EXPECT().ChangeRole(gomock.Any(), targetID, "user").
+					Return(models.User{}, allerrors.ErrUserNotExists)
+			},
+			expectedStatus:  http.StatusNotFound,
+			expectedMessage: "user not found",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.mockSetup != nil {
+				tc.mockSetup()
+			}
+
+			req := httptest.NewRequest("PUT", "/user/role", bytes.NewBufferString(tc.body))
+			req = req.WithContext(context.WithValue(req.Context(), "claims", tc.claims))
+
+			rr := httptest.NewRecorder()
+			http.HandlerFunc(router.ChangeRole).ServeHTTP(rr, req)
+
+			require.Equal(t, tc.expectedStatus, rr.Code)
+
+			var response ChangeRoleResponse
+			err := json.Unmarshal(rr.Body.Bytes(), &response)
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedMessage, response.Response.Message)
+		})
+	}
+}
+
+func TestReactivateUser(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserService := userRouterMocks.NewMockUserService(ctrl)
+	mockTaskService := userRouterMocks.NewMockDefferedTaskService(ctrl)
+
+	router := New(mockUserService, mockTaskService, nil, nil, nil)
+
+	testCases := []struct {
+		name            string
+		body            string
+		mockSetup       func()
+		expectedStatus  int
+		expectedMessage string
+	}{
+		{
+			name: "success",
+			body: `{"username":"user","password":"password"}`,
+			mockSetup: func() {
+				mockUserService.EXPECT().ReactivateUser(gomock.Any(), models.User{Username: "user", Password: "password"}).Return(nil)
+			},
+			expectedStatus:  http.StatusOK,
+			expectedMessage: "success",
+		},
+		{
+			name: "wrong password",
+			body: `{"username":"user","password":"wrong"}`,
+			mockSetup: func() {
+				mockUserService.EXPECT().ReactivateUser(gomock.Any(), models.User{Username: "user", Password: "wrong"}).Return(allerrors.ErrWrongPass)
+			},
+			expectedStatus:  http.StatusBadRequest,
+			expectedMessage: "wrong password",
+		},
+		{
+			name: "already erased",
+			body: `{"username":"user","password":"password"}`,
+			mockSetup: func() {
+				mockUserService.EXPECT().ReactivateUser(gomock.Any(), models.User{Username: "user", Password: "password"}).Return(allerrors.ErrUserNotExists)
+			},
+			expectedStatus:  http.StatusNotFound,
+			expectedMessage: "username not found",
+		},
+		{
+			name:            "missing password",
+			body:            `{"username":"user"}`,
+			expectedStatus:  http.StatusBadRequest,
+			expectedMessage: "bad request",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.mockSetup != nil {
+				tc.mockSetup()
+			}
+
+			req := httptest.NewRequest("POST", "/user/reactivate", bytes.NewBufferString(tc.body))
+			rr := httptest.NewRecorder()
+			http.HandlerFunc(router.ReactivateUser).ServeHTTP(rr, req)
+
+			require.Equal(t, tc.expectedStatus, rr.Code)
+
+			var response lib.Response
+			err := json.Unmarshal(rr.Body.Bytes(), &response)
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedMessage, response.Message)
+		})
+	}
+}
+
+func TestExportHandlers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserService := userRouterMocks.NewMockUserService(ctrl)
+	mockExportService := userRouterMocks.NewMockExportService(ctrl)
+
+	router := New(mockUserService, nil, mockExportService, nil, nil)
+
+	userID := uuid.NewString()
+	exportID := uuid.NewString()
+	ctx := context.WithValue(context.Background(), "claims", jwt.MapClaims{"sub": userID})
+
+	t.Run("request export", func(t *testing.T) {
+		mockExportService.EXPECT().RequestExport(gomock.Any(), userID).
+			Return(models.DataExport{ID: exportID, UserID: userID, Status: models.ExportPending}, nil)
+
+		req := httptest.NewRequest("POST", "/user/export", nil).WithContext(ctx)
+		rr := httptest.NewRecorder()
+		http.HandlerFunc(router.RequestExport).ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusAccepted, rr.Code)
+
+		var response ExportResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		require.Equal(t, exportID, response.ID)
+		require.Equal(t, models.ExportPending, response.Status)
+		require.Empty(t, response.URL)
+	})
+
+	t.Run("export ready", func(t *testing.T) {
+		mockExportService.EXPECT().GetExport(gomock.Any(), userID, exportID).
+			Return(models.DataExport{ID: exportID, UserID: userID, Status: models.ExportDone}, "https://link", nil)
+
+		req := httptest.NewRequest("GET", "/user/export/"+exportID, nil).WithContext(ctx)
+		req.SetPathValue("id", exportID)
+		rr := httptest.NewRecorder()
+		http.HandlerFunc(router.GetExport).ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var response ExportResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		require.Equal(t, models.ExportDone, response.Status)
+		require.Equal(t, "https://link", response.URL)
+	})
+
+	t.Run("export not found", func(t *testing.T) {
+		mockExportService.EXPECT().GetExport(gomock.Any(), userID, exportID).
+			Return(models.DataExport{}, "", allerrors.ErrExportNotExists)
+
+		req := httptest.NewRequest("GET", "/user/export/"+exportID, nil).WithContext(ctx)
+		req.SetPathValue("id", exportID)
+		rr := httptest.NewRecorder()
+		http.HandlerFunc(router.GetExport).ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
+
 func TestDeleteUser(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -831,7 +1042,7 @@ func TestDeleteUser(t *testing.T) {
 	mockTaskService := userRouterMocks.NewMockDefferedTaskService(ctrl)
 
 	// Инициализируем роутер с использованием моков
-	router := New(mockUserService, mockTaskService, nil)
+	router := New(mockUserService, mockTaskService, nil, nil, nil)
 
 	// Тестовый пользователь
 	testUser := models.User{
@@ -932,7 +1143,7 @@ func TestRefreshTokens(t *testing.T) {
 	mockTaskService := userRouterMocks.NewMockDefferedTaskService(ctrl)
 
 	// Инициализируем роутер с использованием моков
-	router := New(mockUserService, mockTaskService, nil)
+	router := New(mockUserService, mockTaskService, nil, nil, nil)
 
 	// Тестовый пользователь
 	testUser := models.User{
@@ -1100,7 +1311,7 @@ func TestLogoutHandler(t *testing.T) {
 	mockTaskService := userRouterMocks.NewMockDefferedTaskService(ctrl)
 
 	// Инициализируем роутер с использованием моков
-	router := New(mockUserService, mockTaskService, nil)
+	router := New(mockUserService, mockTaskService, nil, nil, nil)
 
 	testExp := time.Now().Add(time.Hour).Unix()
 
@@ -1259,7 +1470,7 @@ func TestLogoutHandler(t *testing.T) {
 //     mockUserService := userRouterMocks.NewMockUserService(ctrl)
 //     mockTaskService := userRouterMocks.NewMockDefferedTaskService(ctrl)
 
-//     router := New(mockUserService, mockTaskService, nil)
+//     router := New(mockUserService, mockTaskService, nil, nil, nil)
 
 //     testUser := models.User{
 //         ID:       "123",
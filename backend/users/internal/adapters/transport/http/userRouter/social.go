@@ -0,0 +1,334 @@
+package userrouter
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Cwby333/user-microservice/internal/adapters/transport/http/lib"
+	allerrors "github.com/Cwby333/user-microservice/internal/allErrors"
+	"github.com/Cwby333/user-microservice/internal/models"
+
+	gojson "github.com/goccy/go-json"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+type FollowCountsResponse struct {
+	Response  lib.Response   `json:"response"`
+	ID        string         `json:"id"`
+	Followers map[string]int `json:"followers"`
+	Following map[string]int `json:"following"`
+}
+
+type FollowDTO struct {
+	ID         string    `json:"id"`
+	Username   string    `json:"username"`
+	Kind       string    `json:"kind"`
+	FollowedAt time.Time `json:"followed_at"`
+}
+
+type FollowsResponse struct {
+	Response   lib.Response `json:"response"`
+	Items      []FollowDTO  `json:"items"`
+	NextCursor string       `json:"next_cursor,omitempty"`
+}
+
+type FeedItemDTO struct {
+	ID        string    `json:"id"`
+	Kind      string    `json:"kind"`
+	ActorID   string    `json:"actor_id"`
+	ActorName string    `json:"actor_name,omitempty"`
+	TrackID   string    `json:"track_id"`
+	Title     string    `json:"title"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type FeedResponse struct {
+	Response   lib.Response  `json:"response"`
+	Items      []FeedItemDTO `json:"items"`
+	NextCursor string        `json:"next_cursor,omitempty"`
+}
+
+type FilterFollowersRequest struct {
+	FolloweeID string   `json:"followee_id" validate:"required"`
+	Kind       string   `json:"kind"`
+	UserIDs    []string `json:"user_ids"`
+}
+
+type FilterFollowersResponse struct {
+	Response lib.Response `json:"response"`
+	UserIDs  []string     `json:"user_ids"`
+}
+
+// Follow handles POST /me/following/{kind}/{id}, kind is user or artist. Following again is not an error.
+func (router *Router) Follow(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value("claims").(jwt.MapClaims)
+	userID, _ := claims["sub"].(string)
+
+	err := router.socialService.Follow(r.Context(), userID, r.PathValue("kind"), r.PathValue("id"))
+	if err != nil {
+		slog.Info("follow handler", slog.String("error", err.Error()))
+
+		router.followError(w, err)
+		return
+	}
+
+	router.writeSocial(w, http.StatusOK, lib.Response{StatusCode: http.StatusOK, Message: "success"})
+}
+
+// Unfollow handles DELETE /me/following/{kind}/{id}.
+func (router *Router) Unfollow(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value("claims").(jwt.MapClaims)
+	userID, _ := claims["sub"].(string)
+
+	err := router.socialService.Unfollow(r.Context(), userID, r.PathValue("kind"), r.PathValue("id"))
+	if err != nil {
+		slog.Info("unfollow handler", slog.String("error", err.Error()))
+
+		router.followError(w, err)
+		return
+	}
+
+	router.writeSocial(w, http.StatusOK, lib.Response{StatusCode: http.StatusOK, Message: "success"})
+}
+
+// FollowCounts handles GET /social/{id}: followers and following by kind.
+func (router *Router) FollowCounts(w http.ResponseWriter, r *http.Request) {
+	ID := r.PathValue("id")
+
+	counts, err := router.socialService.Counts(r.Context(), ID)
+	if err != nil {
+		slog.Info("followCounts handler", slog.String("error", err.Error()))
+
+		if errors.Is(err, allerrors.ErrUserNotExists) || errors.Is(err, allerrors.ErrWrongUUID) {
+			router.accountError(w, http.StatusNotFound, "user not found")
+			return
+		}
+
+		router.accountError(w, http.StatusInternalServerError, "server error")
+		return
+	}
+
+	router.writeSocial(w, http.StatusOK, FollowCountsResponse{
+		Response:  lib.Response{StatusCode: http.StatusOK, Message: "success"},
+		ID:        ID,
+		Followers: counts.Followers,
+		Following: counts.Following,
+	})
+}
+
+// Followers handles GET /social/{id}/followers?kind=user|artist&cursor=&limit=
+func (router *Router) Followers(w http.ResponseWriter, r *http.Request) {
+	limit, ok := router.pageLimit(w, r)
+	if !ok {
+		return
+	}
+
+	follows, next, err := router.socialService.Followers(r.Context(), r.PathValue("id"), followKind(r), r.URL.Query().Get("cursor"), limit)
+	router.writeFollows(w, follows, next, err)
+}
+
+// Following handles GET /social/{id}/following?kind=user|artist&cursor=&limit=
+func (router *Router) Following(w http.ResponseWriter, r *http.Request) {
+	limit, ok := router.pageLimit(w, r)
+	if !ok {
+		return
+	}
+
+	follows, next, err := router.socialService.Following(r.Context(), r.PathValue("id"), followKind(r), r.URL.Query().Get("cursor"), limit)
+	router.writeFollows(w, follows, next, err)
+}
+
+// Feed handles GET /me/feed?cursor=&limit=: new releases of followed artists, newest first.
+func (router *Router) Feed(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value("claims").(jwt.MapClaims)
+	userID, _ := claims["sub"].(string)
+
+	limit, ok := router.pageLimit(w, r)
+	if !ok {
+		return
+	}
+
+	items, next, err := router.socialService.Feed(r.Context(), userID, r.URL.Query().Get("cursor"), limit)
+	if err != nil {
+		slog.Info("feed handler", slog.String("error", err.Error()))
+
+		if errors.Is(err, allerrors.ErrWrongCursor) {
+			router.accountError(w, http.StatusBadRequest, "wrong cursor")
+			return
+		}
+
+		router.accountError(w, http.StatusInternalServerError, "server error")
+		return
+	}
+
+	resp := FeedResponse{
+		Response:   lib.Response{StatusCode: http.StatusOK, Message: "success"},
+		Items:      make([]FeedItemDTO, 0, len(items)),
+		NextCursor: next,
+	}
+	for _, item := range items {
+		resp.Items = append(resp.Items, FeedItemDTO{
+			ID:        strconv.FormatInt(item.ID, 10),
+			Kind:      item.Kind,
+			ActorID:   item.ActorID,
+			ActorName: item.ActorName,
+			TrackID:   item.ObjectID,
+			Title:     item.Title,
+			CreatedAt: item.CreatedAt,
+		})
+	}
+
+	router.writeSocial(w, http.StatusOK, resp)
+}
+
+// FilterFollowers handles POST /internal/social/followers: which of user_ids follow followee_id as kind
+// (artist by default). Only for other services.
+func (router *Router) FilterFollowers(w http.ResponseWriter, r *http.Request) {
+	var req FilterFollowersRequest
+
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		slog.Info("filterFollowers read body", slog.String("error", err.Error()))
+
+		router.accountError(w, http.StatusInternalServerError, "server error")
+		return
+	}
+	r.Body.Close()
+
+	err = gojson.Unmarshal(data, &req)
+	if err != nil {
+		slog.Info("gojson unmarshal", slog.String("error", err.Error()))
+
+		router.accountError(w, http.StatusBadRequest, "bad request")
+		return
+	}
+
+	err = router.validator.Struct(req)
+	if err != nil {
+		slog.Info("filterFollowers validate", slog.String("error", err.Error()))
+
+		router.accountError(w, http.StatusBadRequest, "bad request")
+		return
+	}
+
+	if req.Kind == "" {
+		req.Kind = models.FollowArtist
+	}
+
+	followers, err := router.socialService.FilterFollowers(r.Context(), req.FolloweeID, req.Kind, req.UserIDs)
+	if err != nil {
+		slog.Info("filterFollowers handler", slog.String("error", err.Error()))
+
+		switch {
+		case errors.Is(err, allerrors.ErrWrongFollowKind):
+			router.accountError(w, http.StatusBadRequest, "kind must be user or artist")
+		case errors.Is(err, allerrors.ErrWrongUUID):
+			router.accountError(w, http.StatusBadRequest, "wrong followee_id")
+		case errors.Is(err, allerrors.ErrTooManyIDs):
+			router.accountError(w, http.StatusBadRequest, "too many user_ids")
+		default:
+			router.accountError(w, http.StatusInternalServerError, "server error")
+		}
+		return
+	}
+
+	router.writeSocial(w, http.StatusOK, FilterFollowersResponse{
+		Response: lib.Response{StatusCode: http.StatusOK, Message: "success"},
+		UserIDs:  followers,
+	})
+}
+
+// followKind defaults to user follows.
+func followKind(r *http.Request) string {
+	kind := r.URL.Query().Get("kind")
+	if kind == "" {
+		return models.FollowUser
+	}
+
+	return kind
+}
+
+// pageLimit reads ?limit=, 0 means the service default.
+func (router *Router) pageLimit(w http.ResponseWriter, r *http.Request) (int, bool) {
+	v := r.URL.Query().Get("limit")
+	if v == "" {
+		return 0, true
+	}
+
+	limit, err := strconv.Atoi(v)
+	if err != nil || limit <= 0 {
+		router.accountError(w, http.StatusBadRequest, "wrong limit")
+		return 0, false
+	}
+
+	return limit, true
+}
+
+func (router *Router) followError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, allerrors.ErrWrongFollowKind):
+		router.accountError(w, http.StatusBadRequest, "kind must be user or artist")
+	case errors.Is(err, allerrors.ErrFollowSelf):
+		router.accountError(w, http.StatusBadRequest, "can not follow yourself")
+	case errors.Is(err, allerrors.ErrUserNotExists), errors.Is(err, allerrors.ErrWrongUUID):
+		router.accountError(w, http.StatusNotFound, "user not found")
+	default:
+		router.accountError(w, http.StatusInternalServerError, "server error")
+	}
+}
+
+func (router *Router) writeFollows(w http.ResponseWriter, follows []models.Follow, next string, err error) {
+	if err != nil {
+		slog.Info("follows handler", slog.String("error", err.Error()))
+
+		switch {
+		case errors.Is(err, allerrors.ErrWrongFollowKind):
+			router.accountError(w, http.StatusBadRequest, "kind must be user or artist")
+		case errors.Is(err, allerrors.ErrWrongCursor):
+			router.accountError(w, http.StatusBadRequest, "wrong cursor")
+		case errors.Is(err, allerrors.ErrWrongUUID):
+			router.accountError(w, http.StatusNotFound, "user not found")
+		default:
+			router.accountError(w, http.StatusInternalServerError, "server error")
+		}
+		return
+	}
+
+	resp := FollowsResponse{
+		Response:   lib.Response{StatusCode: http.StatusOK, Message: "success"},
+		Items:      make([]FollowDTO, 0, len(follows)),
+		NextCursor: next,
+	}
+	for _, f := range follows {
+		resp.Items = append(resp.Items, FollowDTO{
+			ID:         f.UserID,
+			Username:   f.Username,
+			Kind:       f.Kind,
+			FollowedAt: f.CreatedAt,
+		})
+	}
+
+	router.writeSocial(w, http.StatusOK, resp)
+}
+
+func (router *Router) writeSocial(w http.ResponseWriter, status int, resp any) {
+	data, err := gojson.Marshal(resp)
+	if err != nil {
+		slog.Info("gojson marshal", slog.String("error", err.Error()))
+
+		http.Error(w, "server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	_, err = w.Write(data)
+	if err != nil {
+		slog.Info("response write", slog.String("error", err.Error()))
+	}
+}
@@ -0,0 +1,186 @@
+package userrouter
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Cwby333/user-microservice/internal/adapters/transport/http/userRouter/userRouterMocks"
+	allerrors "github.com/Cwby333/user-microservice/internal/allErrors"
+	"github.com/Cwby333/user-microservice/internal/models"
+	gojson "github.com/goccy/go-json"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFollowHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockSocialService := userRouterMocks.NewMockSocialService(ctrl)
+
+	router := New(nil, nil, nil, mockSocialService, nil)
+
+	userID := uuid.NewString()
+	artistID := uuid.NewString()
+	ctx := context.WithValue(context.Background(), "claims", jwt.MapClaims{"sub": userID})
+
+	testCases := []struct {
+		name           string
+		kind           string
+		mockErr        error
+		expectedStatus int
+	}{
+		{
+			name:           "followed",
+			kind:           models.FollowArtist,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "wrong kind",
+			kind:           "band",
+			mockErr:        allerrors.ErrWrongFollowKind,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "follow yourself",
+			kind:           models.FollowUser,
+			mockErr:        allerrors.ErrFollowSelf,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "user not found",
+			kind:           models.FollowUser,
+			mockErr:        allerrors.ErrUserNotExists,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "db error",
+			kind:           models.FollowUser,
+			mockErr:        errors.New("db down"),
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockSocialService.EXPECT().Follow(gomock.Any(), userID, tc.kind, artistID).Return(tc.mockErr)
+
+			req := httptest.NewRequest("POST", "/me/following/"+tc.kind+"/"+artistID, nil).WithContext(ctx)
+			req.SetPathValue("kind", tc.kind)
+			req.SetPathValue("id", artistID)
+			rr := httptest.NewRecorder()
+			http.HandlerFunc(router.Follow).ServeHTTP(rr, req)
+
+			require.Equal(t, tc.expectedStatus, rr.Code)
+		})
+	}
+}
+
+func TestFeedHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockSocialService := userRouterMocks.NewMockSocialService(ctrl)
+
+	router := New(nil, nil, nil, mockSocialService, nil)
+
+	userID := uuid.NewString()
+	ctx := context.WithValue(context.Background(), "claims", jwt.MapClaims{"sub": userID})
+
+	item := models.FeedItem{
+		ID:        42,
+		Kind:      models.FeedTrackPublished,
+		ActorID:   uuid.NewString(),
+		ActorName: "artist",
+		ObjectID:  uuid.NewString(),
+		Title:     "new track",
+		CreatedAt: time.Now().UTC(),
+	}
+
+	t.Run("page with next cursor", func(t *testing.T) {
+		mockSocialService.EXPECT().Feed(gomock.Any(), userID, "50", 1).Return([]models.FeedItem{item}, "42", nil)
+
+		req := httptest.NewRequest("GET", "/me/feed?cursor=50&limit=1", nil).WithContext(ctx)
+		rr := httptest.NewRecorder()
+		http.HandlerFunc(router.Feed).ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var resp FeedResponse
+		require.NoError(t, gojson.Unmarshal(rr.Body.Bytes(), &resp))
+		require.Equal(t, "42", resp.NextCursor)
+		require.Len(t, resp.Items, 1)
+		require.Equal(t, item.ObjectID, resp.Items[0].TrackID)
+		require.Equal(t, "artist", resp.Items[0].ActorName)
+	})
+
+	t.Run("wrong limit", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/me/feed?limit=-1", nil).WithContext(ctx)
+		rr := httptest.NewRecorder()
+		http.HandlerFunc(router.Feed).ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("wrong cursor", func(t *testing.T) {
+		mockSocialService.EXPECT().Feed(gomock.Any(), userID, "abc", 0).Return(nil, "", allerrors.ErrWrongCursor)
+
+		req := httptest.NewRequest("GET", "/me/feed?cursor=abc", nil).WithContext(ctx)
+		rr := httptest.NewRecorder()
+		http.HandlerFunc(router.Feed).ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
+
+func TestFilterFollowersHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockSocialService := userRouterMocks.NewMockSocialService(ctrl)
+
+	router := New(nil, nil, nil, mockSocialService, nil)
+
+	artistID := uuid.NewString()
+	follower := uuid.NewString()
+
+	t.Run("artist by default", func(t *testing.T) {
+		mockSocialService.EXPECT().FilterFollowers(gomock.Any(), artistID, models.FollowArtist, []string{follower}).Return([]string{follower}, nil)
+
+		body := `{"followee_id":"` + artistID + `","user_ids":["` + follower + `"]}`
+		req := httptest.NewRequest("POST", "/internal/social/followers", strings.NewReader(body))
+		rr := httptest.NewRecorder()
+		http.HandlerFunc(router.FilterFollowers).ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var resp FilterFollowersResponse
+		require.NoError(t, gojson.Unmarshal(rr.Body.Bytes(), &resp))
+		require.Equal(t, []string{follower}, resp.UserIDs)
+	})
+
+	t.Run("missing followee", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/internal/social/followers", strings.NewReader(`{"user_ids":[]}`))
+		rr := httptest.NewRecorder()
+		http.HandlerFunc(router.FilterFollowers).ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("too many ids", func(t *testing.T) {
+		mockSocialService.EXPECT().FilterFollowers(gomock.Any(), artistID, models.FollowArtist, gomock.Any()).Return(nil, allerrors.ErrTooManyIDs)
+
+		req := httptest.NewRequest("POST", "/internal/social/followers", strings.NewReader(`{"followee_id":"`+artistID+`"}`))
+		rr := httptest.NewRecorder()
+		http.HandlerFunc(router.FilterFollowers).ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
@@ -36,6 +36,51 @@ func (m *MockUserService) EXPECT() *MockUserServiceMockRecorder {
 	return m.recorder
 }
 
+// BanUser mocks base method.
+func (m *MockUserService) BanUser(ctx context.Context, ID string, banned bool) (models.User, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BanUser", ctx, ID, banned)
+	ret0, _ := ret[0].(models.User)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// BanUser indicates an expected call of BanUser.
+func (mr *MockUserServiceMockRecorder) BanUser(ctx, ID, banned interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BanUser", reflect.TypeOf((*MockUserService)(nil).BanUser), ctx, ID, banned)
+}
+
+// ChangeRole mocks base method.
+func (m *MockUserService) ChangeRole(ctx context.Context, ID, role string) (models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChangeRole", ctx, ID, role)
+	ret0, _ := ret[0].(models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChangeRole indicates an expected call of ChangeRole.
+func (mr *MockUserServiceMockRecorder) ChangeRole(ctx, ID, role interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangeRole", reflect.TypeOf((*MockUserService)(nil).ChangeRole), ctx, ID, role)
+}
+
+// CheckAccess mocks base method.
+func (m *MockUserService) CheckAccess(ctx context.Context, access models.JWTAccess) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheckAccess", ctx, access)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CheckAccess indicates an expected call of CheckAccess.
+func (mr *MockUserServiceMockRecorder) CheckAccess(ctx, access interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckAccess", reflect.TypeOf((*MockUserService)(nil).CheckAccess), ctx, access)
+}
+
 // DeleteUser mocks base method.
 func (m *MockUserService) DeleteUser(ctx context.Context, ID string) error {
 	m.ctrl.T.Helper()
@@ -65,6 +110,21 @@ func (mr *MockUserServiceMockRecorder) FindUserByID(ctx, ID interface{}) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindUserByID", reflect.TypeOf((*MockUserService)(nil).FindUserByID), ctx, ID)
 }
 
+// FindUsersByIDs mocks base method.
+func (m *MockUserService) FindUsersByIDs(ctx context.Context, IDs []string) ([]models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindUsersByIDs", ctx, IDs)
+	ret0, _ := ret[0].([]models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindUsersByIDs indicates an expected call of FindUsersByIDs.
+func (mr *MockUserServiceMockRecorder) FindUsersByIDs(ctx, IDs interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindUsersByIDs", reflect.TypeOf((*MockUserService)(nil).FindUsersByIDs), ctx, IDs)
+}
+
 // GetAllUsers mocks base method.
 func (m *MockUserService) GetAllUsers(ctx context.Context) ([]models.User, error) {
 	m.ctrl.T.Helper()
@@ -80,6 +140,21 @@ func (mr *MockUserServiceMockRecorder) GetAllUsers(ctx interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllUsers", reflect.TypeOf((*MockUserService)(nil).GetAllUsers), ctx)
 }
 
+// IntrospectToken mocks base method.
+func (m *MockUserService) IntrospectToken(ctx context.Context, token string) (models.TokenIntrospection, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IntrospectToken", ctx, token)
+	ret0, _ := ret[0].(models.TokenIntrospection)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IntrospectToken indicates an expected call of IntrospectToken.
+func (mr *MockUserServiceMockRecorder) IntrospectToken(ctx, token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IntrospectToken", reflect.TypeOf((*MockUserService)(nil).IntrospectToken), ctx, token)
+}
+
 // Login mocks base method.
 func (m *MockUserService) Login(ctx context.Context, user models.User) (models.JWTAccess, models.JWTRefresh, error) {
 	m.ctrl.T.Helper()
@@ -110,6 +185,20 @@ func (mr *MockUserServiceMockRecorder) Logout(ctx, tokenID, unixTimeExpired inte
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Logout", reflect.TypeOf((*MockUserService)(nil).Logout), ctx, tokenID, unixTimeExpired)
 }
 
+// ReactivateUser mocks base method.
+func (m *MockUserService) ReactivateUser(ctx context.Context, user models.User) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReactivateUser", ctx, user)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReactivateUser indicates an expected call of ReactivateUser.
+func (mr *MockUserServiceMockRecorder) ReactivateUser(ctx, user interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReactivateUser", reflect.TypeOf((*MockUserService)(nil).ReactivateUser), ctx, user)
+}
+
 // RefreshTokens mocks base method.
 func (m *MockUserService) RefreshTokens(ctx context.Context, tokenID string, refreshVersionCredentials int, expTime time.Time, user models.User) (models.JWTAccess, models.JWTRefresh, error) {
 	m.ctrl.T.Helper()
@@ -141,6 +230,34 @@ func (mr *MockUserServiceMockRecorder) Register(ctx, user interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Register", reflect.TypeOf((*MockUserService)(nil).Register), ctx, user)
 }
 
+// RevokeAccess mocks base method.
+func (m *MockUserService) RevokeAccess(ctx context.Context, tokenID string, expTime time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeAccess", ctx, tokenID, expTime)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeAccess indicates an expected call of RevokeAccess.
+func (mr *MockUserServiceMockRecorder) RevokeAccess(ctx, tokenID, expTime interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeAccess", reflect.TypeOf((*MockUserService)(nil).RevokeAccess), ctx, tokenID, expTime)
+}
+
+// RevokeToken mocks base method.
+func (m *MockUserService) RevokeToken(ctx context.Context, token string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeToken", ctx, token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeToken indicates an expected call of RevokeToken.
+func (mr *MockUserServiceMockRecorder) RevokeToken(ctx, token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeToken", reflect.TypeOf((*MockUserService)(nil).RevokeToken), ctx, token)
+}
+
 // UpdateUser mocks base method.
 func (m *MockUserService) UpdateUser(ctx context.Context, ID string, newUserInfo models.User) (models.User, error) {
 	m.ctrl.T.Helper()
@@ -192,3 +309,186 @@ func (mr *MockDefferedTaskServiceMockRecorder) ActionWithSong(ctx, task interfac
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ActionWithSong", reflect.TypeOf((*MockDefferedTaskService)(nil).ActionWithSong), ctx, task)
 }
+
+// MockExportService is a mock of ExportService interface.
+type MockExportService struct {
+	ctrl     *gomock.Controller
+	recorder *MockExportServiceMockRecorder
+}
+
+// MockExportServiceMockRecorder is the mock recorder for MockExportService.
+type MockExportServiceMockRecorder struct {
+	mock *MockExportService
+}
+
+// NewMockExportService creates a new mock instance.
+func NewMockExportService(ctrl *gomock.Controller) *MockExportService {
+	mock := &MockExportService{ctrl: ctrl}
+	mock.recorder = &MockExportServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockExportService) EXPECT() *MockExportServiceMockRecorder {
+	return m.recorder
+}
+
+// GetExport mocks base method.
+func (m *MockExportService) GetExport(ctx context.Context, userID, exportID string) (models.DataExport, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetExport", ctx, userID, exportID)
+	ret0, _ := ret[0].(models.DataExport)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetExport indicates an expected call of GetExport.
+func (mr *MockExportServiceMockRecorder) GetExport(ctx, userID, exportID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetExport", reflect.TypeOf((*MockExportService)(nil).GetExport), ctx, userID, exportID)
+}
+
+// RequestExport mocks base method.
+func (m *MockExportService) RequestExport(ctx context.Context, userID string) (models.DataExport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RequestExport", ctx, userID)
+	ret0, _ := ret[0].(models.DataExport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RequestExport indicates an expected call of RequestExport.
+func (mr *MockExportServiceMockRecorder) RequestExport(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RequestExport", reflect.TypeOf((*MockExportService)(nil).RequestExport), ctx, userID)
+}
+
+// MockSocialService is a mock of SocialService interface.
+type MockSocialService struct {
+	ctrl     *gomock.Controller
+	recorder *MockSocialServiceMockRecorder
+}
+
+// MockSocialServiceMockRecorder is the mock recorder for MockSocialService.
+type MockSocialServiceMockRecorder struct {
+	mock *MockSocialService
+}
+
+// NewMockSocialService creates a new mock instance.
+func NewMockSocialService(ctrl *gomock.Controller) *MockSocialService {
+	mock := &MockSocialService{ctrl: ctrl}
+	mock.recorder = &MockSocialServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSocialService) EXPECT() *MockSocialServiceMockRecorder {
+	return m.recorder
+}
+
+// Counts mocks base method.
+func (m *MockSocialService) Counts(ctx context.Context, userID string) (models.FollowCounts, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Counts", ctx, userID)
+	ret0, _ := ret[0].(models.FollowCounts)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Counts indicates an expected call of Counts.
+func (mr *MockSocialServiceMockRecorder) Counts(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Counts", reflect.TypeOf((*MockSocialService)(nil).Counts), ctx, userID)
+}
+
+// Feed mocks base method.
+func (m *MockSocialService) Feed(ctx context.Context, userID, cursor string, limit int) ([]models.FeedItem, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Feed", ctx, userID, cursor, limit)
+	ret0, _ := ret[0].([]models.FeedItem)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Feed indicates an expected call of Feed.
+func (mr *MockSocialServiceMockRecorder) Feed(ctx, userID, cursor, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Feed", reflect.TypeOf((*MockSocialService)(nil).Feed), ctx, userID, cursor, limit)
+}
+
+// FilterFollowers mocks base method.
+func (m *MockSocialService) FilterFollowers(ctx context.Context, followeeID, kind string, userIDs []string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FilterFollowers", ctx, followeeID, kind, userIDs)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FilterFollowers indicates an expected call of FilterFollowers.
+func (mr *MockSocialServiceMockRecorder) FilterFollowers(ctx, followeeID, kind, userIDs interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FilterFollowers", reflect.TypeOf((*MockSocialService)(nil).FilterFollowers), ctx, followeeID, kind, userIDs)
+}
+
+// Follow mocks base method.
+func (m *MockSocialService) Follow(ctx context.Context, followerID, kind, followeeID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Follow", ctx, followerID, kind, followeeID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Follow indicates an expected call of Follow.
+func (mr *MockSocialServiceMockRecorder) Follow(ctx, followerID, kind, followeeID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Follow", reflect.TypeOf((*MockSocialService)(nil).Follow), ctx, followerID, kind, followeeID)
+}
+
+// Followers mocks base method.
+func (m *MockSocialService) Followers(ctx context.Context, userID, kind, cursor string, limit int) ([]models.Follow, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Followers", ctx, userID, kind, cursor, limit)
+	ret0, _ := ret[0].([]models.Follow)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Followers indicates an expected call of Followers.
+func (mr *MockSocialServiceMockRecorder) Followers(ctx, userID, kind, cursor, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Followers", reflect.TypeOf((*MockSocialService)(nil).Followers), ctx, userID, kind, cursor, limit)
+}
+
+// Following mocks base method.
+func (m *MockSocialService) Following(ctx context.Context, userID, kind, cursor string, limit int) ([]models.Follow, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Following", ctx, userID, kind, cursor, limit)
+	ret0, _ := ret[0].([]models.Follow)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Following indicates an expected call of Following.
+func (mr *MockSocialServiceMockRecorder) Following(ctx, userID, kind, cursor, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Following", reflect.TypeOf((*MockSocialService)(nil).Following), ctx, userID, kind, cursor, limit)
+}
+
+// Unfollow mocks base method.
+func (m *MockSocialService) Unfollow(ctx context.Context, followerID, kind, followeeID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Unfollow", ctx, followerID, kind, followeeID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Unfollow indicates an expected call of Unfollow.
+func (mr *MockSocialServiceMockRecorder) Unfollow(ctx, followerID, kind, followeeID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Unfollow", reflect.TypeOf((*MockSocialService)(nil).Unfollow), ctx, followerID, kind, followeeID)
+}
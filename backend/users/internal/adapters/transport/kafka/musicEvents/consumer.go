@@ -0,0 +1,186 @@
+package musicevents
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"gitlab.com/Go34/Mute/shared/events"
+)
+
+type Config struct {
+	Brokers string
+	GroupID string
+	Topic   string
+}
+
+type ReleaseService interface {
+	AddRelease(ctx context.Context, eventID string, artistID string, trackID string, title string, publishedAt time.Time) (int64, error)
+	HideRelease(ctx context.Context, trackID string, hidden bool) (int64, error)
+	RemoveRelease(ctx context.Context, trackID string) (int64, error)
+}
+
+// Consumer reads events published by the music service. A message is committed only
+// after its handler succeeded, handlers must be idempotent: a message can come twice.
+type Consumer struct {
+	config Config
+	router *events.Router
+	logger *slog.Logger
+}
+
+func New(cfg Config, releases ReleaseService, logger *slog.Logger) Consumer {
+	router := events.NewRouter()
+
+	events.On(router, events.TypeTrackPublished, func(ctx context.Context, e events.Envelope, p events.TrackPublished) error {
+		n, err := releases.AddRelease(ctx, e.ID, p.ArtistID, p.TrackID, p.Title, e.OccurredAt)
+		if err != nil {
+			return err
+		}
+
+		logger.Info("release added to feeds", slog.String("track_id", p.TrackID), slog.Int64("feeds", n))
+		return nil
+	})
+
+	// feeds follow the track visibility in music: a hidden track is filtered out
+	// until it is restored, a deleted one is dropped
+	events.On(router, events.TypeTrackHidden, func(ctx context.Context, e events.Envelope, p events.TrackHidden) error {
+		n, err := releases.HideRelease(ctx, p.TrackID, true)
+		if err != nil {
+			return err
+		}
+
+		logger.Info("release hidden in feeds", slog.String("track_id", p.TrackID), slog.Int64("feeds", n))
+		return nil
+	})
+
+	events.On(router, events.TypeTrackRestored, func(ctx context.Context, e events.Envelope, p events.TrackRestored) error {
+		n, err := releases.HideRelease(ctx, p.TrackID, false)
+		if err != nil {
+			return err
+		}
+
+		logger.Info("release restored in feeds", slog.String("track_id", p.TrackID), slog.Int64("feeds", n))
+		return nil
+	})
+
+	events.On(router, events.TypeTrackDeleted, func(ctx context.Context, e events.Envelope, p events.TrackDeleted) error {
+		n, err := releases.RemoveRelease(ctx, p.TrackID)
+		if err != nil {
+			return err
+		}
+
+		logger.Info("release removed from feeds", slog.String("track_id", p.TrackID), slog.Int64("feeds", n))
+		return nil
+	})
+
+	return Consumer{
+		config: cfg,
+		router: router,
+		logger: logger,
+	}
+}
+
+// Run consumes until ctx is done, recreating the consumer after fatal Kafka errors.
+func (c Consumer) Run(ctx context.Context) error {
+	backoff := time.Second
+
+	for {
+		err := c.consume(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		c.logger.Error("music events consumer", slog.String("error", err.Error()), slog.Duration("restart_in", backoff))
+		if !sleep(ctx, backoff) {
+			return nil
+		}
+		backoff = min(backoff*2, time.Minute)
+	}
+}
+
+func (c Consumer) consume(ctx context.Context) error {
+	const op = "./internal/adapters/transport/kafka/musicEvents/consumer.go.consume"
+
+	consumer, err := kafka.NewConsumer(&kafka.ConfigMap{
+		"bootstrap.servers":  c.config.Brokers,
+		"group.id":           c.config.GroupID,
+		"auto.offset.reset":  "earliest",
+		"enable.auto.commit": false,
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer consumer.Close()
+
+	err = consumer.Subscribe(c.config.Topic, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	c.logger.Info("music events consumer subscribed", slog.String("topic", c.config.Topic))
+
+	for ctx.Err() == nil {
+		msg, err := consumer.ReadMessage(500 * time.Millisecond)
+		if err != nil {
+			var kafkaErr kafka.Error
+			if errors.As(err, &kafkaErr) && (kafkaErr.Code() == kafka.ErrTimedOut || !kafkaErr.IsFatal()) {
+				continue
+			}
+
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		if !c.handle(ctx, msg) {
+			return nil
+		}
+
+		_, err = consumer.CommitMessage(msg)
+		if err != nil {
+			c.logger.Info("music events commit", slog.String("error", err.Error()))
+		}
+	}
+
+	return nil
+}
+
+// handle retries the message until it is applied. Messages that can never be applied
+// (invalid, or events users does not need) are skipped. false means ctx is done.
+func (c Consumer) handle(ctx context.Context, msg *kafka.Message) bool {
+	e, err := events.Parse(msg.Value)
+	if err != nil {
+		if !errors.Is(err, events.ErrUnknownEvent) {
+			c.logger.Error("music event skipped", slog.String("error", err.Error()), slog.String("offset", msg.TopicPartition.String()))
+		}
+
+		return true
+	}
+
+	backoff := time.Second
+	for {
+		err := c.router.Dispatch(ctx, e)
+		switch {
+		case err == nil, errors.Is(err, events.ErrUnknownEvent):
+			return true
+		case errors.Is(err, events.ErrInvalidEvent):
+			c.logger.Error("music event skipped", slog.String("event_id", e.ID), slog.String("error", err.Error()))
+			return true
+		}
+
+		c.logger.Error("music event", slog.String("event_id", e.ID), slog.String("error", err.Error()), slog.Duration("retry_in", backoff))
+		if !sleep(ctx, backoff) {
+			return false
+		}
+		backoff = min(backoff*2, time.Minute)
+	}
+}
+
+func sleep(ctx context.Context, d time.Duration) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(d):
+		return true
+	}
+}
@@ -15,12 +15,15 @@ var (
 	ErrUsernameExists = errors.New("username exists")
 	ErrEmailExists    = errors.New("email exists")
 	ErrUserNotExists  = errors.New("user not exists")
+	ErrExportNotExists = errors.New("export not exists")
+	ErrNoPendingExports = errors.New("no pending exports")
 )
 
 // CACHE
 var (
 	ErrNotFoundInCache = errors.New("not found in cache")
 	ErrTokenInBlackList = errors.New("refresh token in blacklist")
+	ErrAccessTokenInBlackList = errors.New("access token in blacklist")
 )
 
 // SERVICE
@@ -30,4 +33,11 @@ var (
 	ErrPasswordBig   = errors.New("password bigger than " + strconv.Itoa(maxPasswordLen))
 	ErrWrongUUID     = errors.New("wrong uuid")
 	ErrDifferentVersionCredentials = errors.New("version in token is different of current version")
+	ErrUserDeactivated = errors.New("user deactivated")
+	ErrWrongFollowKind = errors.New("follow kind must be user or artist")
+	ErrFollowSelf = errors.New("can not follow yourself")
+	ErrWrongCursor = errors.New("wrong cursor")
+	ErrTooManyIDs = errors.New("too many ids")
+	ErrUserBanned = errors.New("user banned")
+	ErrBanAdmin = errors.New("can not ban an admin")
 )
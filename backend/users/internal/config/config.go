@@ -9,10 +9,14 @@ import (
 )
 
 type Config struct {
-	Server Server `yaml:"server" env-required:"true"`
-	DB     DB     `yaml:"postgres" env-required:"true"`
-	JWT    JWT    `yaml:"jwt" env-required:"true"`
-	Redis  Redis  `yaml:"redis" env-required:"true"`
+	Server   Server   `yaml:"server" env-required:"true"`
+	DB       DB       `yaml:"postgres" env-required:"true"`
+	JWT      JWT      `yaml:"jwt" env-required:"true"`
+	Redis    Redis    `yaml:"redis" env-required:"true"`
+	GRPC     GRPC     `yaml:"grpc" env-required:"true"`
+	Deletion Deletion `yaml:"deletion"`
+	Export   Export   `yaml:"export"`
+	Kafka    Kafka    `yaml:"kafka"`
 }
 
 type Server struct {
@@ -21,6 +25,54 @@ type Server struct {
 	ShutdownTimeout time.Duration `yaml:"shutdown-timeout" env-required:"true"`
 }
 
+// Internal API for other services, not proxied by the gateway.
+// Clients authenticate with ServiceToken or, if TLS.CAFile is set, with a client certificate.
+// ServiceToken is read only from SERVICE_TOKEN: the HTTP internal endpoints of users and music
+// check the same variable, and the music client sends it.
+type GRPC struct {
+	Address      string `yaml:"address" env-required:"true"`
+	ServiceToken string `env:"SERVICE_TOKEN" env-required:"true"`
+
+	TLS struct {
+		CertFile string `yaml:"cert-file"`
+		KeyFile  string `yaml:"key-file"`
+		CAFile   string `yaml:"ca-file"`
+	} `yaml:"tls"`
+}
+
+// Deleted users can reactivate the account during GracePeriod, after that
+// they are erased by a job running every EraseInterval.
+type Deletion struct {
+	GracePeriod   time.Duration `yaml:"grace-period" env-default:"720h"`
+	EraseInterval time.Duration `yaml:"erase-interval" env-default:"1h"`
+	BatchSize     int           `yaml:"batch-size" env-default:"100"`
+}
+
+// Personal data export. Archives are stored in the same S3 bucket as the music
+// service uses, MusicURL points to its internal (not proxied) HTTP API.
+type Export struct {
+	Interval   time.Duration `yaml:"interval" env-default:"10s"`
+	LinkTTL    time.Duration `yaml:"link-ttl" env-default:"24h"`
+	StaleAfter time.Duration `yaml:"stale-after" env-default:"30m"`
+	MusicURL   string        `yaml:"music-url" env:"MUSIC_SERVICE_URL" env-default:"http://music:8080"`
+
+	S3 struct {
+		AccessKey string `yaml:"access-key" env:"S3_ACCESS_KEY"`
+		SecretKey string `yaml:"secret-key" env:"S3_SECRET_KEY"`
+		Region    string `yaml:"region" env:"S3_REGION"`
+		Endpoint  string `yaml:"endpoint" env:"S3_ENDPOINT"`
+		Bucket    string `yaml:"bucket" env:"S3_BUCKET"`
+	} `yaml:"s3"`
+}
+
+// Events published by other services. Users itself publishes through the
+// deffered_tasks outbox, sent to Kafka by the sender service.
+type Kafka struct {
+	Brokers          string `yaml:"brokers" env:"KAFKA_BOOTSTRAP_SERVERS" env-default:"kafka:9092"`
+	GroupID          string `yaml:"group-id" env-default:"users-consumer-group"`
+	MusicEventsTopic string `yaml:"music-events-topic" env-default:"music_events"`
+}
+
 // Postgres(pgxpool)
 type DB struct {
 	Host     string `yaml:"host" env-required:"true"`
@@ -5,8 +5,11 @@ import "time"
 // Task for sender microrservice
 
 type DefferedTask struct {
-	ID        string
-	Topic     string
+	ID    string
+	Topic string
+	// Key is the Kafka message key. Tasks with the same key are delivered in creation order,
+	// e.g. "user_id:track_id" for likes so a like and an unlike can't be reordered.
+	Key       string
 	Data      []byte
 	CreatedAt time.Time
 }
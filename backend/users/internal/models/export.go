@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+const (
+	ExportPending = "pending"
+	ExportRunning = "running"
+	ExportDone    = "done"
+	ExportFailed  = "failed"
+)
+
+type DataExport struct {
+	ID         string
+	UserID     string
+	Status     string
+	FileKey    string
+	Error      string
+	CreatedAt  time.Time
+	FinishedAt time.Time
+}
+
+// Login of a user, kept for the personal data export.
+type Session struct {
+	ID        string
+	UserID    string
+	TokenID   string
+	CreatedAt time.Time
+}
+
+// not entity, user's data owned by the music service
+type MusicData struct {
+	Likes   []Track
+	Uploads []Track
+}
+
+type Track struct {
+	ID        string
+	Title     string
+	ArtistID  string
+	CreatedAt time.Time
+}
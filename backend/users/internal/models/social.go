@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// Follow kinds: a user is followed as a person or as an artist (the uploader of tracks).
+// New releases reach the feed only through artist follows.
+const (
+	FollowUser   = "user"
+	FollowArtist = "artist"
+)
+
+const FeedTrackPublished = "track_published"
+
+type Follow struct {
+	UserID    string
+	Username  string
+	Kind      string
+	CreatedAt time.Time
+}
+
+type FollowCounts struct {
+	Followers map[string]int
+	Following map[string]int
+}
+
+type FeedItem struct {
+	ID        int64
+	EventID   string
+	Kind      string
+	ActorID   string
+	ActorName string
+	ObjectID  string
+	Title     string
+	CreatedAt time.Time
+}
@@ -1,6 +1,10 @@
 package models
 
-import "github.com/golang-jwt/jwt/v5"
+import (
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
 
 // not entity
 type JWTAccess struct {
@@ -9,6 +13,7 @@ type JWTAccess struct {
 	Type string `json:"type"`
 	Role string `json:"role"`
 	TokenID string `json:"token_id"`
+	VersionCredentials int `json:"version_credentials"`
 }
 
 // not entity
@@ -20,3 +25,14 @@ type JWTRefresh struct {
 	TokenID string `json:"token_id"`
 	VersionCredentials int `json:"version_credentials"`
 }
+
+// not entity
+type TokenIntrospection struct {
+	Active    bool
+	Type      string
+	Subject   string
+	Role      string
+	TokenID   string
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+}
@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 type User struct {
 	ID       string
 	Username string
@@ -7,4 +9,8 @@ type User struct {
 	Email    string
 	Role     string
 	VersionCredentials int
+	// zero for active users
+	DeletedAt time.Time
+	// zero unless a moderator banned the user
+	BannedAt time.Time
 }
@@ -0,0 +1,119 @@
+package userservice
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Cwby333/user-microservice/internal/models"
+	"gitlab.com/Go34/Mute/shared/events"
+
+	gojson "github.com/goccy/go-json"
+)
+
+const userDeletedTopic = "songs_actions"
+
+// eventProducer is the producer name written into event envelopes
+const eventProducer = "users"
+
+type DeletionConfig struct {
+	GracePeriod time.Duration
+	Interval    time.Duration
+	BatchSize   int
+}
+
+// Eraser permanently removes users whose grace period is over, together with their
+// data export archives. Other services learn about it from the user_deleted event.
+type Eraser struct {
+	repo    ErasureRepo
+	storage FileStorage
+	config  DeletionConfig
+}
+
+func NewEraser(repo ErasureRepo, storage FileStorage, cfg DeletionConfig) (Eraser, error) {
+	const op = "./internal/service/userService/erasure.go.NewEraser"
+
+	// time.NewTicker panics on a non-positive interval, and a zero batch never drains
+	if cfg.Interval <= 0 {
+		return Eraser{}, fmt.Errorf("%s: %w", op, fmt.Errorf("erase interval must be positive, got %s", cfg.Interval))
+	}
+	if cfg.BatchSize <= 0 {
+		return Eraser{}, fmt.Errorf("%s: %w", op, fmt.Errorf("erase batch size must be positive, got %d", cfg.BatchSize))
+	}
+
+	return Eraser{
+		repo:    repo,
+		storage: storage,
+		config:  cfg,
+	}, nil
+}
+
+// Run erases users every Interval until ctx is done.
+func (e Eraser) Run(ctx context.Context) error {
+	ticker := time.NewTicker(e.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		n, err := e.EraseExpired(ctx)
+		if err != nil {
+			slog.Info("erase deleted users", slog.String("error", err.Error()))
+			continue
+		}
+
+		if n > 0 {
+			slog.Info("erased deleted users", slog.Int("count", n))
+		}
+	}
+}
+
+// EraseExpired erases users in batches until nothing is left and returns how many were erased.
+func (e Eraser) EraseExpired(ctx context.Context) (int, error) {
+	const op = "./internal/service/userService/erasure.go.EraseExpired"
+
+	deletedBefore := time.Now().Add(-e.config.GracePeriod)
+	total := 0
+
+	for {
+		IDs, err := e.repo.EraseDeletedUsers(ctx, deletedBefore, e.config.BatchSize, newUserDeletedTask, e.eraseExports)
+		if err != nil {
+			return total, fmt.Errorf("%s: %w", op, err)
+		}
+
+		total += len(IDs)
+
+		if len(IDs) < e.config.BatchSize {
+			return total, nil
+		}
+	}
+}
+
+// eraseExports removes the user's export archives, the data_exports rows go in the same transaction.
+func (e Eraser) eraseExports(ctx context.Context, userID string) error {
+	return e.storage.DeletePrefix(ctx, exportPrefix(userID))
+}
+
+func newUserDeletedTask(userID string) (models.DefferedTask, error) {
+	event, err := events.New(events.TypeUserDeleted, eventProducer, events.UserDeleted{UserID: userID})
+	if err != nil {
+		return models.DefferedTask{}, err
+	}
+
+	data, err := gojson.Marshal(event)
+	if err != nil {
+		return models.DefferedTask{}, err
+	}
+
+	return models.DefferedTask{
+		Topic:     userDeletedTopic,
+		Key:       userID,
+		Data:      data,
+		CreatedAt: time.Now(),
+	}, nil
+}
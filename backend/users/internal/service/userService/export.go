@@ -0,0 +1,261 @@
+package userservice
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	allerrors "github.com/Cwby333/user-microservice/internal/allErrors"
+	"github.com/Cwby333/user-microservice/internal/models"
+	"github.com/google/uuid"
+
+	gojson "github.com/goccy/go-json"
+)
+
+type ExportConfig struct {
+	Interval   time.Duration
+	LinkTTL    time.Duration
+	StaleAfter time.Duration
+}
+
+// Exporter builds a ZIP with everything we store about a user: profile and
+// login history from this service, likes and uploads from the music service.
+type Exporter struct {
+	repo    ExportRepo
+	music   MusicClient
+	storage FileStorage
+	config  ExportConfig
+}
+
+type exportProfile struct {
+	ID       string `json:"id"`
+	Username string `json:"username"`
+	Email    string `json:"email"`
+	Role     string `json:"role"`
+}
+
+type exportSession struct {
+	TokenID   string    `json:"token_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type exportTrack struct {
+	ID        string    `json:"id"`
+	Title     string    `json:"title"`
+	ArtistID  string    `json:"artist_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func NewExporter(repo ExportRepo, music MusicClient, storage FileStorage, cfg ExportConfig) Exporter {
+	return Exporter{
+		repo:    repo,
+		music:   music,
+		storage: storage,
+		config:  cfg,
+	}
+}
+
+// RequestExport starts an export or returns the one already in progress.
+func (e Exporter) RequestExport(ctx context.Context, userID string) (models.DataExport, error) {
+	const op = "./internal/service/userService/export.go.RequestExport"
+
+	if err := uuid.Validate(userID); err != nil {
+		return models.DataExport{}, fmt.Errorf("%s: %w", op, allerrors.ErrWrongUUID)
+	}
+
+	export, err := e.repo.CreateExport(ctx, userID)
+	if err != nil {
+		return models.DataExport{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return export, nil
+}
+
+// GetExport returns the user's export and, once it is done, a presigned download link.
+func (e Exporter) GetExport(ctx context.Context, userID string, exportID string) (models.DataExport, string, error) {
+	const op = "./internal/service/userService/export.go.GetExport"
+
+	if err := uuid.Validate(exportID); err != nil {
+		return models.DataExport{}, "", fmt.Errorf("%s: %w", op, allerrors.ErrWrongUUID)
+	}
+
+	export, err := e.repo.GetExport(ctx, exportID)
+	if err != nil {
+		return models.DataExport{}, "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	// don't tell other users that the export exists
+	if export.UserID != userID {
+		return models.DataExport{}, "", fmt.Errorf("%s: %w", op, allerrors.ErrExportNotExists)
+	}
+
+	if export.Status != models.ExportDone {
+		return export, "", nil
+	}
+
+	link, err := e.storage.PresignGet(export.FileKey, e.config.LinkTTL)
+	if err != nil {
+		return models.DataExport{}, "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return export, link, nil
+}
+
+// Run processes pending exports every Interval until ctx is done.
+func (e Exporter) Run(ctx context.Context) error {
+	ticker := time.NewTicker(e.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		for {
+			processed, err := e.ProcessNext(ctx)
+			if err != nil {
+				slog.Info("data export", slog.String("error", err.Error()))
+				break
+			}
+			if !processed {
+				break
+			}
+		}
+	}
+}
+
+// ProcessNext builds one pending export. It returns false when there is nothing to do.
+func (e Exporter) ProcessNext(ctx context.Context) (bool, error) {
+	const op = "./internal/service/userService/export.go.ProcessNext"
+
+	export, err := e.repo.ClaimExport(ctx, e.config.StaleAfter)
+	if err != nil {
+		if errors.Is(err, allerrors.ErrNoPendingExports) {
+			return false, nil
+		}
+
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	key, err := e.build(ctx, export)
+	if err != nil {
+		slog.Info("data export failed", slog.String("export_id", export.ID), slog.String("error", err.Error()))
+
+		// the reason is shown to the user, internal details stay in the log
+		errFail := e.repo.FailExport(ctx, export.ID, "export failed, please, try again later")
+		if errFail != nil {
+			return true, fmt.Errorf("%s: %w", op, errFail)
+		}
+
+		return true, nil
+	}
+
+	err = e.repo.FinishExport(ctx, export.ID, key)
+	if err != nil {
+		return true, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return true, nil
+}
+
+func (e Exporter) build(ctx context.Context, export models.DataExport) (string, error) {
+	user, err := e.repo.GetUserByID(ctx, export.UserID)
+	if err != nil {
+		return "", err
+	}
+
+	sessions, err := e.repo.GetSessionsByUserID(ctx, export.UserID)
+	if err != nil {
+		return "", err
+	}
+
+	music, err := e.music.GetUserData(ctx, export.UserID)
+	if err != nil {
+		return "", err
+	}
+
+	files := map[string]any{
+		"profile.json": exportProfile{
+			ID:       user.ID,
+			Username: user.Username,
+			Email:    user.Email,
+			Role:     user.Role,
+		},
+		"sessions.json": toExportSessions(sessions),
+		"likes.json":    toExportTracks(music.Likes),
+		"uploads.json":  toExportTracks(music.Uploads),
+	}
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+
+	for _, name := range []string{"profile.json", "sessions.json", "likes.json", "uploads.json"} {
+		data, err := gojson.MarshalIndent(files[name], "", "  ")
+		if err != nil {
+			return "", err
+		}
+
+		f, err := archive.Create(name)
+		if err != nil {
+			return "", err
+		}
+
+		_, err = f.Write(data)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	err = archive.Close()
+	if err != nil {
+		return "", err
+	}
+
+	key := fmt.Sprintf("%s%s.zip", exportPrefix(export.UserID), export.ID)
+
+	err = e.storage.Upload(ctx, key, buf.Bytes(), "application/zip")
+	if err != nil {
+		return "", err
+	}
+
+	return key, nil
+}
+
+// exportPrefix is the S3 prefix holding all archives of a user, the eraser removes it as a whole.
+func exportPrefix(userID string) string {
+	return "exports/" + userID + "/"
+}
+
+func toExportSessions(sessions []models.Session) []exportSession {
+	out := make([]exportSession, 0, len(sessions))
+
+	for i := range sessions {
+		out = append(out, exportSession{
+			TokenID:   sessions[i].TokenID,
+			CreatedAt: sessions[i].CreatedAt,
+		})
+	}
+
+	return out
+}
+
+func toExportTracks(tracks []models.Track) []exportTrack {
+	out := make([]exportTrack, 0, len(tracks))
+
+	for i := range tracks {
+		out = append(out, exportTrack{
+			ID:        tracks[i].ID,
+			Title:     tracks[i].Title,
+			ArtistID:  tracks[i].ArtistID,
+			CreatedAt: tracks[i].CreatedAt,
+		})
+	}
+
+	return out
+}
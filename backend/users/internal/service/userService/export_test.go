@@ -0,0 +1,126 @@
+package userservice
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	allerrors "github.com/Cwby333/user-microservice/internal/allErrors"
+	"github.com/Cwby333/user-microservice/internal/models"
+	mock_userservice "github.com/Cwby333/user-microservice/internal/service/userService/mock_userService"
+	"github.com/google/uuid"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExporterProcessNext(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repoMock := mock_userservice.NewMockExportRepo(ctrl)
+	musicMock := mock_userservice.NewMockMusicClient(ctrl)
+	storageMock := mock_userservice.NewMockFileStorage(ctrl)
+
+	exporter := NewExporter(repoMock, musicMock, storageMock, ExportConfig{StaleAfter: time.Minute})
+
+	export := models.DataExport{
+		ID:     uuid.NewString(),
+		UserID: uuid.NewString(),
+		Status: models.ExportRunning,
+	}
+	key := "exports/" + export.UserID + "/" + export.ID + ".zip"
+
+	t.Run("archive uploaded", func(t *testing.T) {
+		repoMock.EXPECT().ClaimExport(context.Background(), time.Minute).Return(export, nil)
+		repoMock.EXPECT().GetUserByID(context.Background(), export.UserID).Return(models.User{ID: export.UserID, Username: "username", Password: "hash"}, nil)
+		repoMock.EXPECT().GetSessionsByUserID(context.Background(), export.UserID).Return([]models.Session{{TokenID: "token-id"}}, nil)
+		musicMock.EXPECT().GetUserData(context.Background(), export.UserID).Return(models.MusicData{Likes: []models.Track{{ID: "track-id"}}}, nil)
+		storageMock.EXPECT().Upload(context.Background(), key, gomock.Any(), "application/zip").
+			DoAndReturn(func(ctx context.Context, key string, data []byte, contentType string) error {
+				archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+				require.NoError(t, err)
+
+				files := map[string]string{}
+				for _, f := range archive.File {
+					r, err := f.Open()
+					require.NoError(t, err)
+					content, err := io.ReadAll(r)
+					require.NoError(t, err)
+					files[f.Name] = string(content)
+				}
+
+				require.Len(t, files, 4)
+				require.Contains(t, files["profile.json"], "username")
+				require.NotContains(t, files["profile.json"], "hash")
+				require.Contains(t, files["sessions.json"], "token-id")
+				require.Contains(t, files["likes.json"], "track-id")
+				require.JSONEq(t, "[]", files["uploads.json"])
+
+				return nil
+			})
+		repoMock.EXPECT().FinishExport(context.Background(), export.ID, key).Return(nil)
+
+		processed, err := exporter.ProcessNext(context.Background())
+		require.NoError(t, err)
+		require.True(t, processed)
+	})
+
+	t.Run("music service unavailable", func(t *testing.T) {
+		repoMock.EXPECT().ClaimExport(context.Background(), time.Minute).Return(export, nil)
+		repoMock.EXPECT().GetUserByID(context.Background(), export.UserID).Return(models.User{ID: export.UserID}, nil)
+		repoMock.EXPECT().GetSessionsByUserID(context.Background(), export.UserID).Return(nil, nil)
+		musicMock.EXPECT().GetUserData(context.Background(), export.UserID).Return(models.MusicData{}, errors.New("connection refused"))
+		repoMock.EXPECT().FailExport(context.Background(), export.ID, gomock.Any()).Return(nil)
+
+		processed, err := exporter.ProcessNext(context.Background())
+		require.NoError(t, err)
+		require.True(t, processed)
+	})
+
+	t.Run("nothing to do", func(t *testing.T) {
+		repoMock.EXPECT().ClaimExport(context.Background(), time.Minute).Return(models.DataExport{}, allerrors.ErrNoPendingExports)
+
+		processed, err := exporter.ProcessNext(context.Background())
+		require.NoError(t, err)
+		require.False(t, processed)
+	})
+}
+
+func TestExporterGetExport(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repoMock := mock_userservice.NewMockExportRepo(ctrl)
+	storageMock := mock_userservice.NewMockFileStorage(ctrl)
+
+	exporter := NewExporter(repoMock, nil, storageMock, ExportConfig{LinkTTL: time.Hour})
+
+	userID := uuid.NewString()
+	done := models.DataExport{
+		ID:      uuid.NewString(),
+		UserID:  userID,
+		Status:  models.ExportDone,
+		FileKey: "exports/key.zip",
+	}
+
+	repoMock.EXPECT().GetExport(context.Background(), done.ID).Return(done, nil)
+	storageMock.EXPECT().PresignGet("exports/key.zip", time.Hour).Return("https://link", nil)
+
+	_, link, err := exporter.GetExport(context.Background(), userID, done.ID)
+	require.NoError(t, err)
+	require.Equal(t, "https://link", link)
+
+	// other user's export
+	repoMock.EXPECT().GetExport(context.Background(), done.ID).Return(done, nil)
+
+	_, _, err = exporter.GetExport(context.Background(), uuid.NewString(), done.ID)
+	require.ErrorIs(t, err, allerrors.ErrExportNotExists)
+
+	_, _, err = exporter.GetExport(context.Background(), userID, "not-uuid")
+	require.ErrorIs(t, err, allerrors.ErrWrongUUID)
+}
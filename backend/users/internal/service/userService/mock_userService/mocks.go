@@ -36,6 +36,35 @@ func (m *MockUserRepo) EXPECT() *MockUserRepoMockRecorder {
 	return m.recorder
 }
 
+// BanUserByID mocks base method.
+func (m *MockUserRepo) BanUserByID(ctx context.Context, ID string, banned bool) (models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BanUserByID", ctx, ID, banned)
+	ret0, _ := ret[0].(models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BanUserByID indicates an expected call of BanUserByID.
+func (mr *MockUserRepoMockRecorder) BanUserByID(ctx, ID, banned interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BanUserByID", reflect.TypeOf((*MockUserRepo)(nil).BanUserByID), ctx, ID, banned)
+}
+
+// CreateSession mocks base method.
+func (m *MockUserRepo) CreateSession(ctx context.Context, session models.Session) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSession", ctx, session)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateSession indicates an expected call of CreateSession.
+func (mr *MockUserRepoMockRecorder) CreateSession(ctx, session interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSession", reflect.TypeOf((*MockUserRepo)(nil).CreateSession), ctx, session)
+}
+
 // CreateUser mocks base method.
 func (m *MockUserRepo) CreateUser(ctx context.Context, user models.User) (models.User, error) {
 	m.ctrl.T.Helper()
@@ -110,6 +139,35 @@ func (mr *MockUserRepoMockRecorder) GetUserByUsername(ctx, username interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByUsername", reflect.TypeOf((*MockUserRepo)(nil).GetUserByUsername), ctx, username)
 }
 
+// GetUsersByIDs mocks base method.
+func (m *MockUserRepo) GetUsersByIDs(ctx context.Context, IDs []string) ([]models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUsersByIDs", ctx, IDs)
+	ret0, _ := ret[0].([]models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUsersByIDs indicates an expected call of GetUsersByIDs.
+func (mr *MockUserRepoMockRecorder) GetUsersByIDs(ctx, IDs interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsersByIDs", reflect.TypeOf((*MockUserRepo)(nil).GetUsersByIDs), ctx, IDs)
+}
+
+// RestoreUserByID mocks base method.
+func (m *MockUserRepo) RestoreUserByID(ctx context.Context, ID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreUserByID", ctx, ID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RestoreUserByID indicates an expected call of RestoreUserByID.
+func (mr *MockUserRepoMockRecorder) RestoreUserByID(ctx, ID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreUserByID", reflect.TypeOf((*MockUserRepo)(nil).RestoreUserByID), ctx, ID)
+}
+
 // UpdateUserByID mocks base method.
 func (m *MockUserRepo) UpdateUserByID(ctx context.Context, ID string, newUserInfo models.User) (models.User, error) {
 	m.ctrl.T.Helper()
@@ -125,6 +183,460 @@ func (mr *MockUserRepoMockRecorder) UpdateUserByID(ctx, ID, newUserInfo interfac
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUserByID", reflect.TypeOf((*MockUserRepo)(nil).UpdateUserByID), ctx, ID, newUserInfo)
 }
 
+// MockErasureRepo is a mock of ErasureRepo interface.
+type MockErasureRepo struct {
+	ctrl     *gomock.Controller
+	recorder *MockErasureRepoMockRecorder
+}
+
+// MockErasureRepoMockRecorder is the mock recorder for MockErasureRepo.
+type MockErasureRepoMockRecorder struct {
+	mock *MockErasureRepo
+}
+
+// NewMockErasureRepo creates a new mock instance.
+func NewMockErasureRepo(ctrl *gomock.Controller) *MockErasureRepo {
+	mock := &MockErasureRepo{ctrl: ctrl}
+	mock.recorder = &MockErasureRepoMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockErasureRepo) EXPECT() *MockErasureRepoMockRecorder {
+	return m.recorder
+}
+
+// EraseDeletedUsers mocks base method.
+func (m *MockErasureRepo) EraseDeletedUsers(ctx context.Context, deletedBefore time.Time, limit int, newTask func(string) (models.DefferedTask, error), eraseFiles func(context.Context, string) error) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EraseDeletedUsers", ctx, deletedBefore, limit, newTask, eraseFiles)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EraseDeletedUsers indicates an expected call of EraseDeletedUsers.
+func (mr *MockErasureRepoMockRecorder) EraseDeletedUsers(ctx, deletedBefore, limit, newTask, eraseFiles interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EraseDeletedUsers", reflect.TypeOf((*MockErasureRepo)(nil).EraseDeletedUsers), ctx, deletedBefore, limit, newTask, eraseFiles)
+}
+
+// MockExportRepo is a mock of ExportRepo interface.
+type MockExportRepo struct {
+	ctrl     *gomock.Controller
+	recorder *MockExportRepoMockRecorder
+}
+
+// MockExportRepoMockRecorder is the mock recorder for MockExportRepo.
+type MockExportRepoMockRecorder struct {
+	mock *MockExportRepo
+}
+
+// NewMockExportRepo creates a new mock instance.
+func NewMockExportRepo(ctrl *gomock.Controller) *MockExportRepo {
+	mock := &MockExportRepo{ctrl: ctrl}
+	mock.recorder = &MockExportRepoMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockExportRepo) EXPECT() *MockExportRepoMockRecorder {
+	return m.recorder
+}
+
+// ClaimExport mocks base method.
+func (m *MockExportRepo) ClaimExport(ctx context.Context, staleAfter time.Duration) (models.DataExport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimExport", ctx, staleAfter)
+	ret0, _ := ret[0].(models.DataExport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimExport indicates an expected call of ClaimExport.
+func (mr *MockExportRepoMockRecorder) ClaimExport(ctx, staleAfter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimExport", reflect.TypeOf((*MockExportRepo)(nil).ClaimExport), ctx, staleAfter)
+}
+
+// CreateExport mocks base method.
+func (m *MockExportRepo) CreateExport(ctx context.Context, userID string) (models.DataExport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateExport", ctx, userID)
+	ret0, _ := ret[0].(models.DataExport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateExport indicates an expected call of CreateExport.
+func (mr *MockExportRepoMockRecorder) CreateExport(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateExport", reflect.TypeOf((*MockExportRepo)(nil).CreateExport), ctx, userID)
+}
+
+// FailExport mocks base method.
+func (m *MockExportRepo) FailExport(ctx context.Context, ID, reason string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FailExport", ctx, ID, reason)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FailExport indicates an expected call of FailExport.
+func (mr *MockExportRepoMockRecorder) FailExport(ctx, ID, reason interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FailExport", reflect.TypeOf((*MockExportRepo)(nil).FailExport), ctx, ID, reason)
+}
+
+// FinishExport mocks base method.
+func (m *MockExportRepo) FinishExport(ctx context.Context, ID, fileKey string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FinishExport", ctx, ID, fileKey)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FinishExport indicates an expected call of FinishExport.
+func (mr *MockExportRepoMockRecorder) FinishExport(ctx, ID, fileKey interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FinishExport", reflect.TypeOf((*MockExportRepo)(nil).FinishExport), ctx, ID, fileKey)
+}
+
+// GetExport mocks base method.
+func (m *MockExportRepo) GetExport(ctx context.Context, ID string) (models.DataExport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetExport", ctx, ID)
+	ret0, _ := ret[0].(models.DataExport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetExport indicates an expected call of GetExport.
+func (mr *MockExportRepoMockRecorder) GetExport(ctx, ID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetExport", reflect.TypeOf((*MockExportRepo)(nil).GetExport), ctx, ID)
+}
+
+// GetSessionsByUserID mocks base method.
+func (m *MockExportRepo) GetSessionsByUserID(ctx context.Context, userID string) ([]models.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSessionsByUserID", ctx, userID)
+	ret0, _ := ret[0].([]models.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSessionsByUserID indicates an expected call of GetSessionsByUserID.
+func (mr *MockExportRepoMockRecorder) GetSessionsByUserID(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSessionsByUserID", reflect.TypeOf((*MockExportRepo)(nil).GetSessionsByUserID), ctx, userID)
+}
+
+// GetUserByID mocks base method.
+func (m *MockExportRepo) GetUserByID(ctx context.Context, ID string) (models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserByID", ctx, ID)
+	ret0, _ := ret[0].(models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserByID indicates an expected call of GetUserByID.
+func (mr *MockExportRepoMockRecorder) GetUserByID(ctx, ID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByID", reflect.TypeOf((*MockExportRepo)(nil).GetUserByID), ctx, ID)
+}
+
+// MockSocialRepo is a mock of SocialRepo interface.
+type MockSocialRepo struct {
+	ctrl     *gomock.Controller
+	recorder *MockSocialRepoMockRecorder
+}
+
+// MockSocialRepoMockRecorder is the mock recorder for MockSocialRepo.
+type MockSocialRepoMockRecorder struct {
+	mock *MockSocialRepo
+}
+
+// NewMockSocialRepo creates a new mock instance.
+func NewMockSocialRepo(ctrl *gomock.Controller) *MockSocialRepo {
+	mock := &MockSocialRepo{ctrl: ctrl}
+	mock.recorder = &MockSocialRepoMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSocialRepo) EXPECT() *MockSocialRepoMockRecorder {
+	return m.recorder
+}
+
+// AddToFeed mocks base method.
+func (m *MockSocialRepo) AddToFeed(ctx context.Context, item models.FeedItem) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddToFeed", ctx, item)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddToFeed indicates an expected call of AddToFeed.
+func (mr *MockSocialRepoMockRecorder) AddToFeed(ctx, item interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddToFeed", reflect.TypeOf((*MockSocialRepo)(nil).AddToFeed), ctx, item)
+}
+
+// CountFollows mocks base method.
+func (m *MockSocialRepo) CountFollows(ctx context.Context, userID string) (models.FollowCounts, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountFollows", ctx, userID)
+	ret0, _ := ret[0].(models.FollowCounts)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountFollows indicates an expected call of CountFollows.
+func (mr *MockSocialRepoMockRecorder) CountFollows(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountFollows", reflect.TypeOf((*MockSocialRepo)(nil).CountFollows), ctx, userID)
+}
+
+// DeleteFeedObject mocks base method.
+func (m *MockSocialRepo) DeleteFeedObject(ctx context.Context, objectID string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteFeedObject", ctx, objectID)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteFeedObject indicates an expected call of DeleteFeedObject.
+func (mr *MockSocialRepoMockRecorder) DeleteFeedObject(ctx, objectID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteFeedObject", reflect.TypeOf((*MockSocialRepo)(nil).DeleteFeedObject), ctx, objectID)
+}
+
+// FilterFollowers mocks base method.
+func (m *MockSocialRepo) FilterFollowers(ctx context.Context, followeeID, kind string, userIDs []string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FilterFollowers", ctx, followeeID, kind, userIDs)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FilterFollowers indicates an expected call of FilterFollowers.
+func (mr *MockSocialRepoMockRecorder) FilterFollowers(ctx, followeeID, kind, userIDs interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FilterFollowers", reflect.TypeOf((*MockSocialRepo)(nil).FilterFollowers), ctx, followeeID, kind, userIDs)
+}
+
+// Follow mocks base method.
+func (m *MockSocialRepo) Follow(ctx context.Context, followerID, followeeID, kind string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Follow", ctx, followerID, followeeID, kind)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Follow indicates an expected call of Follow.
+func (mr *MockSocialRepoMockRecorder) Follow(ctx, followerID, followeeID, kind interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Follow", reflect.TypeOf((*MockSocialRepo)(nil).Follow), ctx, followerID, followeeID, kind)
+}
+
+// GetFeed mocks base method.
+func (m *MockSocialRepo) GetFeed(ctx context.Context, userID string, beforeID int64, limit int) ([]models.FeedItem, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFeed", ctx, userID, beforeID, limit)
+	ret0, _ := ret[0].([]models.FeedItem)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFeed indicates an expected call of GetFeed.
+func (mr *MockSocialRepoMockRecorder) GetFeed(ctx, userID, beforeID, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFeed", reflect.TypeOf((*MockSocialRepo)(nil).GetFeed), ctx, userID, beforeID, limit)
+}
+
+// GetFollowers mocks base method.
+func (m *MockSocialRepo) GetFollowers(ctx context.Context, userID, kind string, beforeAt time.Time, beforeID string, limit int) ([]models.Follow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFollowers", ctx, userID, kind, beforeAt, beforeID, limit)
+	ret0, _ := ret[0].([]models.Follow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFollowers indicates an expected call of GetFollowers.
+func (mr *MockSocialRepoMockRecorder) GetFollowers(ctx, userID, kind, beforeAt, beforeID, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFollowers", reflect.TypeOf((*MockSocialRepo)(nil).GetFollowers), ctx, userID, kind, beforeAt, beforeID, limit)
+}
+
+// GetFollowing mocks base method.
+func (m *MockSocialRepo) GetFollowing(ctx context.Context, userID, kind string, beforeAt time.Time, beforeID string, limit int) ([]models.Follow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFollowing", ctx, userID, kind, beforeAt, beforeID, limit)
+	ret0, _ := ret[0].([]models.Follow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFollowing indicates an expected call of GetFollowing.
+func (mr *MockSocialRepoMockRecorder) GetFollowing(ctx, userID, kind, beforeAt, beforeID, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFollowing", reflect.TypeOf((*MockSocialRepo)(nil).GetFollowing), ctx, userID, kind, beforeAt, beforeID, limit)
+}
+
+// GetUserByID mocks base method.
+func (m *MockSocialRepo) GetUserByID(ctx context.Context, ID string) (models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserByID", ctx, ID)
+	ret0, _ := ret[0].(models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserByID indicates an expected call of GetUserByID.
+func (mr *MockSocialRepoMockRecorder) GetUserByID(ctx, ID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByID", reflect.TypeOf((*MockSocialRepo)(nil).GetUserByID), ctx, ID)
+}
+
+// SetFeedObjectHidden mocks base method.
+func (m *MockSocialRepo) SetFeedObjectHidden(ctx context.Context, objectID string, hidden bool) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetFeedObjectHidden", ctx, objectID, hidden)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetFeedObjectHidden indicates an expected call of SetFeedObjectHidden.
+func (mr *MockSocialRepoMockRecorder) SetFeedObjectHidden(ctx, objectID, hidden interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetFeedObjectHidden", reflect.TypeOf((*MockSocialRepo)(nil).SetFeedObjectHidden), ctx, objectID, hidden)
+}
+
+// Unfollow mocks base method.
+func (m *MockSocialRepo) Unfollow(ctx context.Context, followerID, followeeID, kind string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Unfollow", ctx, followerID, followeeID, kind)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Unfollow indicates an expected call of Unfollow.
+func (mr *MockSocialRepoMockRecorder) Unfollow(ctx, followerID, followeeID, kind interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Unfollow", reflect.TypeOf((*MockSocialRepo)(nil).Unfollow), ctx, followerID, followeeID, kind)
+}
+
+// MockMusicClient is a mock of MusicClient interface.
+type MockMusicClient struct {
+	ctrl     *gomock.Controller
+	recorder *MockMusicClientMockRecorder
+}
+
+// MockMusicClientMockRecorder is the mock recorder for MockMusicClient.
+type MockMusicClientMockRecorder struct {
+	mock *MockMusicClient
+}
+
+// NewMockMusicClient creates a new mock instance.
+func NewMockMusicClient(ctrl *gomock.Controller) *MockMusicClient {
+	mock := &MockMusicClient{ctrl: ctrl}
+	mock.recorder = &MockMusicClientMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMusicClient) EXPECT() *MockMusicClientMockRecorder {
+	return m.recorder
+}
+
+// GetUserData mocks base method.
+func (m *MockMusicClient) GetUserData(ctx context.Context, userID string) (models.MusicData, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserData", ctx, userID)
+	ret0, _ := ret[0].(models.MusicData)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserData indicates an expected call of GetUserData.
+func (mr *MockMusicClientMockRecorder) GetUserData(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserData", reflect.TypeOf((*MockMusicClient)(nil).GetUserData), ctx, userID)
+}
+
+// MockFileStorage is a mock of FileStorage interface.
+type MockFileStorage struct {
+	ctrl     *gomock.Controller
+	recorder *MockFileStorageMockRecorder
+}
+
+// MockFileStorageMockRecorder is the mock recorder for MockFileStorage.
+type MockFileStorageMockRecorder struct {
+	mock *MockFileStorage
+}
+
+// NewMockFileStorage creates a new mock instance.
+func NewMockFileStorage(ctrl *gomock.Controller) *MockFileStorage {
+	mock := &MockFileStorage{ctrl: ctrl}
+	mock.recorder = &MockFileStorageMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockFileStorage) EXPECT() *MockFileStorageMockRecorder {
+	return m.recorder
+}
+
+// DeletePrefix mocks base method.
+func (m *MockFileStorage) DeletePrefix(ctx context.Context, prefix string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeletePrefix", ctx, prefix)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeletePrefix indicates an expected call of DeletePrefix.
+func (mr *MockFileStorageMockRecorder) DeletePrefix(ctx, prefix interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePrefix", reflect.TypeOf((*MockFileStorage)(nil).DeletePrefix), ctx, prefix)
+}
+
+// PresignGet mocks base method.
+func (m *MockFileStorage) PresignGet(key string, expires time.Duration) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PresignGet", key, expires)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PresignGet indicates an expected call of PresignGet.
+func (mr *MockFileStorageMockRecorder) PresignGet(key, expires interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PresignGet", reflect.TypeOf((*MockFileStorage)(nil).PresignGet), key, expires)
+}
+
+// Upload mocks base method.
+func (m *MockFileStorage) Upload(ctx context.Context, key string, data []byte, contentType string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Upload", ctx, key, data, contentType)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Upload indicates an expected call of Upload.
+func (mr *MockFileStorageMockRecorder) Upload(ctx, key, data, contentType interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Upload", reflect.TypeOf((*MockFileStorage)(nil).Upload), ctx, key, data, contentType)
+}
+
 // MockDefferedTaksRepo is a mock of DefferedTaksRepo interface.
 type MockDefferedTaksRepo struct {
 	ctrl     *gomock.Controller
@@ -185,6 +697,20 @@ func (m *MockRefreshInvalidator) EXPECT() *MockRefreshInvalidatorMockRecorder {
 	return m.recorder
 }
 
+// CheckAccessInBlackList mocks base method.
+func (m *MockRefreshInvalidator) CheckAccessInBlackList(ctx context.Context, tokenID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheckAccessInBlackList", ctx, tokenID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CheckAccessInBlackList indicates an expected call of CheckAccessInBlackList.
+func (mr *MockRefreshInvalidatorMockRecorder) CheckAccessInBlackList(ctx, tokenID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckAccessInBlackList", reflect.TypeOf((*MockRefreshInvalidator)(nil).CheckAccessInBlackList), ctx, tokenID)
+}
+
 // CheckTokenInBlackList mocks base method.
 func (m *MockRefreshInvalidator) CheckTokenInBlackList(ctx context.Context, tokenID string) error {
 	m.ctrl.T.Helper()
@@ -199,6 +725,20 @@ func (mr *MockRefreshInvalidatorMockRecorder) CheckTokenInBlackList(ctx, tokenID
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckTokenInBlackList", reflect.TypeOf((*MockRefreshInvalidator)(nil).CheckTokenInBlackList), ctx, tokenID)
 }
 
+// InvalidAccess mocks base method.
+func (m *MockRefreshInvalidator) InvalidAccess(ctx context.Context, tokenID string, expired time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InvalidAccess", ctx, tokenID, expired)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InvalidAccess indicates an expected call of InvalidAccess.
+func (mr *MockRefreshInvalidatorMockRecorder) InvalidAccess(ctx, tokenID, expired interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InvalidAccess", reflect.TypeOf((*MockRefreshInvalidator)(nil).InvalidAccess), ctx, tokenID, expired)
+}
+
 // InvalidRefresh mocks base method.
 func (m *MockRefreshInvalidator) InvalidRefresh(ctx context.Context, tokenID string, expired time.Time) error {
 	m.ctrl.T.Helper()
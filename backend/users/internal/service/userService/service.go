@@ -19,12 +19,59 @@ type UserRepo interface {
 	CreateUser(ctx context.Context, user models.User) (models.User, error)
 
 	GetUserByID(ctx context.Context, ID string) (models.User, error)
+	GetUsersByIDs(ctx context.Context, IDs []string) ([]models.User, error)
 	GetUserByUsername(ctx context.Context, username string) (models.User, error)
 	GetAllUsers(ctx context.Context) ([]models.User, error)
 
 	DeleteUserByID(ctx context.Context, ID string) error
+	RestoreUserByID(ctx context.Context, ID string) error
 
 	UpdateUserByID(ctx context.Context, ID string, newUserInfo models.User) (models.User, error)
+	BanUserByID(ctx context.Context, ID string, banned bool) (models.User, error)
+
+	CreateSession(ctx context.Context, session models.Session) error
+}
+
+type ErasureRepo interface {
+	EraseDeletedUsers(ctx context.Context, deletedBefore time.Time, limit int, newTask func(userID string) (models.DefferedTask, error), eraseFiles func(ctx context.Context, userID string) error) ([]string, error)
+}
+
+type ExportRepo interface {
+	CreateExport(ctx context.Context, userID string) (models.DataExport, error)
+	GetExport(ctx context.Context, ID string) (models.DataExport, error)
+	ClaimExport(ctx context.Context, staleAfter time.Duration) (models.DataExport, error)
+	FinishExport(ctx context.Context, ID string, fileKey string) error
+	FailExport(ctx context.Context, ID string, reason string) error
+
+	GetUserByID(ctx context.Context, ID string) (models.User, error)
+	GetSessionsByUserID(ctx context.Context, userID string) ([]models.Session, error)
+}
+
+type SocialRepo interface {
+	Follow(ctx context.Context, followerID string, followeeID string, kind string) error
+	Unfollow(ctx context.Context, followerID string, followeeID string, kind string) error
+	CountFollows(ctx context.Context, userID string) (models.FollowCounts, error)
+	GetFollowers(ctx context.Context, userID string, kind string, beforeAt time.Time, beforeID string, limit int) ([]models.Follow, error)
+	GetFollowing(ctx context.Context, userID string, kind string, beforeAt time.Time, beforeID string, limit int) ([]models.Follow, error)
+
+	AddToFeed(ctx context.Context, item models.FeedItem) (int64, error)
+	SetFeedObjectHidden(ctx context.Context, objectID string, hidden bool) (int64, error)
+	DeleteFeedObject(ctx context.Context, objectID string) (int64, error)
+	GetFeed(ctx context.Context, userID string, beforeID int64, limit int) ([]models.FeedItem, error)
+
+	FilterFollowers(ctx context.Context, followeeID string, kind string, userIDs []string) ([]string, error)
+
+	GetUserByID(ctx context.Context, ID string) (models.User, error)
+}
+
+type MusicClient interface {
+	GetUserData(ctx context.Context, userID string) (models.MusicData, error)
+}
+
+type FileStorage interface {
+	Upload(ctx context.Context, key string, data []byte, contentType string) error
+	PresignGet(key string, expires time.Duration) (string, error)
+	DeletePrefix(ctx context.Context, prefix string) error
 }
 
 type DefferedTaksRepo interface {
@@ -34,6 +81,9 @@ type DefferedTaksRepo interface {
 type RefreshInvalidator interface {
 	InvalidRefresh(ctx context.Context, tokenID string, expired time.Time) error
 	CheckTokenInBlackList(ctx context.Context, tokenID string) error
+
+	InvalidAccess(ctx context.Context, tokenID string, expired time.Time) error
+	CheckAccessInBlackList(ctx context.Context, tokenID string) error
 }
 
 type UserCache interface {
@@ -108,12 +158,30 @@ func (s Service) Login(ctx context.Context, user models.User) (access models.JWT
 		return models.JWTAccess{}, models.JWTRefresh{}, fmt.Errorf("%s: %w", op, allerrors.ErrWrongPass)
 	}
 
+	if !userFromRepo.DeletedAt.IsZero() {
+		return models.JWTAccess{}, models.JWTRefresh{}, fmt.Errorf("%s: %w", op, allerrors.ErrUserDeactivated)
+	}
+
+	if !userFromRepo.BannedAt.IsZero() {
+		return models.JWTAccess{}, models.JWTRefresh{}, fmt.Errorf("%s: %w", op, allerrors.ErrUserBanned)
+	}
+
 	user = userFromRepo
 	access, refresh, err = s.createTokens(ctx, user)
 	if err != nil {
 		return models.JWTAccess{}, models.JWTRefresh{}, fmt.Errorf("%s: %w", op, err)
 	}
 
+	// history is only used by the data export, a failed write must not block login
+	err = s.userRepo.CreateSession(ctx, models.Session{
+		UserID:    user.ID,
+		TokenID:   refresh.TokenID,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		slog.Info("create session", slog.String("error", err.Error()))
+	}
+
 	return access, refresh, nil
 }
 
@@ -160,7 +228,39 @@ func (s Service) FindUserByID(ctx context.Context, ID string) (models.User, erro
 		slog.Info("user cache", slog.String("error", err.Error()))
 	}
 
-	return user, err
+	return user, nil
+}
+
+func (s Service) FindUsersByIDs(ctx context.Context, IDs []string) ([]models.User, error) {
+	const op = "./internal/service/userService/service.go.FindUsersByIDs"
+
+	for i := range IDs {
+		if err := uuid.Validate(IDs[i]); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, allerrors.ErrWrongUUID)
+		}
+	}
+
+	if len(IDs) == 0 {
+		return []models.User{}, nil
+	}
+
+	users, err := s.userRepo.GetUsersByIDs(ctx, IDs)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return users, nil
+}
+
+func (s Service) CheckRole(ctx context.Context, userID string, role string) (bool, error) {
+	const op = "./internal/service/userService/service.go.CheckRole"
+
+	user, err := s.FindUserByID(ctx, userID)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return user.Role == role, nil
 }
 
 func (s Service) GetAllUsers(ctx context.Context) ([]models.User, error) {
@@ -194,6 +294,38 @@ func (s Service) DeleteUser(ctx context.Context, ID string) error {
 	return nil
 }
 
+// ReactivateUser restores a deleted user who is still in the grace period, i.e. not erased yet.
+// The user has no valid tokens at this point, so the password is checked like on login.
+func (s Service) ReactivateUser(ctx context.Context, user models.User) error {
+	const op = "./internal/service/userService/service.go.ReactivateUser"
+
+	userFromRepo, err := s.userRepo.GetUserByUsername(ctx, user.Username)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = bcrypt.CompareHashAndPassword([]byte(userFromRepo.Password), []byte(user.Password))
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, allerrors.ErrWrongPass)
+	}
+
+	if userFromRepo.DeletedAt.IsZero() {
+		return nil
+	}
+
+	err = s.userRepo.RestoreUserByID(ctx, userFromRepo.ID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = s.userCache.Delete(ctx, userFromRepo.ID)
+	if err != nil {
+		slog.Info("cache", slog.String("error", err.Error()))
+	}
+
+	return nil
+}
+
 func (s Service) UpdateUser(ctx context.Context, ID string, newUserInfo models.User) (models.User, error) {
 	const op = "./internal/service/userService/service.go.UpdateUser"
 
@@ -228,12 +360,98 @@ func (s Service) UpdateUser(ctx context.Context, ID string, newUserInfo models.U
 		return models.User{}, fmt.Errorf("%s: %w", op, err)
 	}
 
+	// A stale entry would keep old tokens alive, so drop it if it can't be overwritten.
 	err = s.userCache.Set(ctx, ID, newUserInfo)
 	if err != nil {
 		slog.Info("cache", slog.String("error", err.Error()))
+
+		err = s.userCache.Delete(ctx, ID)
+		if err != nil {
+			slog.Info("cache", slog.String("error", err.Error()))
+		}
+	}
+
+	return user, nil
+}
+
+// ChangeRole bumps the credentials version, so tokens carrying the old role stop working at once.
+func (s Service) ChangeRole(ctx context.Context, ID string, role string) (models.User, error) {
+	const op = "./internal/service/userService/service.go.ChangeRole"
+
+	if err := uuid.Validate(ID); err != nil {
+		return models.User{}, fmt.Errorf("%s: %w", op, allerrors.ErrWrongUUID)
+	}
+
+	user, err := s.userRepo.GetUserByID(ctx, ID)
+	if err != nil {
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if user.Role == role {
+		return user, nil
+	}
+
+	user.Role = role
+	user.VersionCredentials++
+
+	user, err = s.userRepo.UpdateUserByID(ctx, ID, user)
+	if err != nil {
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = s.userCache.Set(ctx, ID, user)
+	if err != nil {
+		slog.Info("cache", slog.String("error", err.Error()))
+
+		err = s.userCache.Delete(ctx, ID)
+		if err != nil {
+			slog.Info("cache", slog.String("error", err.Error()))
+		}
+	}
+
+	return user, nil
+}
+
+// BanUser is called by the music moderation queue. A ban revokes every token of the user
+// and blocks login until the ban is lifted; admins can't be banned.
+// wasBanned is the state before the call, so the caller can roll its change back.
+func (s Service) BanUser(ctx context.Context, ID string, banned bool) (user models.User, wasBanned bool, err error) {
+	const op = "./internal/service/userService/service.go.BanUser"
+
+	if err := uuid.Validate(ID); err != nil {
+		return models.User{}, false, fmt.Errorf("%s: %w", op, allerrors.ErrWrongUUID)
+	}
+
+	user, err = s.userRepo.GetUserByID(ctx, ID)
+	if err != nil {
+		return models.User{}, false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if banned && user.Role == "admin" {
+		return models.User{}, false, fmt.Errorf("%s: %w", op, allerrors.ErrBanAdmin)
+	}
+
+	wasBanned = !user.BannedAt.IsZero()
+	if wasBanned == banned {
+		return user, wasBanned, nil
+	}
+
+	user, err = s.userRepo.BanUserByID(ctx, ID, banned)
+	if err != nil {
+		return models.User{}, false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = s.userCache.Set(ctx, ID, user)
+	if err != nil {
+		slog.Info("cache", slog.String("error", err.Error()))
+
+		err = s.userCache.Delete(ctx, ID)
+		if err != nil {
+			slog.Info("cache", slog.String("error", err.Error()))
+		}
 	}
 
-	return user, err
+	return user, wasBanned, nil
 }
 
 func (s Service) ActionWithSong(ctx context.Context, task models.DefferedTask) error {
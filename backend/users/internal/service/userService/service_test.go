@@ -13,6 +13,7 @@ import (
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/require"
+	"gitlab.com/Go34/Mute/shared/events"
 )
 
 
@@ -159,6 +160,11 @@ func TestLogin(t *testing.T) {
 			repoMock.EXPECT().
 				GetUserByUsername(context.Background(), tt.inputUser.Username).
 				Return(tt.mockUserFromRepo, nil)
+			if tt.expectedError == nil {
+				repoMock.EXPECT().
+					CreateSession(context.Background(), gomock.Any()).
+					Return(nil)
+			}
 
 			_, _, err := service.Login(context.Background(), tt.inputUser)
 
@@ -544,4 +550,612 @@ func TestRefreshTokens(t *testing.T) {
 			require.Equal(t, tc.user.Role, refresh.Role)
 		}
 	}
-}
\ No newline at end of file
+}
+func TestFindUsersByIDs(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repoMock := mock_userservice.NewMockUserRepo(ctrl)
+
+	service := New(repoMock, nil, nil, nil, JWTConfig{})
+
+	IDs := []string{uuid.NewString(), uuid.NewString()}
+
+	testTable := []struct {
+		name          string
+		mockSetup     func()
+		IDs           []string
+		expectedLen   int
+		expectedError error
+	}{
+		{
+			name: "success",
+			mockSetup: func() {
+				repoMock.EXPECT().GetUsersByIDs(context.Background(), IDs).Return([]models.User{
+					{ID: IDs[0]},
+					{ID: IDs[1]},
+				}, nil)
+			},
+			IDs:         IDs,
+			expectedLen: 2,
+		},
+		{
+			name:        "empty batch",
+			IDs:         []string{},
+			expectedLen: 0,
+		},
+		{
+			name:          "wrong uuid in batch",
+			IDs:           []string{IDs[0], "wrongUUID"},
+			expectedError: allerrors.ErrWrongUUID,
+		},
+	}
+
+	for _, tc := range testTable {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.mockSetup != nil {
+				tc.mockSetup()
+			}
+
+			users, err := service.FindUsersByIDs(context.Background(), tc.IDs)
+			if tc.expectedError != nil {
+				require.ErrorIs(t, err, tc.expectedError)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Len(t, users, tc.expectedLen)
+		})
+	}
+}
+
+func TestCheckRole(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cacheMock := mock_userservice.NewMockUserCache(ctrl)
+
+	service := New(nil, nil, cacheMock, nil, JWTConfig{})
+
+	userID := uuid.NewString()
+
+	cacheMock.EXPECT().Get(context.Background(), userID).Return(models.User{ID: userID, Role: "admin"}, nil).Times(2)
+
+	allowed, err := service.CheckRole(context.Background(), userID, "admin")
+	require.NoError(t, err)
+	require.True(t, allowed)
+
+	allowed, err = service.CheckRole(context.Background(), userID, "moderator")
+	require.NoError(t, err)
+	require.False(t, allowed)
+}
+
+func TestIntrospectToken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	invalidatorMock := mock_userservice.NewMockRefreshInvalidator(ctrl)
+	cacheMock := mock_userservice.NewMockUserCache(ctrl)
+
+	cfg := JWTConfig{
+		SecretKey:      "secret",
+		Issuer:         "issuer",
+		AccessExpired:  time.Minute,
+		RefreshExpired: time.Hour,
+	}
+	service := New(nil, nil, cacheMock, invalidatorMock, cfg)
+
+	user := models.User{
+		ID:                 uuid.NewString(),
+		Role:               "user",
+		VersionCredentials: 1,
+	}
+	access, refresh, err := service.createTokens(context.Background(), user)
+	require.NoError(t, err)
+
+	changed := user
+	changed.VersionCredentials = 2
+
+	otherService := New(nil, nil, nil, nil, JWTConfig{SecretKey: "other", Issuer: "issuer", AccessExpired: time.Minute, RefreshExpired: time.Hour})
+	foreignAccess, _, err := otherService.createTokens(context.Background(), user)
+	require.NoError(t, err)
+
+	testTable := []struct {
+		name           string
+		mockSetup      func()
+		token          string
+		expectedActive bool
+		expectedType   string
+	}{
+		{
+			name: "active access token",
+			mockSetup: func() {
+				invalidatorMock.EXPECT().CheckAccessInBlackList(context.Background(), access.TokenID).Return(nil)
+				cacheMock.EXPECT().Get(context.Background(), user.ID).Return(user, nil)
+			},
+			token:          access.Sign,
+			expectedActive: true,
+			expectedType:   "access",
+		},
+		{
+			name: "access token in blacklist",
+			mockSetup: func() {
+				invalidatorMock.EXPECT().CheckAccessInBlackList(context.Background(), access.TokenID).Return(allerrors.ErrAccessTokenInBlackList)
+			},
+			token:          access.Sign,
+			expectedActive: false,
+		},
+		{
+			name: "access token issued before credentials change",
+			mockSetup: func() {
+				invalidatorMock.EXPECT().CheckAccessInBlackList(context.Background(), access.TokenID).Return(nil)
+				cacheMock.EXPECT().Get(context.Background(), user.ID).Return(changed, nil)
+			},
+			token:          access.Sign,
+			expectedActive: false,
+		},
+		{
+			name: "active refresh token",
+			mockSetup: func() {
+				invalidatorMock.EXPECT().CheckTokenInBlackList(context.Background(), refresh.TokenID).Return(nil)
+				cacheMock.EXPECT().Get(context.Background(), user.ID).Return(user, nil)
+			},
+			token:          refresh.Sign,
+			expectedActive: true,
+			expectedType:   "refresh",
+		},
+		{
+			name: "refresh token in blacklist",
+			mockSetup: func() {
+				invalidatorMock.EXPECT().CheckTokenInBlackList(context.Background(), refresh.TokenID).Return(allerrors.ErrTokenInBlackList)
+			},
+			token:          refresh.Sign,
+			expectedActive: false,
+		},
+		{
+			name: "refresh token issued before credentials change",
+			mockSetup: func() {
+				invalidatorMock.EXPECT().CheckTokenInBlackList(context.Background(), refresh.TokenID).Return(nil)
+				cacheMock.EXPECT().Get(context.Background(), user.ID).Return(changed, nil)
+			},
+			token:          refresh.Sign,
+			expectedActive: false,
+		},
+		{
+			name:           "wrong signature",
+			token:          foreignAccess.Sign,
+			expectedActive: false,
+		},
+		{
+			name:           "malformed token",
+			token:          "malformed.token.string",
+			expectedActive: false,
+		},
+	}
+
+	for _, tc := range testTable {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.mockSetup != nil {
+				tc.mockSetup()
+			}
+
+			info, err := service.IntrospectToken(context.Background(), tc.token)
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedActive, info.Active)
+
+			if tc.expectedActive {
+				require.Equal(t, tc.expectedType, info.Type)
+				require.Equal(t, user.ID, info.Subject)
+				require.Equal(t, user.Role, info.Role)
+			}
+		})
+	}
+}
+
+func TestRevokeToken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	invalidatorMock := mock_userservice.NewMockRefreshInvalidator(ctrl)
+
+	cfg := JWTConfig{
+		SecretKey:      "secret",
+		Issuer:         "issuer",
+		AccessExpired:  time.Minute,
+		RefreshExpired: time.Hour,
+	}
+	service := New(nil, nil, nil, invalidatorMock, cfg)
+
+	access, refresh, err := service.createTokens(context.Background(), models.User{ID: uuid.NewString(), Role: "user"})
+	require.NoError(t, err)
+
+	invalidatorMock.EXPECT().InvalidAccess(context.Background(), access.TokenID, access.ExpiresAt.Time.Truncate(time.Second)).Return(nil)
+	require.NoError(t, service.RevokeToken(context.Background(), access.Sign))
+
+	invalidatorMock.EXPECT().InvalidRefresh(context.Background(), refresh.TokenID, refresh.ExpiresAt.Time.Truncate(time.Second)).Return(nil)
+	require.NoError(t, service.RevokeToken(context.Background(), refresh.Sign))
+
+	// nothing to revoke, no calls to the storage
+	require.NoError(t, service.RevokeToken(context.Background(), "malformed.token.string"))
+}
+
+func TestCheckAccess(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repoMock := mock_userservice.NewMockUserRepo(ctrl)
+	cacheMock := mock_userservice.NewMockUserCache(ctrl)
+	invalidatorMock := mock_userservice.NewMockRefreshInvalidator(ctrl)
+
+	service := New(repoMock, nil, cacheMock, invalidatorMock, JWTConfig{})
+
+	user := models.User{
+		ID:                 uuid.NewString(),
+		Role:               "user",
+		VersionCredentials: 3,
+	}
+	access := models.JWTAccess{
+		TokenID:            uuid.NewString(),
+		VersionCredentials: 3,
+	}
+	access.Subject = user.ID
+
+	testTable := []struct {
+		name          string
+		mockSetup     func()
+		access        models.JWTAccess
+		expectedError error
+	}{
+		{
+			name: "current version from cache",
+			mockSetup: func() {
+				invalidatorMock.EXPECT().CheckAccessInBlackList(context.Background(), access.TokenID).Return(nil)
+				cacheMock.EXPECT().Get(context.Background(), user.ID).Return(user, nil)
+			},
+			access: access,
+		},
+		{
+			name: "cache miss goes to repo",
+			mockSetup: func() {
+				invalidatorMock.EXPECT().CheckAccessInBlackList(context.Background(), access.TokenID).Return(nil)
+				cacheMock.EXPECT().Get(context.Background(), user.ID).Return(models.User{}, allerrors.ErrNotFoundInCache)
+				repoMock.EXPECT().GetUserByID(context.Background(), user.ID).Return(user, nil)
+				cacheMock.EXPECT().Set(context.Background(), user.ID, user).Return(nil)
+			},
+			access: access,
+		},
+		{
+			name: "stale version",
+			mockSetup: func() {
+				stale := user
+				stale.VersionCredentials = 4
+
+				invalidatorMock.EXPECT().CheckAccessInBlackList(context.Background(), access.TokenID).Return(nil)
+				cacheMock.EXPECT().Get(context.Background(), user.ID).Return(stale, nil)
+			},
+			access:        access,
+			expectedError: allerrors.ErrDifferentVersionCredentials,
+		},
+		{
+			name: "deleted user",
+			mockSetup: func() {
+				invalidatorMock.EXPECT().CheckAccessInBlackList(context.Background(), access.TokenID).Return(nil)
+				cacheMock.EXPECT().Get(context.Background(), user.ID).Return(models.User{}, allerrors.ErrNotFoundInCache)
+				repoMock.EXPECT().GetUserByID(context.Background(), user.ID).Return(models.User{}, allerrors.ErrUserNotExists)
+			},
+			access:        access,
+			expectedError: allerrors.ErrUserNotExists,
+		},
+		{
+			name: "token in denylist",
+			mockSetup: func() {
+				invalidatorMock.EXPECT().CheckAccessInBlackList(context.Background(), access.TokenID).Return(allerrors.ErrAccessTokenInBlackList)
+			},
+			access:        access,
+			expectedError: allerrors.ErrAccessTokenInBlackList,
+		},
+	}
+
+	for _, tc := range testTable {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.mockSetup()
+
+			err := service.CheckAccess(context.Background(), tc.access)
+			if tc.expectedError != nil {
+				require.ErrorIs(t, err, tc.expectedError)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestChangeRole(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repoMock := mock_userservice.NewMockUserRepo(ctrl)
+	cacheMock := mock_userservice.NewMockUserCache(ctrl)
+
+	service := New(repoMock, nil, cacheMock, nil, JWTConfig{})
+
+	user := models.User{
+		ID:                 uuid.NewString(),
+		Role:               "user",
+		VersionCredentials: 1,
+	}
+	promoted := user
+	promoted.Role = "admin"
+	promoted.VersionCredentials = 2
+
+	repoMock.EXPECT().GetUserByID(context.Background(), user.ID).Return(user, nil)
+	repoMock.EXPECT().UpdateUserByID(context.Background(), user.ID, promoted).Return(promoted, nil)
+	cacheMock.EXPECT().Set(context.Background(), user.ID, promoted).Return(nil)
+
+	out, err := service.ChangeRole(context.Background(), user.ID, "admin")
+	require.NoError(t, err)
+	require.Equal(t, promoted, out)
+
+	// same role, version is not bumped
+	repoMock.EXPECT().GetUserByID(context.Background(), user.ID).Return(promoted, nil)
+
+	out, err = service.ChangeRole(context.Background(), user.ID, "admin")
+	require.NoError(t, err)
+	require.Equal(t, promoted, out)
+
+	_, err = service.ChangeRole(context.Background(), "not-uuid", "admin")
+	require.ErrorIs(t, err, allerrors.ErrWrongUUID)
+}
+
+func TestReactivateUser(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repoMock := mock_userservice.NewMockUserRepo(ctrl)
+	cacheMock := mock_userservice.NewMockUserCache(ctrl)
+
+	service := New(repoMock, nil, cacheMock, nil, JWTConfig{})
+
+	psw, err := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	deleted := models.User{
+		ID:        uuid.NewString(),
+		Username:  "username",
+		Password:  string(psw),
+		DeletedAt: time.Now().Add(-time.Hour),
+	}
+	active := deleted
+	active.DeletedAt = time.Time{}
+
+	testTable := []struct {
+		name          string
+		mockSetup     func()
+		password      string
+		expectedError error
+	}{
+		{
+			name: "deleted user restored",
+			mockSetup: func() {
+				repoMock.EXPECT().GetUserByUsername(context.Background(), "username").Return(deleted, nil)
+				repoMock.EXPECT().RestoreUserByID(context.Background(), deleted.ID).Return(nil)
+				cacheMock.EXPECT().Delete(context.Background(), deleted.ID).Return(nil)
+			},
+			password: "password",
+		},
+		{
+			name: "active user, nothing to do",
+			mockSetup: func() {
+				repoMock.EXPECT().GetUserByUsername(context.Background(), "username").Return(active, nil)
+			},
+			password: "password",
+		},
+		{
+			name: "wrong password",
+			mockSetup: func() {
+				repoMock.EXPECT().GetUserByUsername(context.Background(), "username").Return(deleted, nil)
+			},
+			password:      "wrong password",
+			expectedError: allerrors.ErrWrongPass,
+		},
+		{
+			name: "already erased",
+			mockSetup: func() {
+				repoMock.EXPECT().GetUserByUsername(context.Background(), "username").Return(models.User{}, allerrors.ErrUserNotExists)
+			},
+			password:      "password",
+			expectedError: allerrors.ErrUserNotExists,
+		},
+	}
+
+	for _, tc := range testTable {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.mockSetup()
+
+			err := service.ReactivateUser(context.Background(), models.User{Username: "username", Password: tc.password})
+			if tc.expectedError != nil {
+				require.ErrorIs(t, err, tc.expectedError)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestLoginDeactivatedUser(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repoMock := mock_userservice.NewMockUserRepo(ctrl)
+
+	service := New(repoMock, nil, nil, nil, JWTConfig{})
+
+	psw, err := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	repoMock.EXPECT().GetUserByUsername(context.Background(), "username").Return(models.User{
+		ID:        uuid.NewString(),
+		Username:  "username",
+		Password:  string(psw),
+		DeletedAt: time.Now(),
+	}, nil)
+
+	_, _, err = service.Login(context.Background(), models.User{Username: "username", Password: "password"})
+	require.ErrorIs(t, err, allerrors.ErrUserDeactivated)
+}
+
+func TestBanUser(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repoMock := mock_userservice.NewMockUserRepo(ctrl)
+	cacheMock := mock_userservice.NewMockUserCache(ctrl)
+
+	service := New(repoMock, nil, cacheMock, nil, JWTConfig{})
+
+	user := models.User{
+		ID:                 uuid.NewString(),
+		Role:               "user",
+		VersionCredentials: 1,
+	}
+	banned := user
+	banned.BannedAt = time.Now()
+	banned.VersionCredentials = 2
+
+	repoMock.EXPECT().GetUserByID(context.Background(), user.ID).Return(user, nil)
+	repoMock.EXPECT().BanUserByID(context.Background(), user.ID, true).Return(banned, nil)
+	cacheMock.EXPECT().Set(context.Background(), user.ID, banned).Return(nil)
+
+	out, wasBanned, err := service.BanUser(context.Background(), user.ID, true)
+	require.NoError(t, err)
+	require.Equal(t, banned, out)
+	require.False(t, wasBanned)
+
+	// already banned, version is not bumped
+	repoMock.EXPECT().GetUserByID(context.Background(), user.ID).Return(banned, nil)
+
+	out, wasBanned, err = service.BanUser(context.Background(), user.ID, true)
+	require.NoError(t, err)
+	require.Equal(t, banned, out)
+	require.True(t, wasBanned)
+
+	admin := user
+	admin.Role = "admin"
+	repoMock.EXPECT().GetUserByID(context.Background(), user.ID).Return(admin, nil)
+
+	_, _, err = service.BanUser(context.Background(), user.ID, true)
+	require.ErrorIs(t, err, allerrors.ErrBanAdmin)
+
+	_, _, err = service.BanUser(context.Background(), "not-uuid", true)
+	require.ErrorIs(t, err, allerrors.ErrWrongUUID)
+}
+
+func TestLoginBannedUser(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repoMock := mock_userservice.NewMockUserRepo(ctrl)
+
+	service := New(repoMock, nil, nil, nil, JWTConfig{})
+
+	psw, err := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	repoMock.EXPECT().GetUserByUsername(context.Background(), "username").Return(models.User{
+		ID:       uuid.NewString(),
+		Username: "username",
+		Password: string(psw),
+		BannedAt: time.Now(),
+	}, nil)
+
+	_, _, err = service.Login(context.Background(), models.User{Username: "username", Password: "password"})
+	require.ErrorIs(t, err, allerrors.ErrUserBanned)
+}
+
+func TestEraseExpired(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repoMock := mock_userservice.NewMockErasureRepo(ctrl)
+	storageMock := mock_userservice.NewMockFileStorage(ctrl)
+
+	eraser, err := NewEraser(repoMock, storageMock, DeletionConfig{GracePeriod: time.Hour, Interval: time.Hour, BatchSize: 2})
+	require.NoError(t, err)
+
+	userID := uuid.NewString()
+
+	gomock.InOrder(
+		repoMock.EXPECT().EraseDeletedUsers(context.Background(), gomock.Any(), 2, gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, deletedBefore time.Time, limit int, newTask func(string) (models.DefferedTask, error), eraseFiles func(context.Context, string) error) ([]string, error) {
+				require.WithinDuration(t, time.Now().Add(-time.Hour), deletedBefore, time.Minute)
+
+				task, err := newTask(userID)
+				require.NoError(t, err)
+				require.Equal(t, "songs_actions", task.Topic)
+				require.Equal(t, userID, task.Key)
+
+				event, err := events.Parse(task.Data)
+				require.NoError(t, err)
+				require.Equal(t, events.TypeUserDeleted, event.Type)
+				require.Equal(t, "users", event.Producer)
+
+				var payload events.UserDeleted
+				require.NoError(t, event.Decode(&payload))
+				require.Equal(t, userID, payload.UserID)
+
+				storageMock.EXPECT().DeletePrefix(ctx, "exports/"+userID+"/").Return(nil)
+				require.NoError(t, eraseFiles(ctx, userID))
+
+				return []string{userID, uuid.NewString()}, nil
+			}),
+		repoMock.EXPECT().EraseDeletedUsers(context.Background(), gomock.Any(), 2, gomock.Any(), gomock.Any()).Return([]string{uuid.NewString()}, nil),
+	)
+
+	n, err := eraser.EraseExpired(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 3, n)
+}
+
+func TestNewEraser(t *testing.T) {
+	testCases := []struct {
+		name    string
+		cfg     DeletionConfig
+		wantErr bool
+	}{
+		{
+			name: "valid",
+			cfg:  DeletionConfig{GracePeriod: time.Hour, Interval: time.Minute, BatchSize: 10},
+		},
+		{
+			name:    "zero interval",
+			cfg:     DeletionConfig{GracePeriod: time.Hour, BatchSize: 10},
+			wantErr: true,
+		},
+		{
+			name:    "negative interval",
+			cfg:     DeletionConfig{GracePeriod: time.Hour, Interval: -time.Minute, BatchSize: 10},
+			wantErr: true,
+		},
+		{
+			name:    "zero batch size",
+			cfg:     DeletionConfig{GracePeriod: time.Hour, Interval: time.Minute},
+			wantErr: true,
+		},
+		{
+			name:    "negative batch size",
+			cfg:     DeletionConfig{GracePeriod: time.Hour, Interval: time.Minute, BatchSize: -1},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := NewEraser(nil, nil, tc.cfg)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
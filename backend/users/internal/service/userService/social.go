@@ -0,0 +1,298 @@
+package userservice
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	allerrors "github.com/Cwby333/user-microservice/internal/allErrors"
+	"github.com/Cwby333/user-microservice/internal/models"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+
+	maxFilterIDs = 1000
+)
+
+// Social keeps who follows whom and the feeds built from it. Feed items are
+// added by AddRelease when the music service publishes a track, hidden by HideRelease
+// and dropped by RemoveRelease when moderation or the artist takes it down.
+type Social struct {
+	repo SocialRepo
+}
+
+func NewSocial(repo SocialRepo) Social {
+	return Social{
+		repo: repo,
+	}
+}
+
+func (s Social) Follow(ctx context.Context, followerID string, kind string, followeeID string) error {
+	const op = "./internal/service/userService/social.go.Follow"
+
+	if err := validateFollow(followerID, kind, followeeID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	// the account must exist and not be deleted
+	_, err := s.repo.GetUserByID(ctx, followeeID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = s.repo.Follow(ctx, followerID, followeeID, kind)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s Social) Unfollow(ctx context.Context, followerID string, kind string, followeeID string) error {
+	const op = "./internal/service/userService/social.go.Unfollow"
+
+	if err := validateFollow(followerID, kind, followeeID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err := s.repo.Unfollow(ctx, followerID, followeeID, kind)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s Social) Counts(ctx context.Context, userID string) (models.FollowCounts, error) {
+	const op = "./internal/service/userService/social.go.Counts"
+
+	if err := uuid.Validate(userID); err != nil {
+		return models.FollowCounts{}, fmt.Errorf("%s: %w", op, allerrors.ErrWrongUUID)
+	}
+
+	_, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return models.FollowCounts{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	counts, err := s.repo.CountFollows(ctx, userID)
+	if err != nil {
+		return models.FollowCounts{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return counts, nil
+}
+
+// Followers returns a page of the user's followers of kind and the cursor of the next page,
+// empty on the last one.
+func (s Social) Followers(ctx context.Context, userID string, kind string, cursor string, limit int) ([]models.Follow, string, error) {
+	const op = "./internal/service/userService/social.go.Followers"
+
+	follows, next, err := s.list(ctx, s.repo.GetFollowers, userID, kind, cursor, limit)
+	if err != nil {
+		return nil, "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return follows, next, nil
+}
+
+// Following returns a page of the accounts the user follows as kind.
+func (s Social) Following(ctx context.Context, userID string, kind string, cursor string, limit int) ([]models.Follow, string, error) {
+	const op = "./internal/service/userService/social.go.Following"
+
+	follows, next, err := s.list(ctx, s.repo.GetFollowing, userID, kind, cursor, limit)
+	if err != nil {
+		return nil, "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return follows, next, nil
+}
+
+type followsPage func(ctx context.Context, userID string, kind string, beforeAt time.Time, beforeID string, limit int) ([]models.Follow, error)
+
+func (s Social) list(ctx context.Context, page followsPage, userID string, kind string, cursor string, limit int) ([]models.Follow, string, error) {
+	if err := uuid.Validate(userID); err != nil {
+		return nil, "", allerrors.ErrWrongUUID
+	}
+	if kind != models.FollowUser && kind != models.FollowArtist {
+		return nil, "", allerrors.ErrWrongFollowKind
+	}
+
+	beforeAt, beforeID, err := decodeFollowCursor(cursor)
+	if err != nil {
+		return nil, "", err
+	}
+
+	limit = pageSize(limit)
+	follows, err := page(ctx, userID, kind, beforeAt, beforeID, limit)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if len(follows) < limit {
+		return follows, "", nil
+	}
+	last := follows[len(follows)-1]
+
+	return follows, encodeFollowCursor(last.CreatedAt, last.UserID), nil
+}
+
+// Feed returns a page of the user's feed, newest first, and the cursor of the next page.
+func (s Social) Feed(ctx context.Context, userID string, cursor string, limit int) ([]models.FeedItem, string, error) {
+	const op = "./internal/service/userService/social.go.Feed"
+
+	var beforeID int64
+	if cursor != "" {
+		id, err := strconv.ParseInt(cursor, 10, 64)
+		if err != nil || id <= 0 {
+			return nil, "", fmt.Errorf("%s: %w", op, allerrors.ErrWrongCursor)
+		}
+		beforeID = id
+	}
+
+	limit = pageSize(limit)
+	items, err := s.repo.GetFeed(ctx, userID, beforeID, limit)
+	if err != nil {
+		return nil, "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	if len(items) < limit {
+		return items, "", nil
+	}
+
+	return items, strconv.FormatInt(items[len(items)-1].ID, 10), nil
+}
+
+// AddRelease fans a published track out into the feeds of the artist's followers.
+// Safe to call again with the same event.
+func (s Social) AddRelease(ctx context.Context, eventID string, artistID string, trackID string, title string, publishedAt time.Time) (int64, error) {
+	const op = "./internal/service/userService/social.go.AddRelease"
+
+	n, err := s.repo.AddToFeed(ctx, models.FeedItem{
+		EventID:   eventID,
+		Kind:      models.FeedTrackPublished,
+		ActorID:   artistID,
+		ObjectID:  trackID,
+		Title:     title,
+		CreatedAt: publishedAt,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return n, nil
+}
+
+// HideRelease hides a track from the feeds when music moderation hides it, or shows it
+// again when the track is restored. Returns how many feed items changed.
+func (s Social) HideRelease(ctx context.Context, trackID string, hidden bool) (int64, error) {
+	const op = "./internal/service/userService/social.go.HideRelease"
+
+	n, err := s.repo.SetFeedObjectHidden(ctx, trackID, hidden)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return n, nil
+}
+
+// RemoveRelease drops a deleted track from the feeds.
+func (s Social) RemoveRelease(ctx context.Context, trackID string) (int64, error) {
+	const op = "./internal/service/userService/social.go.RemoveRelease"
+
+	n, err := s.repo.DeleteFeedObject(ctx, trackID)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return n, nil
+}
+
+// FilterFollowers returns those of userIDs that follow followeeID as kind. The notifications
+// service asks it for the users connected to it when a release comes out.
+func (s Social) FilterFollowers(ctx context.Context, followeeID string, kind string, userIDs []string) ([]string, error) {
+	const op = "./internal/service/userService/social.go.FilterFollowers"
+
+	if kind != models.FollowUser && kind != models.FollowArtist {
+		return nil, fmt.Errorf("%s: %w", op, allerrors.ErrWrongFollowKind)
+	}
+	if err := uuid.Validate(followeeID); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, allerrors.ErrWrongUUID)
+	}
+	if len(userIDs) > maxFilterIDs {
+		return nil, fmt.Errorf("%s: %w", op, allerrors.ErrTooManyIDs)
+	}
+
+	// one malformed id would fail the whole query, it can't follow anyone anyway
+	valid := make([]string, 0, len(userIDs))
+	for _, ID := range userIDs {
+		if uuid.Validate(ID) == nil {
+			valid = append(valid, ID)
+		}
+	}
+	if len(valid) == 0 {
+		return []string{}, nil
+	}
+
+	followers, err := s.repo.FilterFollowers(ctx, followeeID, kind, valid)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return followers, nil
+}
+
+func validateFollow(followerID string, kind string, followeeID string) error {
+	if kind != models.FollowUser && kind != models.FollowArtist {
+		return allerrors.ErrWrongFollowKind
+	}
+	if err := uuid.Validate(followeeID); err != nil {
+		return allerrors.ErrWrongUUID
+	}
+	if followerID == followeeID {
+		return allerrors.ErrFollowSelf
+	}
+
+	return nil
+}
+
+func pageSize(limit int) int {
+	if limit <= 0 {
+		return defaultPageSize
+	}
+
+	return min(limit, maxPageSize)
+}
+
+func encodeFollowCursor(at time.Time, ID string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(at.Format(time.RFC3339Nano) + "|" + ID))
+}
+
+func decodeFollowCursor(cursor string) (time.Time, string, error) {
+	if cursor == "" {
+		return time.Time{}, "", nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, "", allerrors.ErrWrongCursor
+	}
+
+	at, ID, ok := strings.Cut(string(raw), "|")
+	if !ok || uuid.Validate(ID) != nil {
+		return time.Time{}, "", allerrors.ErrWrongCursor
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, at)
+	if err != nil {
+		return time.Time{}, "", allerrors.ErrWrongCursor
+	}
+
+	return t, ID, nil
+}
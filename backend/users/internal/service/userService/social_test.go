@@ -0,0 +1,145 @@
+package userservice
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	allerrors "github.com/Cwby333/user-microservice/internal/allErrors"
+	"github.com/Cwby333/user-microservice/internal/models"
+	mock_userservice "github.com/Cwby333/user-microservice/internal/service/userService/mock_userService"
+	"github.com/google/uuid"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSocialFollow(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repoMock := mock_userservice.NewMockSocialRepo(ctrl)
+	social := NewSocial(repoMock)
+
+	userID := uuid.NewString()
+	artistID := uuid.NewString()
+
+	t.Run("followed", func(t *testing.T) {
+		repoMock.EXPECT().GetUserByID(context.Background(), artistID).Return(models.User{ID: artistID}, nil)
+		repoMock.EXPECT().Follow(context.Background(), userID, artistID, models.FollowArtist).Return(nil)
+
+		require.NoError(t, social.Follow(context.Background(), userID, models.FollowArtist, artistID))
+	})
+
+	t.Run("deleted user", func(t *testing.T) {
+		repoMock.EXPECT().GetUserByID(context.Background(), artistID).Return(models.User{}, allerrors.ErrUserNotExists)
+
+		err := social.Follow(context.Background(), userID, models.FollowUser, artistID)
+		require.ErrorIs(t, err, allerrors.ErrUserNotExists)
+	})
+
+	t.Run("wrong kind", func(t *testing.T) {
+		err := social.Follow(context.Background(), userID, "band", artistID)
+		require.ErrorIs(t, err, allerrors.ErrWrongFollowKind)
+	})
+
+	t.Run("yourself", func(t *testing.T) {
+		err := social.Follow(context.Background(), userID, models.FollowUser, userID)
+		require.ErrorIs(t, err, allerrors.ErrFollowSelf)
+	})
+
+	t.Run("wrong uuid", func(t *testing.T) {
+		err := social.Unfollow(context.Background(), userID, models.FollowUser, "not-a-uuid")
+		require.ErrorIs(t, err, allerrors.ErrWrongUUID)
+	})
+}
+
+func TestSocialFollowersCursor(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repoMock := mock_userservice.NewMockSocialRepo(ctrl)
+	social := NewSocial(repoMock)
+
+	userID := uuid.NewString()
+	page := []models.Follow{
+		{UserID: uuid.NewString(), Kind: models.FollowUser, CreatedAt: time.Now().UTC()},
+		{UserID: uuid.NewString(), Kind: models.FollowUser, CreatedAt: time.Now().UTC().Add(-time.Minute)},
+	}
+
+	repoMock.EXPECT().GetFollowers(context.Background(), userID, models.FollowUser, time.Time{}, "", 2).Return(page, nil)
+
+	follows, next, err := social.Followers(context.Background(), userID, models.FollowUser, "", 2)
+	require.NoError(t, err)
+	require.Len(t, follows, 2)
+	require.NotEmpty(t, next)
+
+	// the next page starts after the last follower of this one
+	repoMock.EXPECT().GetFollowers(context.Background(), userID, models.FollowUser, page[1].CreatedAt, page[1].UserID, 2).Return(page[:0], nil)
+
+	follows, next, err = social.Followers(context.Background(), userID, models.FollowUser, next, 2)
+	require.NoError(t, err)
+	require.Empty(t, follows)
+	require.Empty(t, next)
+
+	_, _, err = social.Followers(context.Background(), userID, models.FollowUser, "garbage", 2)
+	require.ErrorIs(t, err, allerrors.ErrWrongCursor)
+}
+
+func TestSocialFeedPageSize(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repoMock := mock_userservice.NewMockSocialRepo(ctrl)
+	social := NewSocial(repoMock)
+
+	userID := uuid.NewString()
+
+	repoMock.EXPECT().GetFeed(context.Background(), userID, int64(0), defaultPageSize).Return(nil, nil)
+	_, next, err := social.Feed(context.Background(), userID, "", 0)
+	require.NoError(t, err)
+	require.Empty(t, next)
+
+	repoMock.EXPECT().GetFeed(context.Background(), userID, int64(10), maxPageSize).Return(nil, nil)
+	_, _, err = social.Feed(context.Background(), userID, "10", 1000)
+	require.NoError(t, err)
+
+	_, _, err = social.Feed(context.Background(), userID, "-1", 0)
+	require.ErrorIs(t, err, allerrors.ErrWrongCursor)
+}
+
+func TestSocialFilterFollowers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repoMock := mock_userservice.NewMockSocialRepo(ctrl)
+	social := NewSocial(repoMock)
+
+	artistID := uuid.NewString()
+	follower := uuid.NewString()
+	stranger := uuid.NewString()
+
+	t.Run("malformed ids are dropped", func(t *testing.T) {
+		repoMock.EXPECT().FilterFollowers(context.Background(), artistID, models.FollowArtist, []string{follower, stranger}).Return([]string{follower}, nil)
+
+		followers, err := social.FilterFollowers(context.Background(), artistID, models.FollowArtist, []string{follower, "not-a-uuid", stranger})
+		require.NoError(t, err)
+		require.Equal(t, []string{follower}, followers)
+	})
+
+	t.Run("nothing to filter", func(t *testing.T) {
+		followers, err := social.FilterFollowers(context.Background(), artistID, models.FollowArtist, []string{"not-a-uuid"})
+		require.NoError(t, err)
+		require.Empty(t, followers)
+	})
+
+	t.Run("too many ids", func(t *testing.T) {
+		_, err := social.FilterFollowers(context.Background(), artistID, models.FollowArtist, make([]string, maxFilterIDs+1))
+		require.ErrorIs(t, err, allerrors.ErrTooManyIDs)
+	})
+
+	t.Run("wrong followee", func(t *testing.T) {
+		_, err := social.FilterFollowers(context.Background(), "not-a-uuid", models.FollowArtist, []string{follower})
+		require.ErrorIs(t, err, allerrors.ErrWrongUUID)
+	})
+}
@@ -2,6 +2,7 @@ package userservice
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -28,6 +29,7 @@ func (s Service) createTokens(ctx context.Context, user models.User) (access mod
 		Type: "access",
 		Role: user.Role,
 		TokenID: accessTokenID,
+		VersionCredentials: user.VersionCredentials,
 	})
 
 	accessSign, err := accessToken.SignedString([]byte(s.config.SecretKey))
@@ -51,6 +53,7 @@ func (s Service) createTokens(ctx context.Context, user models.User) (access mod
 		Type: "refresh",
 		Role: user.Role,
 		TokenID: refreshTokenID,
+		VersionCredentials: user.VersionCredentials,
 	})
 	refreshSign, err := refreshToken.SignedString([]byte(s.config.SecretKey))
 
@@ -93,4 +96,159 @@ func (s Service) RefreshTokens(ctx context.Context, tokenID string, refreshVersi
 	}
 
 	return access, refresh, nil
-}
\ No newline at end of file
+}
+// IntrospectToken reports whether token is currently usable. Invalid, expired
+// and revoked tokens are not an error, they are returned as inactive.
+func (s Service) IntrospectToken(ctx context.Context, token string) (models.TokenIntrospection, error) {
+	const op = "./internal/service/userService/tokens.go.IntrospectToken"
+
+	claims, err := s.parseToken(token)
+	if err != nil {
+		return models.TokenIntrospection{Active: false}, nil
+	}
+
+	typeToken, _ := claims["type"].(string)
+	subject, _ := claims["sub"].(string)
+	role, _ := claims["role"].(string)
+	tokenID, _ := claims["jti"].(string)
+	version, _ := claims["version_credentials"].(float64)
+
+	switch typeToken {
+	case "refresh":
+		err = s.invalidator.CheckTokenInBlackList(ctx, tokenID)
+		if err == nil {
+			err = s.checkVersionCredentials(ctx, subject, int(version))
+		}
+	case "access":
+		access := models.JWTAccess{TokenID: tokenID, VersionCredentials: int(version)}
+		access.Subject = subject
+
+		err = s.CheckAccess(ctx, access)
+	default:
+		return models.TokenIntrospection{Active: false}, nil
+	}
+	if err != nil {
+		if errors.Is(err, allerrors.ErrTokenInBlackList) ||
+			errors.Is(err, allerrors.ErrAccessTokenInBlackList) ||
+			errors.Is(err, allerrors.ErrDifferentVersionCredentials) ||
+			errors.Is(err, allerrors.ErrUserNotExists) ||
+			errors.Is(err, allerrors.ErrWrongUUID) {
+			return models.TokenIntrospection{Active: false}, nil
+		}
+
+		return models.TokenIntrospection{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	out := models.TokenIntrospection{
+		Active:  true,
+		Type:    typeToken,
+		Subject: subject,
+		Role:    role,
+		TokenID: tokenID,
+	}
+
+	if iat, err := claims.GetIssuedAt(); err == nil && iat != nil {
+		out.IssuedAt = iat.Time
+	}
+	if exp, err := claims.GetExpirationTime(); err == nil && exp != nil {
+		out.ExpiresAt = exp.Time
+	}
+
+	return out, nil
+}
+
+// RevokeToken puts an access or refresh token on the matching denylist until it expires.
+// Tokens that are already invalid are ignored, there is nothing left to revoke.
+func (s Service) RevokeToken(ctx context.Context, token string) error {
+	const op = "./internal/service/userService/tokens.go.RevokeToken"
+
+	claims, err := s.parseToken(token)
+	if err != nil {
+		return nil
+	}
+
+	typeToken, _ := claims["type"].(string)
+	tokenID, _ := claims["jti"].(string)
+
+	exp, err := claims.GetExpirationTime()
+	if err != nil || exp == nil {
+		return nil
+	}
+
+	switch typeToken {
+	case "refresh":
+		err = s.invalidator.InvalidRefresh(ctx, tokenID, exp.Time)
+	case "access":
+		err = s.invalidator.InvalidAccess(ctx, tokenID, exp.Time)
+	default:
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s Service) RevokeAccess(ctx context.Context, tokenID string, expTime time.Time) error {
+	const op = "./internal/service/userService/tokens.go.RevokeAccess"
+
+	err := s.invalidator.InvalidAccess(ctx, tokenID, expTime)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// CheckAccess is called for every request with an already verified access token.
+// Besides the denylist it rejects tokens issued before the last change of the
+// user's credentials or role, and tokens of deleted users.
+func (s Service) CheckAccess(ctx context.Context, access models.JWTAccess) error {
+	const op = "./internal/service/userService/tokens.go.CheckAccess"
+
+	err := s.invalidator.CheckAccessInBlackList(ctx, access.TokenID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = s.checkVersionCredentials(ctx, access.Subject, access.VersionCredentials)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// checkVersionCredentials goes through the user cache, so in the common case it costs one Redis HGET.
+func (s Service) checkVersionCredentials(ctx context.Context, userID string, version int) error {
+	user, err := s.FindUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if user.VersionCredentials != version {
+		return allerrors.ErrDifferentVersionCredentials
+	}
+
+	return nil
+}
+
+func (s Service) parseToken(token string) (jwt.MapClaims, error) {
+	t, err := jwt.ParseWithClaims(token, jwt.MapClaims{}, func(t *jwt.Token) (interface{}, error) {
+		return []byte(s.config.SecretKey), nil
+	}, jwt.WithIssuer(s.config.Issuer),
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Name}),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	claims, ok := t.Claims.(jwt.MapClaims)
+	if !ok || !t.Valid {
+		return nil, errors.New("invalid token")
+	}
+
+	return claims, nil
+}
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS user_erasures;
+DROP INDEX IF EXISTS users_deleted_at_idx;
+ALTER TABLE users DROP COLUMN deleted_at;
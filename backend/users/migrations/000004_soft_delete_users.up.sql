@@ -0,0 +1,9 @@
+ALTER TABLE users ADD COLUMN deleted_at timestamp;
+
+CREATE INDEX IF NOT EXISTS users_deleted_at_idx ON users(deleted_at) WHERE deleted_at IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS user_erasures(
+id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+user_id uuid NOT NULL,
+deleted_at timestamp NOT NULL,
+erased_at timestamp NOT NULL DEFAULT now());
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS data_exports;
+DROP TABLE IF EXISTS user_sessions;
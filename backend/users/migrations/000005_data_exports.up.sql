@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS user_sessions(
+id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+user_id uuid NOT NULL,
+token_id varchar(255) NOT NULL,
+created_at timestamp NOT NULL DEFAULT now());
+
+CREATE INDEX IF NOT EXISTS user_sessions_user_id_idx ON user_sessions(user_id);
+
+CREATE TABLE IF NOT EXISTS data_exports(
+id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+user_id uuid NOT NULL,
+status varchar(16) NOT NULL DEFAULT 'pending',
+file_key text NOT NULL DEFAULT '',
+error text NOT NULL DEFAULT '',
+created_at timestamp NOT NULL DEFAULT now(),
+started_at timestamp,
+finished_at timestamp);
+
+CREATE UNIQUE INDEX IF NOT EXISTS data_exports_active_uidx ON data_exports(user_id) WHERE status IN ('pending', 'running');
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS deffered_tasks_pending_idx;
+ALTER TABLE deffered_tasks
+    DROP COLUMN sent_at,
+    DROP COLUMN next_attempt_at,
+    DROP COLUMN last_error,
+    DROP COLUMN attempts,
+    DROP COLUMN status;
@@ -0,0 +1,8 @@
+ALTER TABLE deffered_tasks
+    ADD COLUMN status varchar(16) NOT NULL DEFAULT 'pending',
+    ADD COLUMN attempts int NOT NULL DEFAULT 0,
+    ADD COLUMN last_error text NOT NULL DEFAULT '',
+    ADD COLUMN next_attempt_at timestamp NOT NULL DEFAULT now(),
+    ADD COLUMN sent_at timestamp;
+
+CREATE INDEX IF NOT EXISTS deffered_tasks_pending_idx ON deffered_tasks(next_attempt_at) WHERE status = 'pending';
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS deffered_tasks_dead;
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS deffered_tasks_dead(
+    id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    topic varchar(255) NOT NULL,
+    data text NOT NULL,
+    source varchar(16) NOT NULL,
+    attempts int NOT NULL DEFAULT 0,
+    last_error text NOT NULL DEFAULT '',
+    created_at timestamp NOT NULL DEFAULT now(),
+    failed_at timestamp NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS deffered_tasks_dead_failed_at_idx ON deffered_tasks_dead(failed_at);
@@ -0,0 +1,8 @@
+ALTER TABLE deffered_tasks_dead
+    DROP COLUMN key;
+
+DROP INDEX IF EXISTS deffered_tasks_key_idx;
+ALTER TABLE deffered_tasks
+    DROP COLUMN locked_until,
+    DROP COLUMN locked_by,
+    DROP COLUMN key;
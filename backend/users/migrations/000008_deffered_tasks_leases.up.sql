@@ -0,0 +1,9 @@
+ALTER TABLE deffered_tasks
+    ADD COLUMN key varchar(255) NOT NULL DEFAULT '',
+    ADD COLUMN locked_by varchar(255),
+    ADD COLUMN locked_until timestamp;
+
+CREATE INDEX IF NOT EXISTS deffered_tasks_key_idx ON deffered_tasks(key, created_at) WHERE status = 'pending';
+
+ALTER TABLE deffered_tasks_dead
+    ADD COLUMN key varchar(255) NOT NULL DEFAULT '';
@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS follows(
+follower_id uuid NOT NULL,
+followee_id uuid NOT NULL,
+kind varchar(16) NOT NULL,
+created_at timestamp NOT NULL DEFAULT now(),
+PRIMARY KEY (follower_id, kind, followee_id));
+
+CREATE INDEX IF NOT EXISTS follows_followee_idx ON follows(followee_id, kind, created_at DESC);
+CREATE INDEX IF NOT EXISTS follows_follower_created_idx ON follows(follower_id, kind, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS feed_items(
+id bigserial PRIMARY KEY,
+user_id uuid NOT NULL,
+event_id uuid NOT NULL,
+kind varchar(32) NOT NULL,
+actor_id uuid NOT NULL,
+object_id uuid NOT NULL,
+title text NOT NULL DEFAULT '',
+created_at timestamp NOT NULL);
+
+CREATE UNIQUE INDEX IF NOT EXISTS feed_items_event_uidx ON feed_items(user_id, event_id);
+CREATE INDEX IF NOT EXISTS feed_items_user_idx ON feed_items(user_id, id DESC);
+CREATE INDEX IF NOT EXISTS feed_items_actor_idx ON feed_items(actor_id);
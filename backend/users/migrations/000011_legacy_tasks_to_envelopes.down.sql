@@ -0,0 +1 @@
+-- Envelopes are a superset of the legacy format; nothing to revert.
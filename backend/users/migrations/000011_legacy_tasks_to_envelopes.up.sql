@@ -0,0 +1,16 @@
+UPDATE deffered_tasks
+SET data = json_build_object(
+    'id', id,
+    'type', CASE data->>'action'
+        WHEN 'like' THEN 'track_liked'
+        WHEN 'dislike' THEN 'track_unliked'
+        ELSE 'user_deleted'
+    END,
+    'version', 1,
+    'occurred_at', to_char(COALESCE(created_at, now()), 'YYYY-MM-DD"T"HH24:MI:SS.US"Z"'),
+    'producer', 'legacy',
+    'payload', CASE data->>'action'
+        WHEN 'user_deleted' THEN json_build_object('user_id', data->>'user_id')
+        ELSE json_build_object('user_id', data->>'user_id', 'track_id', data->>'track_id')
+    END)
+WHERE status = 'pending' AND data->>'action' IN ('like', 'dislike', 'user_deleted');
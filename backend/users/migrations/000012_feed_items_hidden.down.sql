@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS feed_items_object_idx;
+
+ALTER TABLE feed_items DROP COLUMN IF EXISTS hidden;
@@ -0,0 +1,3 @@
+ALTER TABLE feed_items ADD COLUMN IF NOT EXISTS hidden boolean NOT NULL DEFAULT false;
+
+CREATE INDEX IF NOT EXISTS feed_items_object_idx ON feed_items(object_id);
@@ -32,7 +32,7 @@ func TestRegisterHandler(t *testing.T) {
 	mockUserService := userRouterMocks.NewMockUserService(ctrl)
 	mockTaskService := userRouterMocks.NewMockDefferedTaskService(ctrl)
 
-	router := userrouter.New(mockUserService, mockTaskService, nil)
+	router := userrouter.New(mockUserService, mockTaskService, nil, nil, nil)
 
 	testCases := []struct {
 		name           string
@@ -232,7 +232,7 @@ func TestLoginHandler(t *testing.T) {
 	mockUserService := userRouterMocks.NewMockUserService(ctrl)
 	mockTaskService := userRouterMocks.NewMockDefferedTaskService(ctrl)
 
-	router := userrouter.New(mockUserService, mockTaskService, nil)
+	router := userrouter.New(mockUserService, mockTaskService, nil, nil, nil)
 
 	testCases := []struct {
 		name           string
@@ -407,7 +407,7 @@ func TestGetUserByIDHandler(t *testing.T) {
 	mockUserService := userRouterMocks.NewMockUserService(ctrl)
 	mockTaskService := userRouterMocks.NewMockDefferedTaskService(ctrl)
 
-	router := userrouter.New(mockUserService, mockTaskService, nil)
+	router := userrouter.New(mockUserService, mockTaskService, nil, nil, nil)
 
 	testCases := []struct {
 		name                 string
@@ -575,7 +575,7 @@ func TestGetAllUsersHandler(t *testing.T) {
 	mockTaskService := userRouterMocks.NewMockDefferedTaskService(ctrl)
 
 	// Инициализируем роутер с использованием моков
-	router := userrouter.New(mockUserService, mockTaskService, nil)
+	router := userrouter.New(mockUserService, mockTaskService, nil, nil, nil)
 
 	// Несколько фиктивных пользователей для тестов
 	fakeUsers := []models.User{
@@ -691,7 +691,7 @@ func TestUpdateUserHandler(t *testing.T) {
 	mockTaskService := userRouterMocks.NewMockDefferedTaskService(ctrl)
 
 	// Инициализируем роутер с использованием моков
-	router := userrouter.New(mockUserService, mockTaskService, nil)
+	router := userrouter.New(mockUserService, mockTaskService, nil, nil, nil)
 
 	// Тестовые пользователи
 	testUser := models.User{
@@ -842,7 +842,7 @@ func TestDeleteUserHandler(t *testing.T) {
 	mockTaskService := userRouterMocks.NewMockDefferedTaskService(ctrl)
 
 	// Инициализируем роутер с использованием моков
-	router := userrouter.New(mockUserService, mockTaskService, nil)
+	router := userrouter.New(mockUserService, mockTaskService, nil, nil, nil)
 
 	// Тестовый пользователь
 	testUser := models.User{
@@ -943,7 +943,7 @@ func TestRefreshTokensHandler(t *testing.T) {
 	mockTaskService := userRouterMocks.NewMockDefferedTaskService(ctrl)
 
 	// Инициализируем роутер с использованием моков
-	router := userrouter.New(mockUserService, mockTaskService, nil)
+	router := userrouter.New(mockUserService, mockTaskService, nil, nil, nil)
 
 	// Тестовый пользователь
 	testUser := models.User{
@@ -1106,7 +1106,7 @@ func TestLogoutHandler(t *testing.T) {
 	mockTaskService := userRouterMocks.NewMockDefferedTaskService(ctrl)
 
 	// Инициализируем роутер с использованием моков
-	router := userrouter.New(mockUserService, mockTaskService, nil)
+	router := userrouter.New(mockUserService, mockTaskService, nil, nil, nil)
 
 	testExp := time.Now().Add(time.Hour).Unix()
 
@@ -1265,7 +1265,7 @@ func TestLogoutHandler(t *testing.T) {
 //     mockUserService := userRouterMocks.NewMockUserService(ctrl)
 //     mockTaskService := userRouterMocks.NewMockDefferedTaskService(ctrl)
 
-//     router := New(mockUserService, mockTaskService, nil)
+//     router := New(mockUserService, mockTaskService, nil, nil)
 
 //     testUser := models.User{
 //         ID:       "123",
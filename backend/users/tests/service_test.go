@@ -159,6 +159,11 @@ func TestLogin(t *testing.T) {
 			repoMock.EXPECT().
 				GetUserByUsername(context.Background(), tt.inputUser.Username).
 				Return(tt.mockUserFromRepo, nil)
+			if tt.expectedError == nil {
+				repoMock.EXPECT().
+					CreateSession(context.Background(), gomock.Any()).
+					Return(nil)
+			}
 
 			_, _, err := service.Login(context.Background(), tt.inputUser)
 
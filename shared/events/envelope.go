@@ -0,0 +1,123 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// New собирает событие последней версии для eventType и проверяет payload по схеме.
+func New(eventType, producer string, payload any) (Envelope, error) {
+	version, ok := latestVersions[eventType]
+	if !ok {
+		return Envelope{}, fmt.Errorf("%w: %s", ErrUnknownEvent, eventType)
+	}
+
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return Envelope{}, err
+	}
+	if err := validate(payloadSchemas[schemaKey{eventType, version}], raw); err != nil {
+		return Envelope{}, fmt.Errorf("%s v%d: %w", eventType, version, err)
+	}
+
+	return Envelope{
+		ID:         uuid.NewString(),
+		Type:       eventType,
+		Version:    version,
+		OccurredAt: time.Now().UTC(),
+		Producer:   producer,
+		Payload:    raw,
+	}, nil
+}
+
+// Parse разбирает и проверяет событие: сначала конверт, затем payload по схеме его типа и версии.
+// Сообщения старого формата ({"action": ...} без конверта) приводятся к конверту.
+func Parse(data []byte) (Envelope, error) {
+	if legacy, ok := parseLegacy(data); ok {
+		data = legacy
+	}
+
+	if err := validate(envelopeSchema, data); err != nil {
+		return Envelope{}, fmt.Errorf("envelope: %w", err)
+	}
+
+	var e Envelope
+	if err := json.Unmarshal(data, &e); err != nil {
+		return Envelope{}, fmt.Errorf("%w: %v", ErrInvalidEvent, err)
+	}
+
+	sch, ok := payloadSchemas[schemaKey{e.Type, e.Version}]
+	if !ok {
+		return Envelope{}, fmt.Errorf("%w: %s v%d", ErrUnknownEvent, e.Type, e.Version)
+	}
+	if err := validate(sch, e.Payload); err != nil {
+		return Envelope{}, fmt.Errorf("%s v%d: %w", e.Type, e.Version, err)
+	}
+
+	return e, nil
+}
+
+// Decode раскладывает payload в v, обычно в одну из структур этого пакета.
+func (e Envelope) Decode(v any) error {
+	return json.Unmarshal(e.Payload, v)
+}
+
+// legacyActions — типы событий для поля action старого формата
+var legacyActions = map[string]string{
+	"like":         TypeTrackLiked,
+	"dislike":      TypeTrackUnliked,
+	"user_deleted": TypeUserDeleted,
+}
+
+// legacyNamespace — пространство имен для id событий старого формата
+var legacyNamespace = uuid.MustParse("6f1c2b0e-3a57-4d5e-9a1f-2c8e7b4d9a30")
+
+// parseLegacy переводит сообщение старого формата в конверт. Нужно, пока в топике
+// остаются сообщения, записанные до перехода на конверт (задачи в deffered_tasks
+// переписывает миграция users).
+//
+// id выводится из самого сообщения: повторная доставка того же сообщения получает тот же id
+// и отсеивается дедупликацией. Времени в старом формате нет, OccurredAt остается нулевым —
+// консьюмер подставляет время сообщения в Kafka.
+func parseLegacy(data []byte) ([]byte, bool) {
+	var old struct {
+		Action  string `json:"action"`
+		UserID  string `json:"user_id"`
+		TrackID string `json:"track_id"`
+	}
+	if err := json.Unmarshal(data, &old); err != nil || old.Action == "" {
+		return nil, false
+	}
+
+	eventType, ok := legacyActions[old.Action]
+	if !ok {
+		return nil, false
+	}
+
+	var payload any
+	switch eventType {
+	case TypeTrackLiked:
+		payload = TrackLiked{UserID: old.UserID, TrackID: old.TrackID}
+	case TypeTrackUnliked:
+		payload = TrackUnliked{UserID: old.UserID, TrackID: old.TrackID}
+	case TypeUserDeleted:
+		payload = UserDeleted{UserID: old.UserID}
+	}
+
+	e, err := New(eventType, "legacy", payload)
+	if err != nil {
+		return nil, false
+	}
+	e.ID = uuid.NewSHA1(legacyNamespace, data).String()
+	e.OccurredAt = time.Time{}
+
+	out, err := json.Marshal(e)
+	if err != nil {
+		return nil, false
+	}
+
+	return out, true
+}
@@ -0,0 +1,93 @@
+// Package events описывает события, которыми сервисы обмениваются через Kafka:
+// общий конверт, типы событий с их полезной нагрузкой и JSON Schema для проверки.
+package events
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Типы событий. Схема полезной нагрузки лежит в schemas/<type>.v<version>.json.
+const (
+	TypeTrackLiked     = "track_liked"
+	TypeTrackUnliked   = "track_unliked"
+	TypeUserDeleted    = "user_deleted"
+	TypeTrackPlayed    = "track_played"
+	TypeTrackPublished = "track_published"
+	TypeTrackHidden    = "track_hidden"
+	TypeTrackRestored  = "track_restored"
+	TypeTrackDeleted   = "track_deleted"
+	TypeCommentPosted  = "comment_posted"
+)
+
+// Envelope — конверт события. Payload проверяется по схеме для пары (Type, Version).
+type Envelope struct {
+	ID         string          `json:"id"`
+	Type       string          `json:"type"`
+	Version    int             `json:"version"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Producer   string          `json:"producer"`
+	Payload    json.RawMessage `json:"payload"`
+}
+
+type TrackLiked struct {
+	UserID  string `json:"user_id"`
+	TrackID string `json:"track_id"`
+}
+
+type TrackUnliked struct {
+	UserID  string `json:"user_id"`
+	TrackID string `json:"track_id"`
+}
+
+type UserDeleted struct {
+	UserID string `json:"user_id"`
+}
+
+// TrackPlayed — трек прослушан. PositionMs — позиция, на которой закончилось
+// прослушивание, DurationMs — сколько реально прослушано. nil — клиент не передал,
+// 0 — настоящее значение (например, трек закрыт сразу после старта).
+type TrackPlayed struct {
+	UserID     string `json:"user_id"`
+	TrackID    string `json:"track_id"`
+	PositionMs *int64 `json:"position_ms,omitempty"`
+	DurationMs *int64 `json:"duration_ms,omitempty"`
+}
+
+// TrackPublished — исполнитель загрузил новый трек. Публикует music, подписчикам
+// исполнителя сервис users раскладывает его в ленту.
+type TrackPublished struct {
+	TrackID  string `json:"track_id"`
+	ArtistID string `json:"artist_id"`
+	Title    string `json:"title"`
+}
+
+// TrackHidden — модератор скрыл трек. Публикует music, users убирает трек из лент,
+// пока его не вернут.
+type TrackHidden struct {
+	TrackID  string `json:"track_id"`
+	ArtistID string `json:"artist_id"`
+}
+
+// TrackRestored — модератор вернул скрытый трек, он снова виден в лентах.
+type TrackRestored struct {
+	TrackID  string `json:"track_id"`
+	ArtistID string `json:"artist_id"`
+}
+
+// TrackDeleted — трек удален исполнителем или модератором, users удаляет его из лент.
+type TrackDeleted struct {
+	TrackID  string `json:"track_id"`
+	ArtistID string `json:"artist_id"`
+}
+
+// CommentPosted — пользователь оставил комментарий к треку. Публикует music, исполнителю
+// трека его доставляет сервис notifications. ParentID есть только у ответа.
+type CommentPosted struct {
+	CommentID  string `json:"comment_id"`
+	TrackID    string `json:"track_id"`
+	ArtistID   string `json:"artist_id"`
+	AuthorID   string `json:"author_id"`
+	ParentID   string `json:"parent_id,omitempty"`
+	PositionMs *int64 `json:"position_ms,omitempty"`
+}
@@ -0,0 +1,182 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+const (
+	testUserID  = "6f1c2a0e-3f4b-4c55-9d7e-2b1f0c9a8e11"
+	testTrackID = "0b7d5e3a-1c2f-4a6b-8e9d-7f6a5b4c3d21"
+)
+
+func TestNewAndParse(t *testing.T) {
+	e, err := New(TypeTrackLiked, "users", TrackLiked{UserID: testUserID, TrackID: testTrackID})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if e.Version != 1 {
+		t.Fatalf("version = %d, want 1", e.Version)
+	}
+
+	data, err := json.Marshal(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	var payload TrackLiked
+	if err := parsed.Decode(&payload); err != nil {
+		t.Fatal(err)
+	}
+	if parsed.ID != e.ID || payload.TrackID != testTrackID || payload.UserID != testUserID {
+		t.Fatalf("parsed %+v %+v, want %+v", parsed, payload, e)
+	}
+}
+
+func TestNewInvalidPayload(t *testing.T) {
+	_, err := New(TypeTrackLiked, "users", TrackLiked{UserID: testUserID, TrackID: "not-a-uuid"})
+	if !errors.Is(err, ErrInvalidEvent) {
+		t.Fatalf("err = %v, want ErrInvalidEvent", err)
+	}
+
+	_, err = New("track_exploded", "users", struct{}{})
+	if !errors.Is(err, ErrUnknownEvent) {
+		t.Fatalf("err = %v, want ErrUnknownEvent", err)
+	}
+}
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		name     string
+		data     string
+		wantType string
+		wantErr  error
+	}{
+		{
+			name:     "legacy like",
+			data:     `{"action":"like","user_id":"` + testUserID + `","track_id":"` + testTrackID + `"}`,
+			wantType: TypeTrackLiked,
+		},
+		{
+			name:     "legacy user deleted",
+			data:     `{"action":"user_deleted","user_id":"` + testUserID + `"}`,
+			wantType: TypeUserDeleted,
+		},
+		{
+			name:    "not json",
+			data:    `{"action":`,
+			wantErr: ErrInvalidEvent,
+		},
+		{
+			name:    "missing envelope fields",
+			data:    `{"type":"track_liked","payload":{}}`,
+			wantErr: ErrInvalidEvent,
+		},
+		{
+			name: "unknown version",
+			data: `{"id":"` + testTrackID + `","type":"track_liked","version":7,"occurred_at":"2025-01-01T00:00:00Z",` +
+				`"producer":"users","payload":{}}`,
+			wantErr: ErrUnknownEvent,
+		},
+		{
+			name: "payload does not match schema",
+			data: `{"id":"` + testTrackID + `","type":"user_deleted","version":1,"occurred_at":"2025-01-01T00:00:00Z",` +
+				`"producer":"users","payload":{"user":"x"}}`,
+			wantErr: ErrInvalidEvent,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			e, err := Parse([]byte(tc.data))
+			if tc.wantErr != nil {
+				if !errors.Is(err, tc.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if e.Type != tc.wantType {
+				t.Fatalf("type = %s, want %s", e.Type, tc.wantType)
+			}
+		})
+	}
+}
+
+func TestParseLegacyStableID(t *testing.T) {
+	like := []byte(`{"action":"like","user_id":"` + testUserID + `","track_id":"` + testTrackID + `"}`)
+	unlike := []byte(`{"action":"dislike","user_id":"` + testUserID + `","track_id":"` + testTrackID + `"}`)
+
+	first, err := Parse(like)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	// Повторная доставка того же сообщения — то же событие
+	again, err := Parse(like)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if first.ID != again.ID {
+		t.Fatalf("ids differ on redelivery: %s, %s", first.ID, again.ID)
+	}
+	if !first.OccurredAt.IsZero() {
+		t.Fatalf("occurred_at = %s, want zero for legacy", first.OccurredAt)
+	}
+
+	other, err := Parse(unlike)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if other.ID == first.ID {
+		t.Fatal("different messages got the same id")
+	}
+
+	// Конверт после повторной сериализации (как в sender) разбирается с тем же id
+	raw, err := json.Marshal(first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reparsed, err := Parse(raw)
+	if err != nil {
+		t.Fatalf("Parse envelope: %v", err)
+	}
+	if reparsed.ID != first.ID || !reparsed.OccurredAt.IsZero() {
+		t.Fatalf("reparsed %s at %s, want %s at zero time", reparsed.ID, reparsed.OccurredAt, first.ID)
+	}
+}
+
+func TestRouter(t *testing.T) {
+	r := NewRouter()
+
+	var got UserDeleted
+	On(r, TypeUserDeleted, func(ctx context.Context, e Envelope, payload UserDeleted) error {
+		got = payload
+		return nil
+	})
+
+	e, err := New(TypeUserDeleted, "users", UserDeleted{UserID: testUserID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := r.Dispatch(context.Background(), e); err != nil {
+		t.Fatalf("Dispatch: %v", err)
+	}
+	if got.UserID != testUserID {
+		t.Fatalf("user_id = %s, want %s", got.UserID, testUserID)
+	}
+
+	e.Type = TypeTrackLiked
+	if err := r.Dispatch(context.Background(), e); !errors.Is(err, ErrUnknownEvent) {
+		t.Fatalf("err = %v, want ErrUnknownEvent", err)
+	}
+}
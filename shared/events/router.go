@@ -0,0 +1,46 @@
+package events
+
+import (
+	"context"
+	"fmt"
+)
+
+type Handler func(ctx context.Context, e Envelope) error
+
+// Router вызывает обработчик по типу события. Новый тип события — новый вызов Handle,
+// без правок в цикле консьюмера.
+type Router struct {
+	handlers map[string]Handler
+}
+
+func NewRouter() *Router {
+	return &Router{
+		handlers: make(map[string]Handler),
+	}
+}
+
+func (r *Router) Handle(eventType string, h Handler) {
+	r.handlers[eventType] = h
+}
+
+// Dispatch возвращает ErrUnknownEvent, если для типа нет обработчика.
+func (r *Router) Dispatch(ctx context.Context, e Envelope) error {
+	h, ok := r.handlers[e.Type]
+	if !ok {
+		return fmt.Errorf("%w: no handler for %s", ErrUnknownEvent, e.Type)
+	}
+
+	return h(ctx, e)
+}
+
+// On регистрирует обработчик, которому payload приходит уже разобранным в T.
+func On[T any](r *Router, eventType string, h func(ctx context.Context, e Envelope, payload T) error) {
+	r.Handle(eventType, func(ctx context.Context, e Envelope) error {
+		var payload T
+		if err := e.Decode(&payload); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidEvent, err)
+		}
+
+		return h(ctx, e, payload)
+	})
+}
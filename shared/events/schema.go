@@ -0,0 +1,97 @@
+package events
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"strconv"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+)
+
+var (
+	ErrUnknownEvent = errors.New("unknown event type or version")
+	ErrInvalidEvent = errors.New("invalid event")
+)
+
+//go:embed schemas/*.json
+var schemaFiles embed.FS
+
+var schemaName = regexp.MustCompile(`^([a-z][a-z0-9_]*)\.v([0-9]+)\.json$`)
+
+type schemaKey struct {
+	eventType string
+	version   int
+}
+
+var (
+	envelopeSchema *jsonschema.Schema
+	payloadSchemas = map[schemaKey]*jsonschema.Schema{}
+	// Последняя версия каждого типа, ее использует New
+	latestVersions = map[string]int{}
+)
+
+func init() {
+	c := jsonschema.NewCompiler()
+	c.AssertFormat()
+
+	files, err := fs.Glob(schemaFiles, "schemas/*.json")
+	if err != nil {
+		panic(err)
+	}
+
+	for _, file := range files {
+		data, err := schemaFiles.ReadFile(file)
+		if err != nil {
+			panic(err)
+		}
+		doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(data))
+		if err != nil {
+			panic(fmt.Sprintf("%s: %v", file, err))
+		}
+		if err := c.AddResource(schemaURL(file), doc); err != nil {
+			panic(fmt.Sprintf("%s: %v", file, err))
+		}
+	}
+
+	for _, file := range files {
+		sch, err := c.Compile(schemaURL(file))
+		if err != nil {
+			panic(fmt.Sprintf("%s: %v", file, err))
+		}
+
+		name := path.Base(file)
+		if name == "envelope.json" {
+			envelopeSchema = sch
+			continue
+		}
+
+		m := schemaName.FindStringSubmatch(name)
+		if m == nil {
+			panic(fmt.Sprintf("%s: schema file must be named <type>.v<version>.json", file))
+		}
+		version, _ := strconv.Atoi(m[2])
+		payloadSchemas[schemaKey{m[1], version}] = sch
+		latestVersions[m[1]] = max(latestVersions[m[1]], version)
+	}
+}
+
+func schemaURL(file string) string {
+	return "mem://events/" + file
+}
+
+func validate(sch *jsonschema.Schema, data []byte) error {
+	inst, err := jsonschema.UnmarshalJSON(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidEvent, err)
+	}
+	if err := sch.Validate(inst); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidEvent, err)
+	}
+
+	return nil
+}